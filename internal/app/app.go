@@ -1,9 +1,12 @@
 package app
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"jijin/internal/model"
 	"jijin/internal/repository"
 	"jijin/internal/service"
 	apptheme "jijin/internal/theme"
@@ -32,6 +35,9 @@ type App struct {
 	analysisUI   *ui.AnalysisUI
 	toolsUI      *ui.ToolsUI
 	alertUI      *ui.AlertUI
+	screenerUI   *ui.ScreenerUI
+	watchlistUI  *ui.WatchlistUI
+	settingsUI   *ui.SettingsUI
 
 	// 自动刷新
 	refreshTicker *time.Ticker
@@ -57,9 +63,14 @@ func (a *App) Run() error {
 	if err := repository.InitDB(); err != nil {
 		return err
 	}
+	service.Init(repository.Default())
+
+	// 读取设置(同时应用网络代理)
+	settings := service.GetSettingsService().Get()
 
 	// 创建Fyne应用
 	a.fyneApp = app.New()
+	apptheme.SetMode(settings.Theme)
 	a.fyneApp.Settings().SetTheme(&apptheme.AppTheme{})
 
 	a.mainWindow = a.fyneApp.NewWindow("基金助手")
@@ -69,12 +80,32 @@ func (a *App) Run() error {
 	// 创建UI组件
 	a.createUI()
 
-	// 启动自动刷新(每5分钟)
-	a.startAutoRefresh(5 * time.Minute)
+	// 启动自动刷新，间隔和时段见设置
+	a.startAutoRefresh(service.GetSettingsService().AutoRefreshInterval())
+
+	// 设置变更后调整刷新间隔和主题
+	service.GetSettingsService().OnChange(a.onSettingsChanged)
+
+	// 启动提醒监控，通知经各渠道推送后刷新提醒页
+	service.GetNotifyService().SetDesktopSender(func(title, message string) {
+		a.fyneApp.SendNotification(fyne.NewNotification(title, message))
+	})
+	service.GetAlertService().StartMonitoring(func(alert *model.AlertHistory) {
+		a.alertUI.Refresh()
+	})
+
+	// 启动日报/周报定时生成
+	service.GetReportService().StartScheduler()
+
+	// 启动定时自动备份
+	service.GetBackupService().StartScheduler()
 
 	// 设置关闭处理
 	a.mainWindow.SetOnClosed(func() {
 		a.stopAutoRefresh()
+		service.GetAlertService().StopMonitoring()
+		service.GetReportService().StopScheduler()
+		service.GetBackupService().StopScheduler()
 	})
 
 	a.mainWindow.ShowAndRun()
@@ -92,19 +123,34 @@ func (a *App) createUI() {
 	a.analysisUI = ui.NewAnalysisUI()
 	a.toolsUI = ui.NewToolsUI()
 	a.alertUI = ui.NewAlertUI()
+	a.screenerUI = ui.NewScreenerUI()
+	a.watchlistUI = ui.NewWatchlistUI(func() {
+		a.portfolioUI.Refresh()
+		a.homeUI.Refresh()
+	})
+	a.settingsUI = ui.NewSettingsUI(a.alertUI.ShowChannelsDialog)
 
 	// 设置窗口引用（用于显示对话框）
 	a.toolsUI.SetWindow(a.mainWindow)
 	a.alertUI.SetWindow(a.mainWindow)
+	a.screenerUI.SetWindow(a.mainWindow)
+	a.watchlistUI.SetWindow(a.mainWindow)
+	a.strategyUI.SetWindow(a.mainWindow)
+	a.settingsUI.SetWindow(a.mainWindow)
 
 	// 创建标签页
 	tabs := container.NewAppTabs(
 		container.NewTabItemWithIcon("首页", theme.HomeIcon(), a.homeUI.Content()),
 		container.NewTabItemWithIcon("搜索", theme.SearchIcon(), a.searchUI.Content()),
+		container.NewTabItemWithIcon("选基", theme.GridIcon(), a.screenerUI.Content()),
 		container.NewTabItemWithIcon("持仓", theme.ListIcon(), a.portfolioUI.Content()),
+		container.NewTabItemWithIcon("自选", theme.VisibilityIcon(), a.watchlistUI.Content()),
+		container.NewTabItemWithIcon("策略", theme.DocumentCreateIcon(), a.strategyUI.Content()),
+		container.NewTabItemWithIcon("定投计算", theme.ComputerIcon(), a.calculatorUI.Content()),
 		container.NewTabItemWithIcon("工具箱", theme.SettingsIcon(), a.toolsUI.Content()),
 		container.NewTabItemWithIcon("提醒", theme.WarningIcon(), a.alertUI.Content()),
 		container.NewTabItemWithIcon("分析", theme.DocumentIcon(), a.analysisUI.Content()),
+		container.NewTabItemWithIcon("设置", theme.SettingsIcon(), a.settingsUI.Content()),
 	)
 	tabs.SetTabLocation(container.TabLocationTop)
 
@@ -113,14 +159,22 @@ func (a *App) createUI() {
 		switch ti.Text {
 		case "首页":
 			a.homeUI.Refresh()
+		case "选基":
+			a.screenerUI.Refresh()
 		case "持仓":
 			a.portfolioUI.Refresh()
+		case "自选":
+			a.watchlistUI.Refresh()
+		case "策略":
+			a.strategyUI.Refresh()
 		case "工具箱":
 			a.toolsUI.Refresh()
 		case "提醒":
 			a.alertUI.Refresh()
 		case "分析":
 			a.analysisUI.Refresh()
+		case "设置":
+			a.settingsUI.Refresh()
 		}
 	}
 
@@ -182,14 +236,26 @@ func (a *App) onRefresh() {
 			a.statusLabel.SetText("刷新失败: " + err.Error())
 			return
 		}
+		service.GetWatchlistService().RefreshWatchlist()
 
 		a.lastUpdate = time.Now()
-		a.statusLabel.SetText("上次更新: " + a.lastUpdate.Format("15:04:05"))
+		status := "上次更新: " + a.lastUpdate.Format("15:04:05")
+
+		// 检查持仓止盈止损提醒，通知由提醒服务推送
+		alerts := service.GetAlertService().CheckHoldingAlerts()
+		if len(alerts) > 0 {
+			status += fmt.Sprintf("  触发%d条持仓提醒", len(alerts))
+		}
+		if drifting := service.GetFundAPI().DriftingEndpoints(); len(drifting) > 0 {
+			status += "  数据源异常: " + strings.Join(drifting, "、") + "(详见设置)"
+		}
+		a.statusLabel.SetText(status)
 
 		// 刷新UI
 		a.homeUI.Refresh()
 		a.portfolioUI.Refresh()
 		a.analysisUI.Refresh()
+		a.watchlistUI.Refresh()
 	}()
 }
 
@@ -212,19 +278,8 @@ func (a *App) startAutoRefresh(interval time.Duration) {
 		for {
 			select {
 			case <-a.refreshTicker.C:
-				// 只在交易时间刷新(9:30-15:00)
-				now := time.Now()
-				hour := now.Hour()
-				minute := now.Minute()
-				weekday := now.Weekday()
-
-				// 周末不刷新
-				if weekday == time.Saturday || weekday == time.Sunday {
-					continue
-				}
-
-				// 非交易时间不刷新
-				if hour < 9 || (hour == 9 && minute < 30) || hour >= 15 {
+				// 按设置的时段刷新，默认只在交易时间
+				if !service.GetSettingsService().ShouldAutoRefresh(time.Now()) {
 					continue
 				}
 
@@ -237,6 +292,15 @@ func (a *App) startAutoRefresh(interval time.Duration) {
 	}()
 }
 
+// onSettingsChanged 设置保存后调整刷新间隔并重新应用主题
+func (a *App) onSettingsChanged(settings model.AppSettings) {
+	if a.refreshTicker != nil {
+		a.refreshTicker.Reset(time.Duration(settings.RefreshMinutes) * time.Minute)
+	}
+	apptheme.SetMode(settings.Theme)
+	a.fyneApp.Settings().SetTheme(&apptheme.AppTheme{})
+}
+
 // stopAutoRefresh 停止自动刷新
 func (a *App) stopAutoRefresh() {
 	if a.refreshTicker != nil {
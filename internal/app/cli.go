@@ -0,0 +1,173 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"jijin/internal/repository"
+	"jijin/internal/service"
+)
+
+// cliUsage 命令行用法
+const cliUsage = `用法:
+  jijin                               启动图形界面
+  jijin export [-format csv|json|xlsx] [-out 目录] [-data holdings,transactions,...]
+                                      导出数据，默认导出全部数据集为CSV到当前目录
+  jijin backup [-out 目录]            创建备份包，默认保存到数据目录backups
+  jijin restore <备份文件>            从备份包恢复，恢复前自动备份当前数据
+  jijin migrate [status|up|down] [-to 版本]
+                                      查看、执行或回退数据库结构迁移，执行前自动备份
+`
+
+// RunCommand 执行命令行子命令。没有子命令时返回false，由调用方启动图形界面
+func RunCommand(args []string) (bool, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-psn") {
+		return false, nil
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "help", "-h", "-help", "--help":
+		fmt.Print(cliUsage)
+		return true, nil
+	case "export", "backup", "restore":
+	case "migrate":
+		return true, runMigrate(args)
+	default:
+		fmt.Fprint(os.Stderr, cliUsage)
+		return true, fmt.Errorf("未知命令: %s", cmd)
+	}
+
+	if err := repository.InitDB(); err != nil {
+		return true, err
+	}
+	service.Init(repository.Default())
+	backup := service.GetBackupService()
+
+	switch cmd {
+	case "export":
+		fs := newFlagSet(cmd)
+		format := fs.String("format", service.ExportCSV, "导出格式: csv/json/xlsx")
+		out := fs.String("out", ".", "导出目录")
+		data := fs.String("data", "", "数据集，逗号分隔，为空导出全部: "+datasetKeys())
+		if err := fs.Parse(args); err != nil {
+			return true, err
+		}
+		var keys []string
+		for _, k := range strings.Split(*data, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keys = append(keys, k)
+			}
+		}
+		files, err := backup.Export(*out, *format, keys)
+		for _, f := range files {
+			fmt.Println("已导出:", f)
+		}
+		return true, err
+
+	case "backup":
+		fs := newFlagSet(cmd)
+		out := fs.String("out", "", "备份目录，为空时使用数据目录backups")
+		if err := fs.Parse(args); err != nil {
+			return true, err
+		}
+		path, err := backup.Backup(*out)
+		if err == nil {
+			fmt.Println("已备份:", path)
+		}
+		return true, err
+
+	default: // restore
+		if len(args) != 1 {
+			fmt.Fprint(os.Stderr, cliUsage)
+			return true, fmt.Errorf("请指定要恢复的备份文件")
+		}
+		manifest, err := service.ReadBackupManifest(args[0])
+		if err != nil {
+			return true, err
+		}
+		fmt.Printf("备份时间: %s  数据结构版本: %d\n", manifest.CreatedAt.Format("2006-01-02 15:04:05"), manifest.SchemaVersion)
+		safety, err := backup.Restore(args[0])
+		if err == nil {
+			fmt.Println("已恢复，恢复前的数据已备份到:", safety)
+		}
+		return true, err
+	}
+}
+
+// runMigrate 迁移子命令，打开数据库时不自动迁移
+func runMigrate(args []string) error {
+	action := "status"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	fs := newFlagSet("migrate")
+	to := fs.Int("to", 0, "目标版本，up默认最新，down必须指定")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := repository.OpenDB(); err != nil {
+		return err
+	}
+	db := repository.Default().DB()
+
+	switch action {
+	case "status":
+	case "up":
+		if err := repository.MigrateUp(db, *to); err != nil {
+			return err
+		}
+	case "down":
+		if !flagSet(fs, "to") {
+			return fmt.Errorf("回退迁移需要用 -to 指定目标版本")
+		}
+		if err := repository.MigrateDown(db, *to); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("未知的迁移操作: %s", action)
+	}
+
+	status, err := repository.GetMigrationStatus(db)
+	if err != nil {
+		return err
+	}
+	current, _ := repository.CurrentSchemaVersion(db)
+	fmt.Printf("数据库: %s\n当前版本: %d  最新版本: %d\n", repository.DBPath(), current, repository.SchemaVersion)
+	for _, m := range status {
+		state := "待执行"
+		if m.Applied {
+			state = "已执行 " + m.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("  %3d  %-24s  %s\n", m.Version, state, m.Name)
+	}
+	return nil
+}
+
+// flagSet 参数是否在命令行中指定
+func flagSet(fs *flag.FlagSet, name string) bool {
+	found := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// newFlagSet 子命令参数解析，出错时返回错误而不退出
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// datasetKeys 可导出的数据集
+func datasetKeys() string {
+	keys := make([]string, len(service.ExportDatasets))
+	for i, ds := range service.ExportDatasets {
+		keys[i] = ds.Key
+	}
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,72 @@
+// Package config 配置文件，保存打开数据库之前就需要确定的设置(如数据目录)。
+// 其余设置保存在数据库中，见service.SettingsService
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// File 配置文件内容
+type File struct {
+	DataDir string `json:"dataDir"` // 数据目录，为空时使用默认目录
+}
+
+// DataDirEnv 指定数据目录的环境变量，优先于配置文件
+const DataDirEnv = "JIJIN_DATA_DIR"
+
+// homeDir 用户主目录，获取失败时为当前目录
+func homeDir() string {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return dir
+}
+
+// DefaultDataDir 默认数据目录 ~/.jijin
+func DefaultDataDir() string {
+	return filepath.Join(homeDir(), ".jijin")
+}
+
+// Path 配置文件路径，固定在默认数据目录下
+func Path() string {
+	return filepath.Join(DefaultDataDir(), "config.json")
+}
+
+// Load 读取配置文件，不存在时返回空配置
+func Load() (*File, error) {
+	f := &File{}
+	data, err := os.ReadFile(Path())
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	return f, json.Unmarshal(data, f)
+}
+
+// Save 保存配置文件
+func Save(f *File) error {
+	if err := os.MkdirAll(filepath.Dir(Path()), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(Path(), data, 0644)
+}
+
+// DataDir 数据目录：环境变量 > 配置文件 > 默认目录
+func DataDir() string {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir
+	}
+	if f, err := Load(); err == nil && f.DataDir != "" {
+		return f.DataDir
+	}
+	return DefaultDataDir()
+}
@@ -16,9 +16,38 @@ type Fund struct {
 	DayGrowth  float64   `json:"dayGrowth"`  // 日涨跌幅(%)
 	EstValue   float64   `json:"estValue"`   // 估算净值
 	EstGrowth  float64   `json:"estGrowth"`  // 估算涨跌幅(%)
+	EstTime    time.Time `json:"estTime"`    // 估值时间
+	EstSource  string    `json:"estSource" gorm:"size:20"` // 估值来源: official/holdings/index/benchmark
 	UpdatedAt  time.Time `json:"updatedAt"`
 }
 
+// IntradayEstimate 盘中估值时间序列
+type IntradayEstimate struct {
+	ID        uint      `gorm:"primaryKey"`
+	FundCode  string    `json:"fundCode" gorm:"size:10;index:idx_intraday_fund_time"`
+	EstTime   time.Time `json:"estTime" gorm:"index:idx_intraday_fund_time"` // 估值时间
+	EstValue  float64   `json:"estValue"`                                     // 估算净值
+	EstGrowth float64   `json:"estGrowth"`                                    // 估算涨跌幅(%)
+	BaseNav   float64   `json:"baseNav"`                                      // 估值基准(上一交易日净值)
+	Source    string    `json:"source" gorm:"size:20"`                        // 估值来源
+}
+
+// FundEstimateModel 自有估值模型(按持仓或跟踪指数估算净值)
+type FundEstimateModel struct {
+	FundCode     string    `json:"fundCode" gorm:"primaryKey;size:10"`
+	Method       string    `json:"method" gorm:"size:20"`      // holdings/index
+	IndexName    string    `json:"indexName" gorm:"size:100"`  // 跟踪指数名称
+	Components   string    `json:"components" gorm:"type:text"` // 成分及权重(JSON)
+	ReportDate   time.Time `json:"reportDate"`                 // 持仓报告期
+	Coverage     float64   `json:"coverage"`                   // 成分合计占净值比例(%)
+	Beta         float64   `json:"beta"`                       // 校准系数
+	Alpha        float64   `json:"alpha"`                      // 校准截距(%)
+	RSquared     float64   `json:"rSquared"`                   // 拟合优度
+	Samples      int       `json:"samples"`                    // 校准样本天数
+	CalibratedAt time.Time `json:"calibratedAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
 // Holding 持仓
 type Holding struct {
 	gorm.Model
@@ -28,6 +57,8 @@ type Holding struct {
 	Cost       float64 `json:"cost"`      // 持仓成本(总投入)
 	CostPrice  float64 `json:"costPrice"` // 成本价(每份)
 	CurrentNav float64 `json:"currentNav"` // 当前净值
+	NavDate    time.Time `json:"navDate"`  // 当前净值对应日期
+	Account    string    `json:"account" gorm:"size:50"` // 所属账户/平台，如支付宝、银行
 }
 
 // 计算当前市值
@@ -48,6 +79,15 @@ func (h *Holding) ProfitRate() float64 {
 	return (h.MarketValue() - h.Cost) / h.Cost * 100
 }
 
+// WatchlistItem 自选基金(不计入持仓)
+type WatchlistItem struct {
+	gorm.Model
+	FundCode  string `json:"fundCode" gorm:"size:10;index"`
+	FundName  string `json:"fundName" gorm:"size:100"`
+	GroupName string `json:"groupName" gorm:"size:50;index"` // 分组
+	Note      string `json:"note" gorm:"size:500"`           // 备注
+}
+
 // Transaction 交易记录
 type Transaction struct {
 	gorm.Model
@@ -91,6 +131,49 @@ type FundSearchResult struct {
 	Type     string `json:"type"`
 	Pinyin   string `json:"pinyin"`
 	PinyinAbbr string `json:"pinyinAbbr"`
+
+	// 以下字段仅在按公司/规模/成立日期筛选或排序时填充
+	Company       string    `json:"company,omitempty"`
+	Scale         float64   `json:"scale,omitempty"` // 资产规模(亿元)
+	EstablishDate time.Time `json:"establishDate,omitempty"`
+	Score         float64   `json:"score"` // 搜索相关度得分
+}
+
+// FundProfile 基金基本概况(公司、规模、成立日期)
+type FundProfile struct {
+	FundCode      string    `json:"fundCode" gorm:"primaryKey;size:10"`
+	Company       string    `json:"company" gorm:"size:100;index"` // 基金管理人
+	Scale         float64   `json:"scale"`                         // 资产规模(亿元)
+	EstablishDate time.Time `json:"establishDate"`                 // 成立日期
+	FeeRate       float64   `json:"feeRate"`                       // 管理费率(%/年)
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// ========== 基金筛选相关 ==========
+
+// FundMetric 基金筛选指标(由排行数据和净值历史计算)
+type FundMetric struct {
+	FundCode      string    `json:"fundCode" gorm:"primaryKey;size:10"`
+	FundName      string    `json:"fundName" gorm:"size:100"`
+	FundType      string    `json:"fundType" gorm:"size:50;index"`
+	Return1Y      float64   `json:"return1y"`      // 近1年收益率(%)
+	Return3Y      float64   `json:"return3y"`      // 近3年收益率(%)
+	Return5Y      float64   `json:"return5y"`      // 近5年收益率(%)
+	MaxDrawdown   float64   `json:"maxDrawdown"`   // 近3年最大回撤(%)
+	SharpeRatio   float64   `json:"sharpeRatio"`   // 近1年夏普比率
+	Scale         float64   `json:"scale"`         // 资产规模(亿元)
+	FeeRate       float64   `json:"feeRate"`       // 管理费率(%/年)
+	Manager       string    `json:"manager" gorm:"size:100"`
+	ManagerTenure float64   `json:"managerTenure"` // 现任基金经理任职年限
+	HistoryDays   int       `json:"historyDays"`   // 可用净值历史天数(自然日)
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// FundScreen 保存的筛选方案
+type FundScreen struct {
+	gorm.Model
+	Name  string `json:"name" gorm:"size:100"`
+	Query string `json:"query" gorm:"type:text"` // JSON: 筛选条件
 }
 
 // ========== 智能提醒相关 ==========
@@ -100,14 +183,57 @@ type AlertRule struct {
 	gorm.Model
 	FundCode        string    `json:"fundCode" gorm:"size:10;index"`
 	FundName        string    `json:"fundName" gorm:"size:100"`
-	AlertType       string    `json:"alertType" gorm:"size:30"`       // price_change/consecutive/nav_update
+	AlertType       string    `json:"alertType" gorm:"size:30"`       // price_change/consecutive/nav_update/expression/持仓提醒类型
 	Threshold       float64   `json:"threshold"`                      // 阈值（如涨跌幅百分比）
 	Direction       string    `json:"direction" gorm:"size:10"`       // up/down/both
 	ConsecutiveDays int       `json:"consecutiveDays"`                // 连续天数（连涨连跌用）
+	Expression      string    `json:"expression" gorm:"size:500"`     // 条件表达式（表达式规则用）
+	HoldingID       uint      `json:"holdingId" gorm:"index"`         // 关联持仓（持仓提醒用）
+	PeakValue       float64   `json:"peakValue"`                      // 规则创建以来的最高持仓市值，随加减仓调整（回撤提醒用）
+	PeakShares      float64   `json:"peakShares"`                     // PeakValue对应的持有份额（回撤提醒用）
+	Active          bool      `json:"active"`                         // 已触发且尚未重新布防
+	CooldownMode    string    `json:"cooldownMode" gorm:"size:20"`    // daily/interval/until_reset，空为默认
+	CooldownMinutes int       `json:"cooldownMinutes"`                // 冷却分钟数（interval用）
+	Hysteresis      float64   `json:"hysteresis"`                     // 回差：回落超过阈值该幅度才重新布防（until_reset用）
+	Channels        string    `json:"channels" gorm:"size:100"`       // 通知渠道ID，逗号分隔，空为全部启用渠道
 	Enabled         bool      `json:"enabled"`
 	LastTriggered   time.Time `json:"lastTriggered"`
 }
 
+// AlertSettings 提醒全局设置（单行）
+type AlertSettings struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	QuietEnabled  bool   `json:"quietEnabled"`                // 启用免打扰时段
+	QuietStart    string `json:"quietStart" gorm:"size:5"`    // 免打扰开始 HH:MM
+	QuietEnd      string `json:"quietEnd" gorm:"size:5"`      // 免打扰结束 HH:MM，可跨零点
+	DigestEnabled bool   `json:"digestEnabled"`               // 启用汇总模式
+	DigestMinutes int    `json:"digestMinutes"`               // 汇总间隔（分钟）
+}
+
+// NotifyChannel 提醒通知渠道
+type NotifyChannel struct {
+	gorm.Model
+	Name    string `json:"name" gorm:"size:50"`
+	Type    string `json:"type" gorm:"size:20"` // desktop/email/webhook/log
+	Enabled bool   `json:"enabled"`
+
+	// 邮件(SMTP)
+	SMTPHost string `json:"smtpHost" gorm:"size:100"`
+	SMTPPort int    `json:"smtpPort"`                 // 465为SSL，其余端口支持时使用STARTTLS
+	Username string `json:"username" gorm:"size:100"`
+	Password string `json:"password" gorm:"size:200"`
+	From     string `json:"from" gorm:"size:100"`
+	To       string `json:"to" gorm:"size:500"` // 收件人，逗号分隔
+
+	// Webhook
+	WebhookURL    string `json:"webhookUrl" gorm:"size:500"`
+	WebhookFormat string `json:"webhookFormat" gorm:"size:20"` // generic/wecom/dingtalk/feishu
+	Secret        string `json:"secret" gorm:"size:200"`       // 钉钉/飞书加签密钥
+
+	// 日志
+	LogPath string `json:"logPath" gorm:"size:300"` // 为空时写入数据目录下alerts.log
+}
+
 // AlertHistory 提醒历史
 type AlertHistory struct {
 	gorm.Model
@@ -119,6 +245,11 @@ type AlertHistory struct {
 	Value       float64   `json:"value"`                              // 触发时的值
 	TriggeredAt time.Time `json:"triggeredAt"`
 	IsRead      bool      `json:"isRead"`
+
+	DeliveryStatus string    `json:"deliveryStatus" gorm:"size:20"` // pending/sent/partial/failed/none
+	DeliveryError  string    `json:"deliveryError" gorm:"size:500"` // 各渠道失败原因
+	Attempts       int       `json:"attempts"`                      // 累计发送次数(含重试)
+	DeliveredAt    time.Time `json:"deliveredAt"`
 }
 
 // ========== 基金排行相关 ==========
@@ -129,9 +260,11 @@ type FundRanking struct {
 	FundCode     string    `json:"fundCode" gorm:"size:10;index"`
 	FundName     string    `json:"fundName" gorm:"size:100"`
 	RankType     string    `json:"rankType" gorm:"size:20;index"` // add_position/reduce_position/gain/loss
-	RankValue    float64   `json:"rankValue"`                     // 排行值
+	Category     string    `json:"category" gorm:"size:10;index"` // all/gp/hh/zq/zs/qdii/fof
+	RankValue    float64   `json:"rankValue"`                     // 排行值(该周期涨跌幅%)
 	RankPosition int       `json:"rankPosition"`                  // 排名位置
-	Period       string    `json:"period" gorm:"size:10"`         // day/week/month
+	TotalCount   int       `json:"totalCount"`                    // 参与排名的基金数
+	Period       string    `json:"period" gorm:"size:10"`         // day/1w/1m/3m/6m/ytd/1y/2y/3y/since
 	StatDate     time.Time `json:"statDate" gorm:"index"`
 }
 
@@ -208,8 +341,29 @@ type TradingSignal struct {
 	TargetPrice    float64   `json:"targetPrice"`                  // 目标价格
 	StopLoss       float64   `json:"stopLoss"`                     // 止损价格
 	Reason         string    `json:"reason" gorm:"size:500"`       // 信号原因
+	Score          float64   `json:"score"`                        // 综合评分 -100~100
+	StrategyID     uint      `json:"strategyId"`                   // 使用的信号策略，0为内置默认
 	GeneratedAt    time.Time `json:"generatedAt" gorm:"index"`
 	IsValid        bool      `json:"isValid"`                      // 是否仍有效
+	Status         string    `json:"status" gorm:"size:20;index"`  // active/superseded/expired/target/stopped
+	ExpiresAt      time.Time `json:"expiresAt"`                    // 到期时间
+	ClosedAt       time.Time `json:"closedAt"`                     // 失效时间
+}
+
+// SignalStrategy 信号策略：各指标投票权重、买卖阈值和目标/止损计算参数
+type SignalStrategy struct {
+	gorm.Model
+	Name          string  `json:"name" gorm:"size:50"`
+	WeightMACD    float64 `json:"weightMacd"`
+	WeightKDJ     float64 `json:"weightKdj"`
+	WeightBoll    float64 `json:"weightBoll"`
+	WeightMA      float64 `json:"weightMa"`
+	WeightRSI     float64 `json:"weightRsi"`
+	BuyThreshold  float64 `json:"buyThreshold"`  // 综合评分不低于该值为买入
+	SellThreshold float64 `json:"sellThreshold"` // 综合评分不高于其相反数为卖出
+	TargetMult    float64 `json:"targetMult"`    // 目标价 = 价格 ± 倍数 × 平均波幅
+	StopMult      float64 `json:"stopMult"`      // 止损价 = 价格 ∓ 倍数 × 平均波幅
+	IsDefault     bool    `json:"isDefault"`
 }
 
 // ========== 截图导入相关 ==========
@@ -239,6 +393,73 @@ type QDIIFund struct {
 	ExchangeRate  float64   `json:"exchangeRate"`
 	T1EstValue    float64   `json:"t1EstValue"`                   // T+1估值
 	T2EstValue    float64   `json:"t2EstValue"`                   // T+2估值
-	LastTradeDate time.Time `json:"lastTradeDate"`
+	NavDate       time.Time `json:"navDate"`                      // 最新公布净值日期
+	LastTradeDate time.Time `json:"lastTradeDate"`                // 最新估值对应的境外交易日
 	UpdatedAt     time.Time `json:"updatedAt"`
 }
+
+// ExchangeRate 人民币汇率中间价
+type ExchangeRate struct {
+	ID       uint      `gorm:"primaryKey"`
+	Currency string    `json:"currency" gorm:"size:10;uniqueIndex:idx_currency_date"` // USD/HKD
+	Date     time.Time `json:"date" gorm:"uniqueIndex:idx_currency_date"`
+	Rate     float64   `json:"rate"` // 1单位外币兑人民币
+}
+
+// ========== 持仓报告相关 ==========
+
+// PortfolioReport 持仓日报/周报
+type PortfolioReport struct {
+	gorm.Model
+	Period         string    `json:"period" gorm:"size:10;index"` // daily/weekly
+	StartDate      time.Time `json:"startDate"`                   // 期初净值日
+	EndDate        time.Time `json:"endDate" gorm:"index"`        // 期末净值日
+	TotalValue     float64   `json:"totalValue"`
+	TotalProfit    float64   `json:"totalProfit"`
+	PeriodProfit   float64   `json:"periodProfit"` // 区间盈亏
+	PeriodReturn   float64   `json:"periodReturn"` // 区间收益率(%)
+	RiskSnapshot   string    `json:"riskSnapshot" gorm:"type:text"` // JSON: 各持仓风险指标，用于下期对比
+	Markdown       string    `json:"markdown" gorm:"type:text"`
+	FilePath       string    `json:"filePath" gorm:"size:300"` // 保存的文件(不含扩展名)
+	DeliveryStatus string    `json:"deliveryStatus" gorm:"size:20"`
+	GeneratedAt    time.Time `json:"generatedAt"`
+}
+
+// ReportSettings 报告设置（单行）
+type ReportSettings struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	DailyEnabled  bool   `json:"dailyEnabled"`               // 每个交易日生成日报
+	WeeklyEnabled bool   `json:"weeklyEnabled"`              // 每周五生成周报
+	GenerateAt    string `json:"generateAt" gorm:"size:5"`   // 生成时间 HH:MM，需晚于净值公布
+	Formats       string `json:"formats" gorm:"size:30"`     // 保存格式，逗号分隔: md/html/pdf
+	SaveDir       string `json:"saveDir" gorm:"size:300"`    // 为空时保存到数据目录reports
+	Channels      string `json:"channels" gorm:"size:100"`   // 推送渠道ID，逗号分隔，空为不推送
+}
+
+// ========== 数据备份相关 ==========
+
+// BackupSettings 自动备份设置（单行）
+type BackupSettings struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	AutoEnabled   bool      `json:"autoEnabled"`                // 定时自动备份
+	IntervalHours int       `json:"intervalHours"`              // 备份间隔(小时)
+	Keep          int       `json:"keep"`                       // 保留最近几份自动备份，0为不清理
+	SaveDir       string    `json:"saveDir" gorm:"size:300"`    // 为空时保存到数据目录backups
+	LastBackupAt  time.Time `json:"lastBackupAt"`
+}
+
+// ========== 应用设置 ==========
+
+// AppSettings 应用设置（单行），数据目录保存在配置文件中
+type AppSettings struct {
+	ID             uint    `json:"id" gorm:"primaryKey"`
+	RefreshMinutes int     `json:"refreshMinutes"`                // 自动刷新间隔(分钟)
+	RefreshPolicy  string  `json:"refreshPolicy" gorm:"size:20"`  // 自动刷新时段: trading/always/manual
+	MonitorMinutes int     `json:"monitorMinutes"`                // 提醒监控间隔(分钟)
+	RiskFreeRate   float64 `json:"riskFreeRate"`                  // 无风险利率(年化%)，用于夏普比率
+	MCSimulations  int     `json:"mcSimulations"`                 // 蒙特卡洛模拟次数
+	BuyFeeRate     float64 `json:"buyFeeRate"`                    // 默认申购费率(%)
+	SellFeeRate    float64 `json:"sellFeeRate"`                   // 默认赎回费率(%)
+	Theme          string  `json:"theme" gorm:"size:10"`          // light/dark/system
+	ProxyURL       string  `json:"proxyUrl" gorm:"size:200"`      // 网络代理，为空不使用
+}
@@ -1,81 +1,198 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
 
+	"jijin/internal/config"
 	"jijin/internal/model"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
-// InitDB 初始化数据库
+// InitDB 打开应用数据库并执行未执行的结构迁移
 func InitDB() error {
-	// 获取用户数据目录
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
+	if err := OpenDB(); err != nil {
+		return err
 	}
+	return MigrateUp(defaultStore.DB(), 0)
+}
 
-	dataDir := filepath.Join(homeDir, ".jijin")
+// OpenDB 打开应用数据库，不执行迁移
+func OpenDB() error {
+	// 数据目录：环境变量、配置文件或默认的~/.jijin
+	dataDir := config.DataDir()
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return err
 	}
 
-	dbPath := filepath.Join(dataDir, "jijin.db")
+	path := filepath.Join(dataDir, "jijin.db")
+	db, err := openFile(path)
+	if err != nil {
+		return err
+	}
+
+	defaultStore.mu.Lock()
+	defaultStore.db = db
+	defaultStore.path = path
+	defaultStore.mu.Unlock()
+	return nil
+}
 
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+// openFile 打开SQLite数据库文件
+func openFile(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
-	if err != nil {
+}
+
+// DataDir 数据目录
+func DataDir() string {
+	return filepath.Dir(DBPath())
+}
+
+// DBPath 应用数据库文件路径
+func DBPath() string {
+	defaultStore.mu.RLock()
+	defer defaultStore.mu.RUnlock()
+	return defaultStore.path
+}
+
+// SnapshotDB 将数据库一致性快照写入dest(VACUUM INTO)，dest已存在时覆盖
+func (s *GormStore) SnapshotDB(dest string) error {
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	return s.conn().Exec("VACUUM INTO ?", dest).Error
+}
 
-	// 自动迁移
-	err = db.AutoMigrate(
-		// 现有模型
-		&model.Fund{},
-		&model.Holding{},
-		&model.Transaction{},
-		&model.Strategy{},
-		&model.NetValueHistory{},
-		// 新增模型
-		&model.AlertRule{},
-		&model.AlertHistory{},
-		&model.FundRanking{},
-		&model.FundRiskProfile{},
-		&model.InstitutionHolding{},
-		&model.RecoveryPrediction{},
-		&model.ProfitProbability{},
-		&model.TradingSignal{},
-		&model.ImportRecord{},
-		&model.QDIIFund{},
-	)
+// ReplaceDB 用src替换数据库文件，重新打开并迁移到当前版本。
+// 替换期间持有写锁，其他调用方等待替换完成后使用新连接；
+// 新文件先写入同目录临时文件再改名，打开或迁移失败时恢复原文件
+func (s *GormStore) ReplaceDB(src string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" {
+		return errors.New("当前数据库不是文件数据库，无法替换")
+	}
+
+	tmp, err := copyToTemp(src, filepath.Dir(s.path))
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp)
 
-	DB = db
+	if sqlDB, err := s.db.DB(); err == nil {
+		sqlDB.Close()
+	}
+	previous := s.path + ".previous"
+	os.Remove(previous)
+	if err := os.Rename(s.path, previous); err != nil {
+		return s.reopen(err)
+	}
+	removeJournals(s.path)
+	if err := os.Rename(tmp, s.path); err != nil {
+		return s.rollback(previous, err)
+	}
+
+	db, err := openFile(s.path)
+	if err == nil {
+		// 恢复前已备份当前数据，不再调用迁移前回调
+		if err = migrateUp(db, 0, nil); err != nil {
+			if sqlDB, e := db.DB(); e == nil {
+				sqlDB.Close()
+			}
+		}
+	}
+	if err != nil {
+		return s.rollback(previous, err)
+	}
+	s.db = db
+	os.Remove(previous)
 	return nil
 }
 
+// rollback 替换失败时恢复原数据库文件并重新打开，返回原错误
+func (s *GormStore) rollback(previous string, cause error) error {
+	os.Remove(s.path)
+	removeJournals(s.path)
+	if err := os.Rename(previous, s.path); err != nil {
+		return fmt.Errorf("%v；恢复原数据库失败，原文件保存在%s: %v", cause, previous, err)
+	}
+	return s.reopen(cause)
+}
+
+// reopen 重新打开当前数据库文件，返回原错误
+func (s *GormStore) reopen(cause error) error {
+	db, err := openFile(s.path)
+	if err != nil {
+		return fmt.Errorf("%v；重新打开数据库失败: %v", cause, err)
+	}
+	s.db = db
+	return cause
+}
+
+// copyToTemp 将src复制到dir中的临时文件并落盘，返回临时文件路径
+func copyToTemp(src, dir string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp(dir, "jijin-restore-*.db")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// removeJournals 删除数据库的WAL和日志文件
+func removeJournals(path string) {
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		os.Remove(path + suffix)
+	}
+}
+
+// CountRows 统计表记录数
+func (s *GormStore) CountRows(m interface{}) int64 {
+	var count int64
+	s.conn().Model(m).Count(&count)
+	return count
+}
+
 // === Fund 操作 ===
 
 // SaveFund 保存基金信息
-func SaveFund(fund *model.Fund) error {
+func (s *GormStore) SaveFund(fund *model.Fund) error {
 	fund.UpdatedAt = time.Now()
-	return DB.Save(fund).Error
+	return s.conn().Save(fund).Error
 }
 
 // GetFund 获取基金信息
-func GetFund(code string) (*model.Fund, error) {
+func (s *GormStore) GetFund(code string) (*model.Fund, error) {
 	var fund model.Fund
-	err := DB.Where("code = ?", code).First(&fund).Error
+	err := s.conn().Where("code = ?", code).First(&fund).Error
 	if err != nil {
 		return nil, err
 	}
@@ -83,23 +200,23 @@ func GetFund(code string) (*model.Fund, error) {
 }
 
 // GetAllFunds 获取所有基金
-func GetAllFunds() ([]model.Fund, error) {
+func (s *GormStore) GetAllFunds() ([]model.Fund, error) {
 	var funds []model.Fund
-	err := DB.Find(&funds).Error
+	err := s.conn().Find(&funds).Error
 	return funds, err
 }
 
 // === Holding 操作 ===
 
 // SaveHolding 保存持仓
-func SaveHolding(holding *model.Holding) error {
-	return DB.Save(holding).Error
+func (s *GormStore) SaveHolding(holding *model.Holding) error {
+	return s.conn().Save(holding).Error
 }
 
 // GetHolding 获取持仓
-func GetHolding(id uint) (*model.Holding, error) {
+func (s *GormStore) GetHolding(id uint) (*model.Holding, error) {
 	var holding model.Holding
-	err := DB.First(&holding, id).Error
+	err := s.conn().First(&holding, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -107,9 +224,9 @@ func GetHolding(id uint) (*model.Holding, error) {
 }
 
 // GetHoldingByFundCode 根据基金代码获取持仓
-func GetHoldingByFundCode(code string) (*model.Holding, error) {
+func (s *GormStore) GetHoldingByFundCode(code string) (*model.Holding, error) {
 	var holding model.Holding
-	err := DB.Where("fund_code = ?", code).First(&holding).Error
+	err := s.conn().Where("fund_code = ?", code).First(&holding).Error
 	if err != nil {
 		return nil, err
 	}
@@ -117,54 +234,112 @@ func GetHoldingByFundCode(code string) (*model.Holding, error) {
 }
 
 // GetAllHoldings 获取所有持仓
-func GetAllHoldings() ([]model.Holding, error) {
+func (s *GormStore) GetAllHoldings() ([]model.Holding, error) {
 	var holdings []model.Holding
-	err := DB.Find(&holdings).Error
+	err := s.conn().Find(&holdings).Error
 	return holdings, err
 }
 
+// UpdateHoldingAccount 更新持仓所属账户
+func (s *GormStore) UpdateHoldingAccount(id uint, account string) error {
+	return s.conn().Model(&model.Holding{}).Where("id = ?", id).Update("account", account).Error
+}
+
 // DeleteHolding 删除持仓
-func DeleteHolding(id uint) error {
-	return DB.Delete(&model.Holding{}, id).Error
+func (s *GormStore) DeleteHolding(id uint) error {
+	return s.conn().Delete(&model.Holding{}, id).Error
+}
+
+// === WatchlistItem 操作 ===
+
+// SaveWatchlistItem 保存自选基金
+func (s *GormStore) SaveWatchlistItem(item *model.WatchlistItem) error {
+	return s.conn().Save(item).Error
+}
+
+// GetWatchlistItem 获取自选基金
+func (s *GormStore) GetWatchlistItem(id uint) (*model.WatchlistItem, error) {
+	var item model.WatchlistItem
+	err := s.conn().First(&item, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetWatchlistItemByFundCode 根据基金代码获取自选基金
+func (s *GormStore) GetWatchlistItemByFundCode(code string) (*model.WatchlistItem, error) {
+	var item model.WatchlistItem
+	err := s.conn().Where("fund_code = ?", code).First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetWatchlistItems 获取自选基金，group为空时返回全部
+func (s *GormStore) GetWatchlistItems(group string) ([]model.WatchlistItem, error) {
+	var items []model.WatchlistItem
+	query := s.conn().Order("group_name asc, id asc")
+	if group != "" {
+		query = query.Where("group_name = ?", group)
+	}
+	err := query.Find(&items).Error
+	return items, err
+}
+
+// GetWatchlistGroups 获取所有自选分组
+func (s *GormStore) GetWatchlistGroups() ([]string, error) {
+	var groups []string
+	err := s.conn().Model(&model.WatchlistItem{}).
+		Distinct("group_name").
+		Order("group_name asc").
+		Pluck("group_name", &groups).Error
+	return groups, err
+}
+
+// DeleteWatchlistItem 删除自选基金
+func (s *GormStore) DeleteWatchlistItem(id uint) error {
+	return s.conn().Delete(&model.WatchlistItem{}, id).Error
 }
 
 // === Transaction 操作 ===
 
 // SaveTransaction 保存交易记录
-func SaveTransaction(tx *model.Transaction) error {
-	return DB.Create(tx).Error
+func (s *GormStore) SaveTransaction(tx *model.Transaction) error {
+	return s.conn().Create(tx).Error
 }
 
 // GetTransactionsByFundCode 获取基金的交易记录
-func GetTransactionsByFundCode(code string) ([]model.Transaction, error) {
+func (s *GormStore) GetTransactionsByFundCode(code string) ([]model.Transaction, error) {
 	var txs []model.Transaction
-	err := DB.Where("fund_code = ?", code).Order("trade_date desc").Find(&txs).Error
+	err := s.conn().Where("fund_code = ?", code).Order("trade_date desc").Find(&txs).Error
 	return txs, err
 }
 
 // GetAllTransactions 获取所有交易记录
-func GetAllTransactions() ([]model.Transaction, error) {
+func (s *GormStore) GetAllTransactions() ([]model.Transaction, error) {
 	var txs []model.Transaction
-	err := DB.Order("trade_date desc").Find(&txs).Error
+	err := s.conn().Order("trade_date desc").Find(&txs).Error
 	return txs, err
 }
 
 // DeleteTransaction 删除交易记录
-func DeleteTransaction(id uint) error {
-	return DB.Delete(&model.Transaction{}, id).Error
+func (s *GormStore) DeleteTransaction(id uint) error {
+	return s.conn().Delete(&model.Transaction{}, id).Error
 }
 
 // === Strategy 操作 ===
 
 // SaveStrategy 保存策略
-func SaveStrategy(strategy *model.Strategy) error {
-	return DB.Save(strategy).Error
+func (s *GormStore) SaveStrategy(strategy *model.Strategy) error {
+	return s.conn().Save(strategy).Error
 }
 
 // GetStrategy 获取策略
-func GetStrategy(id uint) (*model.Strategy, error) {
+func (s *GormStore) GetStrategy(id uint) (*model.Strategy, error) {
 	var strategy model.Strategy
-	err := DB.First(&strategy, id).Error
+	err := s.conn().First(&strategy, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -172,53 +347,64 @@ func GetStrategy(id uint) (*model.Strategy, error) {
 }
 
 // GetAllStrategies 获取所有策略
-func GetAllStrategies() ([]model.Strategy, error) {
+func (s *GormStore) GetAllStrategies() ([]model.Strategy, error) {
 	var strategies []model.Strategy
-	err := DB.Find(&strategies).Error
+	err := s.conn().Find(&strategies).Error
 	return strategies, err
 }
 
 // GetActiveStrategies 获取激活的策略
-func GetActiveStrategies() ([]model.Strategy, error) {
+func (s *GormStore) GetActiveStrategies() ([]model.Strategy, error) {
 	var strategies []model.Strategy
-	err := DB.Where("active = ?", true).Find(&strategies).Error
+	err := s.conn().Where("active = ?", true).Find(&strategies).Error
 	return strategies, err
 }
 
 // DeleteStrategy 删除策略
-func DeleteStrategy(id uint) error {
-	return DB.Delete(&model.Strategy{}, id).Error
+func (s *GormStore) DeleteStrategy(id uint) error {
+	return s.conn().Delete(&model.Strategy{}, id).Error
 }
 
 // === NetValueHistory 操作 ===
 
 // SaveNetValueHistory 保存净值历史
-func SaveNetValueHistory(history *model.NetValueHistory) error {
-	return DB.Save(history).Error
+func (s *GormStore) SaveNetValueHistory(history *model.NetValueHistory) error {
+	return s.conn().Save(history).Error
 }
 
 // SaveNetValueHistories 批量保存净值历史
-func SaveNetValueHistories(histories []model.NetValueHistory) error {
+func (s *GormStore) SaveNetValueHistories(histories []model.NetValueHistory) error {
 	if len(histories) == 0 {
 		return nil
 	}
-	return DB.CreateInBatches(histories, 100).Error
+	// 同一基金同一日期已存在时更新(唯一索引见迁移2)
+	return s.conn().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "fund_code"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"net_value", "total_value", "day_growth"}),
+	}).CreateInBatches(histories, 100).Error
 }
 
 // GetNetValueHistory 获取基金净值历史
-func GetNetValueHistory(code string, days int) ([]model.NetValueHistory, error) {
+func (s *GormStore) GetNetValueHistory(code string, days int) ([]model.NetValueHistory, error) {
 	var histories []model.NetValueHistory
-	err := DB.Where("fund_code = ?", code).
+	err := s.conn().Where("fund_code = ?", code).
 		Order("date desc").
 		Limit(days).
 		Find(&histories).Error
 	return histories, err
 }
 
+// GetAllNetValueHistories 获取全部净值历史
+func (s *GormStore) GetAllNetValueHistories() ([]model.NetValueHistory, error) {
+	var histories []model.NetValueHistory
+	err := s.conn().Order("fund_code asc, date asc").Find(&histories).Error
+	return histories, err
+}
+
 // GetLatestNetValue 获取最新净值
-func GetLatestNetValue(code string) (*model.NetValueHistory, error) {
+func (s *GormStore) GetLatestNetValue(code string) (*model.NetValueHistory, error) {
 	var history model.NetValueHistory
-	err := DB.Where("fund_code = ?", code).
+	err := s.conn().Where("fund_code = ?", code).
 		Order("date desc").
 		First(&history).Error
 	if err != nil {
@@ -227,17 +413,89 @@ func GetLatestNetValue(code string) (*model.NetValueHistory, error) {
 	return &history, nil
 }
 
+// GetNetValueOnDate 获取基金指定日期的净值(净值日期按UTC零点存储)
+func (s *GormStore) GetNetValueOnDate(code string, date time.Time) (*model.NetValueHistory, error) {
+	var history model.NetValueHistory
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	err := s.conn().Where("fund_code = ? AND date >= ? AND date < ?", code, start, start.AddDate(0, 0, 1)).
+		First(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// === IntradayEstimate 操作 ===
+
+// SaveIntradayEstimate 保存盘中估值，与最近一条估值时间相同时跳过
+func (s *GormStore) SaveIntradayEstimate(estimate *model.IntradayEstimate) error {
+	var last model.IntradayEstimate
+	err := s.conn().Where("fund_code = ?", estimate.FundCode).Order("est_time desc").First(&last).Error
+	if err == nil && last.EstTime.Equal(estimate.EstTime) {
+		return nil
+	}
+	return s.conn().Create(estimate).Error
+}
+
+// GetIntradayEstimates 获取基金某一天的盘中估值(按时间升序)
+func (s *GormStore) GetIntradayEstimates(code string, date time.Time) ([]model.IntradayEstimate, error) {
+	var estimates []model.IntradayEstimate
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	err := s.conn().Where("fund_code = ? AND est_time >= ? AND est_time < ?", code, start, start.AddDate(0, 0, 1)).
+		Order("est_time asc").
+		Find(&estimates).Error
+	return estimates, err
+}
+
+// GetFinalEstimates 获取基金最近若干个交易日每天最后一条估值(按日期降序)
+func (s *GormStore) GetFinalEstimates(code string, days int) ([]model.IntradayEstimate, error) {
+	var estimates []model.IntradayEstimate
+	err := s.conn().Where("fund_code = ? AND est_time >= ?", code, time.Now().AddDate(0, 0, -days*2)).
+		Order("est_time desc").
+		Find(&estimates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var finals []model.IntradayEstimate
+	seen := make(map[string]bool)
+	for _, e := range estimates {
+		day := e.EstTime.Format("2006-01-02")
+		if seen[day] {
+			continue
+		}
+		seen[day] = true
+		finals = append(finals, e)
+		if len(finals) >= days {
+			break
+		}
+	}
+	return finals, nil
+}
+
 // === AlertRule 操作 ===
 
 // SaveAlertRule 保存提醒规则
-func SaveAlertRule(rule *model.AlertRule) error {
-	return DB.Save(rule).Error
+func (s *GormStore) SaveAlertRule(rule *model.AlertRule) error {
+	return s.conn().Save(rule).Error
+}
+
+// UpdateAlertTriggerState 只更新规则的触发状态，不覆盖同时修改的其他字段
+func (s *GormStore) UpdateAlertTriggerState(id uint, active bool, lastTriggered time.Time) error {
+	return s.conn().Model(&model.AlertRule{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"active": active, "last_triggered": lastTriggered}).Error
+}
+
+// UpdateAlertPeak 只更新回撤提醒的最高点
+func (s *GormStore) UpdateAlertPeak(id uint, peakValue, peakShares float64) error {
+	return s.conn().Model(&model.AlertRule{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"peak_value": peakValue, "peak_shares": peakShares}).Error
 }
 
 // GetAlertRule 获取提醒规则
-func GetAlertRule(id uint) (*model.AlertRule, error) {
+func (s *GormStore) GetAlertRule(id uint) (*model.AlertRule, error) {
 	var rule model.AlertRule
-	err := DB.First(&rule, id).Error
+	err := s.conn().First(&rule, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -245,90 +503,241 @@ func GetAlertRule(id uint) (*model.AlertRule, error) {
 }
 
 // GetAlertRulesByFundCode 获取基金的提醒规则
-func GetAlertRulesByFundCode(code string) ([]model.AlertRule, error) {
+func (s *GormStore) GetAlertRulesByFundCode(code string) ([]model.AlertRule, error) {
 	var rules []model.AlertRule
-	err := DB.Where("fund_code = ?", code).Find(&rules).Error
+	err := s.conn().Where("fund_code = ?", code).Find(&rules).Error
 	return rules, err
 }
 
 // GetAllAlertRules 获取所有提醒规则
-func GetAllAlertRules() ([]model.AlertRule, error) {
+func (s *GormStore) GetAllAlertRules() ([]model.AlertRule, error) {
 	var rules []model.AlertRule
-	err := DB.Find(&rules).Error
+	err := s.conn().Find(&rules).Error
 	return rules, err
 }
 
 // GetEnabledAlertRules 获取启用的提醒规则
-func GetEnabledAlertRules() ([]model.AlertRule, error) {
+func (s *GormStore) GetEnabledAlertRules() ([]model.AlertRule, error) {
 	var rules []model.AlertRule
-	err := DB.Where("enabled = ?", true).Find(&rules).Error
+	err := s.conn().Where("enabled = ?", true).Find(&rules).Error
 	return rules, err
 }
 
 // DeleteAlertRule 删除提醒规则
-func DeleteAlertRule(id uint) error {
-	return DB.Delete(&model.AlertRule{}, id).Error
+func (s *GormStore) DeleteAlertRule(id uint) error {
+	return s.conn().Delete(&model.AlertRule{}, id).Error
+}
+
+// GetAlertRulesByHoldingID 获取持仓关联的提醒规则
+func (s *GormStore) GetAlertRulesByHoldingID(holdingID uint) ([]model.AlertRule, error) {
+	var rules []model.AlertRule
+	err := s.conn().Where("holding_id = ?", holdingID).Find(&rules).Error
+	return rules, err
+}
+
+// DeleteAlertRulesByHoldingID 删除持仓关联的提醒规则
+func (s *GormStore) DeleteAlertRulesByHoldingID(holdingID uint) error {
+	return s.conn().Where("holding_id = ?", holdingID).Delete(&model.AlertRule{}).Error
 }
 
 // === AlertHistory 操作 ===
 
 // SaveAlertHistory 保存提醒历史
-func SaveAlertHistory(history *model.AlertHistory) error {
-	return DB.Create(history).Error
+func (s *GormStore) SaveAlertHistory(history *model.AlertHistory) error {
+	return s.conn().Create(history).Error
 }
 
 // GetAlertHistoryByFundCode 获取基金的提醒历史
-func GetAlertHistoryByFundCode(code string, limit int) ([]model.AlertHistory, error) {
+func (s *GormStore) GetAlertHistoryByFundCode(code string, limit int) ([]model.AlertHistory, error) {
 	var histories []model.AlertHistory
-	err := DB.Where("fund_code = ?", code).
+	err := s.conn().Where("fund_code = ?", code).
 		Order("triggered_at desc").
 		Limit(limit).
 		Find(&histories).Error
 	return histories, err
 }
 
+// GetAllAlertHistory 获取全部提醒历史
+func (s *GormStore) GetAllAlertHistory() ([]model.AlertHistory, error) {
+	var history []model.AlertHistory
+	err := s.conn().Order("created_at asc").Find(&history).Error
+	return history, err
+}
+
 // GetUnreadAlertHistory 获取未读提醒
-func GetUnreadAlertHistory() ([]model.AlertHistory, error) {
+func (s *GormStore) GetUnreadAlertHistory() ([]model.AlertHistory, error) {
 	var histories []model.AlertHistory
-	err := DB.Where("is_read = ?", false).
+	err := s.conn().Where("is_read = ?", false).
 		Order("triggered_at desc").
 		Find(&histories).Error
 	return histories, err
 }
 
 // MarkAlertAsRead 标记提醒为已读
-func MarkAlertAsRead(id uint) error {
-	return DB.Model(&model.AlertHistory{}).Where("id = ?", id).Update("is_read", true).Error
+func (s *GormStore) MarkAlertAsRead(id uint) error {
+	return s.conn().Model(&model.AlertHistory{}).Where("id = ?", id).Update("is_read", true).Error
+}
+
+// UpdateAlertDelivery 更新提醒的投递状态
+func (s *GormStore) UpdateAlertDelivery(id uint, status, errMsg string, attempts int, deliveredAt time.Time) error {
+	return s.conn().Model(&model.AlertHistory{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"delivery_status": status,
+		"delivery_error":  errMsg,
+		"attempts":        gorm.Expr("attempts + ?", attempts),
+		"delivered_at":    deliveredAt,
+	}).Error
+}
+
+// GetAlertHistoryBetween 获取时间区间内触发的提醒
+func (s *GormStore) GetAlertHistoryBetween(from, to time.Time) ([]model.AlertHistory, error) {
+	var histories []model.AlertHistory
+	err := s.conn().Where("triggered_at > ? AND triggered_at <= ?", from, to).
+		Order("triggered_at desc").
+		Find(&histories).Error
+	return histories, err
+}
+
+// GetRecentAlertHistory 获取最近的提醒历史
+func (s *GormStore) GetRecentAlertHistory(limit int) ([]model.AlertHistory, error) {
+	var histories []model.AlertHistory
+	err := s.conn().Order("triggered_at desc").Limit(limit).Find(&histories).Error
+	return histories, err
+}
+
+// === NotifyChannel 操作 ===
+
+// SaveNotifyChannel 保存通知渠道
+func (s *GormStore) SaveNotifyChannel(channel *model.NotifyChannel) error {
+	return s.conn().Save(channel).Error
+}
+
+// GetNotifyChannel 获取通知渠道
+func (s *GormStore) GetNotifyChannel(id uint) (*model.NotifyChannel, error) {
+	var channel model.NotifyChannel
+	err := s.conn().First(&channel, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// GetAllNotifyChannels 获取所有通知渠道
+func (s *GormStore) GetAllNotifyChannels() ([]model.NotifyChannel, error) {
+	var channels []model.NotifyChannel
+	err := s.conn().Order("id").Find(&channels).Error
+	return channels, err
+}
+
+// DeleteNotifyChannel 删除通知渠道
+func (s *GormStore) DeleteNotifyChannel(id uint) error {
+	return s.conn().Delete(&model.NotifyChannel{}, id).Error
+}
+
+// === AlertSettings 操作 ===
+
+// GetAlertSettings 获取提醒设置，未保存过时返回默认值
+func (s *GormStore) GetAlertSettings() (*model.AlertSettings, error) {
+	settings := &model.AlertSettings{
+		ID:            1,
+		QuietStart:    "22:00",
+		QuietEnd:      "08:00",
+		DigestMinutes: 30,
+	}
+	err := s.conn().Where("id = ?", 1).Limit(1).Find(settings).Error
+	return settings, err
+}
+
+// SaveAlertSettings 保存提醒设置
+func (s *GormStore) SaveAlertSettings(settings *model.AlertSettings) error {
+	settings.ID = 1
+	return s.conn().Save(settings).Error
 }
 
 // === FundRanking 操作 ===
 
 // SaveFundRanking 保存基金排行
-func SaveFundRanking(ranking *model.FundRanking) error {
-	return DB.Save(ranking).Error
+func (s *GormStore) SaveFundRanking(ranking *model.FundRanking) error {
+	return s.conn().Save(ranking).Error
+}
+
+// SaveFundRankings 保存一期排行，覆盖同一统计日的旧数据
+func (s *GormStore) SaveFundRankings(rankings []model.FundRanking) error {
+	if len(rankings) == 0 {
+		return nil
+	}
+	first := rankings[0]
+	return s.conn().Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Where("rank_type = ? AND period = ? AND category = ? AND stat_date = ?",
+				first.RankType, first.Period, first.Category, first.StatDate).
+			Delete(&model.FundRanking{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.CreateInBatches(rankings, 100).Error
+	})
 }
 
-// GetFundRankingByType 获取指定类型的排行
-func GetFundRankingByType(rankType, period string, limit int) ([]model.FundRanking, error) {
+// GetFundRankingByType 获取指定类型最新一期的排行
+func (s *GormStore) GetFundRankingByType(rankType, period, category string, limit int) ([]model.FundRanking, error) {
 	var rankings []model.FundRanking
-	err := DB.Where("rank_type = ? AND period = ?", rankType, period).
+	latest := s.conn().Model(&model.FundRanking{}).
+		Select("MAX(stat_date)").
+		Where("rank_type = ? AND period = ? AND category = ?", rankType, period, category)
+	err := s.conn().Where("rank_type = ? AND period = ? AND category = ? AND stat_date = (?)", rankType, period, category, latest).
 		Order("rank_position asc").
 		Limit(limit).
 		Find(&rankings).Error
 	return rankings, err
 }
 
+// GetFundRankHistory 获取基金的历史排名(按统计日降序)
+func (s *GormStore) GetFundRankHistory(code, rankType, period, category string, limit int) ([]model.FundRanking, error) {
+	var rankings []model.FundRanking
+	err := s.conn().Where("fund_code = ? AND rank_type = ? AND period = ? AND category = ?", code, rankType, period, category).
+		Order("stat_date desc").
+		Limit(limit).
+		Find(&rankings).Error
+	return rankings, err
+}
+
+// GetPreviousFundRanks 批量获取基金在before之前最近一期的排名，按基金代码索引
+func (s *GormStore) GetPreviousFundRanks(codes []string, rankType, period, category string, before time.Time) (map[string]model.FundRanking, error) {
+	ranks := make(map[string]model.FundRanking, len(codes))
+	for start := 0; start < len(codes); start += 500 {
+		end := min(start+500, len(codes))
+		latest := s.conn().Model(&model.FundRanking{}).
+			Select("fund_code, MAX(stat_date) AS stat_date").
+			Where("fund_code IN ? AND rank_type = ? AND period = ? AND category = ? AND stat_date < ?",
+				codes[start:end], rankType, period, category, before).
+			Group("fund_code")
+		var batch []model.FundRanking
+		err := s.conn().Table("fund_rankings AS r").
+			Select("r.*").
+			Joins("JOIN (?) AS m ON r.fund_code = m.fund_code AND r.stat_date = m.stat_date", latest).
+			Where("r.rank_type = ? AND r.period = ? AND r.category = ?", rankType, period, category).
+			Find(&batch).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, rank := range batch {
+			ranks[rank.FundCode] = rank
+		}
+	}
+	return ranks, nil
+}
+
 // === FundRiskProfile 操作 ===
 
 // SaveFundRiskProfile 保存风险档案
-func SaveFundRiskProfile(profile *model.FundRiskProfile) error {
-	return DB.Save(profile).Error
+func (s *GormStore) SaveFundRiskProfile(profile *model.FundRiskProfile) error {
+	return s.conn().Save(profile).Error
 }
 
 // GetFundRiskProfile 获取风险档案
-func GetFundRiskProfile(code string) (*model.FundRiskProfile, error) {
+func (s *GormStore) GetFundRiskProfile(code string) (*model.FundRiskProfile, error) {
 	var profile model.FundRiskProfile
-	err := DB.Where("fund_code = ?", code).First(&profile).Error
+	err := s.conn().Where("fund_code = ?", code).First(&profile).Error
 	if err != nil {
 		return nil, err
 	}
@@ -338,14 +747,14 @@ func GetFundRiskProfile(code string) (*model.FundRiskProfile, error) {
 // === InstitutionHolding 操作 ===
 
 // SaveInstitutionHolding 保存机构持仓
-func SaveInstitutionHolding(holding *model.InstitutionHolding) error {
-	return DB.Save(holding).Error
+func (s *GormStore) SaveInstitutionHolding(holding *model.InstitutionHolding) error {
+	return s.conn().Save(holding).Error
 }
 
 // GetInstitutionHolding 获取机构持仓
-func GetInstitutionHolding(code string) (*model.InstitutionHolding, error) {
+func (s *GormStore) GetInstitutionHolding(code string) (*model.InstitutionHolding, error) {
 	var holding model.InstitutionHolding
-	err := DB.Where("fund_code = ?", code).
+	err := s.conn().Where("fund_code = ?", code).
 		Order("report_date desc").
 		First(&holding).Error
 	if err != nil {
@@ -357,39 +766,385 @@ func GetInstitutionHolding(code string) (*model.InstitutionHolding, error) {
 // === TradingSignal 操作 ===
 
 // SaveTradingSignal 保存交易信号
-func SaveTradingSignal(signal *model.TradingSignal) error {
-	return DB.Create(signal).Error
+func (s *GormStore) SaveTradingSignal(signal *model.TradingSignal) error {
+	return s.conn().Create(signal).Error
+}
+
+// GetLatestSignal 获取基金最新的交易信号
+func (s *GormStore) GetLatestSignal(code string) (*model.TradingSignal, error) {
+	var signal model.TradingSignal
+	err := s.conn().Where("fund_code = ?", code).
+		Order("generated_at desc").
+		First(&signal).Error
+	if err != nil {
+		return nil, err
+	}
+	return &signal, nil
 }
 
 // GetActiveSignals 获取有效信号
-func GetActiveSignals() ([]model.TradingSignal, error) {
+func (s *GormStore) GetActiveSignals() ([]model.TradingSignal, error) {
 	var signals []model.TradingSignal
-	err := DB.Where("is_valid = ?", true).
+	err := s.conn().Where("is_valid = ?", true).
 		Order("generated_at desc").
 		Find(&signals).Error
 	return signals, err
 }
 
+// GetSignalsBetween 获取时间区间内生成的信号
+func (s *GormStore) GetSignalsBetween(from, to time.Time) ([]model.TradingSignal, error) {
+	var signals []model.TradingSignal
+	err := s.conn().Where("generated_at > ? AND generated_at <= ?", from, to).
+		Order("generated_at desc").
+		Find(&signals).Error
+	return signals, err
+}
+
+// SupersedeSignals 将基金除exceptID外的有效信号标记为被新信号取代
+func (s *GormStore) SupersedeSignals(code string, exceptID uint, at time.Time) error {
+	return s.conn().Model(&model.TradingSignal{}).
+		Where("fund_code = ? AND is_valid = ? AND id <> ?", code, true, exceptID).
+		Updates(map[string]interface{}{"is_valid": false, "status": "superseded", "closed_at": at}).Error
+}
+
+// CloseSignal 将信号标记为失效
+func (s *GormStore) CloseSignal(id uint, status string, at time.Time) error {
+	return s.conn().Model(&model.TradingSignal{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"is_valid": false, "status": status, "closed_at": at}).Error
+}
+
+// GetSignalHistory 获取指定时间以来的信号(按生成时间升序)，code为空时返回全部基金
+func (s *GormStore) GetSignalHistory(code string, from time.Time) ([]model.TradingSignal, error) {
+	var signals []model.TradingSignal
+	query := s.conn().Where("generated_at >= ?", from)
+	if code != "" {
+		query = query.Where("fund_code = ?", code)
+	}
+	err := query.Order("generated_at asc").Find(&signals).Error
+	return signals, err
+}
+
+// === SignalStrategy 操作 ===
+
+// SaveSignalStrategy 保存信号策略，设为默认时取消其他策略的默认标记
+func (s *GormStore) SaveSignalStrategy(strategy *model.SignalStrategy) error {
+	return s.conn().Transaction(func(tx *gorm.DB) error {
+		if strategy.IsDefault {
+			if err := tx.Model(&model.SignalStrategy{}).Where("id <> ?", strategy.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(strategy).Error
+	})
+}
+
+// GetSignalStrategy 获取信号策略
+func (s *GormStore) GetSignalStrategy(id uint) (*model.SignalStrategy, error) {
+	var strategy model.SignalStrategy
+	err := s.conn().First(&strategy, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &strategy, nil
+}
+
+// GetDefaultSignalStrategy 获取默认信号策略
+func (s *GormStore) GetDefaultSignalStrategy() (*model.SignalStrategy, error) {
+	var strategy model.SignalStrategy
+	err := s.conn().Where("is_default = ?", true).First(&strategy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &strategy, nil
+}
+
+// GetAllSignalStrategies 获取所有信号策略
+func (s *GormStore) GetAllSignalStrategies() ([]model.SignalStrategy, error) {
+	var strategies []model.SignalStrategy
+	err := s.conn().Order("id").Find(&strategies).Error
+	return strategies, err
+}
+
+// DeleteSignalStrategy 删除信号策略
+func (s *GormStore) DeleteSignalStrategy(id uint) error {
+	return s.conn().Delete(&model.SignalStrategy{}, id).Error
+}
+
+// === PortfolioReport 操作 ===
+
+// SavePortfolioReport 保存报告，同一周期同一净值日只保留一份
+func (s *GormStore) SavePortfolioReport(report *model.PortfolioReport) error {
+	return s.conn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("period = ? AND end_date = ?", report.Period, report.EndDate).
+			Delete(&model.PortfolioReport{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(report).Error
+	})
+}
+
+// GetLatestPortfolioReport 获取指定周期在某净值日之前的最近一份报告
+func (s *GormStore) GetLatestPortfolioReport(period string, before time.Time) (*model.PortfolioReport, error) {
+	var report model.PortfolioReport
+	err := s.conn().Where("period = ? AND end_date < ?", period, before).
+		Order("end_date desc").
+		First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetPortfolioReports 获取最近的报告
+func (s *GormStore) GetPortfolioReports(limit int) ([]model.PortfolioReport, error) {
+	var reports []model.PortfolioReport
+	err := s.conn().Order("end_date desc, period asc").Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+// UpdateReportDelivery 更新报告推送状态
+func (s *GormStore) UpdateReportDelivery(id uint, status string) error {
+	return s.conn().Model(&model.PortfolioReport{}).Where("id = ?", id).Update("delivery_status", status).Error
+}
+
+// GetReportSettings 获取报告设置，未保存过时返回默认值
+func (s *GormStore) GetReportSettings() (*model.ReportSettings, error) {
+	settings := &model.ReportSettings{
+		ID:         1,
+		GenerateAt: "21:30",
+		Formats:    "md,html",
+	}
+	err := s.conn().Where("id = ?", 1).Limit(1).Find(settings).Error
+	return settings, err
+}
+
+// SaveReportSettings 保存报告设置
+func (s *GormStore) SaveReportSettings(settings *model.ReportSettings) error {
+	settings.ID = 1
+	return s.conn().Save(settings).Error
+}
+
+// GetBackupSettings 获取自动备份设置，未保存过时返回默认值
+func (s *GormStore) GetBackupSettings() (*model.BackupSettings, error) {
+	settings := &model.BackupSettings{
+		ID:            1,
+		IntervalHours: 24,
+		Keep:          7,
+	}
+	err := s.conn().Where("id = ?", 1).Limit(1).Find(settings).Error
+	return settings, err
+}
+
+// SaveBackupSettings 保存自动备份设置
+func (s *GormStore) SaveBackupSettings(settings *model.BackupSettings) error {
+	settings.ID = 1
+	return s.conn().Save(settings).Error
+}
+
+// DefaultAppSettings 应用设置默认值
+func DefaultAppSettings() *model.AppSettings {
+	return &model.AppSettings{
+		ID:             1,
+		RefreshMinutes: 5,
+		RefreshPolicy:  "trading",
+		MonitorMinutes: 1,
+		RiskFreeRate:   2.0,
+		MCSimulations:  1000,
+		BuyFeeRate:     0.15,
+		SellFeeRate:    0.5,
+		Theme:          "light",
+	}
+}
+
+// GetAppSettings 获取应用设置，未保存过时返回默认值
+func (s *GormStore) GetAppSettings() (*model.AppSettings, error) {
+	settings := DefaultAppSettings()
+	err := s.conn().Where("id = ?", 1).Limit(1).Find(settings).Error
+	return settings, err
+}
+
+// SaveAppSettings 保存应用设置
+func (s *GormStore) SaveAppSettings(settings *model.AppSettings) error {
+	settings.ID = 1
+	return s.conn().Save(settings).Error
+}
+
 // === ImportRecord 操作 ===
 
 // SaveImportRecord 保存导入记录
-func SaveImportRecord(record *model.ImportRecord) error {
-	return DB.Create(record).Error
+func (s *GormStore) SaveImportRecord(record *model.ImportRecord) error {
+	return s.conn().Create(record).Error
+}
+
+// GetImportRecords 获取最近的导入记录
+func (s *GormStore) GetImportRecords(limit int) ([]model.ImportRecord, error) {
+	var records []model.ImportRecord
+	err := s.conn().Order("imported_at desc").Limit(limit).Find(&records).Error
+	return records, err
 }
 
 // === QDIIFund 操作 ===
 
 // SaveQDIIFund 保存QDII基金
-func SaveQDIIFund(fund *model.QDIIFund) error {
-	return DB.Save(fund).Error
+func (s *GormStore) SaveQDIIFund(fund *model.QDIIFund) error {
+	return s.conn().Save(fund).Error
 }
 
 // GetQDIIFund 获取QDII基金
-func GetQDIIFund(code string) (*model.QDIIFund, error) {
+func (s *GormStore) GetQDIIFund(code string) (*model.QDIIFund, error) {
 	var fund model.QDIIFund
-	err := DB.Where("fund_code = ?", code).First(&fund).Error
+	err := s.conn().Where("fund_code = ?", code).First(&fund).Error
 	if err != nil {
 		return nil, err
 	}
 	return &fund, nil
 }
+
+// GetAllQDIIFunds 获取所有QDII基金
+func (s *GormStore) GetAllQDIIFunds() ([]model.QDIIFund, error) {
+	var funds []model.QDIIFund
+	err := s.conn().Order("fund_code").Find(&funds).Error
+	return funds, err
+}
+
+// === ExchangeRate 操作 ===
+
+// SaveExchangeRates 保存汇率，同一币种同一日期覆盖
+func (s *GormStore) SaveExchangeRates(rates []model.ExchangeRate) error {
+	return s.conn().Transaction(func(tx *gorm.DB) error {
+		for _, r := range rates {
+			err := tx.Where(model.ExchangeRate{Currency: r.Currency, Date: r.Date}).
+				Assign(model.ExchangeRate{Rate: r.Rate}).
+				FirstOrCreate(&model.ExchangeRate{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetExchangeRateOn 获取指定日期(含)之前最近一天的汇率(日期按UTC零点存储)
+func (s *GormStore) GetExchangeRateOn(currency string, date time.Time) (*model.ExchangeRate, error) {
+	end := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	var rate model.ExchangeRate
+	err := s.conn().Where("currency = ? AND date < ?", currency, end).
+		Order("date desc").
+		First(&rate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// GetExchangeRates 获取指定日期以来的汇率，按日期升序
+func (s *GormStore) GetExchangeRates(currency string, from time.Time) ([]model.ExchangeRate, error) {
+	var rates []model.ExchangeRate
+	err := s.conn().Where("currency = ? AND date >= ?", currency, from).
+		Order("date asc").
+		Find(&rates).Error
+	return rates, err
+}
+
+// === FundProfile 操作 ===
+
+// SaveFundProfile 保存基金概况
+func (s *GormStore) SaveFundProfile(profile *model.FundProfile) error {
+	profile.UpdatedAt = time.Now()
+	return s.conn().Save(profile).Error
+}
+
+// GetFundProfiles 批量获取基金概况，按基金代码索引
+func (s *GormStore) GetFundProfiles(codes []string) (map[string]model.FundProfile, error) {
+	profiles := make(map[string]model.FundProfile, len(codes))
+	for start := 0; start < len(codes); start += 500 {
+		end := min(start+500, len(codes))
+		var batch []model.FundProfile
+		if err := s.conn().Where("fund_code IN ?", codes[start:end]).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range batch {
+			profiles[p.FundCode] = p
+		}
+	}
+	return profiles, nil
+}
+
+// GetFundProfile 获取基金概况
+func (s *GormStore) GetFundProfile(code string) (*model.FundProfile, error) {
+	var profile model.FundProfile
+	err := s.conn().Where("fund_code = ?", code).First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// === FundEstimateModel 操作 ===
+
+// SaveFundEstimateModel 保存基金估值模型
+func (s *GormStore) SaveFundEstimateModel(m *model.FundEstimateModel) error {
+	m.UpdatedAt = time.Now()
+	return s.conn().Save(m).Error
+}
+
+// GetFundEstimateModel 获取基金估值模型
+func (s *GormStore) GetFundEstimateModel(code string) (*model.FundEstimateModel, error) {
+	var m model.FundEstimateModel
+	err := s.conn().Where("fund_code = ?", code).First(&m).Error
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// === FundMetric 操作 ===
+
+// SaveFundMetric 保存基金筛选指标
+func (s *GormStore) SaveFundMetric(metric *model.FundMetric) error {
+	metric.UpdatedAt = time.Now()
+	return s.conn().Save(metric).Error
+}
+
+// GetAllFundMetrics 获取所有基金筛选指标
+func (s *GormStore) GetAllFundMetrics() ([]model.FundMetric, error) {
+	var metrics []model.FundMetric
+	err := s.conn().Find(&metrics).Error
+	return metrics, err
+}
+
+// === FundScreen 操作 ===
+
+// SaveFundScreen 保存筛选方案
+func (s *GormStore) SaveFundScreen(screen *model.FundScreen) error {
+	return s.conn().Save(screen).Error
+}
+
+// GetAllFundScreens 获取所有筛选方案
+func (s *GormStore) GetAllFundScreens() ([]model.FundScreen, error) {
+	var screens []model.FundScreen
+	err := s.conn().Order("name asc").Find(&screens).Error
+	return screens, err
+}
+
+// DeleteFundScreen 删除筛选方案
+func (s *GormStore) DeleteFundScreen(id uint) error {
+	return s.conn().Delete(&model.FundScreen{}, id).Error
+}
+
+// SaveNewNetValueHistories 仅保存比本地最新记录更新的净值历史
+func (s *GormStore) SaveNewNetValueHistories(code string, histories []model.NetValueHistory) error {
+	latest, err := s.GetLatestNetValue(code)
+	if err != nil {
+		return s.SaveNetValueHistories(histories)
+	}
+
+	var fresh []model.NetValueHistory
+	for _, h := range histories {
+		if h.Date.After(latest.Date) {
+			fresh = append(fresh, h)
+		}
+	}
+	return s.SaveNetValueHistories(fresh)
+}
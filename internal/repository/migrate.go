@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"jijin/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Migration 数据库结构迁移，按版本号依次执行，每个迁移在一个事务中完成。
+// 迁移一旦发布不再修改，模型变化需要新增迁移
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:100"`
+	AppliedAt time.Time
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus 迁移及其执行状态
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// frozenTable 迁移中冻结的表结构，迁移不依赖会继续变化的模型
+type frozenTable struct {
+	name       string
+	columns    []string // 列定义，按建表顺序
+	primaryKey string   // 表级主键，列定义中已含主键时为空
+	indexes    []string
+}
+
+// createSQL 建表语句
+func (t frozenTable) createSQL() string {
+	defs := t.columns
+	if t.primaryKey != "" {
+		defs = append(defs[:len(defs):len(defs)], "PRIMARY KEY ("+t.primaryKey+")")
+	}
+	return fmt.Sprintf("CREATE TABLE `%s` (%s)", t.name, strings.Join(defs, ","))
+}
+
+// createFrozenTables 建立缺少的表；表已存在时补齐缺少的列(主键列除外)和索引
+func createFrozenTables(tx *gorm.DB, tables []frozenTable) error {
+	for _, t := range tables {
+		if !tx.Migrator().HasTable(t.name) {
+			if err := tx.Exec(t.createSQL()).Error; err != nil {
+				return err
+			}
+		} else {
+			for _, def := range t.columns {
+				column := strings.Trim(strings.Fields(def)[0], "`")
+				if strings.Contains(def, "PRIMARY KEY") || tx.Migrator().HasColumn(t.name, column) {
+					continue
+				}
+				if err := tx.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s", t.name, def)).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for _, index := range t.indexes {
+			if err := tx.Exec(index).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrations 全部迁移，版本号递增
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "初始结构",
+		// 早期版本由AutoMigrate建表，对已有数据库执行时只补齐缺少的表和列
+		Up: func(tx *gorm.DB) error {
+			return createFrozenTables(tx, schemaV1)
+		},
+		Down: func(tx *gorm.DB) error {
+			for i := len(schemaV1) - 1; i >= 0; i-- {
+				if err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", schemaV1[i].name)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 2,
+		Name:    "净值历史按基金和日期去重并建立唯一索引",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec(`DELETE FROM net_value_histories WHERE id NOT IN (
+				SELECT MAX(id) FROM net_value_histories GROUP BY fund_code, date)`).Error; err != nil {
+				return err
+			}
+			return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_nav_fund_date ON net_value_histories(fund_code, date)").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP INDEX IF EXISTS idx_nav_fund_date").Error
+		},
+	},
+	{
+		Version: 3,
+		Name:    "回撤提醒按持仓市值记录最高点",
+		// 旧规则的最高点是净值，按当前份额折算为市值
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("ALTER TABLE `alert_rules` ADD COLUMN `peak_shares` real").Error; err != nil {
+				return err
+			}
+			return tx.Exec(`UPDATE alert_rules SET
+				peak_shares = (SELECT shares FROM holdings WHERE holdings.id = alert_rules.holding_id),
+				peak_value = peak_value * (SELECT shares FROM holdings WHERE holdings.id = alert_rules.holding_id)
+				WHERE alert_type = 'trailing_stop' AND (peak_shares IS NULL OR peak_shares = 0)
+				AND holding_id IN (SELECT id FROM holdings)`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Exec(`UPDATE alert_rules SET peak_value = peak_value / peak_shares
+				WHERE alert_type = 'trailing_stop' AND peak_shares > 0`).Error; err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE `alert_rules` DROP COLUMN `peak_shares`").Error
+		},
+	},
+}
+
+// SchemaVersion 当前程序的数据库结构版本，即最新迁移的版本号
+var SchemaVersion = migrations[len(migrations)-1].Version
+
+// beforeMigrate 执行迁移前的回调，用于备份已有数据
+var beforeMigrate func(from, to int) error
+
+// SetBeforeMigrate 设置迁移前回调，已有数据的数据库升级前调用，返回错误时不执行迁移
+func SetBeforeMigrate(fn func(from, to int) error) {
+	beforeMigrate = fn
+}
+
+// Migrations 全部迁移
+func Migrations() []Migration {
+	return migrations
+}
+
+// appliedMigrations 已执行的迁移
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// CurrentSchemaVersion 数据库已执行到的迁移版本，0表示新数据库
+func CurrentSchemaVersion(db *gorm.DB) (int, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// GetMigrationStatus 全部迁移及执行状态
+func GetMigrationStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i] = MigrationStatus{Migration: m}
+		if r, ok := applied[m.Version]; ok {
+			status[i].Applied = true
+			status[i].AppliedAt = r.AppliedAt
+		}
+	}
+	return status, nil
+}
+
+// MigrateUp 执行版本不超过target的未执行迁移，target<=0表示全部，已有数据时先调用迁移前回调
+func MigrateUp(db *gorm.DB, target int) error {
+	return migrateUp(db, target, beforeMigrate)
+}
+
+// MigrateDB 对指定数据库执行全部未执行的迁移，不做迁移前备份
+func MigrateDB(db *gorm.DB) error {
+	return migrateUp(db, 0, nil)
+}
+
+// migrateUp 执行迁移，backup不为空时在已有数据的数据库迁移前调用
+func migrateUp(db *gorm.DB, target int, backup func(from, to int) error) error {
+	if target <= 0 {
+		target = SchemaVersion
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	current := 0
+	for v := range applied {
+		if v > current {
+			current = v
+		}
+	}
+	if current > SchemaVersion {
+		return fmt.Errorf("数据库结构版本%d高于程序支持的%d，请升级应用", current, SchemaVersion)
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok && m.Version <= target {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// 已有数据时先备份；早期版本的数据库没有迁移记录但已有表
+	if backup != nil && (current > 0 || db.Migrator().HasTable(&model.Holding{})) {
+		if err := backup(current, pending[len(pending)-1].Version); err != nil {
+			return fmt.Errorf("迁移前备份失败: %v", err)
+		}
+	}
+
+	for _, m := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("迁移%d(%s)失败: %v", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown 回退版本大于target的已执行迁移，按版本从高到低执行
+func MigrateDown(db *gorm.DB, target int) error {
+	if target < 0 {
+		return fmt.Errorf("目标版本不能为负数")
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	var rollback []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok && m.Version > target {
+			rollback = append(rollback, m)
+		}
+	}
+	sort.Slice(rollback, func(i, j int) bool { return rollback[i].Version > rollback[j].Version })
+	if len(rollback) > 0 && beforeMigrate != nil {
+		current, _ := CurrentSchemaVersion(db)
+		if err := beforeMigrate(current, target); err != nil {
+			return fmt.Errorf("迁移前备份失败: %v", err)
+		}
+	}
+
+	for _, m := range rollback {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("回退迁移%d(%s)失败: %v", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
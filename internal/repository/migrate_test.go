@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"testing"
+
+	"jijin/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// models 程序使用的全部模型，迁移后的结构必须包含其所有列
+var models = []interface{}{
+	&model.Fund{},
+	&model.Holding{},
+	&model.WatchlistItem{},
+	&model.Transaction{},
+	&model.Strategy{},
+	&model.NetValueHistory{},
+	&model.IntradayEstimate{},
+	&model.AlertRule{},
+	&model.AlertHistory{},
+	&model.AlertSettings{},
+	&model.NotifyChannel{},
+	&model.FundRanking{},
+	&model.FundRiskProfile{},
+	&model.InstitutionHolding{},
+	&model.RecoveryPrediction{},
+	&model.ProfitProbability{},
+	&model.TradingSignal{},
+	&model.SignalStrategy{},
+	&model.ImportRecord{},
+	&model.QDIIFund{},
+	&model.ExchangeRate{},
+	&model.FundProfile{},
+	&model.FundMetric{},
+	&model.FundScreen{},
+	&model.FundEstimateModel{},
+	&model.PortfolioReport{},
+	&model.ReportSettings{},
+	&model.BackupSettings{},
+	&model.AppSettings{},
+}
+
+// openBlank 未执行任何迁移的内存数据库
+func openBlank(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func assertSchemaMatchesModels(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			t.Fatal(err)
+		}
+		if !db.Migrator().HasTable(stmt.Schema.Table) {
+			t.Fatalf("缺少表%s，模型变化需要新增迁移", stmt.Schema.Table)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(stmt.Schema.Table, field.DBName) {
+				t.Fatalf("表%s缺少列%s，模型变化需要新增迁移", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestMigrationsMatchModels(t *testing.T) {
+	db := openBlank(t)
+	if err := MigrateDB(db); err != nil {
+		t.Fatal(err)
+	}
+	assertSchemaMatchesModels(t, db)
+
+	// 全部回退后再升级，结构与模型一致
+	if err := MigrateDown(db, 0); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("holdings") {
+		t.Fatal("回退到0后不应保留表")
+	}
+	if err := MigrateUp(db, 0); err != nil {
+		t.Fatal(err)
+	}
+	assertSchemaMatchesModels(t, db)
+}
+
+func TestInitialMigrationPatchesEarlyDatabase(t *testing.T) {
+	// 早期版本由AutoMigrate建的表缺少后来增加的列，迁移1补齐
+	db := openBlank(t)
+	if err := db.Exec("CREATE TABLE `holdings` (`id` integer PRIMARY KEY AUTOINCREMENT,`fund_code` text,`shares` real)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO holdings (fund_code, shares) VALUES ('000001', 100)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateDB(db); err != nil {
+		t.Fatal(err)
+	}
+	assertSchemaMatchesModels(t, db)
+
+	holding, err := NewGormStore(db).GetHoldingByFundCode("000001")
+	if err != nil || holding.Shares != 100 {
+		t.Fatalf("已有数据应保留: %+v, %v", holding, err)
+	}
+}
+
+func TestTrailingStopPeakMigration(t *testing.T) {
+	db := openBlank(t)
+	if err := MigrateUp(db, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO holdings (id, fund_code, shares) VALUES (1, '000001', 500)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO alert_rules (fund_code, alert_type, holding_id, peak_value) VALUES ('000001', 'trailing_stop', 1, 1.2)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧规则的最高净值按份额折算为市值
+	if err := MigrateUp(db, 3); err != nil {
+		t.Fatal(err)
+	}
+	var rule model.AlertRule
+	if err := db.First(&rule).Error; err != nil {
+		t.Fatal(err)
+	}
+	if rule.PeakValue != 600 || rule.PeakShares != 500 {
+		t.Fatalf("want peak 600 (500份), got %v (%v份)", rule.PeakValue, rule.PeakShares)
+	}
+
+	if err := MigrateDown(db, 2); err != nil {
+		t.Fatal(err)
+	}
+	var peak float64
+	if err := db.Raw("SELECT peak_value FROM alert_rules").Scan(&peak).Error; err != nil {
+		t.Fatal(err)
+	}
+	if peak != 1.2 || db.Migrator().HasColumn("alert_rules", "peak_shares") {
+		t.Fatalf("回退后应恢复最高净值并删除份额列，got %v", peak)
+	}
+}
@@ -0,0 +1,579 @@
+package repository
+
+// schemaV1 迁移1(初始结构)的表结构，冻结自建立迁移体系时的模型，不随模型变化。
+// 之后的结构变化(包括新增列)都需要新增迁移
+var schemaV1 = []frozenTable{
+	{
+		name: "funds",
+		columns: []string{
+			"`code` text",
+			"`name` text",
+			"`type` text",
+			"`net_value` real",
+			"`total_value` real",
+			"`day_growth` real",
+			"`est_value` real",
+			"`est_growth` real",
+			"`est_time` datetime",
+			"`est_source` text",
+			"`updated_at` datetime",
+		},
+		primaryKey: "`code`",
+	},
+	{
+		name: "holdings",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`shares` real",
+			"`cost` real",
+			"`cost_price` real",
+			"`current_nav` real",
+			"`nav_date` datetime",
+			"`account` text",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_holdings_fund_code` ON `holdings`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_holdings_deleted_at` ON `holdings`(`deleted_at`)",
+		},
+	},
+	{
+		name: "watchlist_items",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`group_name` text",
+			"`note` text",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_watchlist_items_deleted_at` ON `watchlist_items`(`deleted_at`)",
+			"CREATE INDEX IF NOT EXISTS `idx_watchlist_items_group_name` ON `watchlist_items`(`group_name`)",
+			"CREATE INDEX IF NOT EXISTS `idx_watchlist_items_fund_code` ON `watchlist_items`(`fund_code`)",
+		},
+	},
+	{
+		name: "transactions",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`type` text",
+			"`amount` real",
+			"`net_value` real",
+			"`shares` real",
+			"`fee` real",
+			"`trade_date` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_transactions_fund_code` ON `transactions`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_transactions_deleted_at` ON `transactions`(`deleted_at`)",
+		},
+	},
+	{
+		name: "strategies",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`name` text",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`base_amount` real",
+			"`frequency` text",
+			"`strategy_type` text",
+			"`params` text",
+			"`active` numeric",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_strategies_fund_code` ON `strategies`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_strategies_deleted_at` ON `strategies`(`deleted_at`)",
+		},
+	},
+	{
+		name: "net_value_histories",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`fund_code` text",
+			"`net_value` real",
+			"`total_value` real",
+			"`day_growth` real",
+			"`date` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_net_value_histories_date` ON `net_value_histories`(`date`)",
+			"CREATE INDEX IF NOT EXISTS `idx_net_value_histories_fund_code` ON `net_value_histories`(`fund_code`)",
+		},
+	},
+	{
+		name: "intraday_estimates",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`fund_code` text",
+			"`est_time` datetime",
+			"`est_value` real",
+			"`est_growth` real",
+			"`base_nav` real",
+			"`source` text",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_intraday_fund_time` ON `intraday_estimates`(`fund_code`,`est_time`)",
+		},
+	},
+	{
+		name: "alert_rules",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`alert_type` text",
+			"`threshold` real",
+			"`direction` text",
+			"`consecutive_days` integer",
+			"`expression` text",
+			"`holding_id` integer",
+			"`peak_value` real",
+			"`active` numeric",
+			"`cooldown_mode` text",
+			"`cooldown_minutes` integer",
+			"`hysteresis` real",
+			"`channels` text",
+			"`enabled` numeric",
+			"`last_triggered` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_alert_rules_holding_id` ON `alert_rules`(`holding_id`)",
+			"CREATE INDEX IF NOT EXISTS `idx_alert_rules_fund_code` ON `alert_rules`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_alert_rules_deleted_at` ON `alert_rules`(`deleted_at`)",
+		},
+	},
+	{
+		name: "alert_histories",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`rule_id` integer",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`alert_type` text",
+			"`message` text",
+			"`value` real",
+			"`triggered_at` datetime",
+			"`is_read` numeric",
+			"`delivery_status` text",
+			"`delivery_error` text",
+			"`attempts` integer",
+			"`delivered_at` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_alert_histories_fund_code` ON `alert_histories`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_alert_histories_rule_id` ON `alert_histories`(`rule_id`)",
+			"CREATE INDEX IF NOT EXISTS `idx_alert_histories_deleted_at` ON `alert_histories`(`deleted_at`)",
+		},
+	},
+	{
+		name: "alert_settings",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`quiet_enabled` numeric",
+			"`quiet_start` text",
+			"`quiet_end` text",
+			"`digest_enabled` numeric",
+			"`digest_minutes` integer",
+		},
+	},
+	{
+		name: "notify_channels",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`name` text",
+			"`type` text",
+			"`enabled` numeric",
+			"`smtp_host` text",
+			"`smtp_port` integer",
+			"`username` text",
+			"`password` text",
+			"`from` text",
+			"`to` text",
+			"`webhook_url` text",
+			"`webhook_format` text",
+			"`secret` text",
+			"`log_path` text",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_notify_channels_deleted_at` ON `notify_channels`(`deleted_at`)",
+		},
+	},
+	{
+		name: "fund_rankings",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`rank_type` text",
+			"`category` text",
+			"`rank_value` real",
+			"`rank_position` integer",
+			"`total_count` integer",
+			"`period` text",
+			"`stat_date` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_fund_rankings_stat_date` ON `fund_rankings`(`stat_date`)",
+			"CREATE INDEX IF NOT EXISTS `idx_fund_rankings_category` ON `fund_rankings`(`category`)",
+			"CREATE INDEX IF NOT EXISTS `idx_fund_rankings_rank_type` ON `fund_rankings`(`rank_type`)",
+			"CREATE INDEX IF NOT EXISTS `idx_fund_rankings_fund_code` ON `fund_rankings`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_fund_rankings_deleted_at` ON `fund_rankings`(`deleted_at`)",
+		},
+	},
+	{
+		name: "fund_risk_profiles",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`risk_level` integer",
+			"`risk_score` real",
+			"`manager_change_count` integer",
+			"`scale_change_rate` real",
+			"`max_drawdown` real",
+			"`volatility` real",
+			"`sharpe_ratio` real",
+			"`risk_factors` text",
+		},
+		indexes: []string{
+			"CREATE UNIQUE INDEX IF NOT EXISTS `idx_fund_risk_profiles_fund_code` ON `fund_risk_profiles`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_fund_risk_profiles_deleted_at` ON `fund_risk_profiles`(`deleted_at`)",
+		},
+	},
+	{
+		name: "institution_holdings",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`institution_ratio` real",
+			"`personal_ratio` real",
+			"`total_shares` real",
+			"`institution_change` real",
+			"`report_date` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_institution_holdings_fund_code` ON `institution_holdings`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_institution_holdings_deleted_at` ON `institution_holdings`(`deleted_at`)",
+			"CREATE INDEX IF NOT EXISTS `idx_institution_holdings_report_date` ON `institution_holdings`(`report_date`)",
+		},
+	},
+	{
+		name: "recovery_predictions",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`holding_id` integer",
+			"`fund_code` text",
+			"`current_loss` real",
+			"`loss_rate` real",
+			"`predicted_days` integer",
+			"`confidence_level` real",
+			"`prediction_method` text",
+			"`calculated_at` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_recovery_predictions_fund_code` ON `recovery_predictions`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_recovery_predictions_holding_id` ON `recovery_predictions`(`holding_id`)",
+			"CREATE INDEX IF NOT EXISTS `idx_recovery_predictions_deleted_at` ON `recovery_predictions`(`deleted_at`)",
+		},
+	},
+	{
+		name: "profit_probabilities",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`holding_period` integer",
+			"`profit_prob` real",
+			"`expected_return` real",
+			"`worst_case` real",
+			"`best_case` real",
+			"`calculated_at` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_profit_probabilities_fund_code` ON `profit_probabilities`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_profit_probabilities_deleted_at` ON `profit_probabilities`(`deleted_at`)",
+		},
+	},
+	{
+		name: "trading_signals",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`signal_type` text",
+			"`signal_strength` real",
+			"`indicator` text",
+			"`price` real",
+			"`target_price` real",
+			"`stop_loss` real",
+			"`reason` text",
+			"`score` real",
+			"`strategy_id` integer",
+			"`generated_at` datetime",
+			"`is_valid` numeric",
+			"`status` text",
+			"`expires_at` datetime",
+			"`closed_at` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_trading_signals_status` ON `trading_signals`(`status`)",
+			"CREATE INDEX IF NOT EXISTS `idx_trading_signals_generated_at` ON `trading_signals`(`generated_at`)",
+			"CREATE INDEX IF NOT EXISTS `idx_trading_signals_fund_code` ON `trading_signals`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_trading_signals_deleted_at` ON `trading_signals`(`deleted_at`)",
+		},
+	},
+	{
+		name: "signal_strategies",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`name` text",
+			"`weight_macd` real",
+			"`weight_kdj` real",
+			"`weight_boll` real",
+			"`weight_ma` real",
+			"`weight_rsi` real",
+			"`buy_threshold` real",
+			"`sell_threshold` real",
+			"`target_mult` real",
+			"`stop_mult` real",
+			"`is_default` numeric",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_signal_strategies_deleted_at` ON `signal_strategies`(`deleted_at`)",
+		},
+	},
+	{
+		name: "import_records",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`import_type` text",
+			"`source_app` text",
+			"`imported_count` integer",
+			"`success_count` integer",
+			"`failed_count` integer",
+			"`details` text",
+			"`imported_at` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_import_records_deleted_at` ON `import_records`(`deleted_at`)",
+		},
+	},
+	{
+		name: "qdii_funds",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`fund_code` text",
+			"`fund_name` text",
+			"`market_type` text",
+			"`tracking_index` text",
+			"`currency` text",
+			"`exchange_rate` real",
+			"`t1_est_value` real",
+			"`t2_est_value` real",
+			"`nav_date` datetime",
+			"`last_trade_date` datetime",
+		},
+		indexes: []string{
+			"CREATE UNIQUE INDEX IF NOT EXISTS `idx_qdii_funds_fund_code` ON `qdii_funds`(`fund_code`)",
+			"CREATE INDEX IF NOT EXISTS `idx_qdii_funds_deleted_at` ON `qdii_funds`(`deleted_at`)",
+		},
+	},
+	{
+		name: "exchange_rates",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`currency` text",
+			"`date` datetime",
+			"`rate` real",
+		},
+		indexes: []string{
+			"CREATE UNIQUE INDEX IF NOT EXISTS `idx_currency_date` ON `exchange_rates`(`currency`,`date`)",
+		},
+	},
+	{
+		name: "fund_profiles",
+		columns: []string{
+			"`fund_code` text",
+			"`company` text",
+			"`scale` real",
+			"`establish_date` datetime",
+			"`fee_rate` real",
+			"`updated_at` datetime",
+		},
+		primaryKey: "`fund_code`",
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_fund_profiles_company` ON `fund_profiles`(`company`)",
+		},
+	},
+	{
+		name: "fund_metrics",
+		columns: []string{
+			"`fund_code` text",
+			"`fund_name` text",
+			"`fund_type` text",
+			"`return1_y` real",
+			"`return3_y` real",
+			"`return5_y` real",
+			"`max_drawdown` real",
+			"`sharpe_ratio` real",
+			"`scale` real",
+			"`fee_rate` real",
+			"`manager` text",
+			"`manager_tenure` real",
+			"`history_days` integer",
+			"`updated_at` datetime",
+		},
+		primaryKey: "`fund_code`",
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_fund_metrics_fund_type` ON `fund_metrics`(`fund_type`)",
+		},
+	},
+	{
+		name: "fund_screens",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`name` text",
+			"`query` text",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_fund_screens_deleted_at` ON `fund_screens`(`deleted_at`)",
+		},
+	},
+	{
+		name: "fund_estimate_models",
+		columns: []string{
+			"`fund_code` text",
+			"`method` text",
+			"`index_name` text",
+			"`components` text",
+			"`report_date` datetime",
+			"`coverage` real",
+			"`beta` real",
+			"`alpha` real",
+			"`r_squared` real",
+			"`samples` integer",
+			"`calibrated_at` datetime",
+			"`updated_at` datetime",
+		},
+		primaryKey: "`fund_code`",
+	},
+	{
+		name: "portfolio_reports",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`created_at` datetime",
+			"`updated_at` datetime",
+			"`deleted_at` datetime",
+			"`period` text",
+			"`start_date` datetime",
+			"`end_date` datetime",
+			"`total_value` real",
+			"`total_profit` real",
+			"`period_profit` real",
+			"`period_return` real",
+			"`risk_snapshot` text",
+			"`markdown` text",
+			"`file_path` text",
+			"`delivery_status` text",
+			"`generated_at` datetime",
+		},
+		indexes: []string{
+			"CREATE INDEX IF NOT EXISTS `idx_portfolio_reports_end_date` ON `portfolio_reports`(`end_date`)",
+			"CREATE INDEX IF NOT EXISTS `idx_portfolio_reports_period` ON `portfolio_reports`(`period`)",
+			"CREATE INDEX IF NOT EXISTS `idx_portfolio_reports_deleted_at` ON `portfolio_reports`(`deleted_at`)",
+		},
+	},
+	{
+		name: "report_settings",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`daily_enabled` numeric",
+			"`weekly_enabled` numeric",
+			"`generate_at` text",
+			"`formats` text",
+			"`save_dir` text",
+			"`channels` text",
+		},
+	},
+	{
+		name: "backup_settings",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`auto_enabled` numeric",
+			"`interval_hours` integer",
+			"`keep` integer",
+			"`save_dir` text",
+			"`last_backup_at` datetime",
+		},
+	},
+	{
+		name: "app_settings",
+		columns: []string{
+			"`id` integer PRIMARY KEY AUTOINCREMENT",
+			"`refresh_minutes` integer",
+			"`refresh_policy` text",
+			"`monitor_minutes` integer",
+			"`risk_free_rate` real",
+			"`mc_simulations` integer",
+			"`buy_fee_rate` real",
+			"`sell_fee_rate` real",
+			"`theme` text",
+			"`proxy_url` text",
+		},
+	},
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"jijin/internal/model"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// FundRepository 基金信息
+type FundRepository interface {
+	SaveFund(fund *model.Fund) error
+	GetFund(code string) (*model.Fund, error)
+	GetAllFunds() ([]model.Fund, error)
+}
+
+// HoldingRepository 持仓
+type HoldingRepository interface {
+	SaveHolding(holding *model.Holding) error
+	GetHolding(id uint) (*model.Holding, error)
+	GetHoldingByFundCode(code string) (*model.Holding, error)
+	GetAllHoldings() ([]model.Holding, error)
+	UpdateHoldingAccount(id uint, account string) error
+	DeleteHolding(id uint) error
+}
+
+// TransactionRepository 交易记录
+type TransactionRepository interface {
+	SaveTransaction(tx *model.Transaction) error
+	GetTransactionsByFundCode(code string) ([]model.Transaction, error)
+	GetAllTransactions() ([]model.Transaction, error)
+	DeleteTransaction(id uint) error
+}
+
+// StrategyRepository 定投策略
+type StrategyRepository interface {
+	SaveStrategy(strategy *model.Strategy) error
+	GetStrategy(id uint) (*model.Strategy, error)
+	GetAllStrategies() ([]model.Strategy, error)
+	GetActiveStrategies() ([]model.Strategy, error)
+	DeleteStrategy(id uint) error
+}
+
+// AlertRepository 提醒规则和提醒历史
+type AlertRepository interface {
+	SaveAlertRule(rule *model.AlertRule) error
+	UpdateAlertTriggerState(id uint, active bool, lastTriggered time.Time) error
+	UpdateAlertPeak(id uint, peakValue, peakShares float64) error
+	GetAlertRule(id uint) (*model.AlertRule, error)
+	GetAlertRulesByFundCode(code string) ([]model.AlertRule, error)
+	GetAllAlertRules() ([]model.AlertRule, error)
+	GetEnabledAlertRules() ([]model.AlertRule, error)
+	DeleteAlertRule(id uint) error
+	GetAlertRulesByHoldingID(holdingID uint) ([]model.AlertRule, error)
+	DeleteAlertRulesByHoldingID(holdingID uint) error
+	SaveAlertHistory(history *model.AlertHistory) error
+	GetAlertHistoryByFundCode(code string, limit int) ([]model.AlertHistory, error)
+	GetAllAlertHistory() ([]model.AlertHistory, error)
+	GetUnreadAlertHistory() ([]model.AlertHistory, error)
+	MarkAlertAsRead(id uint) error
+	UpdateAlertDelivery(id uint, status, errMsg string, attempts int, deliveredAt time.Time) error
+	GetAlertHistoryBetween(from, to time.Time) ([]model.AlertHistory, error)
+	GetRecentAlertHistory(limit int) ([]model.AlertHistory, error)
+}
+
+// HistoryRepository 净值历史和盘中估值
+type HistoryRepository interface {
+	SaveNetValueHistory(history *model.NetValueHistory) error
+	SaveNetValueHistories(histories []model.NetValueHistory) error
+	SaveNewNetValueHistories(code string, histories []model.NetValueHistory) error
+	GetAllNetValueHistories() ([]model.NetValueHistory, error)
+	GetNetValueHistory(code string, days int) ([]model.NetValueHistory, error)
+	GetLatestNetValue(code string) (*model.NetValueHistory, error)
+	GetNetValueOnDate(code string, date time.Time) (*model.NetValueHistory, error)
+	SaveIntradayEstimate(estimate *model.IntradayEstimate) error
+	GetIntradayEstimates(code string, date time.Time) ([]model.IntradayEstimate, error)
+	GetFinalEstimates(code string, days int) ([]model.IntradayEstimate, error)
+}
+
+// WatchlistRepository 自选基金
+type WatchlistRepository interface {
+	SaveWatchlistItem(item *model.WatchlistItem) error
+	GetWatchlistItem(id uint) (*model.WatchlistItem, error)
+	GetWatchlistItemByFundCode(code string) (*model.WatchlistItem, error)
+	GetWatchlistItems(group string) ([]model.WatchlistItem, error)
+	GetWatchlistGroups() ([]string, error)
+	DeleteWatchlistItem(id uint) error
+}
+
+// NotifyRepository 通知渠道和提醒设置
+type NotifyRepository interface {
+	SaveNotifyChannel(channel *model.NotifyChannel) error
+	GetNotifyChannel(id uint) (*model.NotifyChannel, error)
+	GetAllNotifyChannels() ([]model.NotifyChannel, error)
+	DeleteNotifyChannel(id uint) error
+	GetAlertSettings() (*model.AlertSettings, error)
+	SaveAlertSettings(settings *model.AlertSettings) error
+}
+
+// RankingRepository 基金排行
+type RankingRepository interface {
+	SaveFundRanking(ranking *model.FundRanking) error
+	SaveFundRankings(rankings []model.FundRanking) error
+	GetFundRankingByType(rankType, period, category string, limit int) ([]model.FundRanking, error)
+	GetFundRankHistory(code, rankType, period, category string, limit int) ([]model.FundRanking, error)
+	GetPreviousFundRanks(codes []string, rankType, period, category string, before time.Time) (map[string]model.FundRanking, error)
+}
+
+// FundInfoRepository 基金概况、风险档案、机构持仓、QDII信息、汇率和估值模型
+type FundInfoRepository interface {
+	SaveFundProfile(profile *model.FundProfile) error
+	GetFundProfile(code string) (*model.FundProfile, error)
+	GetFundProfiles(codes []string) (map[string]model.FundProfile, error)
+	SaveFundRiskProfile(profile *model.FundRiskProfile) error
+	GetFundRiskProfile(code string) (*model.FundRiskProfile, error)
+	SaveInstitutionHolding(holding *model.InstitutionHolding) error
+	GetInstitutionHolding(code string) (*model.InstitutionHolding, error)
+	SaveQDIIFund(fund *model.QDIIFund) error
+	GetQDIIFund(code string) (*model.QDIIFund, error)
+	GetAllQDIIFunds() ([]model.QDIIFund, error)
+	SaveExchangeRates(rates []model.ExchangeRate) error
+	GetExchangeRateOn(currency string, date time.Time) (*model.ExchangeRate, error)
+	GetExchangeRates(currency string, from time.Time) ([]model.ExchangeRate, error)
+	SaveFundEstimateModel(m *model.FundEstimateModel) error
+	GetFundEstimateModel(code string) (*model.FundEstimateModel, error)
+}
+
+// SignalRepository 交易信号和信号策略
+type SignalRepository interface {
+	SaveTradingSignal(signal *model.TradingSignal) error
+	GetLatestSignal(code string) (*model.TradingSignal, error)
+	GetActiveSignals() ([]model.TradingSignal, error)
+	GetSignalsBetween(from, to time.Time) ([]model.TradingSignal, error)
+	SupersedeSignals(code string, exceptID uint, at time.Time) error
+	CloseSignal(id uint, status string, at time.Time) error
+	GetSignalHistory(code string, from time.Time) ([]model.TradingSignal, error)
+	SaveSignalStrategy(strategy *model.SignalStrategy) error
+	GetSignalStrategy(id uint) (*model.SignalStrategy, error)
+	GetDefaultSignalStrategy() (*model.SignalStrategy, error)
+	GetAllSignalStrategies() ([]model.SignalStrategy, error)
+	DeleteSignalStrategy(id uint) error
+}
+
+// ReportRepository 组合报告和报告设置
+type ReportRepository interface {
+	SavePortfolioReport(report *model.PortfolioReport) error
+	GetLatestPortfolioReport(period string, before time.Time) (*model.PortfolioReport, error)
+	GetPortfolioReports(limit int) ([]model.PortfolioReport, error)
+	UpdateReportDelivery(id uint, status string) error
+	GetReportSettings() (*model.ReportSettings, error)
+	SaveReportSettings(settings *model.ReportSettings) error
+}
+
+// ScreenerRepository 筛选指标和保存的筛选条件
+type ScreenerRepository interface {
+	SaveFundMetric(metric *model.FundMetric) error
+	GetAllFundMetrics() ([]model.FundMetric, error)
+	SaveFundScreen(screen *model.FundScreen) error
+	GetAllFundScreens() ([]model.FundScreen, error)
+	DeleteFundScreen(id uint) error
+}
+
+// SettingsRepository 应用设置、备份设置、导入记录和数据库快照
+type SettingsRepository interface {
+	GetAppSettings() (*model.AppSettings, error)
+	SaveAppSettings(settings *model.AppSettings) error
+	GetBackupSettings() (*model.BackupSettings, error)
+	SaveBackupSettings(settings *model.BackupSettings) error
+	SaveImportRecord(record *model.ImportRecord) error
+	GetImportRecords(limit int) ([]model.ImportRecord, error)
+	SnapshotDB(dest string) error
+	ReplaceDB(src string) error
+	CountRows(m interface{}) int64
+	Opened() bool
+	CurrentSchemaVersion() (int, error)
+}
+
+// Store 全部仓储
+type Store interface {
+	FundRepository
+	HoldingRepository
+	TransactionRepository
+	StrategyRepository
+	AlertRepository
+	HistoryRepository
+	WatchlistRepository
+	NotifyRepository
+	RankingRepository
+	FundInfoRepository
+	SignalRepository
+	ReportRepository
+	ScreenerRepository
+	SettingsRepository
+
+	// Transaction 在一个事务中执行fn，fn返回错误时回滚
+	Transaction(fn func(tx Store) error) error
+}
+
+// GormStore 基于GORM/SQLite的仓储实现
+type GormStore struct {
+	// mu 保护db，恢复备份时替换数据库连接
+	mu   sync.RWMutex
+	db   *gorm.DB
+	path string // 数据库文件路径，内存数据库和事务为空
+}
+
+var _ Store = (*GormStore)(nil)
+
+// NewGormStore 使用指定的数据库连接创建仓储
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// defaultStore 应用数据库的仓储，由OpenDB打开
+var defaultStore = &GormStore{}
+
+// Default 应用数据库的仓储，恢复备份等重新打开数据库后仍然有效
+func Default() *GormStore {
+	return defaultStore
+}
+
+// Transaction 在一个事务中执行fn，fn返回错误时回滚
+func (s *GormStore) Transaction(fn func(tx Store) error) error {
+	return s.conn().Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormStore(tx))
+	})
+}
+
+// conn 当前数据库连接
+func (s *GormStore) conn() *gorm.DB {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db
+}
+
+// DB 当前数据库连接，供迁移等需要直接操作数据库的调用方使用
+func (s *GormStore) DB() *gorm.DB {
+	return s.conn()
+}
+
+// Opened 数据库是否已打开
+func (s *GormStore) Opened() bool {
+	return s.conn() != nil
+}
+
+// CurrentSchemaVersion 数据库已执行到的迁移版本
+func (s *GormStore) CurrentSchemaVersion() (int, error) {
+	return CurrentSchemaVersion(s.conn())
+}
+
+// OpenMemory 打开内存数据库并建好全部表结构，每次调用得到独立的数据库，用于测试
+func OpenMemory() (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 内存数据库每个连接各自独立，只保留一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := MigrateDB(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
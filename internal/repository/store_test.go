@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func newTestStore(t *testing.T) *GormStore {
+	t.Helper()
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatalf("打开内存数据库失败: %v", err)
+	}
+	return NewGormStore(db)
+}
+
+func TestMemoryStoresAreIsolated(t *testing.T) {
+	a, b := newTestStore(t), newTestStore(t)
+	if err := a.SaveHolding(&model.Holding{FundCode: "000001", FundName: "测试基金"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.GetHoldingByFundCode("000001"); err != nil {
+		t.Fatalf("写入的仓储应能读到持仓: %v", err)
+	}
+	if holdings, _ := b.GetAllHoldings(); len(holdings) != 0 {
+		t.Fatalf("另一个内存数据库不应看到持仓，got %d", len(holdings))
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	store := newTestStore(t)
+	errAbort := errors.New("abort")
+
+	err := store.Transaction(func(tx Store) error {
+		if err := tx.SaveHolding(&model.Holding{FundCode: "000001"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Transaction应返回fn的错误，got %v", err)
+	}
+	if holdings, _ := store.GetAllHoldings(); len(holdings) != 0 {
+		t.Fatalf("回滚后不应保留持仓，got %d", len(holdings))
+	}
+
+	err = store.Transaction(func(tx Store) error {
+		return tx.SaveHolding(&model.Holding{FundCode: "000002"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetHoldingByFundCode("000002"); err != nil {
+		t.Fatalf("提交后应能读到持仓: %v", err)
+	}
+}
+
+func TestSaveNewNetValueHistories(t *testing.T) {
+	store := newTestStore(t)
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	nav := func(d int, v float64) model.NetValueHistory {
+		return model.NetValueHistory{FundCode: "000001", Date: day(d), NetValue: v}
+	}
+
+	if err := store.SaveNewNetValueHistories("000001", []model.NetValueHistory{nav(2, 1.01), nav(3, 1.02)}); err != nil {
+		t.Fatal(err)
+	}
+	// 只追加比本地最新日期更新的记录
+	if err := store.SaveNewNetValueHistories("000001", []model.NetValueHistory{nav(1, 1.00), nav(3, 1.02), nav(4, 1.03)}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := store.GetNetValueHistory("000001", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("want 3 records, got %d", len(list))
+	}
+	if !list[0].Date.Equal(day(4)) || !list[2].Date.Equal(day(2)) {
+		t.Fatalf("应按日期降序返回，got %v ... %v", list[0].Date, list[2].Date)
+	}
+}
+
+// openFileStore 临时目录中的文件数据库仓储
+func openFileStore(t *testing.T, name string) *GormStore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	db, err := openFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateDB(db); err != nil {
+		t.Fatal(err)
+	}
+	store := &GormStore{db: db, path: path}
+	t.Cleanup(func() {
+		if sqlDB, err := store.DB().DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return store
+}
+
+func TestReplaceDB(t *testing.T) {
+	store := openFileStore(t, "jijin.db")
+	if err := store.SaveHolding(&model.Holding{FundCode: "000001"}); err != nil {
+		t.Fatal(err)
+	}
+	backup := openFileStore(t, "backup.db")
+	if err := backup.SaveHolding(&model.Holding{FundCode: "000002"}); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := backup.SnapshotDB(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.ReplaceDB(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	holdings, err := store.GetAllHoldings()
+	if err != nil || len(holdings) != 1 || holdings[0].FundCode != "000002" {
+		t.Fatalf("替换后应读到备份中的数据: %+v, %v", holdings, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(store.path)); len(entries) != 1 {
+		t.Fatalf("替换后不应留下临时文件: %v", entries)
+	}
+}
+
+func TestReplaceDBKeepsCurrentOnFailure(t *testing.T) {
+	store := openFileStore(t, "jijin.db")
+	if err := store.SaveHolding(&model.Holding{FundCode: "000001"}); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := filepath.Join(t.TempDir(), "corrupt.db")
+	if err := os.WriteFile(corrupt, []byte("not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.ReplaceDB(corrupt); err == nil {
+		t.Fatal("替换为损坏的文件应返回错误")
+	}
+	// 原数据库恢复并重新打开，仍可使用
+	if _, err := store.GetHoldingByFundCode("000001"); err != nil {
+		t.Fatalf("替换失败后应恢复原数据库: %v", err)
+	}
+	if err := store.SaveHolding(&model.Holding{FundCode: "000003"}); err != nil {
+		t.Fatalf("替换失败后数据库应可写: %v", err)
+	}
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,61 +17,109 @@ const (
 	AlertTypePriceChange = "price_change" // 盘中涨跌提醒
 	AlertTypeConsecutive = "consecutive"  // 连涨连跌提醒
 	AlertTypeNavUpdate   = "nav_update"   // 净值更新提醒
+	AlertTypeExpression  = "expression"   // 条件表达式提醒
 )
 
+// AlertTypeNames 提醒类型显示名称
+var AlertTypeNames = map[string]string{
+	AlertTypePriceChange:  "盘中涨跌",
+	AlertTypeConsecutive:  "连涨连跌",
+	AlertTypeNavUpdate:    "净值更新",
+	AlertTypeExpression:   "条件表达式",
+	AlertTypeTakeProfit:   "止盈",
+	AlertTypeStopLoss:     "止损",
+	AlertTypeProfitAmount: "收益金额",
+	AlertTypeTrailingStop: "回撤止盈",
+	AlertTypeTargetValue:  "目标市值",
+	AlertTypeDigest:       "提醒汇总",
+}
+
 // AlertService 智能提醒服务
 type AlertService struct {
+	holdings repository.HoldingRepository
+	alerts   repository.AlertRepository
+	history  repository.HistoryRepository
+	notify   repository.NotifyRepository
+
 	mu            sync.Mutex
+	ruleMu        sync.Mutex // 规则触发状态的判断和更新在同一把锁内完成
 	isRunning     bool
 	stopChan      chan bool
 	alertCallback func(alert *model.AlertHistory)
+	pending       []model.AlertHistory // 免打扰或汇总模式下待推送的提醒
+	lastDigest    time.Time
+}
+
+// NewAlertService 使用指定仓储创建提醒服务
+func NewAlertService(store repository.Store) *AlertService {
+	return &AlertService{
+		stopChan: make(chan bool),
+		holdings: store,
+		alerts:   store,
+		history:  store,
+		notify:   store,
+	}
 }
 
-var alertService *AlertService
-var alertOnce sync.Once
+var alertService = NewAlertService(repository.Default())
 
 // GetAlertService 获取提醒服务实例
 func GetAlertService() *AlertService {
-	alertOnce.Do(func() {
-		alertService = &AlertService{
-			stopChan: make(chan bool),
-		}
-	})
 	return alertService
 }
 
 // CreateAlertRule 创建提醒规则
 func (a *AlertService) CreateAlertRule(rule *model.AlertRule) error {
+	if err := validateAlertRule(rule); err != nil {
+		return err
+	}
 	rule.Enabled = true
-	return repository.SaveAlertRule(rule)
+	return a.alerts.SaveAlertRule(rule)
 }
 
 // UpdateAlertRule 更新提醒规则
 func (a *AlertService) UpdateAlertRule(rule *model.AlertRule) error {
-	return repository.SaveAlertRule(rule)
+	if err := validateAlertRule(rule); err != nil {
+		return err
+	}
+	return a.alerts.SaveAlertRule(rule)
+}
+
+// validateAlertRule 校验规则，表达式规则需能正确解析
+func validateAlertRule(rule *model.AlertRule) error {
+	if rule.FundCode == "" {
+		return errors.New("请输入基金代码")
+	}
+	if rule.AlertType == AlertTypeExpression {
+		return ValidateAlertExpression(rule.Expression)
+	}
+	if IsHoldingAlertType(rule.AlertType) && rule.HoldingID == 0 {
+		return errors.New("持仓提醒需关联持仓")
+	}
+	return nil
 }
 
 // DeleteAlertRule 删除提醒规则
 func (a *AlertService) DeleteAlertRule(id uint) error {
-	return repository.DeleteAlertRule(id)
+	return a.alerts.DeleteAlertRule(id)
 }
 
 // GetAlertRules 获取提醒规则
 func (a *AlertService) GetAlertRules(fundCode string) ([]model.AlertRule, error) {
 	if fundCode == "" {
-		return repository.GetAllAlertRules()
+		return a.alerts.GetAllAlertRules()
 	}
-	return repository.GetAlertRulesByFundCode(fundCode)
+	return a.alerts.GetAlertRulesByFundCode(fundCode)
 }
 
 // ToggleAlertRule 切换规则状态
 func (a *AlertService) ToggleAlertRule(id uint) error {
-	rule, err := repository.GetAlertRule(id)
+	rule, err := a.alerts.GetAlertRule(id)
 	if err != nil {
 		return err
 	}
 	rule.Enabled = !rule.Enabled
-	return repository.SaveAlertRule(rule)
+	return a.alerts.SaveAlertRule(rule)
 }
 
 // StartMonitoring 启动监控
@@ -83,14 +134,14 @@ func (a *AlertService) StartMonitoring(callback func(alert *model.AlertHistory))
 	a.mu.Unlock()
 
 	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
+		ticker := time.NewTicker(GetSettingsService().MonitorInterval())
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ticker.C:
-				if isTradingTime() {
-					a.checkAllAlerts()
-				}
+				a.checkAllAlerts()
+				// 按设置调整下次检查的间隔
+				ticker.Reset(GetSettingsService().MonitorInterval())
 			case <-a.stopChan:
 				return
 			}
@@ -108,58 +159,85 @@ func (a *AlertService) StopMonitoring() {
 	}
 }
 
+// pauseMonitoring 停止监控(等待进行中的检查完成)，返回恢复监控的函数
+func (a *AlertService) pauseMonitoring() (resume func()) {
+	a.mu.Lock()
+	running, callback := a.isRunning, a.alertCallback
+	a.mu.Unlock()
+	if !running {
+		return func() {}
+	}
+	a.StopMonitoring()
+	return func() { a.StartMonitoring(callback) }
+}
+
 // checkAllAlerts 检查所有提醒
 func (a *AlertService) checkAllAlerts() {
-	rules, err := repository.GetEnabledAlertRules()
+	rules, err := a.alerts.GetEnabledAlertRules()
 	if err != nil {
 		return
 	}
 
+	// 净值更新在收盘后公布，其余规则只在交易时间检查
+	now := time.Now()
+	trading := isTradingTime()
+	var alerts []*model.AlertHistory
 	for _, rule := range rules {
+		if rule.AlertType == AlertTypeNavUpdate {
+			// 净值更新按净值日去重，不走冷却策略
+			if alert := a.checkNavUpdateAlert(&rule); alert != nil {
+				alerts = append(alerts, alert)
+			}
+			continue
+		}
+		if !trading {
+			continue
+		}
+
 		var alert *model.AlertHistory
-		switch rule.AlertType {
-		case AlertTypePriceChange:
-			alert = a.checkPriceChangeAlert(&rule)
-		case AlertTypeConsecutive:
-			alert = a.checkConsecutiveAlert(&rule)
+		margin := math.NaN()
+		switch {
+		case rule.AlertType == AlertTypePriceChange:
+			alert, margin = a.checkPriceChangeAlert(&rule)
+		case rule.AlertType == AlertTypeConsecutive:
+			alert, margin = a.checkConsecutiveAlert(&rule)
+		case rule.AlertType == AlertTypeExpression:
+			alert, margin = a.checkExpressionAlert(&rule)
+		case IsHoldingAlertType(rule.AlertType):
+			alert, margin = a.checkHoldingAlert(&rule)
 		}
-		if alert != nil && a.alertCallback != nil {
-			repository.SaveAlertHistory(alert)
-			a.alertCallback(alert)
+		if a.allowAlert(&rule, margin, now) && alert != nil {
+			alerts = append(alerts, alert)
 		}
 	}
+	a.deliver(alerts)
+	a.flushPending(now)
 }
 
-// checkPriceChangeAlert 检查盘中涨跌提醒
-func (a *AlertService) checkPriceChangeAlert(rule *model.AlertRule) *model.AlertHistory {
+// checkPriceChangeAlert 检查盘中涨跌提醒，返回越过阈值的幅度(百分点)
+func (a *AlertService) checkPriceChangeAlert(rule *model.AlertRule) (*model.AlertHistory, float64) {
 	fund, err := GetFundAPI().GetFundDetail(rule.FundCode)
 	if err != nil {
-		return nil
+		return nil, math.NaN()
 	}
 
-	triggered := false
+	var margin float64
 	var message string
 
 	switch rule.Direction {
 	case "up":
-		if fund.EstGrowth >= rule.Threshold {
-			triggered = true
-			message = fmt.Sprintf("%s 涨幅达到 %.2f%%", fund.Name, fund.EstGrowth)
-		}
+		margin = fund.EstGrowth - rule.Threshold
+		message = fmt.Sprintf("%s 涨幅达到 %.2f%%", fund.Name, fund.EstGrowth)
 	case "down":
-		if fund.EstGrowth <= -rule.Threshold {
-			triggered = true
-			message = fmt.Sprintf("%s 跌幅达到 %.2f%%", fund.Name, fund.EstGrowth)
-		}
-	case "both":
-		if fund.EstGrowth >= rule.Threshold || fund.EstGrowth <= -rule.Threshold {
-			triggered = true
-			message = fmt.Sprintf("%s 涨跌幅达到 %.2f%%", fund.Name, fund.EstGrowth)
-		}
+		margin = -fund.EstGrowth - rule.Threshold
+		message = fmt.Sprintf("%s 跌幅达到 %.2f%%", fund.Name, fund.EstGrowth)
+	default:
+		margin = math.Abs(fund.EstGrowth) - rule.Threshold
+		message = fmt.Sprintf("%s 涨跌幅达到 %.2f%%", fund.Name, fund.EstGrowth)
 	}
 
-	if !triggered {
-		return nil
+	if margin < 0 {
+		return nil, margin
 	}
 
 	return &model.AlertHistory{
@@ -170,14 +248,14 @@ func (a *AlertService) checkPriceChangeAlert(rule *model.AlertRule) *model.Alert
 		Message:     message,
 		Value:       fund.EstGrowth,
 		TriggeredAt: time.Now(),
-	}
+	}, margin
 }
 
-// checkConsecutiveAlert 检查连涨连跌提醒
-func (a *AlertService) checkConsecutiveAlert(rule *model.AlertRule) *model.AlertHistory {
-	histories, err := repository.GetNetValueHistory(rule.FundCode, rule.ConsecutiveDays+1)
+// checkConsecutiveAlert 检查连涨连跌提醒，返回超出设定天数的天数
+func (a *AlertService) checkConsecutiveAlert(rule *model.AlertRule) (*model.AlertHistory, float64) {
+	histories, err := a.history.GetNetValueHistory(rule.FundCode, rule.ConsecutiveDays+1)
 	if err != nil || len(histories) < rule.ConsecutiveDays {
-		return nil
+		return nil, math.NaN()
 	}
 
 	upCount, downCount := 0, 0
@@ -190,18 +268,18 @@ func (a *AlertService) checkConsecutiveAlert(rule *model.AlertRule) *model.Alert
 	}
 
 	var message string
-	triggered := false
+	margin := math.Inf(-1)
 
-	if rule.Direction == "up" && upCount >= rule.ConsecutiveDays {
-		triggered = true
+	if rule.Direction == "up" {
+		margin = float64(upCount - rule.ConsecutiveDays)
 		message = fmt.Sprintf("%s 连涨%d天", rule.FundName, upCount)
-	} else if rule.Direction == "down" && downCount >= rule.ConsecutiveDays {
-		triggered = true
+	} else if rule.Direction == "down" {
+		margin = float64(downCount - rule.ConsecutiveDays)
 		message = fmt.Sprintf("%s 连跌%d天", rule.FundName, downCount)
 	}
 
-	if !triggered {
-		return nil
+	if margin < 0 {
+		return nil, margin
 	}
 
 	return &model.AlertHistory{
@@ -212,27 +290,255 @@ func (a *AlertService) checkConsecutiveAlert(rule *model.AlertRule) *model.Alert
 		Message:     message,
 		Value:       float64(upCount),
 		TriggeredAt: time.Now(),
+	}, margin
+}
+
+// checkNavUpdateAlert 检查净值更新提醒，每个净值日只提醒一次
+func (a *AlertService) checkNavUpdateAlert(rule *model.AlertRule) *model.AlertHistory {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if !rule.LastTriggered.Before(today) {
+		return nil
+	}
+
+	histories, err := GetFundAPI().GetFundHistory(rule.FundCode, 1)
+	if err != nil || len(histories) == 0 {
+		return nil
+	}
+	latest := histories[0]
+	if latest.Date.Format("2006-01-02") != now.Format("2006-01-02") {
+		return nil
+	}
+
+	a.history.SaveNewNetValueHistories(rule.FundCode, histories)
+
+	// 与allowAlert共用规则锁，以数据库中的最新状态为准，只更新触发时间
+	a.ruleMu.Lock()
+	defer a.ruleMu.Unlock()
+	if latest, err := a.alerts.GetAlertRule(rule.ID); err == nil {
+		rule.Active = latest.Active
+		rule.LastTriggered = latest.LastTriggered
+	}
+	if !rule.LastTriggered.Before(today) {
+		return nil
+	}
+	rule.LastTriggered = now
+	a.alerts.UpdateAlertTriggerState(rule.ID, rule.Active, rule.LastTriggered)
+
+	return &model.AlertHistory{
+		RuleID:      rule.ID,
+		FundCode:    rule.FundCode,
+		FundName:    rule.FundName,
+		AlertType:   AlertTypeNavUpdate,
+		Message:     fmt.Sprintf("%s 净值已更新: %.4f (%+.2f%%)", rule.FundName, latest.NetValue, latest.DayGrowth),
+		Value:       latest.DayGrowth,
+		TriggeredAt: now,
+	}
+}
+
+// checkExpressionAlert 检查条件表达式提醒，表达式只有真假，不满足即视为解除
+func (a *AlertService) checkExpressionAlert(rule *model.AlertRule) (*model.AlertHistory, float64) {
+	expr, err := ParseAlertExpression(rule.Expression)
+	if err != nil {
+		return nil, math.NaN()
+	}
+
+	names := expr.Variables()
+	vars := a.BuildAlertVariables(rule.FundCode, names)
+	triggered, err := expr.Eval(vars)
+	if err != nil {
+		return nil, math.NaN()
+	}
+	if !triggered {
+		return nil, math.Inf(-1)
+	}
+
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		if v, ok := vars[name]; ok {
+			values = append(values, fmt.Sprintf("%s=%.2f", name, v))
+		}
+	}
+
+	alert := &model.AlertHistory{
+		RuleID:      rule.ID,
+		FundCode:    rule.FundCode,
+		FundName:    rule.FundName,
+		AlertType:   AlertTypeExpression,
+		Message:     fmt.Sprintf("%s 满足条件 %s (%s)", rule.FundName, rule.Expression, strings.Join(values, ", ")),
+		TriggeredAt: time.Now(),
+	}
+	if len(names) > 0 {
+		alert.Value = vars[names[0]]
+	}
+	return alert, 0
+}
+
+// BuildAlertVariables 计算表达式所需的变量，无法获取的变量不出现在结果中
+func (a *AlertService) BuildAlertVariables(fundCode string, names []string) map[string]float64 {
+	vars := make(map[string]float64)
+	need := func(group ...string) bool {
+		for _, name := range names {
+			for _, g := range group {
+				if name == g {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	estValue := 0.0
+	if need("est_growth", "est_value", "profit_rate", "profit", "market_value") {
+		if fund, err := GetFundAPI().GetFundDetail(fundCode); err == nil && fund.EstValue > 0 {
+			estValue = fund.EstValue
+			vars["est_growth"] = fund.EstGrowth
+			vars["est_value"] = fund.EstValue
+		} else if est, err := GetEstimationService().Estimate(fundCode); err == nil {
+			estValue = est.EstValue
+			vars["est_growth"] = est.EstGrowth
+			vars["est_value"] = est.EstValue
+		}
+	}
+
+	if need("nav", "day_growth", "return_1w", "return_1m", "return_3m", "drawdown_from_peak",
+		"consecutive_up", "consecutive_down", "ma5", "ma20", "ma60", "rsi6", "rsi14",
+		"macd", "macd_signal", "macd_hist", "kdj_k", "kdj_d", "kdj_j", "boll_upper", "boll_lower") {
+		a.addHistoryVariables(fundCode, vars)
+	}
+
+	if need("profit_rate", "profit", "market_value", "cost", "shares") {
+		if holding, err := a.holdings.GetHoldingByFundCode(fundCode); err == nil && holding != nil {
+			if estValue > 0 {
+				holding.CurrentNav = estValue
+			}
+			vars["profit_rate"] = holding.ProfitRate()
+			vars["profit"] = holding.Profit()
+			vars["market_value"] = holding.MarketValue()
+			vars["cost"] = holding.Cost
+			vars["shares"] = holding.Shares
+		}
 	}
+	return vars
+}
+
+// addHistoryVariables 根据近一年净值计算收益、回撤和技术指标变量
+func (a *AlertService) addHistoryVariables(fundCode string, vars map[string]float64) {
+	histories, _ := a.history.GetNetValueHistory(fundCode, 250)
+	if len(histories) < 60 {
+		if fetched, err := GetFundAPI().GetFundHistory(fundCode, 250); err == nil {
+			a.history.SaveNewNetValueHistories(fundCode, fetched)
+			histories, _ = a.history.GetNetValueHistory(fundCode, 250)
+		}
+	}
+	if len(histories) == 0 {
+		return
+	}
+
+	latest := histories[0]
+	vars["nav"] = latest.NetValue
+	vars["day_growth"] = latest.DayGrowth
+	vars["return_1w"] = periodReturn(histories, latest.Date.AddDate(0, 0, -7))
+	vars["return_1m"] = periodReturn(histories, latest.Date.AddDate(0, -1, 0))
+	vars["return_3m"] = periodReturn(histories, latest.Date.AddDate(0, -3, 0))
+	vars["drawdown_from_peak"] = drawdownFromPeak(histories)
+
+	up, down := 0, 0
+	for _, h := range histories {
+		if h.DayGrowth <= 0 {
+			break
+		}
+		up++
+	}
+	for _, h := range histories {
+		if h.DayGrowth >= 0 {
+			break
+		}
+		down++
+	}
+	vars["consecutive_up"] = float64(up)
+	vars["consecutive_down"] = float64(down)
+
+	prices := make([]float64, len(histories))
+	for i, h := range histories {
+		prices[len(histories)-1-i] = h.NetValue
+	}
+	last := len(prices) - 1
+
+	for _, period := range []int{5, 20, 60} {
+		if len(prices) >= period {
+			sum := 0.0
+			for _, p := range prices[len(prices)-period:] {
+				sum += p
+			}
+			vars[fmt.Sprintf("ma%d", period)] = sum / float64(period)
+		}
+	}
+
+	indicator := GetIndicatorService()
+	if rsi := indicator.CalculateRSI(prices, 6); len(rsi) > 0 {
+		vars["rsi6"] = rsi[last]
+	}
+	if rsi := indicator.CalculateRSI(prices, 14); len(rsi) > 0 {
+		vars["rsi14"] = rsi[last]
+	}
+	if macd := indicator.CalculateMACD(prices); macd != nil && len(macd.Signal) > 0 {
+		vars["macd"] = macd.MACD[last]
+		vars["macd_signal"] = macd.Signal[len(macd.Signal)-1]
+		vars["macd_hist"] = macd.Hist[last]
+	}
+	if kdj := indicator.CalculateKDJ(prices, 9); kdj != nil {
+		vars["kdj_k"] = kdj.K[last]
+		vars["kdj_d"] = kdj.D[last]
+		vars["kdj_j"] = kdj.J[last]
+	}
+	if boll := indicator.CalculateBollinger(prices, 20); boll != nil {
+		vars["boll_upper"] = boll.Upper[last]
+		vars["boll_lower"] = boll.Lower[last]
+	}
+}
+
+// drawdownFromPeak 最新净值距区间最高净值的回撤(%)，histories按日期降序
+func drawdownFromPeak(histories []model.NetValueHistory) float64 {
+	peak := 0.0
+	for _, h := range histories {
+		if nav := navValue(h); nav > peak {
+			peak = nav
+		}
+	}
+	if peak <= 0 {
+		return 0
+	}
+	return (peak - navValue(histories[0])) / peak * 100
 }
 
 // GetAlertHistory 获取提醒历史
 func (a *AlertService) GetAlertHistory(fundCode string, limit int) ([]model.AlertHistory, error) {
-	return repository.GetAlertHistoryByFundCode(fundCode, limit)
+	return a.alerts.GetAlertHistoryByFundCode(fundCode, limit)
+}
+
+// GetRecentAlerts 获取最近的提醒历史(含投递状态)
+func (a *AlertService) GetRecentAlerts(limit int) ([]model.AlertHistory, error) {
+	return a.alerts.GetRecentAlertHistory(limit)
 }
 
 // GetUnreadAlerts 获取未读提醒
 func (a *AlertService) GetUnreadAlerts() ([]model.AlertHistory, error) {
-	return repository.GetUnreadAlertHistory()
+	return a.alerts.GetUnreadAlertHistory()
 }
 
 // MarkAsRead 标记提醒为已读
 func (a *AlertService) MarkAsRead(id uint) error {
-	return repository.MarkAlertAsRead(id)
+	return a.alerts.MarkAlertAsRead(id)
 }
 
-// isTradingTime 判断是否为交易时间
+// isTradingTime 判断当前是否为交易时间
 func isTradingTime() bool {
-	now := time.Now()
+	return IsTradingTime(time.Now())
+}
+
+// IsTradingTime 判断是否为交易时间(工作日9:30-15:00)
+func IsTradingTime(now time.Time) bool {
 	weekday := now.Weekday()
 	if weekday == time.Saturday || weekday == time.Sunday {
 		return false
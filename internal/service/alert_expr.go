@@ -0,0 +1,583 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// AlertVariables 条件表达式可用变量及说明
+var AlertVariables = map[string]string{
+	"est_growth":         "盘中估算涨跌幅(%)",
+	"est_value":          "盘中估算净值",
+	"nav":                "最新单位净值",
+	"day_growth":         "最新公布日涨跌幅(%)",
+	"return_1w":          "近1周收益率(%)",
+	"return_1m":          "近1月收益率(%)",
+	"return_3m":          "近3月收益率(%)",
+	"drawdown_from_peak": "距近一年最高净值回撤(%)",
+	"consecutive_up":     "连涨天数",
+	"consecutive_down":   "连跌天数",
+	"ma5":                "5日均线",
+	"ma20":               "20日均线",
+	"ma60":               "60日均线",
+	"rsi6":               "6日RSI",
+	"rsi14":              "14日RSI",
+	"macd":               "MACD(DIF)",
+	"macd_signal":        "MACD信号线(DEA)",
+	"macd_hist":          "MACD柱",
+	"kdj_k":              "KDJ的K值",
+	"kdj_d":              "KDJ的D值",
+	"kdj_j":              "KDJ的J值",
+	"boll_upper":         "布林上轨",
+	"boll_lower":         "布林下轨",
+	"profit_rate":        "持仓收益率(%)",
+	"profit":             "持仓收益(元)",
+	"market_value":       "持仓市值(元)",
+	"cost":               "持仓成本(元)",
+	"shares":             "持有份额",
+}
+
+// AlertVariableNames 按名称排序的可用变量
+func AlertVariableNames() []string {
+	names := make([]string, 0, len(AlertVariables))
+	for name := range AlertVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AlertExpr 解析后的条件表达式
+type AlertExpr struct {
+	source string
+	root   exprNode
+}
+
+// ParseAlertExpression 解析条件表达式，如 "est_growth < -2 AND rsi14 < 30"
+func ParseAlertExpression(source string) (*AlertExpr, error) {
+	tokens, err := tokenizeExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, fmt.Errorf("表达式不能为空")
+	}
+
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("第%d个字符附近: 多余的 %q", tok.pos+1, tok.text)
+	}
+	if !root.isBool() {
+		return nil, fmt.Errorf("表达式结果必须是条件判断，如 est_growth < -2")
+	}
+	return &AlertExpr{source: source, root: root}, nil
+}
+
+// ValidateAlertExpression 校验条件表达式
+func ValidateAlertExpression(source string) error {
+	_, err := ParseAlertExpression(source)
+	return err
+}
+
+// String 返回原始表达式
+func (e *AlertExpr) String() string {
+	return e.source
+}
+
+// Variables 表达式引用的变量(去重、按出现顺序)
+func (e *AlertExpr) Variables() []string {
+	var names []string
+	seen := make(map[string]bool)
+	e.root.collect(func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	})
+	return names
+}
+
+// Eval 用给定变量值求值，因变量暂无数据无法确定真假时返回错误
+func (e *AlertExpr) Eval(vars map[string]float64) (bool, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	return v != 0, nil
+}
+
+// ---- 词法分析 ----
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func tokenizeExpr(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("第%d个字符附近: 无效的数字 %q", start+1, text)
+			}
+			// 允许百分号后缀，如 20%
+			if i < len(runes) && runes[i] == '%' {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, text: text, num: num, pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			text := string(runes[start:i])
+			switch strings.ToUpper(text) {
+			case "AND":
+				tokens = append(tokens, exprToken{kind: tokAnd, text: text, pos: start})
+			case "OR":
+				tokens = append(tokens, exprToken{kind: tokOr, text: text, pos: start})
+			case "NOT":
+				tokens = append(tokens, exprToken{kind: tokNot, text: text, pos: start})
+			default:
+				name := strings.ToLower(text)
+				if _, ok := AlertVariables[name]; !ok {
+					return nil, fmt.Errorf("第%d个字符附近: 未知变量 %q", start+1, text)
+				}
+				tokens = append(tokens, exprToken{kind: tokIdent, text: name, pos: start})
+			}
+		case r == '(':
+			tokens = append(tokens, exprToken{kind: tokLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, exprToken{kind: tokRParen, text: ")", pos: i})
+			i++
+		default:
+			start := i
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch {
+			case two == "&&":
+				tokens = append(tokens, exprToken{kind: tokAnd, text: two, pos: start})
+				i += 2
+			case two == "||":
+				tokens = append(tokens, exprToken{kind: tokOr, text: two, pos: start})
+				i += 2
+			case two == "<=" || two == ">=" || two == "==" || two == "!=":
+				tokens = append(tokens, exprToken{kind: tokOp, text: two, pos: start})
+				i += 2
+			case r == '!':
+				tokens = append(tokens, exprToken{kind: tokNot, text: "!", pos: start})
+				i++
+			case r == '=':
+				tokens = append(tokens, exprToken{kind: tokOp, text: "==", pos: start})
+				i++
+			case strings.ContainsRune("<>+-*/", r):
+				tokens = append(tokens, exprToken{kind: tokOp, text: string(r), pos: start})
+				i++
+			default:
+				return nil, fmt.Errorf("第%d个字符附近: 无法识别的字符 %q", start+1, string(r))
+			}
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF, pos: len(runes)}), nil
+}
+
+// ---- 语法分析 ----
+// or      := and (OR and)*
+// and     := not (AND not)*
+// not     := NOT not | compare
+// compare := sum (op sum)?
+// sum     := product ((+|-) product)*
+// product := unary ((*|/) unary)*
+// unary   := - unary | primary
+// primary := number | ident | ( or )
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) errorf(tok exprToken, format string, args ...interface{}) error {
+	if tok.kind == tokEOF {
+		return fmt.Errorf("表达式不完整: "+format, args...)
+	}
+	return fmt.Errorf("第%d个字符附近: "+format, append([]interface{}{tok.pos + 1}, args...)...)
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		tok := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if !left.isBool() || !right.isBool() {
+			return nil, p.errorf(tok, "OR 两侧必须是条件判断")
+		}
+		left = &logicNode{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		tok := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if !left.isBool() || !right.isBool() {
+			return nil, p.errorf(tok, "AND 两侧必须是条件判断")
+		}
+		left = &logicNode{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.peek().kind == tokNot {
+		tok := p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if !operand.isBool() {
+			return nil, p.errorf(tok, "NOT 后必须是条件判断")
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (exprNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind != tokOp || !isCompareOp(tok.text) {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if left.isBool() || right.isBool() {
+		return nil, p.errorf(tok, "比较运算 %s 两侧必须是数值", tok.text)
+	}
+	return &compareNode{op: tok.text, left: left, right: right}, nil
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.kind == tokOp && (tok.text == "+" || tok.text == "-"); tok = p.peek() {
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		if left.isBool() || right.isBool() {
+			return nil, p.errorf(tok, "运算 %s 两侧必须是数值", tok.text)
+		}
+		left = &arithNode{op: tok.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.kind == tokOp && (tok.text == "*" || tok.text == "/"); tok = p.peek() {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left.isBool() || right.isBool() {
+			return nil, p.errorf(tok, "运算 %s 两侧必须是数值", tok.text)
+		}
+		left = &arithNode{op: tok.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.isBool() {
+			return nil, p.errorf(tok, "负号后必须是数值")
+		}
+		return &arithNode{op: "-", left: &numberNode{value: 0}, right: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return &numberNode{value: tok.num}, nil
+	case tokIdent:
+		return &varNode{name: tok.text}, nil
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "缺少右括号")
+		}
+		return inner, nil
+	}
+	if tok.kind == tokEOF {
+		return nil, p.errorf(tok, "缺少数值或变量")
+	}
+	return nil, p.errorf(tok, "此处应为数值或变量，而不是 %q", tok.text)
+}
+
+func isCompareOp(op string) bool {
+	switch op {
+	case "<", "<=", ">", ">=", "==", "!=":
+		return true
+	}
+	return false
+}
+
+// ---- 语法树 ----
+
+type exprNode interface {
+	eval(vars map[string]float64) (float64, error)
+	isBool() bool
+	collect(fn func(name string))
+}
+
+type numberNode struct{ value float64 }
+
+func (n *numberNode) eval(map[string]float64) (float64, error) { return n.value, nil }
+func (n *numberNode) isBool() bool                             { return false }
+func (n *numberNode) collect(func(string))                     {}
+
+// missingVarError 变量暂无数据(如新基金没有足够的净值历史)
+type missingVarError struct{ name string }
+
+func (e *missingVarError) Error() string {
+	return fmt.Sprintf("变量 %s 暂无数据", e.name)
+}
+
+func isMissingVar(err error) bool {
+	var missing *missingVarError
+	return errors.As(err, &missing)
+}
+
+type varNode struct{ name string }
+
+func (n *varNode) eval(vars map[string]float64) (float64, error) {
+	v, ok := vars[n.name]
+	if !ok {
+		return 0, &missingVarError{name: n.name}
+	}
+	return v, nil
+}
+func (n *varNode) isBool() bool                 { return false }
+func (n *varNode) collect(fn func(name string)) { fn(n.name) }
+
+type arithNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *arithNode) eval(vars map[string]float64) (float64, error) {
+	l, err := n.left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	}
+	if r == 0 {
+		return 0, fmt.Errorf("除数为0")
+	}
+	return l / r, nil
+}
+func (n *arithNode) isBool() bool { return false }
+func (n *arithNode) collect(fn func(name string)) {
+	n.left.collect(fn)
+	n.right.collect(fn)
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *compareNode) eval(vars map[string]float64) (float64, error) {
+	l, err := n.left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	var result bool
+	switch n.op {
+	case "<":
+		result = l < r
+	case "<=":
+		result = l <= r
+	case ">":
+		result = l > r
+	case ">=":
+		result = l >= r
+	case "==":
+		result = math.Abs(l-r) < 1e-9
+	case "!=":
+		result = math.Abs(l-r) >= 1e-9
+	}
+	return boolValue(result), nil
+}
+func (n *compareNode) isBool() bool { return true }
+func (n *compareNode) collect(fn func(name string)) {
+	n.left.collect(fn)
+	n.right.collect(fn)
+}
+
+type logicNode struct {
+	op          string
+	left, right exprNode
+}
+
+// eval 三值逻辑：暂无数据的一侧为"未知"，另一侧能确定结果时(AND遇假、OR遇真)按该结果，
+// 否则返回missingVarError，外层NOT和顶层求值都保持未知，不会因缺数据而触发提醒
+func (n *logicNode) eval(vars map[string]float64) (float64, error) {
+	l, lerr := n.left.eval(vars)
+	if lerr != nil && !isMissingVar(lerr) {
+		return 0, lerr
+	}
+	// 短路求值
+	if lerr == nil && n.op == "AND" && l == 0 {
+		return 0, nil
+	}
+	if lerr == nil && n.op == "OR" && l != 0 {
+		return 1, nil
+	}
+	r, rerr := n.right.eval(vars)
+	if rerr != nil && !isMissingVar(rerr) {
+		return 0, rerr
+	}
+	if rerr == nil && n.op == "AND" && r == 0 {
+		return 0, nil
+	}
+	if rerr == nil && n.op == "OR" && r != 0 {
+		return 1, nil
+	}
+	if lerr != nil {
+		return 0, lerr
+	}
+	if rerr != nil {
+		return 0, rerr
+	}
+	return boolValue(n.op == "AND"), nil
+}
+func (n *logicNode) isBool() bool { return true }
+func (n *logicNode) collect(fn func(name string)) {
+	n.left.collect(fn)
+	n.right.collect(fn)
+}
+
+type notNode struct{ operand exprNode }
+
+// eval 操作数未知(含暂无数据)时结果仍为未知
+func (n *notNode) eval(vars map[string]float64) (float64, error) {
+	v, err := n.operand.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	return boolValue(v == 0), nil
+}
+func (n *notNode) isBool() bool                 { return true }
+func (n *notNode) collect(fn func(name string)) { n.operand.collect(fn) }
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
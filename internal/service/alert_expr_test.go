@@ -0,0 +1,49 @@
+package service
+
+import "testing"
+
+func TestAlertExprMissingVariables(t *testing.T) {
+	// 新基金没有足够历史，rsi14等变量暂无数据
+	vars := map[string]float64{"est_growth": -3.5, "nav": 1.2}
+	tests := []struct {
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{expr: "rsi14 < 30 OR est_growth < -3", want: true},
+		{expr: "est_growth < -3 OR rsi14 < 30", want: true},
+		{expr: "rsi14 < 30 AND est_growth > 0", want: false},
+		{expr: "est_growth > 0 AND rsi14 < 30", want: false},
+		{expr: "(rsi14 < 30 OR ma60 > nav) OR nav > 1", want: true},
+		{expr: "NOT rsi14 > 70 OR nav > 1", want: true},
+		{expr: "NOT (rsi14 < 30 AND nav > 2)", want: true},
+		{expr: "NOT (rsi14 > 70 OR nav > 1)", want: false},
+		{expr: "rsi14 < 30 OR est_growth > 0", wantErr: true},
+		{expr: "rsi14 < 30 AND est_growth < -3", wantErr: true},
+		{expr: "est_growth < -3 AND rsi14 < 30", wantErr: true},
+		{expr: "NOT (rsi14 > 70 OR nav > 2)", wantErr: true},
+		{expr: "NOT (rsi14 < 30 AND nav > 1)", wantErr: true},
+		{expr: "NOT rsi14 > 70", wantErr: true},
+		{expr: "rsi14 < 30 OR ma60 > nav", wantErr: true},
+		{expr: "rsi14 < 30", wantErr: true},
+		{expr: "rsi14 < 30 OR nav / 0 > 1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := ParseAlertExpression(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := expr.Eval(vars)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got %v", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("want %v, got %v, %v", tt.want, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"jijin/internal/model"
+)
+
+// 提醒冷却策略
+const (
+	CooldownDaily      = "daily"       // 每天最多提醒一次
+	CooldownInterval   = "interval"    // 每N分钟最多提醒一次
+	CooldownUntilReset = "until_reset" // 提醒一次，条件解除(超过回差)或手动重置后才再次提醒
+)
+
+// CooldownModes 冷却策略(按显示顺序)
+var CooldownModes = []string{CooldownDaily, CooldownInterval, CooldownUntilReset}
+
+// CooldownModeNames 冷却策略显示名称
+var CooldownModeNames = map[string]string{
+	CooldownDaily:      "每天一次",
+	CooldownInterval:   "每N分钟一次",
+	CooldownUntilReset: "直到重置",
+}
+
+// AlertTypeDigest 汇总提醒，只用于推送，不保存历史
+const AlertTypeDigest = "digest"
+
+const defaultCooldownMinutes = 60
+
+// ruleCooldownMode 规则的冷却策略，未设置时持仓提醒为直到重置，其余为每天一次
+func ruleCooldownMode(rule *model.AlertRule) string {
+	if rule.CooldownMode != "" {
+		return rule.CooldownMode
+	}
+	if IsHoldingAlertType(rule.AlertType) {
+		return CooldownUntilReset
+	}
+	return CooldownDaily
+}
+
+// allowAlert 按冷却策略判断本次是否提醒，并维护规则的触发状态。
+// margin为当前值越过阈值的幅度，条件不满足时为负，无法计算时为NaN
+func (a *AlertService) allowAlert(rule *model.AlertRule, margin float64, now time.Time) bool {
+	if math.IsNaN(margin) {
+		return false
+	}
+
+	// 定时监控和刷新持仓后的检查可能同时进行，以数据库中的最新状态为准
+	a.ruleMu.Lock()
+	defer a.ruleMu.Unlock()
+	if latest, err := a.alerts.GetAlertRule(rule.ID); err == nil {
+		rule.Active = latest.Active
+		rule.LastTriggered = latest.LastTriggered
+	}
+
+	if margin < 0 {
+		// 回落超过回差后重新布防
+		if rule.Active && -margin > rule.Hysteresis {
+			rule.Active = false
+			a.alerts.UpdateAlertTriggerState(rule.ID, rule.Active, rule.LastTriggered)
+		}
+		return false
+	}
+
+	switch ruleCooldownMode(rule) {
+	case CooldownUntilReset:
+		if rule.Active {
+			return false
+		}
+	case CooldownInterval:
+		minutes := rule.CooldownMinutes
+		if minutes <= 0 {
+			minutes = defaultCooldownMinutes
+		}
+		if now.Sub(rule.LastTriggered) < time.Duration(minutes)*time.Minute {
+			return false
+		}
+	default:
+		if sameDay(rule.LastTriggered, now) {
+			return false
+		}
+	}
+
+	rule.Active = true
+	rule.LastTriggered = now
+	a.alerts.UpdateAlertTriggerState(rule.ID, rule.Active, rule.LastTriggered)
+	return true
+}
+
+// ResetAlertRule 手动重置规则的触发状态，使其可以再次提醒
+func (a *AlertService) ResetAlertRule(id uint) error {
+	a.ruleMu.Lock()
+	defer a.ruleMu.Unlock()
+	rule, err := a.alerts.GetAlertRule(id)
+	if err != nil {
+		return err
+	}
+	return a.alerts.UpdateAlertTriggerState(rule.ID, false, time.Time{})
+}
+
+// GetAlertSettings 获取免打扰和汇总设置
+func (a *AlertService) GetAlertSettings() (*model.AlertSettings, error) {
+	return a.notify.GetAlertSettings()
+}
+
+// SaveAlertSettings 保存免打扰和汇总设置
+func (a *AlertService) SaveAlertSettings(settings *model.AlertSettings) error {
+	if settings.QuietEnabled {
+		if _, err := parseClock(settings.QuietStart); err != nil {
+			return fmt.Errorf("免打扰开始时间格式错误: %s", settings.QuietStart)
+		}
+		if _, err := parseClock(settings.QuietEnd); err != nil {
+			return fmt.Errorf("免打扰结束时间格式错误: %s", settings.QuietEnd)
+		}
+	}
+	if settings.DigestEnabled && settings.DigestMinutes <= 0 {
+		return fmt.Errorf("汇总间隔必须大于0")
+	}
+	return a.notify.SaveAlertSettings(settings)
+}
+
+// deliver 保存提醒历史并推送：免打扰时段或汇总模式下先暂存，稍后合并推送
+func (a *AlertService) deliver(alerts []*model.AlertHistory) {
+	if len(alerts) == 0 {
+		return
+	}
+	for _, alert := range alerts {
+		alert.DeliveryStatus = DeliveryPending
+		a.alerts.SaveAlertHistory(alert)
+	}
+
+	settings, _ := a.notify.GetAlertSettings()
+	now := time.Now()
+	if settings != nil && (settings.DigestEnabled || inQuietHours(settings, now)) {
+		a.mu.Lock()
+		for _, alert := range alerts {
+			a.pending = append(a.pending, *alert)
+		}
+		a.mu.Unlock()
+		a.flushPending(now)
+		return
+	}
+
+	for _, alert := range alerts {
+		a.push(alert, []model.AlertHistory{*alert})
+	}
+}
+
+// flushPending 免打扰结束且到达汇总间隔后，将暂存的提醒合并为一条推送
+func (a *AlertService) flushPending(now time.Time) {
+	settings, _ := a.notify.GetAlertSettings()
+	if settings != nil && inQuietHours(settings, now) {
+		return
+	}
+
+	a.mu.Lock()
+	if len(a.pending) == 0 {
+		a.mu.Unlock()
+		return
+	}
+	if settings != nil && settings.DigestEnabled &&
+		now.Sub(a.lastDigest) < time.Duration(settings.DigestMinutes)*time.Minute {
+		a.mu.Unlock()
+		return
+	}
+	batch := a.pending
+	a.pending = nil
+	a.lastDigest = now
+	a.mu.Unlock()
+
+	if len(batch) == 1 {
+		a.push(&batch[0], batch)
+		return
+	}
+	a.push(digestAlert(batch, now), batch)
+}
+
+// push 通过相关规则配置的渠道推送通知，并记录各条提醒的投递状态
+func (a *AlertService) push(notification *model.AlertHistory, sources []model.AlertHistory) {
+	notify := GetNotifyService()
+	status, errMsg, attempts := notify.Deliver(notify.channelsFor(sources), notification)
+	deliveredAt := time.Now()
+	for _, alert := range sources {
+		if alert.ID != 0 {
+			a.alerts.UpdateAlertDelivery(alert.ID, status, errMsg, attempts, deliveredAt)
+		}
+	}
+	notification.DeliveryStatus = status
+	notification.DeliveryError = errMsg
+
+	a.mu.Lock()
+	callback := a.alertCallback
+	a.mu.Unlock()
+	if callback != nil {
+		callback(notification)
+	}
+}
+
+// digestAlert 合并多条提醒，相同内容只保留一条
+func digestAlert(batch []model.AlertHistory, now time.Time) *model.AlertHistory {
+	seen := make(map[string]bool)
+	lines := make([]string, 0, len(batch))
+	for _, alert := range batch {
+		if seen[alert.Message] {
+			continue
+		}
+		seen[alert.Message] = true
+		lines = append(lines, fmt.Sprintf("[%s] %s", alert.TriggeredAt.Format("15:04"), alert.Message))
+	}
+	return &model.AlertHistory{
+		FundName:    fmt.Sprintf("%d条提醒", len(lines)),
+		AlertType:   AlertTypeDigest,
+		Message:     strings.Join(lines, "\n"),
+		Value:       float64(len(lines)),
+		TriggeredAt: now,
+	}
+}
+
+// inQuietHours 是否处于免打扰时段，结束时间早于开始时间表示跨零点
+func inQuietHours(settings *model.AlertSettings, now time.Time) bool {
+	if !settings.QuietEnabled {
+		return false
+	}
+	start, err1 := parseClock(settings.QuietStart)
+	end, err2 := parseClock(settings.QuietEnd)
+	if err1 != nil || err2 != nil || start == end {
+		return false
+	}
+	current := now.Hour()*60 + now.Minute()
+	if start < end {
+		return current >= start && current < end
+	}
+	return current >= start || current < end
+}
+
+// parseClock 解析HH:MM为当天分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// sameDay 两个时间是否为同一天
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func TestAllowAlertConcurrentChecks(t *testing.T) {
+	for _, mode := range []string{CooldownDaily, CooldownInterval, CooldownUntilReset} {
+		t.Run(mode, func(t *testing.T) {
+			store := newTestStore(t)
+			a := NewAlertService(store)
+			rule := model.AlertRule{FundCode: "000001", AlertType: AlertTypePriceChange, Threshold: 2, CooldownMode: mode, Enabled: true}
+			if err := store.SaveAlertRule(&rule); err != nil {
+				t.Fatal(err)
+			}
+
+			// 各检查各自读取规则副本，模拟定时监控和刷新持仓后的检查同时进行
+			now := time.Now()
+			var allowed int32
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(r model.AlertRule) {
+					defer wg.Done()
+					if a.allowAlert(&r, 1, now) {
+						atomic.AddInt32(&allowed, 1)
+					}
+				}(rule)
+			}
+			wg.Wait()
+
+			if allowed != 1 {
+				t.Fatalf("同一次越过阈值只应提醒一次，got %d", allowed)
+			}
+		})
+	}
+}
+
+func TestAllowAlertKeepsConcurrentEdits(t *testing.T) {
+	store := newTestStore(t)
+	a := NewAlertService(store)
+	rule := model.AlertRule{FundCode: "000001", AlertType: AlertTypePriceChange, Threshold: 2, CooldownMode: CooldownUntilReset, Enabled: true}
+	if err := store.SaveAlertRule(&rule); err != nil {
+		t.Fatal(err)
+	}
+
+	// 检查持有的是旧副本，期间用户修改了阈值和渠道
+	stale := rule
+	edited := rule
+	edited.Threshold, edited.Channels = 5, "1"
+	if err := a.UpdateAlertRule(&edited); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, margin := range []float64{1, -3} {
+		a.allowAlert(&stale, margin, time.Now())
+		saved, err := store.GetAlertRule(rule.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Threshold != 5 || saved.Channels != "1" {
+			t.Fatalf("margin %v: 触发状态更新覆盖了用户修改: %+v", margin, saved)
+		}
+		if saved.Active != (margin >= 0) {
+			t.Fatalf("margin %v: want active %v, got %v", margin, margin >= 0, saved.Active)
+		}
+	}
+}
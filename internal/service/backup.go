@@ -0,0 +1,394 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// 备份包内的文件
+const (
+	backupManifestFile = "manifest.json"
+	backupDBFile       = "jijin.db"
+	backupApp          = "jijin"
+	// backupFormatVersion 备份包格式版本，包结构变化时递增
+	backupFormatVersion = 1
+	// autoBackupPrefix 自动备份文件名前缀，保留份数只清理自动备份
+	autoBackupPrefix   = "jijin-auto-"
+	manualBackupPrefix = "jijin-backup-"
+)
+
+// BackupManifest 备份清单
+type BackupManifest struct {
+	App           string           `json:"app"`
+	FormatVersion int              `json:"formatVersion"`
+	SchemaVersion int              `json:"schemaVersion"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	DBFile        string           `json:"dbFile"`
+	SHA256        string           `json:"sha256"`
+	Tables        map[string]int64 `json:"tables"` // 各数据集记录数
+}
+
+// BackupService 数据导出、备份与恢复服务
+type BackupService struct {
+	settings repository.SettingsRepository
+	store    repository.Store
+
+	mu        sync.Mutex
+	isRunning bool
+	stopChan  chan bool
+}
+
+// NewBackupService 使用指定仓储创建备份服务
+func NewBackupService(store repository.Store) *BackupService {
+	return &BackupService{
+		settings: store,
+		store:    store,
+	}
+}
+
+var backupService = NewBackupService(repository.Default())
+
+func init() {
+	// 升级或回退数据库结构前自动备份
+	repository.SetBeforeMigrate(func(from, to int) error {
+		_, err := backupService.createBackup("", fmt.Sprintf("jijin-before-migrate-v%d-", from))
+		return err
+	})
+}
+
+// GetBackupService 获取备份服务实例
+func GetBackupService() *BackupService {
+	return backupService
+}
+
+// GetSettings 获取自动备份设置
+func (b *BackupService) GetSettings() (*model.BackupSettings, error) {
+	return b.settings.GetBackupSettings()
+}
+
+// SaveSettings 保存自动备份设置
+func (b *BackupService) SaveSettings(settings *model.BackupSettings) error {
+	if settings.AutoEnabled && settings.IntervalHours <= 0 {
+		return errors.New("备份间隔必须大于0小时")
+	}
+	if settings.Keep < 0 {
+		return errors.New("保留份数不能为负数")
+	}
+	return b.settings.SaveBackupSettings(settings)
+}
+
+// BackupDir 备份目录：设置的目录，未设置时为数据目录backups
+func (b *BackupService) BackupDir() string {
+	if settings, err := b.settings.GetBackupSettings(); err == nil && settings.SaveDir != "" {
+		return settings.SaveDir
+	}
+	return filepath.Join(repository.DataDir(), "backups")
+}
+
+// Backup 在dir(为空时使用备份目录)创建备份包，返回文件路径
+func (b *BackupService) Backup(dir string) (string, error) {
+	return b.createBackup(dir, manualBackupPrefix)
+}
+
+// createBackup 生成数据库快照，连同清单打包为zip
+func (b *BackupService) createBackup(dir, prefix string) (string, error) {
+	if dir == "" {
+		dir = b.BackupDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "jijin-backup")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	snapshot := filepath.Join(tmpDir, backupDBFile)
+	if err := b.settings.SnapshotDB(snapshot); err != nil {
+		return "", fmt.Errorf("生成数据库快照失败: %v", err)
+	}
+	sum, err := fileSHA256(snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := b.settings.CurrentSchemaVersion()
+	if err != nil {
+		return "", err
+	}
+	manifest := BackupManifest{
+		App:           backupApp,
+		FormatVersion: backupFormatVersion,
+		SchemaVersion: version,
+		CreatedAt:     time.Now(),
+		DBFile:        backupDBFile,
+		SHA256:        sum,
+		Tables:        make(map[string]int64),
+	}
+	for _, ds := range ExportDatasets {
+		if data, err := ds.load(b.store); err == nil {
+			manifest.Tables[ds.Key] = int64(reflect.ValueOf(data).Len())
+		}
+	}
+
+	path := filepath.Join(dir, prefix+manifest.CreatedAt.Format("20060102-150405")+".zip")
+	if err := writeBackupZip(path, &manifest, snapshot); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// writeBackupZip 写入清单和数据库快照
+func writeBackupZip(path string, manifest *BackupManifest, snapshot string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+
+	w, err := zw.Create(backupManifestFile)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	w, err = zw.Create(manifest.DBFile)
+	if err != nil {
+		return err
+	}
+	db, err := os.Open(snapshot)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := io.Copy(w, db); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadBackupManifest 读取并校验备份包清单
+func ReadBackupManifest(path string) (*BackupManifest, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开备份文件: %v", err)
+	}
+	defer zr.Close()
+
+	var manifest BackupManifest
+	f, err := zr.Open(backupManifestFile)
+	if err != nil {
+		return nil, errors.New("不是有效的备份文件: 缺少清单")
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("备份清单格式错误: %v", err)
+	}
+	if err := checkManifest(&manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// checkManifest 检查备份包和数据库结构版本是否可恢复
+func checkManifest(m *BackupManifest) error {
+	switch {
+	case m.App != backupApp:
+		return errors.New("不是本应用的备份文件")
+	case m.FormatVersion > backupFormatVersion:
+		return fmt.Errorf("备份包格式版本%d高于当前支持的%d，请升级应用后再恢复", m.FormatVersion, backupFormatVersion)
+	case m.SchemaVersion > repository.SchemaVersion:
+		return fmt.Errorf("备份的数据结构版本%d高于当前的%d，请升级应用后再恢复", m.SchemaVersion, repository.SchemaVersion)
+	case m.DBFile == "":
+		return errors.New("备份清单缺少数据库文件")
+	}
+	return nil
+}
+
+// Restore 从备份包恢复数据库，较低结构版本的备份恢复后自动迁移到当前版本。
+// 恢复前先自动备份当前数据，返回该备份路径
+func (b *BackupService) Restore(path string) (string, error) {
+	manifest, err := ReadBackupManifest(path)
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "jijin-restore")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	restored := filepath.Join(tmpDir, backupDBFile)
+	if err := extractZipFile(path, manifest.DBFile, restored); err != nil {
+		return "", err
+	}
+	if sum, err := fileSHA256(restored); err != nil || sum != manifest.SHA256 {
+		return "", errors.New("备份文件已损坏: 数据库校验失败")
+	}
+
+	safety, err := b.createBackup("", "jijin-before-restore-")
+	if err != nil {
+		return "", fmt.Errorf("恢复前备份当前数据失败: %v", err)
+	}
+	// 替换期间暂停定时监控和定时任务，避免使用正在关闭的数据库
+	for _, pause := range []func() func(){
+		GetAlertService().pauseMonitoring, GetReportService().pauseScheduler, b.pauseScheduler,
+	} {
+		defer pause()()
+	}
+	if err := b.settings.ReplaceDB(restored); err != nil {
+		return safety, fmt.Errorf("恢复失败，当前数据已备份到%s: %v", safety, err)
+	}
+	GetSettingsService().Reload()
+	return safety, nil
+}
+
+// extractZipFile 解压zip中的单个文件
+func extractZipFile(path, name, dest string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	src, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("备份文件缺少%s", name)
+	}
+	defer src.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// fileSHA256 计算文件SHA256
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ListBackups 备份目录中的备份文件，按时间倒序
+func (b *BackupService) ListBackups() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(b.BackupDir(), "jijin-*.zip"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	return files, nil
+}
+
+// pruneAutoBackups 只保留最近keep份自动备份
+func pruneAutoBackups(dir string, keep int) {
+	if keep <= 0 {
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(dir, autoBackupPrefix+"*.zip"))
+	if err != nil || len(files) <= keep {
+		return
+	}
+	// 文件名中的时间戳可直接按字符串排序
+	sort.Strings(files)
+	for _, f := range files[:len(files)-keep] {
+		if strings.HasPrefix(filepath.Base(f), autoBackupPrefix) {
+			os.Remove(f)
+		}
+	}
+}
+
+// StartScheduler 启动自动备份，按设置的间隔备份并清理过期的自动备份
+func (b *BackupService) StartScheduler() {
+	b.mu.Lock()
+	if b.isRunning {
+		b.mu.Unlock()
+		return
+	}
+	b.isRunning = true
+	b.stopChan = make(chan bool)
+	b.mu.Unlock()
+
+	go func() {
+		b.runScheduled(time.Now())
+		ticker := time.NewTicker(30 * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.runScheduled(time.Now())
+			case <-b.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// StopScheduler 停止自动备份
+func (b *BackupService) StopScheduler() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.isRunning {
+		b.stopChan <- true
+		b.isRunning = false
+	}
+}
+
+// pauseScheduler 停止自动备份(等待进行中的任务完成)，返回恢复的函数
+func (b *BackupService) pauseScheduler() (resume func()) {
+	b.mu.Lock()
+	running := b.isRunning
+	b.mu.Unlock()
+	if !running {
+		return func() {}
+	}
+	b.StopScheduler()
+	return b.StartScheduler
+}
+
+// runScheduled 距上次自动备份超过间隔时备份
+func (b *BackupService) runScheduled(now time.Time) {
+	settings, err := b.settings.GetBackupSettings()
+	if err != nil || !settings.AutoEnabled || settings.IntervalHours <= 0 {
+		return
+	}
+	if now.Sub(settings.LastBackupAt) < time.Duration(settings.IntervalHours)*time.Hour {
+		return
+	}
+	if _, err := b.createBackup("", autoBackupPrefix); err != nil {
+		return
+	}
+	settings.LastBackupAt = now
+	b.settings.SaveBackupSettings(settings)
+	pruneAutoBackups(b.BackupDir(), settings.Keep)
+}
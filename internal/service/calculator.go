@@ -9,9 +9,16 @@ import (
 )
 
 // CalculatorService 定投计算服务
-type CalculatorService struct{}
+type CalculatorService struct {
+	history repository.HistoryRepository
+}
+
+// NewCalculatorService 使用指定仓储创建计算服务
+func NewCalculatorService(store repository.Store) *CalculatorService {
+	return &CalculatorService{history: store}
+}
 
-var calculatorService = &CalculatorService{}
+var calculatorService = NewCalculatorService(repository.Default())
 
 // GetCalculatorService 获取计算服务实例
 func GetCalculatorService() *CalculatorService {
@@ -30,6 +37,15 @@ type InvestmentResult struct {
 	StartDate      time.Time // 开始日期
 	EndDate        time.Time // 结束日期
 	AvgCost        float64   // 平均成本
+	Points         []InvestmentPoint // 每期定投后的累计投入与市值
+}
+
+// InvestmentPoint 定投回测曲线上的一点
+type InvestmentPoint struct {
+	Date   time.Time
+	Invest float64 // 累计投入
+	Value  float64 // 按当期净值计算的市值
+	Amount float64 // 本期投入
 }
 
 // CalculateInvestment 计算定投收益(使用历史数据)
@@ -69,6 +85,12 @@ func (c *CalculatorService) CalculateInvestment(fundCode string, amount float64,
 			result.TotalInvest += amount
 			result.TotalShares += shares
 			result.InvestCount++
+			result.Points = append(result.Points, InvestmentPoint{
+				Date:   current,
+				Invest: result.TotalInvest,
+				Value:  result.TotalShares * nav,
+				Amount: amount,
+			})
 		}
 
 		// 计算下一次定投日期
@@ -157,7 +179,7 @@ func (c *CalculatorService) CalculateAnnualReturn(holding *model.Holding) float6
 
 // GetHistoryData 获取历史净值数据(用于图表)
 func (c *CalculatorService) GetHistoryData(fundCode string, days int) (dates []string, values []float64, err error) {
-	histories, err := repository.GetNetValueHistory(fundCode, days)
+	histories, err := c.history.GetNetValueHistory(fundCode, days)
 	if err != nil || len(histories) == 0 {
 		// 尝试从网络获取
 		histories, err = GetFundAPI().GetFundHistory(fundCode, days)
@@ -165,7 +187,7 @@ func (c *CalculatorService) GetHistoryData(fundCode string, days int) (dates []s
 			return nil, nil, err
 		}
 		// 保存到数据库
-		repository.SaveNetValueHistories(histories)
+		c.history.SaveNetValueHistories(histories)
 	}
 
 	// 倒序(从旧到新)
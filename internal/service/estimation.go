@@ -0,0 +1,549 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// 估值来源
+const (
+	EstSourceOfficial  = "official"  // 天天基金估值
+	EstSourceHoldings  = "holdings"  // 按披露持仓估算
+	EstSourceIndex     = "index"     // 按跟踪指数估算
+	EstSourceBenchmark = "benchmark" // 按基金类别的基准指数估算
+)
+
+// EstSourceNames 估值来源显示名称
+var EstSourceNames = map[string]string{
+	EstSourceOfficial:  "天天基金",
+	EstSourceHoldings:  "持仓估算",
+	EstSourceIndex:     "指数估算",
+	EstSourceBenchmark: "基准估算",
+}
+
+// EstSourceLabel 获取估值来源显示名称
+func EstSourceLabel(source string) string {
+	if name, ok := EstSourceNames[source]; ok {
+		return name
+	}
+	return ""
+}
+
+const (
+	estimateModelTTL      = 30 * 24 * time.Hour // 持仓按季度披露，模型每月重建
+	calibrationTTL        = 7 * 24 * time.Hour
+	calibrationDays       = 60
+	minCalibrationSamples = 10
+	estimateMissTTL       = 6 * time.Hour // 持仓、跟踪指数查询或校准失败后，在此期间不再重复请求
+)
+
+var errEstimateStale = errors.New("行情尚未开盘，当日暂无估值")
+
+// EstimateComponent 估值成分(股票或指数)
+type EstimateComponent struct {
+	SecID  string  `json:"secid"` // 行情代码，如 1.600519
+	Code   string  `json:"code"`
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"` // 占净值比例(%)
+}
+
+// StockQuote 实时行情
+type StockQuote struct {
+	Code      string
+	Name      string
+	Price     float64
+	ChangePct float64 // 涨跌幅(%)
+	Time      time.Time
+}
+
+// NavEstimate 自有估值结果
+type NavEstimate struct {
+	FundCode  string
+	Source    string
+	BaseNav   float64 // 最新公布净值
+	BaseDate  time.Time
+	RawGrowth float64 // 成分加权涨跌幅(%)
+	EstGrowth float64 // 校准后估算涨跌幅(%)
+	EstValue  float64
+	EstTime   time.Time
+	Coverage  float64 // 成分合计占净值比例(%)
+}
+
+// EstimationService 自有净值估算服务
+type EstimationService struct {
+	fundInfo repository.FundInfoRepository
+
+	mu     sync.Mutex
+	misses map[string]time.Time // 查询失败的时间，键为"查询类型:基金代码"
+}
+
+// NewEstimationService 使用指定仓储创建估值服务
+func NewEstimationService(store repository.Store) *EstimationService {
+	return &EstimationService{fundInfo: store, misses: make(map[string]time.Time)}
+}
+
+var estimationService = NewEstimationService(repository.Default())
+
+// GetEstimationService 获取估值服务实例
+func GetEstimationService() *EstimationService {
+	return estimationService
+}
+
+// Estimate 根据持仓或跟踪指数的实时行情估算基金当日净值
+func (e *EstimationService) Estimate(fundCode string) (*NavEstimate, error) {
+	m, err := e.GetModel(fundCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var components []EstimateComponent
+	if err := json.Unmarshal([]byte(m.Components), &components); err != nil || len(components) == 0 {
+		return nil, fmt.Errorf("估值模型无成分数据: %s", fundCode)
+	}
+
+	latest, err := GetFundAPI().GetFundHistory(fundCode, 1)
+	if err != nil || len(latest) == 0 {
+		return nil, fmt.Errorf("无法获取基金净值: %s", fundCode)
+	}
+	base := latest[0]
+
+	secids := make([]string, len(components))
+	for i, c := range components {
+		secids[i] = c.SecID
+	}
+	quotes, err := GetFundAPI().GetStockQuotes(secids)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := 0.0
+	var quoteTime time.Time
+	for _, c := range components {
+		q, ok := quotes[c.SecID]
+		if !ok {
+			continue // 停牌或无行情按不变计
+		}
+		raw += c.Weight / 100 * q.ChangePct
+		if q.Time.After(quoteTime) {
+			quoteTime = q.Time
+		}
+	}
+	if quoteTime.IsZero() {
+		return nil, fmt.Errorf("无法获取成分行情: %s", fundCode)
+	}
+	// 行情日期不晚于最新净值日期，说明当日净值已公布或尚未开盘
+	if quoteTime.Format("2006-01-02") <= base.Date.Format("2006-01-02") {
+		return nil, errEstimateStale
+	}
+
+	growth := m.Alpha + m.Beta*raw
+	return &NavEstimate{
+		FundCode:  fundCode,
+		Source:    m.Method,
+		BaseNav:   base.NetValue,
+		BaseDate:  base.Date,
+		RawGrowth: raw,
+		EstGrowth: math.Round(growth*100) / 100,
+		EstValue:  math.Round(base.NetValue*(1+growth/100)*10000) / 10000,
+		EstTime:   quoteTime,
+		Coverage:  m.Coverage,
+	}, nil
+}
+
+// GetModel 获取基金估值模型，过期时重建，校准过期时重新校准
+func (e *EstimationService) GetModel(fundCode string) (*model.FundEstimateModel, error) {
+	m, err := e.fundInfo.GetFundEstimateModel(fundCode)
+	if err != nil || time.Since(m.UpdatedAt) > estimateModelTTL {
+		return e.BuildModel(fundCode)
+	}
+
+	if time.Since(m.CalibratedAt) > calibrationTTL && !e.missed("calibration", fundCode) {
+		if err := e.calibrate(m); err == nil {
+			e.fundInfo.SaveFundEstimateModel(m)
+		} else {
+			e.markMiss("calibration", fundCode)
+		}
+	}
+	return m, nil
+}
+
+// BuildModel 构建估值模型：指数基金使用跟踪指数，其余使用最新披露的股票持仓，
+// 无股票持仓的债券基金和FOF使用所属类别的基准指数
+func (e *EstimationService) BuildModel(fundCode string) (*model.FundEstimateModel, error) {
+	api := GetFundAPI()
+	m := &model.FundEstimateModel{FundCode: fundCode, Beta: 1}
+
+	var components []EstimateComponent
+	if !e.missed("index", fundCode) {
+		indexName, err := api.GetTrackingIndex(fundCode)
+		if err == nil && indexName != "" {
+			if secid, name, err := api.SearchIndexSecID(indexName); err == nil {
+				m.Method = EstSourceIndex
+				m.IndexName = indexName
+				components = []EstimateComponent{{SecID: secid, Code: secid[strings.Index(secid, ".")+1:], Name: name, Weight: 100}}
+			}
+		}
+		if len(components) == 0 {
+			e.markMiss("index", fundCode)
+		}
+	}
+
+	if len(components) == 0 && !e.missed("holdings", fundCode) {
+		holdings, reportDate, err := api.GetFundStockHoldings(fundCode)
+		if err == nil && len(holdings) > 0 {
+			m.Method = EstSourceHoldings
+			m.ReportDate = reportDate
+			components = holdings
+		} else {
+			e.markMiss("holdings", fundCode)
+		}
+	}
+
+	if len(components) == 0 {
+		components = categoryBenchmark(api.fundTypeOf(fundCode))
+		if len(components) == 0 {
+			return nil, fmt.Errorf("基金 %s 无股票持仓或跟踪指数数据，无法估值", fundCode)
+		}
+		m.Method = EstSourceBenchmark
+	}
+
+	for _, c := range components {
+		m.Coverage += c.Weight
+	}
+	data, err := json.Marshal(components)
+	if err != nil {
+		return nil, err
+	}
+	m.Components = string(data)
+
+	// 校准失败(如新基金样本不足)时按系数1估算
+	if err := e.calibrate(m); err != nil {
+		e.markMiss("calibration", fundCode)
+	}
+
+	if err := e.fundInfo.SaveFundEstimateModel(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// missed 该基金的查询最近失败过，仍在负缓存有效期内
+func (e *EstimationService) missed(kind, fundCode string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	at, ok := e.misses[kind+":"+fundCode]
+	if ok && time.Since(at) > estimateMissTTL {
+		delete(e.misses, kind+":"+fundCode)
+		return false
+	}
+	return ok
+}
+
+// markMiss 记录查询失败，有效期内不再重复请求
+func (e *EstimationService) markMiss(kind, fundCode string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.misses[kind+":"+fundCode] = time.Now()
+}
+
+// 类别基准的成分指数
+var (
+	benchmarkBond   = EstimateComponent{SecID: "1.000012", Code: "000012", Name: "国债指数"}
+	benchmarkEquity = EstimateComponent{SecID: "1.000300", Code: "000300", Name: "沪深300"}
+)
+
+// categoryBenchmark 按基金类别组合债券和股票指数作为估值基准，
+// 系数由校准拟合，其他类别返回空
+func categoryBenchmark(fundType string) []EstimateComponent {
+	blend := func(bondWeight float64) []EstimateComponent {
+		bond, equity := benchmarkBond, benchmarkEquity
+		bond.Weight, equity.Weight = bondWeight, 100-bondWeight
+		if equity.Weight == 0 {
+			return []EstimateComponent{bond}
+		}
+		return []EstimateComponent{bond, equity}
+	}
+
+	switch {
+	case strings.Contains(fundType, "QDII"):
+		return nil
+	case strings.Contains(fundType, "FOF"):
+		switch {
+		case strings.Contains(fundType, "偏股"):
+			return blend(20)
+		case strings.Contains(fundType, "稳健"):
+			return blend(80)
+		}
+		return blend(50)
+	case IsBondFund(fundType):
+		if strings.Contains(fundType, "二级") || strings.Contains(fundType, "偏债") {
+			return blend(85)
+		}
+		return blend(100)
+	}
+	return nil
+}
+
+// Calibrate 用近期公布净值重新拟合估值模型的系数
+func (e *EstimationService) Calibrate(fundCode string) (*model.FundEstimateModel, error) {
+	m, err := e.fundInfo.GetFundEstimateModel(fundCode)
+	if err != nil {
+		return e.BuildModel(fundCode)
+	}
+	if err := e.calibrate(m); err != nil {
+		return nil, err
+	}
+	if err := e.fundInfo.SaveFundEstimateModel(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// calibrate 以成分加权日涨跌幅为自变量、公布的日涨跌幅为因变量做线性回归，
+// 得到的系数修正未披露仓位和仓位变动带来的偏差
+func (e *EstimationService) calibrate(m *model.FundEstimateModel) error {
+	var components []EstimateComponent
+	if err := json.Unmarshal([]byte(m.Components), &components); err != nil {
+		return err
+	}
+
+	histories, err := GetFundAPI().GetFundHistory(m.FundCode, calibrationDays)
+	if err != nil {
+		return err
+	}
+
+	weighted := make(map[string]float64)
+	for _, c := range components {
+		daily, err := GetFundAPI().GetDailyChanges(c.SecID, calibrationDays+10)
+		if err != nil {
+			continue
+		}
+		for date, pct := range daily {
+			weighted[date] += c.Weight / 100 * pct
+		}
+	}
+
+	var xs, ys []float64
+	for _, h := range histories {
+		x, ok := weighted[h.Date.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		xs = append(xs, x)
+		ys = append(ys, h.DayGrowth)
+	}
+	if len(xs) < minCalibrationSamples {
+		return fmt.Errorf("校准样本不足(%d天)", len(xs))
+	}
+
+	beta, alpha, r2 := linearFit(xs, ys)
+	m.Beta = math.Max(0, math.Min(2, beta))
+	m.Alpha = alpha
+	m.RSquared = r2
+	m.Samples = len(xs)
+	m.CalibratedAt = time.Now()
+	return nil
+}
+
+// linearFit 最小二乘拟合 y = beta*x + alpha，返回系数和拟合优度
+func linearFit(xs, ys []float64) (beta, alpha, r2 float64) {
+	n := float64(len(xs))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return 1, 0, 0
+	}
+
+	beta = sxy / sxx
+	alpha = meanY - beta*meanX
+	if syy > 0 {
+		r2 = sxy * sxy / (sxx * syy)
+	}
+	return beta, alpha, r2
+}
+
+// GetFundStockHoldings 获取基金最新一期披露的股票持仓，未披露股票持仓时为空
+func (f *FundAPI) GetFundStockHoldings(code string) ([]EstimateComponent, time.Time, error) {
+	apiURL := fmt.Sprintf("https://fundf10.eastmoney.com/FundArchivesDatas.aspx?type=jjcc&code=%s&topline=10&rt=%d", code, time.Now().UnixMilli())
+	body, err := fetch(apiURL, "https://fundf10.eastmoney.com/")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	holdings, err := ParseStockHoldings(body)
+	if errors.Is(err, ErrNoData) {
+		parseStats.record(EndpointStockHoldings, nil, nil)
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		parseStats.record(EndpointStockHoldings, err, nil)
+		return nil, time.Time{}, fmt.Errorf("无法解析股票持仓: %s: %w", code, err)
+	}
+	parseStats.record(EndpointStockHoldings, nil, holdings.RowErrors)
+
+	valid, rejected := validateStockHoldings(holdings, code)
+	parseStats.reject(EndpointStockHoldings, rejected...)
+	return valid, holdings.ReportDate, nil
+}
+
+// GetTrackingIndex 获取基金跟踪标的，非指数基金返回空
+func (f *FundAPI) GetTrackingIndex(code string) (string, error) {
+	pageURL := fmt.Sprintf("https://fundf10.eastmoney.com/jbgk_%s.html", code)
+	body, err := fetch(pageURL, "https://fundf10.eastmoney.com/")
+	if err != nil {
+		return "", err
+	}
+
+	name, err := ParseTrackingIndex(body)
+	parseStats.record(EndpointTrackingIndex, err, nil)
+	if err != nil {
+		return "", fmt.Errorf("无法解析跟踪标的: %s: %w", code, err)
+	}
+	return name, nil
+}
+
+// SearchIndexSecID 按指数名称查找指数行情代码
+func (f *FundAPI) SearchIndexSecID(indexName string) (string, string, error) {
+	keyword := strings.TrimSuffix(strings.TrimSuffix(indexName, "收益率"), "指数")
+	apiURL := fmt.Sprintf("https://searchapi.eastmoney.com/api/suggest/get?input=%s&type=14&token=D43BF722C8E33BDC906FB84D85E326E8&count=10",
+		url.QueryEscape(keyword))
+
+	resp, err := client.R().Get(apiURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	var result struct {
+		QuotationCodeTable struct {
+			Data []struct {
+				Code             string `json:"Code"`
+				Name             string `json:"Name"`
+				MktNum           string `json:"MktNum"`
+				QuoteID          string `json:"QuoteID"`
+				SecurityTypeName string `json:"SecurityTypeName"`
+			} `json:"Data"`
+		} `json:"QuotationCodeTable"`
+	}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return "", "", err
+	}
+
+	for _, d := range result.QuotationCodeTable.Data {
+		if !strings.Contains(d.SecurityTypeName, "指数") {
+			continue
+		}
+		if d.QuoteID != "" {
+			return d.QuoteID, d.Name, nil
+		}
+		return d.MktNum + "." + d.Code, d.Name, nil
+	}
+	return "", "", fmt.Errorf("未找到指数行情: %s", indexName)
+}
+
+// GetStockQuotes 批量获取实时行情，以行情代码为键
+func (f *FundAPI) GetStockQuotes(secids []string) (map[string]StockQuote, error) {
+	apiURL := fmt.Sprintf("https://push2.eastmoney.com/api/qt/ulist.np/get?fltt=2&fields=f2,f3,f12,f13,f14,f124&secids=%s",
+		strings.Join(secids, ","))
+
+	resp, err := client.R().
+		SetHeader("Referer", "https://quote.eastmoney.com/").
+		Get(apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Diff []map[string]interface{} `json:"diff"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return nil, err
+	}
+
+	quotes := make(map[string]StockQuote)
+	for _, d := range result.Data.Diff {
+		// 停牌时价格字段为"-"
+		price, ok := d["f2"].(float64)
+		if !ok {
+			continue
+		}
+		change, _ := d["f3"].(float64)
+		market, _ := d["f13"].(float64)
+		ts, _ := d["f124"].(float64)
+
+		code := getString(d, "f12")
+		quotes[fmt.Sprintf("%d.%s", int(market), code)] = StockQuote{
+			Code:      code,
+			Name:      getString(d, "f14"),
+			Price:     price,
+			ChangePct: change,
+			Time:      time.Unix(int64(ts), 0),
+		}
+	}
+	return quotes, nil
+}
+
+// DailyBar 日K线
+type DailyBar struct {
+	Date      time.Time // UTC零点，与净值日期一致
+	Close     float64
+	ChangePct float64 // 涨跌幅(%)
+}
+
+// GetDailyKlines 获取近若干交易日的日K线，按日期升序，无法解析的行跳过
+func (f *FundAPI) GetDailyKlines(secid string, days int) ([]DailyBar, error) {
+	apiURL := fmt.Sprintf("https://push2his.eastmoney.com/api/qt/stock/kline/get?secid=%s&fields1=f1&fields2=f51,f53,f59&klt=101&fqt=1&lmt=%d",
+		secid, days)
+	body, err := fetch(apiURL, "https://quote.eastmoney.com/")
+	if err != nil {
+		return nil, err
+	}
+
+	klines, err := ParseKlines(body)
+	if errors.Is(err, ErrNoData) {
+		parseStats.record(EndpointKline, nil, nil)
+		return nil, nil
+	}
+	if err != nil {
+		parseStats.record(EndpointKline, err, nil)
+		return nil, fmt.Errorf("无法解析日K线: %s: %w", secid, err)
+	}
+	parseStats.record(EndpointKline, nil, klines.RowErrors)
+	return klines.Bars, nil
+}
+
+// GetDailyChanges 获取近若干交易日的日涨跌幅，以日期(2006-01-02)为键
+func (f *FundAPI) GetDailyChanges(secid string, days int) (map[string]float64, error) {
+	bars, err := f.GetDailyKlines(secid, days)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]float64, len(bars))
+	for _, bar := range bars {
+		changes[bar.Date.Format("2006-01-02")] = bar.ChangePct
+	}
+	return changes, nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+
+	"jijin/internal/model"
+)
+
+func TestGetModelBacksOffFailedCalibration(t *testing.T) {
+	store := newTestStore(t)
+	e := NewEstimationService(store)
+	// 成分数据无法解析，校准必然失败
+	m := &model.FundEstimateModel{FundCode: "000001", Method: EstSourceHoldings, Components: "{", Beta: 1}
+	if err := store.SaveFundEstimateModel(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.GetModel("000001"); err != nil {
+		t.Fatal(err)
+	}
+	if !e.missed("calibration", "000001") {
+		t.Fatal("校准失败后应记录，有效期内不再重试")
+	}
+	got, err := e.GetModel("000001")
+	if err != nil || got.Beta != 1 || !got.CalibratedAt.IsZero() {
+		t.Fatalf("未校准的模型按系数1估算: %+v, %v", got, err)
+	}
+}
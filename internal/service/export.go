@@ -0,0 +1,336 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"jijin/internal/repository"
+)
+
+// 导出格式
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+	ExportXLSX = "xlsx"
+)
+
+// ExportFormats 支持的导出格式
+var ExportFormats = []string{ExportCSV, ExportJSON, ExportXLSX}
+
+// ExportDataset 可导出的数据集
+type ExportDataset struct {
+	Key  string
+	Name string
+	load func(store repository.Store) (interface{}, error)
+}
+
+// ExportDatasets 可导出的数据集，按导出顺序
+var ExportDatasets = []ExportDataset{
+	{Key: "holdings", Name: "持仓", load: func(store repository.Store) (interface{}, error) { return store.GetAllHoldings() }},
+	{Key: "transactions", Name: "交易记录", load: func(store repository.Store) (interface{}, error) { return store.GetAllTransactions() }},
+	{Key: "strategies", Name: "定投策略", load: func(store repository.Store) (interface{}, error) { return store.GetAllStrategies() }},
+	{Key: "watchlist", Name: "自选", load: func(store repository.Store) (interface{}, error) { return store.GetWatchlistItems("") }},
+	{Key: "alert_rules", Name: "提醒规则", load: func(store repository.Store) (interface{}, error) { return store.GetAllAlertRules() }},
+	{Key: "alert_history", Name: "提醒历史", load: func(store repository.Store) (interface{}, error) { return store.GetAllAlertHistory() }},
+	{Key: "nav_history", Name: "净值历史", load: func(store repository.Store) (interface{}, error) { return store.GetAllNetValueHistories() }},
+}
+
+// exportTable 数据集展开后的表格，numeric标记数值列
+type exportTable struct {
+	name    string
+	header  []string
+	rows    [][]string
+	numeric []bool
+}
+
+// Export 将选中的数据集导出到dir，keys为空时导出全部。
+// CSV每个数据集一个文件，JSON和XLSX合并为一个文件；返回写入的文件
+func (b *BackupService) Export(dir, format string, keys []string) ([]string, error) {
+	datasets, err := selectDatasets(keys)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	stamp := time.Now().Format("20060102-150405")
+
+	switch format {
+	case ExportCSV:
+		var files []string
+		for _, ds := range datasets {
+			table, err := loadExportTable(b.store, ds)
+			if err != nil {
+				return files, err
+			}
+			path := filepath.Join(dir, fmt.Sprintf("jijin-%s-%s.csv", ds.Key, stamp))
+			if err := writeCSVFile(path, table); err != nil {
+				return files, err
+			}
+			files = append(files, path)
+		}
+		return files, nil
+
+	case ExportJSON:
+		doc := map[string]interface{}{
+			"exportedAt":    time.Now(),
+			"schemaVersion": repository.SchemaVersion,
+		}
+		for _, ds := range datasets {
+			data, err := ds.load(b.store)
+			if err != nil {
+				return nil, err
+			}
+			doc[ds.Key] = data
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("jijin-export-%s.json", stamp))
+		return []string{path}, os.WriteFile(path, data, 0644)
+
+	case ExportXLSX:
+		tables := make([]*exportTable, 0, len(datasets))
+		for _, ds := range datasets {
+			table, err := loadExportTable(b.store, ds)
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, table)
+		}
+		var buf bytes.Buffer
+		if err := writeXLSX(&buf, tables); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("jijin-export-%s.xlsx", stamp))
+		return []string{path}, os.WriteFile(path, buf.Bytes(), 0644)
+	}
+	return nil, fmt.Errorf("不支持的导出格式: %s", format)
+}
+
+// selectDatasets 按key选择数据集
+func selectDatasets(keys []string) ([]ExportDataset, error) {
+	if len(keys) == 0 {
+		return ExportDatasets, nil
+	}
+	var selected []ExportDataset
+	for _, key := range keys {
+		found := false
+		for _, ds := range ExportDatasets {
+			if ds.Key == key || ds.Name == key {
+				selected = append(selected, ds)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("未知的数据集: %s", key)
+		}
+	}
+	return selected, nil
+}
+
+// loadExportTable 读取数据集并按字段展开为表格，列名使用json字段名
+func loadExportTable(store repository.Store, ds ExportDataset) (*exportTable, error) {
+	data, err := ds.load(store)
+	if err != nil {
+		return nil, err
+	}
+	table := &exportTable{name: ds.Name}
+	list := reflect.ValueOf(data)
+	elem := list.Type().Elem()
+	fields := exportFields(elem, nil)
+	for _, f := range fields {
+		sf := elem.FieldByIndex(f)
+		table.header = append(table.header, exportColumnName(sf))
+		switch sf.Type.Kind() {
+		case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Float64:
+			table.numeric = append(table.numeric, true)
+		default:
+			table.numeric = append(table.numeric, false)
+		}
+	}
+	for i := 0; i < list.Len(); i++ {
+		item := list.Index(i)
+		row := make([]string, len(fields))
+		for j, f := range fields {
+			row[j] = exportValue(item.FieldByIndex(f))
+		}
+		table.rows = append(table.rows, row)
+	}
+	return table, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// exportFields 可导出的字段下标，展开嵌入的gorm.Model，跳过软删除字段和其他嵌套结构
+func exportFields(t reflect.Type, prefix []int) [][]int {
+	var fields [][]int
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		index := append(append([]int{}, prefix...), i)
+		if !sf.IsExported() || sf.Tag.Get("json") == "-" {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, exportFields(sf.Type, index)...)
+			continue
+		}
+		switch sf.Type.Kind() {
+		case reflect.Struct:
+			if sf.Type != timeType {
+				continue
+			}
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			continue
+		}
+		fields = append(fields, index)
+	}
+	return fields
+}
+
+// exportColumnName 列名：json标签名，没有时为字段名
+func exportColumnName(sf reflect.StructField) string {
+	if tag := sf.Tag.Get("json"); tag != "" {
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// exportValue 字段值转为文本，时间为本地时间，零值时间为空
+func exportValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Local().Format("2006-01-02 15:04:05")
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// writeCSVFile 写入带BOM的UTF-8 CSV，Excel可直接打开
+func writeCSVFile(path string, table *exportTable) error {
+	var buf bytes.Buffer
+	buf.WriteString("\xef\xbb\xbf")
+	w := csv.NewWriter(&buf)
+	w.Write(table.header)
+	w.WriteAll(table.rows)
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// writeXLSX 写入最简XLSX工作簿，每个表格一个工作表，文本使用内联字符串
+func writeXLSX(w io.Writer, tables []*exportTable) error {
+	zw := zip.NewWriter(w)
+	add := func(name, content string) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, xml.Header+content)
+		return err
+	}
+
+	var types, sheets, rels bytes.Buffer
+	for i, t := range tables {
+		n := i + 1
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(t.name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+	}
+	for _, p := range parts {
+		if err := add(p.name, p.content); err != nil {
+			return err
+		}
+	}
+	for i, t := range tables {
+		if err := add(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxSheetXML(t)); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// xlsxSheetXML 工作表内容，首行为表头
+func xlsxSheetXML(t *exportTable) string {
+	var b bytes.Buffer
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	writeRow := func(r int, cells []string, header bool) {
+		fmt.Fprintf(&b, `<row r="%d">`, r)
+		for c, v := range cells {
+			ref := xlsxColumnName(c) + strconv.Itoa(r)
+			if !header && t.numeric[c] && v != "" {
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, v)
+			} else {
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlEscape(v))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	writeRow(1, t.header, true)
+	for i, row := range t.rows {
+		writeRow(i+2, row, false)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// xlsxColumnName 列序号(从0开始)转为A、B...AA形式
+func xlsxColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xmlEscape 转义XML文本
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
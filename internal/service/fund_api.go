@@ -1,11 +1,7 @@
 package service
 
 import (
-	"encoding/json"
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
 
 	"jijin/internal/model"
@@ -20,195 +16,235 @@ var client = resty.New().
 
 // FundAPI 基金数据服务
 type FundAPI struct {
+	funds    repository.FundRepository
+	holdings repository.HoldingRepository
+	history  repository.HistoryRepository
+	rankings repository.RankingRepository
+	fundInfo repository.FundInfoRepository
+
 	allFunds []model.FundSearchResult // 缓存所有基金列表
 }
 
-var fundAPI = &FundAPI{}
+// NewFundAPI 使用指定仓储创建基金API服务
+func NewFundAPI(store repository.Store) *FundAPI {
+	return &FundAPI{
+		funds:    store,
+		holdings: store,
+		history:  store,
+		rankings: store,
+		fundInfo: store,
+	}
+}
+
+var fundAPI = NewFundAPI(repository.Default())
 
 // GetFundAPI 获取基金API服务实例
 func GetFundAPI() *FundAPI {
 	return fundAPI
 }
 
-// SearchFund 搜索基金
+// SearchFund 搜索基金(按相关度排序)
 func (f *FundAPI) SearchFund(keyword string) ([]model.FundSearchResult, error) {
-	// 如果没有缓存，先加载
-	if len(f.allFunds) == 0 {
-		if err := f.loadAllFunds(); err != nil {
-			return nil, err
-		}
-	}
-
-	keyword = strings.ToLower(keyword)
-	var results []model.FundSearchResult
-
-	for _, fund := range f.allFunds {
-		if strings.Contains(fund.Code, keyword) ||
-			strings.Contains(strings.ToLower(fund.Name), keyword) ||
-			strings.Contains(strings.ToLower(fund.Pinyin), keyword) ||
-			strings.Contains(strings.ToLower(fund.PinyinAbbr), keyword) {
-			results = append(results, fund)
-			if len(results) >= 50 { // 最多返回50条
-				break
-			}
-		}
-	}
-
-	return results, nil
+	return f.SearchFundWithOptions(keyword, SearchOptions{})
 }
 
 // loadAllFunds 加载所有基金列表
 func (f *FundAPI) loadAllFunds() error {
-	resp, err := client.R().
-		Get("http://fund.eastmoney.com/js/fundcode_search.js")
+	body, err := fetch("http://fund.eastmoney.com/js/fundcode_search.js", "")
+	if err != nil {
+		return err
+	}
+
+	list, err := ParseFundList(body)
 	if err != nil {
+		parseStats.record(EndpointFundList, err, nil)
 		return err
 	}
+	parseStats.record(EndpointFundList, nil, list.RowErrors)
 
-	body := string(resp.Body())
+	f.allFunds = list.Funds
+	return nil
+}
 
-	// 解析 var r = [["000001","HXCZHH","华夏成长混合","混合型-灵活","HUAXIACHENGZHANGHUNHE"],...]
-	re := regexp.MustCompile(`\["(\d+)","([^"]+)","([^"]+)","([^"]+)","([^"]+)"\]`)
-	matches := re.FindAllStringSubmatch(body, -1)
+// fetch 请求接口并返回响应内容，请求失败不计入解析统计
+func fetch(url, referer string) (string, error) {
+	req := client.R()
+	if referer != "" {
+		req.SetHeader("Referer", referer)
+	}
+	resp, err := req.Get(url)
+	if err != nil {
+		return "", err
+	}
+	if resp.IsError() {
+		return "", fmt.Errorf("请求失败: HTTP %d", resp.StatusCode())
+	}
+	return string(resp.Body()), nil
+}
 
-	f.allFunds = make([]model.FundSearchResult, 0, len(matches))
-	for _, match := range matches {
-		if len(match) >= 6 {
-			f.allFunds = append(f.allFunds, model.FundSearchResult{
-				Code:       match[1],
-				PinyinAbbr: match[2],
-				Name:       match[3],
-				Type:       match[4],
-				Pinyin:     match[5],
-			})
+// fundTypeOf 已知的基金类型(基金列表缓存或数据库)，用于数值校验，不发起请求
+func (f *FundAPI) fundTypeOf(code string) string {
+	for _, r := range f.allFunds {
+		if r.Code == code {
+			return r.Type
 		}
 	}
+	if fund, err := f.funds.GetFund(code); err == nil {
+		return fund.Type
+	}
+	return ""
+}
 
-	return nil
+// findFund 按代码在基金列表中查找，列表未加载时先加载
+func (f *FundAPI) findFund(code string) (model.FundSearchResult, bool) {
+	if len(f.allFunds) == 0 {
+		if err := f.loadAllFunds(); err != nil {
+			return model.FundSearchResult{}, false
+		}
+	}
+	for _, r := range f.allFunds {
+		if r.Code == code {
+			return r, true
+		}
+	}
+	return model.FundSearchResult{}, false
 }
 
 // GetFundDetail 获取基金详情(实时估值)
 func (f *FundAPI) GetFundDetail(code string) (*model.Fund, error) {
 	url := fmt.Sprintf("http://fundgz.1234567.com.cn/js/%s.js?rt=%d", code, time.Now().UnixMilli())
-
-	resp, err := client.R().
-		SetHeader("Referer", "http://fund.eastmoney.com/").
-		Get(url)
+	body, err := fetch(url, "http://fund.eastmoney.com/")
 	if err != nil {
 		return nil, err
 	}
 
-	body := string(resp.Body())
-
-	// 解析 jsonpgz({"fundcode":"000001","name":"华夏成长混合",...});
-	re := regexp.MustCompile(`jsonpgz\((.*)\)`)
-	match := re.FindStringSubmatch(body)
-	if len(match) < 2 {
-		return nil, fmt.Errorf("无法解析基金数据: %s", code)
+	est, err := ParseFundEstimate(body)
+	parseStats.record(EndpointEstimate, err, nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析基金数据: %s: %w", code, err)
 	}
-
-	var data map[string]interface{}
-	if err := json.Unmarshal([]byte(match[1]), &data); err != nil {
+	if err := checkNav(EndpointEstimate, code, "dwjz", est.NetValue); err != nil {
+		parseStats.reject(EndpointEstimate, err)
 		return nil, err
 	}
 
 	fund := &model.Fund{
 		Code:      code,
-		Name:      getString(data, "name"),
+		Name:      est.Name,
+		NetValue:  est.NetValue,
 		UpdatedAt: time.Now(),
 	}
 
-	// 解析净值
-	if dwjz, ok := data["dwjz"].(string); ok {
-		fund.NetValue, _ = strconv.ParseFloat(dwjz, 64)
+	// 估值异常时只丢弃估值，单位净值仍可用
+	if est.EstValue > 0 {
+		err := checkDailyMove(EndpointEstimate, code, f.fundTypeOf(code), "gszzl", est.EstGrowth)
+		if err == nil {
+			err = checkNav(EndpointEstimate, code, "gsz", est.EstValue)
+		}
+		if err != nil {
+			parseStats.reject(EndpointEstimate, err)
+		} else {
+			fund.EstValue = est.EstValue
+			fund.EstGrowth = est.EstGrowth
+			fund.EstTime = est.EstTime
+		}
 	}
 
-	// 解析估值
-	if gsz, ok := data["gsz"].(string); ok {
-		fund.EstValue, _ = strconv.ParseFloat(gsz, 64)
+	return fund, nil
+}
+
+// GetFundProfile 获取基金基本概况(基金公司、资产规模、成立日期)
+func (f *FundAPI) GetFundProfile(code string) (*model.FundProfile, error) {
+	url := fmt.Sprintf("https://fundf10.eastmoney.com/jbgk_%s.html", code)
+	body, err := fetch(url, "https://fundf10.eastmoney.com/")
+	if err != nil {
+		return nil, err
 	}
 
-	// 解析估算涨跌幅
-	if gszzl, ok := data["gszzl"].(string); ok {
-		fund.EstGrowth, _ = strconv.ParseFloat(gszzl, 64)
+	profile, err := ParseFundProfile(code, body)
+	parseStats.record(EndpointProfile, err, nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析基金概况: %s: %w", code, err)
 	}
+	return profile, nil
+}
 
-	return fund, nil
+// GetCurrentManager 获取现任基金经理及其任职起始日期
+func (f *FundAPI) GetCurrentManager(code string) (string, time.Time, error) {
+	url := fmt.Sprintf("https://fundf10.eastmoney.com/jjjl_%s.html", code)
+	body, err := fetch(url, "https://fundf10.eastmoney.com/")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	manager, since, err := ParseCurrentManager(body)
+	parseStats.record(EndpointManager, err, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("无法解析基金经理: %s: %w", code, err)
+	}
+	return manager, since, nil
 }
 
 // GetFundNetValue 获取基金净值(历史)
 func (f *FundAPI) GetFundNetValue(code string) (*model.Fund, error) {
-	url := fmt.Sprintf("https://fundf10.eastmoney.com/F10DataApi.aspx?type=lsjz&code=%s&page=1&per=1", code)
-
-	resp, err := client.R().
-		SetHeader("Referer", "https://fundf10.eastmoney.com/").
-		Get(url)
+	histories, err := f.GetFundHistory(code, 1)
 	if err != nil {
 		return nil, err
 	}
-
-	body := string(resp.Body())
-
-	fund := &model.Fund{
-		Code:      code,
-		UpdatedAt: time.Now(),
-	}
-
-	// 解析表格数据
-	// <td>2024-01-15</td><td class='tor bold'>1.2345</td><td class='tor bold'>1.5678</td><td class='tor bold red'>1.23%</td>
-	reRow := regexp.MustCompile(`<td>(\d{4}-\d{2}-\d{2})</td><td[^>]*>([^<]+)</td><td[^>]*>([^<]+)</td><td[^>]*>([^<]+)</td>`)
-	match := reRow.FindStringSubmatch(body)
-	if len(match) >= 5 {
-		fund.NetValue, _ = strconv.ParseFloat(match[2], 64)
-		fund.TotalValue, _ = strconv.ParseFloat(match[3], 64)
-		growth := strings.TrimSuffix(match[4], "%")
-		fund.DayGrowth, _ = strconv.ParseFloat(growth, 64)
+	if len(histories) == 0 {
+		return nil, fmt.Errorf("暂无净值数据: %s", code)
 	}
 
-	return fund, nil
+	latest := histories[0]
+	return &model.Fund{
+		Code:       code,
+		NetValue:   latest.NetValue,
+		TotalValue: latest.TotalValue,
+		DayGrowth:  latest.DayGrowth,
+		UpdatedAt:  time.Now(),
+	}, nil
 }
 
-// GetFundHistory 获取基金历史净值
+// GetFundHistory 获取基金历史净值，无法解析或数值不合理的行不返回
 func (f *FundAPI) GetFundHistory(code string, days int) ([]model.NetValueHistory, error) {
 	url := fmt.Sprintf("https://fundf10.eastmoney.com/F10DataApi.aspx?type=lsjz&code=%s&page=1&per=%d", code, days)
-
-	resp, err := client.R().
-		SetHeader("Referer", "https://fundf10.eastmoney.com/").
-		Get(url)
+	body, err := fetch(url, "https://fundf10.eastmoney.com/")
 	if err != nil {
 		return nil, err
 	}
 
-	body := string(resp.Body())
-
-	var histories []model.NetValueHistory
-
-	reRow := regexp.MustCompile(`<td>(\d{4}-\d{2}-\d{2})</td><td[^>]*>([^<]+)</td><td[^>]*>([^<]+)</td><td[^>]*>([^<]*)</td>`)
-	matches := reRow.FindAllStringSubmatch(body, -1)
-
-	for _, match := range matches {
-		if len(match) >= 5 {
-			date, _ := time.Parse("2006-01-02", match[1])
-			netValue, _ := strconv.ParseFloat(match[2], 64)
-			totalValue, _ := strconv.ParseFloat(match[3], 64)
-			growth := strings.TrimSuffix(strings.TrimSpace(match[4]), "%")
-			dayGrowth, _ := strconv.ParseFloat(growth, 64)
-
-			histories = append(histories, model.NetValueHistory{
-				FundCode:   code,
-				NetValue:   netValue,
-				TotalValue: totalValue,
-				DayGrowth:  dayGrowth,
-				Date:       date,
-			})
-		}
+	table, err := ParseNavTable(code, body)
+	if err != nil {
+		parseStats.record(EndpointNavHistory, err, nil)
+		return nil, fmt.Errorf("无法解析历史净值: %s: %w", code, err)
 	}
+	parseStats.record(EndpointNavHistory, nil, table.RowErrors)
 
+	histories, rejected := validateNavRows(table, code, f.fundTypeOf(code))
+	parseStats.reject(EndpointNavHistory, rejected...)
+	if len(histories) == 0 && len(rejected) > 0 {
+		return nil, rejected[0]
+	}
 	return histories, nil
 }
 
+// GetLocalFundHistory 获取本地保存的历史净值，网络不可用时使用
+func (f *FundAPI) GetLocalFundHistory(code string, days int) ([]model.NetValueHistory, error) {
+	return f.history.GetNetValueHistory(code, days)
+}
+
 // RefreshFund 刷新基金数据
 func (f *FundAPI) RefreshFund(code string) (*model.Fund, error) {
+	// 获取基金类型(从搜索列表)，先于净值获取以便按类型校验
+	fundType := ""
+	results, _ := f.SearchFund(code)
+	for _, r := range results {
+		if r.Code == code {
+			fundType = r.Type
+			break
+		}
+	}
+
 	// 获取实时估值
 	fund, err := f.GetFundDetail(code)
 	if err != nil {
@@ -218,27 +254,52 @@ func (f *FundAPI) RefreshFund(code string) (*model.Fund, error) {
 			return nil, err
 		}
 	}
+	fund.Type = fundType
 
-	// 获取基金类型(从搜索列表)
-	results, _ := f.SearchFund(code)
-	for _, r := range results {
-		if r.Code == code {
-			fund.Type = r.Type
-			break
+	// 天天基金无估值时(债券、FOF、新基金等)使用自有估值；
+	// QDII净值滞后公布，估值由QDIIService按境外交易日推算
+	if fund.EstValue > 0 {
+		fund.EstSource = EstSourceOfficial
+	} else if !IsQDII(fund.Type) {
+		if est, err := GetEstimationService().Estimate(code); err == nil {
+			if fund.NetValue == 0 {
+				fund.NetValue = est.BaseNav
+			}
+			fund.EstValue = est.EstValue
+			fund.EstGrowth = est.EstGrowth
+			fund.EstTime = est.EstTime
+			fund.EstSource = est.Source
 		}
 	}
 
+	// 净值不合理时不覆盖数据库中的记录
+	if err := checkNav(EndpointEstimate, code, "单位净值", fund.NetValue); err != nil {
+		return nil, err
+	}
+
 	// 保存到数据库
-	if err := repository.SaveFund(fund); err != nil {
+	if err := f.funds.SaveFund(fund); err != nil {
 		return nil, err
 	}
 
+	// 记录盘中估值序列
+	if fund.EstValue > 0 && !fund.EstTime.IsZero() {
+		f.history.SaveIntradayEstimate(&model.IntradayEstimate{
+			FundCode:  fund.Code,
+			EstTime:   fund.EstTime,
+			EstValue:  fund.EstValue,
+			EstGrowth: fund.EstGrowth,
+			BaseNav:   fund.NetValue,
+			Source:    fund.EstSource,
+		})
+	}
+
 	return fund, nil
 }
 
 // RefreshAllHoldings 刷新所有持仓基金数据
 func (f *FundAPI) RefreshAllHoldings() error {
-	holdings, err := repository.GetAllHoldings()
+	holdings, err := f.holdings.GetAllHoldings()
 	if err != nil {
 		return err
 	}
@@ -251,78 +312,150 @@ func (f *FundAPI) RefreshAllHoldings() error {
 
 		// 更新持仓的当前净值
 		h.CurrentNav = fund.NetValue
+		h.NavDate = time.Time{}
 		if fund.EstValue > 0 {
 			h.CurrentNav = fund.EstValue
+			h.NavDate = fund.EstTime
 		}
-		repository.SaveHolding(&h)
+
+		// QDII净值滞后公布，按境外交易日推算的估值计价
+		if IsQDII(fund.Type) {
+			if qdii, err := GetQDIIService().RefreshQDII(h.FundCode); err == nil {
+				h.CurrentNav, h.NavDate = GetQDIIService().ValuationNav(qdii, fund.NetValue)
+			}
+		}
+		f.holdings.SaveHolding(&h)
 	}
 
 	return nil
 }
 
-// GetFundRanking 获取基金排行榜
-func (f *FundAPI) GetFundRanking(rankType string, limit int) ([]model.FundRanking, error) {
-	// 排序字段: zzf-涨幅, 1nzf-1年涨幅, 6yzf-6月涨幅, 3yzf-3月涨幅
-	sortField := "zzf"
-	sortOrder := "desc"
+// rankColumn 排行周期对应的排序字段和数据列
+type rankColumn struct {
+	sortField string
+	column    int
+}
+
+// rankPeriodColumns 数据格式: 代码,名称,简拼,日期,单位净值,累计净值,日增长率,近1周,近1月,近3月,近6月,近1年,近2年,近3年,今年来,成立来,...
+var rankPeriodColumns = map[string]rankColumn{
+	RankPeriodDay:   {"rzdf", 6},
+	RankPeriodWeek:  {"zzf", 7},
+	RankPeriodMonth: {"1yzf", 8},
+	RankPeriod3M:    {"3yzf", 9},
+	RankPeriod6M:    {"6yzf", 10},
+	RankPeriod1Y:    {"1nzf", 11},
+	RankPeriod2Y:    {"2nzf", 12},
+	RankPeriod3Y:    {"3nzf", 13},
+	RankPeriodYTD:   {"jnzf", 14},
+	RankPeriodSince: {"lnzf", 15},
+}
 
+// legacyRankParams 兼容旧的排行类型(gain/loss/year/month)，返回周期和方向
+func legacyRankParams(rankType string) (period, direction string) {
 	switch rankType {
-	case "gain":
-		sortField = "zzf"
-		sortOrder = "desc"
-	case "loss":
-		sortField = "zzf"
-		sortOrder = "asc"
+	case RankTypeLoss:
+		return RankPeriodDay, RankTypeLoss
 	case "year":
-		sortField = "1nzf"
-		sortOrder = "desc"
+		return RankPeriod1Y, RankTypeGain
 	case "month":
-		sortField = "3yzf"
-		sortOrder = "desc"
+		return RankPeriodMonth, RankTypeGain
+	}
+	if _, ok := rankPeriodColumns[rankType]; ok {
+		return rankType, RankTypeGain
+	}
+	return RankPeriodDay, RankTypeGain
+}
+
+// GetFundRanking 获取基金排行榜(全部类别)
+func (f *FundAPI) GetFundRanking(rankType string, limit int) ([]model.FundRanking, error) {
+	period, direction := legacyRankParams(rankType)
+	return f.GetFundRankingByPeriod(RankCategoryAll, period, direction, limit)
+}
+
+// GetFundRankingByPeriod 获取指定类别、周期的基金排行，limit<=0 时返回全部
+func (f *FundAPI) GetFundRankingByPeriod(category, period, direction string, limit int) ([]model.FundRanking, error) {
+	col, ok := rankPeriodColumns[period]
+	if !ok {
+		return nil, fmt.Errorf("不支持的排行周期: %s", period)
+	}
+	if category == "" {
+		category = RankCategoryAll
+	}
+
+	sortOrder := "desc"
+	if direction == RankTypeLoss {
+		sortOrder = "asc"
+	}
+
+	pageSize := limit
+	if pageSize <= 0 {
+		pageSize = 20000
 	}
 
 	url := fmt.Sprintf(
-		"https://fund.eastmoney.com/data/rankhandler.aspx?op=ph&dt=kf&ft=all&rs=&gs=0&sc=%s&st=%s&pi=1&pn=%d&dx=1",
-		sortField, sortOrder, limit,
+		"https://fund.eastmoney.com/data/rankhandler.aspx?op=ph&dt=kf&ft=%s&rs=&gs=0&sc=%s&st=%s&pi=1&pn=%d&dx=1",
+		category, col.sortField, sortOrder, pageSize,
 	)
 
-	resp, err := client.R().
-		SetHeader("Referer", "https://fund.eastmoney.com/data/fundranking.html").
-		Get(url)
+	body, err := fetch(url, "https://fund.eastmoney.com/data/fundranking.html")
 	if err != nil {
 		return nil, err
 	}
 
-	body := string(resp.Body())
-
-	// 解析数据: var rankData = {datas:["000001,华夏成长混合,HXCZHH,2024-01-15,1.2345,1.5678,1.23,...",...]}
-	re := regexp.MustCompile(`"([^"]+)"`)
-	matches := re.FindAllStringSubmatch(body, -1)
-
-	var rankings []model.FundRanking
-	for i, match := range matches {
-		if len(match) < 2 {
+	data, err := ParseRankData(body)
+	if err != nil {
+		parseStats.record(EndpointRanking, err, nil)
+		return nil, fmt.Errorf("无法解析基金排行: %w", err)
+	}
+	parseStats.record(EndpointRanking, nil, data.RowErrors)
+
+	type rankRow struct {
+		code, name string
+		value      float64
+	}
+	var rows []rankRow
+	var statDate time.Time
+	var rejected []error
+	for _, row := range data.Rows {
+		// 该周期无数据(如成立不足一年)的基金不参与排名
+		value, ok := row.Returns[period]
+		if !ok {
 			continue
 		}
-		parts := strings.Split(match[1], ",")
-		if len(parts) < 10 {
-			continue
+		if row.NetValue != 0 {
+			if err := checkNav(EndpointRanking, row.Code, "单位净值", row.NetValue); err != nil {
+				rejected = append(rejected, err)
+				continue
+			}
 		}
 
-		dayGrowth, _ := strconv.ParseFloat(parts[6], 64)
+		if row.Date.After(statDate) {
+			statDate = row.Date
+		}
+		rows = append(rows, rankRow{code: row.Code, name: row.Name, value: value})
+	}
+	parseStats.reject(EndpointRanking, rejected...)
+
+	if statDate.IsZero() {
+		now := time.Now()
+		statDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
 
-		ranking := model.FundRanking{
-			FundCode:     parts[0],
-			FundName:     parts[1],
-			RankType:     rankType,
-			RankValue:    dayGrowth,
+	var rankings []model.FundRanking
+	for i, row := range rows {
+		rankings = append(rankings, model.FundRanking{
+			FundCode:     row.code,
+			FundName:     row.name,
+			RankType:     direction,
+			Category:     category,
+			RankValue:    row.value,
 			RankPosition: i + 1,
-			Period:       "day",
-			StatDate:     time.Now(),
-		}
-		rankings = append(rankings, ranking)
+			TotalCount:   len(rows),
+			Period:       period,
+			StatDate:     statDate,
+		})
 
-		if len(rankings) >= limit {
+		if limit > 0 && len(rankings) >= limit {
 			break
 		}
 	}
@@ -333,35 +466,23 @@ func (f *FundAPI) GetFundRanking(rankType string, limit int) ([]model.FundRankin
 // GetInstitutionHolding 获取机构持仓数据
 func (f *FundAPI) GetInstitutionHolding(code string) (*model.InstitutionHolding, error) {
 	url := fmt.Sprintf("https://fundf10.eastmoney.com/FundArchivesDatas.aspx?type=jgcc&code=%s", code)
-
-	resp, err := client.R().
-		SetHeader("Referer", "https://fundf10.eastmoney.com/").
-		Get(url)
+	body, err := fetch(url, "https://fundf10.eastmoney.com/")
 	if err != nil {
 		return nil, err
 	}
 
-	body := string(resp.Body())
-
-	holding := &model.InstitutionHolding{
-		FundCode:   code,
-		ReportDate: time.Now(),
-	}
-
-	// 解析机构持仓比例
-	reInst := regexp.MustCompile(`机构持有比例[^>]*>([0-9.]+)%`)
-	if match := reInst.FindStringSubmatch(body); len(match) >= 2 {
-		holding.InstitutionRatio, _ = strconv.ParseFloat(match[1], 64)
+	holding, err := ParseInstitutionHolding(code, body)
+	parseStats.record(EndpointInstitution, err, nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析持有人结构: %s: %w", code, err)
 	}
-
-	// 解析个人持仓比例
-	rePerson := regexp.MustCompile(`个人持有比例[^>]*>([0-9.]+)%`)
-	if match := rePerson.FindStringSubmatch(body); len(match) >= 2 {
-		holding.PersonalRatio, _ = strconv.ParseFloat(match[1], 64)
+	if err := validateInstitutionHolding(holding); err != nil {
+		parseStats.reject(EndpointInstitution, err)
+		return nil, err
 	}
 
 	// 获取基金名称
-	fund, _ := repository.GetFund(code)
+	fund, _ := f.funds.GetFund(code)
 	if fund != nil {
 		holding.FundName = fund.Name
 	}
@@ -376,13 +497,7 @@ func (f *FundAPI) RefreshFundRanking(rankType string, limit int) error {
 		return err
 	}
 
-	for _, ranking := range rankings {
-		if err := repository.SaveFundRanking(&ranking); err != nil {
-			continue
-		}
-	}
-
-	return nil
+	return f.rankings.SaveFundRankings(rankings)
 }
 
 func getString(m map[string]interface{}, key string) string {
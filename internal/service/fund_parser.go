@@ -0,0 +1,859 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"jijin/internal/model"
+)
+
+// 数据接口，解析统计按接口分别计数
+const (
+	EndpointFundList      = "fundlist"      // 基金列表 fundcode_search.js
+	EndpointEstimate      = "estimate"      // 实时估值 fundgz JSONP
+	EndpointNavHistory    = "navhistory"    // 历史净值 F10 lsjz 表格
+	EndpointRanking       = "ranking"       // 基金排行 rankData
+	EndpointProfile       = "profile"       // 基金概况 F10 jbgk 页面
+	EndpointManager       = "manager"       // 基金经理 F10 jjjl 页面
+	EndpointInstitution   = "institution"   // 持有人结构 F10 jgcc 表格
+	EndpointStockHoldings = "stockholdings" // 股票持仓 F10 jjcc 表格
+	EndpointTrackingIndex = "trackingindex" // 跟踪标的 F10 jbgk 页面
+	EndpointKline         = "kline"         // 指数和股票日K线
+)
+
+// Endpoints 全部数据接口
+var Endpoints = []string{
+	EndpointFundList, EndpointEstimate, EndpointNavHistory, EndpointRanking,
+	EndpointProfile, EndpointManager, EndpointInstitution,
+	EndpointStockHoldings, EndpointTrackingIndex, EndpointKline,
+}
+
+// EndpointNames 数据接口名称
+var EndpointNames = map[string]string{
+	EndpointFundList:      "基金列表",
+	EndpointEstimate:      "实时估值",
+	EndpointNavHistory:    "历史净值",
+	EndpointRanking:       "基金排行",
+	EndpointProfile:       "基金概况",
+	EndpointManager:       "基金经理",
+	EndpointInstitution:   "持有人结构",
+	EndpointStockHoldings: "股票持仓",
+	EndpointTrackingIndex: "跟踪标的",
+	EndpointKline:         "日K线",
+}
+
+// maxBondDailyMove 债券基金单日涨跌幅上限(%)，超过视为数据异常
+const maxBondDailyMove = 20.0
+
+// ErrNoData 接口正常返回但没有数据(如新发基金、暂无估值)
+var ErrNoData = errors.New("接口无数据")
+
+// ParseError 响应结构与预期不符，通常是接口格式发生了变化
+type ParseError struct {
+	Endpoint string
+	Field    string // 出错的字段或列
+	Value    string // 原始内容
+	Reason   string
+}
+
+func (e *ParseError) Error() string {
+	msg := EndpointNames[e.Endpoint] + "数据解析失败"
+	if e.Field != "" {
+		msg += ": " + e.Field
+	}
+	if e.Value != "" {
+		value := []rune(e.Value)
+		if len(value) > 40 {
+			value = append(value[:40], '…')
+		}
+		msg += fmt.Sprintf("(%q)", string(value))
+	}
+	if e.Reason != "" {
+		msg += " " + e.Reason
+	}
+	return msg
+}
+
+// ValidationError 数值不合理，拒绝保存
+type ValidationError struct {
+	Endpoint string
+	FundCode string
+	Field    string
+	Value    float64
+	Reason   string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s数据异常: %s %s=%g %s", EndpointNames[e.Endpoint], e.FundCode, e.Field, e.Value, e.Reason)
+}
+
+// IsBondFund 根据基金类型判断是否为债券基金
+func IsBondFund(fundType string) bool {
+	return strings.Contains(fundType, "债券") || strings.Contains(fundType, "固收")
+}
+
+// checkNav 净值必须为正数
+func checkNav(endpoint, code, field string, nav float64) error {
+	if nav <= 0 {
+		return &ValidationError{Endpoint: endpoint, FundCode: code, Field: field, Value: nav, Reason: "净值必须大于0"}
+	}
+	return nil
+}
+
+// checkDailyMove 债券基金单日涨跌幅超过上限视为数据异常
+func checkDailyMove(endpoint, code, fundType, field string, growth float64) error {
+	if IsBondFund(fundType) && math.Abs(growth) > maxBondDailyMove {
+		return &ValidationError{Endpoint: endpoint, FundCode: code, Field: field, Value: growth,
+			Reason: fmt.Sprintf("债券基金单日涨跌幅超过%.0f%%", maxBondDailyMove)}
+	}
+	return nil
+}
+
+// parseField 解析必填数值字段，允许百分号
+func parseField(endpoint, field, s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if s == "" {
+		return 0, &ParseError{Endpoint: endpoint, Field: field, Reason: "为空"}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, &ParseError{Endpoint: endpoint, Field: field, Value: s, Reason: "不是数值"}
+	}
+	return v, nil
+}
+
+// parseOptionalField 解析可缺省的数值字段，为空或"--"时ok为false
+func parseOptionalField(endpoint, field, s string) (v float64, ok bool, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Trim(s, "-") == "" {
+		return 0, false, nil
+	}
+	v, err = parseField(endpoint, field, s)
+	return v, err == nil, err
+}
+
+// parseDateField 解析日期字段，loc为nil时按UTC(与已保存的净值日期一致)
+func parseDateField(endpoint, field, layout, s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	s = strings.TrimSpace(s)
+	t, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		return time.Time{}, &ParseError{Endpoint: endpoint, Field: field, Value: s, Reason: "日期格式错误"}
+	}
+	return t, nil
+}
+
+var reFundCode = regexp.MustCompile(`^\d{6}$`)
+
+// FundList 基金列表接口解析结果
+type FundList struct {
+	Funds     []model.FundSearchResult
+	RowErrors []error // 无法解析的条目
+}
+
+// ParseFundList 解析基金列表
+// var r = [["000001","HXCZHH","华夏成长混合","混合型-灵活","HUAXIACHENGZHANGHUNHE"],...];
+func ParseFundList(body string) (*FundList, error) {
+	start, end := strings.Index(body, "["), strings.LastIndex(body, "]")
+	if start < 0 || end < start {
+		return nil, &ParseError{Endpoint: EndpointFundList, Value: body, Reason: "缺少基金数组"}
+	}
+	var raw [][]string
+	if err := json.Unmarshal([]byte(body[start:end+1]), &raw); err != nil {
+		return nil, &ParseError{Endpoint: EndpointFundList, Reason: err.Error()}
+	}
+
+	list := &FundList{Funds: make([]model.FundSearchResult, 0, len(raw))}
+	for _, r := range raw {
+		if len(r) < 5 || !reFundCode.MatchString(r[0]) || r[2] == "" {
+			list.RowErrors = append(list.RowErrors, &ParseError{Endpoint: EndpointFundList, Field: "基金条目",
+				Value: strings.Join(r, ","), Reason: "应为[代码,简拼,名称,类型,拼音]"})
+			continue
+		}
+		list.Funds = append(list.Funds, model.FundSearchResult{
+			Code:       r[0],
+			PinyinAbbr: r[1],
+			Name:       r[2],
+			Type:       r[3],
+			Pinyin:     r[4],
+		})
+	}
+	if len(list.Funds) == 0 {
+		return nil, &ParseError{Endpoint: EndpointFundList, Reason: "没有有效的基金条目"}
+	}
+	return list, nil
+}
+
+// FundEstimate 实时估值接口解析结果
+type FundEstimate struct {
+	Code      string
+	Name      string
+	NavDate   time.Time // 单位净值日期
+	NetValue  float64   // 单位净值
+	EstValue  float64   // 估算净值，暂无估值时为0
+	EstGrowth float64   // 估算涨跌幅(%)
+	EstTime   time.Time
+}
+
+var reJSONP = regexp.MustCompile(`(?s)^\s*[\w$.]+\((.*)\)\s*;?\s*$`)
+
+// ParseFundEstimate 解析实时估值
+// jsonpgz({"fundcode":"000001","name":"华夏成长混合","jzrq":"2024-01-12","dwjz":"1.2345","gsz":"1.2400","gszzl":"0.45","gztime":"2024-01-15 15:00"});
+func ParseFundEstimate(body string) (*FundEstimate, error) {
+	match := reJSONP.FindStringSubmatch(body)
+	if match == nil {
+		return nil, &ParseError{Endpoint: EndpointEstimate, Value: body, Reason: "不是JSONP格式"}
+	}
+	payload := strings.TrimSpace(match[1])
+	if payload == "" {
+		return nil, ErrNoData
+	}
+
+	var raw struct {
+		FundCode  string `json:"fundcode"`
+		Name      string `json:"name"`
+		NavDate   string `json:"jzrq"`
+		NetValue  string `json:"dwjz"`
+		EstValue  string `json:"gsz"`
+		EstGrowth string `json:"gszzl"`
+		EstTime   string `json:"gztime"`
+	}
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+		return nil, &ParseError{Endpoint: EndpointEstimate, Value: payload, Reason: err.Error()}
+	}
+	if !reFundCode.MatchString(raw.FundCode) {
+		return nil, &ParseError{Endpoint: EndpointEstimate, Field: "fundcode", Value: raw.FundCode, Reason: "基金代码无效"}
+	}
+
+	est := &FundEstimate{Code: raw.FundCode, Name: raw.Name}
+	var err error
+	if est.NetValue, err = parseField(EndpointEstimate, "dwjz", raw.NetValue); err != nil {
+		return nil, err
+	}
+	if est.NavDate, err = parseDateField(EndpointEstimate, "jzrq", "2006-01-02", raw.NavDate, nil); err != nil {
+		return nil, err
+	}
+
+	value, ok, err := parseOptionalField(EndpointEstimate, "gsz", raw.EstValue)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return est, nil
+	}
+	est.EstValue = value
+	if est.EstGrowth, err = parseField(EndpointEstimate, "gszzl", raw.EstGrowth); err != nil {
+		return nil, err
+	}
+	if est.EstTime, err = parseDateField(EndpointEstimate, "gztime", "2006-01-02 15:04", raw.EstTime, time.Local); err != nil {
+		return nil, err
+	}
+	return est, nil
+}
+
+var (
+	reTableRow  = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	reTableHead = regexp.MustCompile(`(?is)<th[^>]*>(.*?)</th>`)
+	reTableCell = regexp.MustCompile(`(?is)<td[^>]*>(.*?)</td>`)
+	reF10Field  = regexp.MustCompile(`(?is)<th[^>]*>(.*?)</th>\s*<td[^>]*>(.*?)</td>`)
+	reHTMLTag   = regexp.MustCompile(`<[^>]+>`)
+)
+
+// cellText 单元格文本，去除标签和实体
+func cellText(s string) string {
+	s = html.UnescapeString(reHTMLTag.ReplaceAllString(s, ""))
+	return strings.TrimSpace(strings.ReplaceAll(s, "\u00a0", " "))
+}
+
+// parseHTMLTable 解析HTML表格，返回第一行表头和全部数据行
+func parseHTMLTable(body string) (headers []string, rows [][]string) {
+	for _, tr := range reTableRow.FindAllStringSubmatch(body, -1) {
+		if headers == nil {
+			if ths := reTableHead.FindAllStringSubmatch(tr[1], -1); len(ths) > 0 {
+				for _, th := range ths {
+					headers = append(headers, cellText(th[1]))
+				}
+				continue
+			}
+		}
+		tds := reTableCell.FindAllStringSubmatch(tr[1], -1)
+		if len(tds) == 0 {
+			continue
+		}
+		cells := make([]string, len(tds))
+		for i, td := range tds {
+			cells[i] = cellText(td[1])
+		}
+		rows = append(rows, cells)
+	}
+	return headers, rows
+}
+
+// headerIndex 表头中以任一名称开头的列，没有时为-1
+func headerIndex(headers []string, names ...string) int {
+	for i, h := range headers {
+		for _, name := range names {
+			if strings.HasPrefix(h, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// NavTable 历史净值接口解析结果
+type NavTable struct {
+	Records   int  // 接口返回的总记录数
+	Yield     bool // 货币基金表格，净值列为每万份收益
+	Rows      []model.NetValueHistory
+	RowErrors []error // 无法解析的行
+}
+
+var reAPIRecords = regexp.MustCompile(`records:\s*(\d+)`)
+
+// ParseNavTable 解析历史净值，按表头定位列
+// var apidata={ content:"<table><thead><tr><th>净值日期</th><th>单位净值</th><th>累计净值</th><th>日增长率</th>...",records:1234,pages:1234,curpage:1};
+func ParseNavTable(code, body string) (*NavTable, error) {
+	m := reAPIRecords.FindStringSubmatch(body)
+	if m == nil {
+		return nil, &ParseError{Endpoint: EndpointNavHistory, Field: "records", Value: body, Reason: "缺少字段"}
+	}
+	table := &NavTable{}
+	table.Records, _ = strconv.Atoi(m[1])
+	if table.Records == 0 {
+		return table, nil
+	}
+
+	headers, rows := parseHTMLTable(body)
+	dateCol := headerIndex(headers, "净值日期")
+	navCol := headerIndex(headers, "单位净值")
+	totalCol := headerIndex(headers, "累计净值")
+	growthCol := headerIndex(headers, "日增长率")
+	if navCol < 0 {
+		if navCol = headerIndex(headers, "每万份收益"); navCol >= 0 {
+			table.Yield = true
+			totalCol = headerIndex(headers, "7日年化")
+		}
+	}
+	if dateCol < 0 || navCol < 0 {
+		return nil, &ParseError{Endpoint: EndpointNavHistory, Field: "表头", Value: strings.Join(headers, ","), Reason: "缺少净值日期或单位净值列"}
+	}
+	need := max(dateCol, navCol, totalCol, growthCol) + 1
+
+	for _, cells := range rows {
+		if len(cells) == 1 {
+			continue // 暂无数据
+		}
+		if len(cells) < need {
+			table.RowErrors = append(table.RowErrors, &ParseError{Endpoint: EndpointNavHistory, Field: "列数",
+				Value: strings.Join(cells, ","), Reason: fmt.Sprintf("少于%d列", need)})
+			continue
+		}
+		row, err := parseNavRow(code, cells, dateCol, navCol, totalCol, growthCol)
+		if err != nil {
+			table.RowErrors = append(table.RowErrors, err)
+			continue
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	if len(table.Rows) == 0 {
+		if len(table.RowErrors) > 0 {
+			return nil, table.RowErrors[0]
+		}
+		return nil, &ParseError{Endpoint: EndpointNavHistory, Reason: fmt.Sprintf("记录数为%d但表格没有数据行", table.Records)}
+	}
+	return table, nil
+}
+
+// parseNavRow 解析历史净值的一行，累计净值和日增长率可为空
+func parseNavRow(code string, cells []string, dateCol, navCol, totalCol, growthCol int) (model.NetValueHistory, error) {
+	row := model.NetValueHistory{FundCode: code}
+	var err error
+	if row.Date, err = parseDateField(EndpointNavHistory, "净值日期", "2006-01-02", cells[dateCol], nil); err != nil {
+		return row, err
+	}
+	if row.NetValue, err = parseField(EndpointNavHistory, "单位净值", cells[navCol]); err != nil {
+		return row, err
+	}
+	if totalCol >= 0 {
+		if row.TotalValue, _, err = parseOptionalField(EndpointNavHistory, "累计净值", cells[totalCol]); err != nil {
+			return row, err
+		}
+	}
+	if growthCol >= 0 {
+		if row.DayGrowth, _, err = parseOptionalField(EndpointNavHistory, "日增长率", cells[growthCol]); err != nil {
+			return row, err
+		}
+	}
+	return row, nil
+}
+
+// validateNavRows 拒绝净值为0、日期在未来和债券基金单日涨跌幅异常的行
+func validateNavRows(table *NavTable, code, fundType string) (valid []model.NetValueHistory, rejected []error) {
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	for _, row := range table.Rows {
+		var err error
+		switch {
+		case row.Date.After(tomorrow):
+			err = &ValidationError{Endpoint: EndpointNavHistory, FundCode: code, Field: row.Date.Format("2006-01-02"), Reason: "净值日期在未来"}
+		case !table.Yield:
+			if err = checkNav(EndpointNavHistory, code, "单位净值", row.NetValue); err == nil {
+				err = checkDailyMove(EndpointNavHistory, code, fundType, "日增长率", row.DayGrowth)
+			}
+		}
+		if err != nil {
+			rejected = append(rejected, err)
+			continue
+		}
+		valid = append(valid, row)
+	}
+	return valid, rejected
+}
+
+// RankRow 排行接口中的一只基金
+type RankRow struct {
+	Code     string
+	Name     string
+	Date     time.Time
+	NetValue float64            // 单位净值，缺失时为0
+	Returns  map[string]float64 // 各周期涨幅(%)，该周期无数据(如成立不足一年)时不含
+}
+
+// RankData 排行接口解析结果
+type RankData struct {
+	AllRecords int
+	Rows       []RankRow
+	RowErrors  []error // 无法解析的行
+}
+
+// rankMinColumns 每行至少包含到"成立来"一列
+const rankMinColumns = 16
+
+var (
+	reRankDatas = regexp.MustCompile(`(?s)datas:\s*\[(.*?)\]`)
+	reRankAll   = regexp.MustCompile(`allRecords:\s*(\d+)`)
+	reRankErr   = regexp.MustCompile(`ErrCode:\s*(-?\d+)`)
+	reRankItem  = regexp.MustCompile(`"([^"]*)"`)
+)
+
+// ParseRankData 解析基金排行
+// var rankData = {datas:["000001,华夏成长混合,HXCZHH,2024-01-15,1.2345,1.5678,1.23,...",...],allRecords:12345,...}
+func ParseRankData(body string) (*RankData, error) {
+	m := reRankDatas.FindStringSubmatch(body)
+	if m == nil {
+		reason := "缺少字段"
+		if e := reRankErr.FindStringSubmatch(body); e != nil {
+			reason = "接口返回错误码" + e[1]
+		}
+		return nil, &ParseError{Endpoint: EndpointRanking, Field: "datas", Value: body, Reason: reason}
+	}
+
+	data := &RankData{}
+	if all := reRankAll.FindStringSubmatch(body); all != nil {
+		data.AllRecords, _ = strconv.Atoi(all[1])
+	}
+	for _, item := range reRankItem.FindAllStringSubmatch(m[1], -1) {
+		row, err := parseRankRow(item[1])
+		if err != nil {
+			data.RowErrors = append(data.RowErrors, err)
+			continue
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	if len(data.Rows) == 0 && len(data.RowErrors) > 0 {
+		return nil, data.RowErrors[0]
+	}
+	return data, nil
+}
+
+// parseRankRow 数据格式: 代码,名称,简拼,日期,单位净值,累计净值,日增长率,近1周,近1月,近3月,近6月,近1年,近2年,近3年,今年来,成立来,...
+func parseRankRow(line string) (RankRow, error) {
+	parts := strings.Split(line, ",")
+	if len(parts) < rankMinColumns {
+		return RankRow{}, &ParseError{Endpoint: EndpointRanking, Field: "列数", Value: line, Reason: fmt.Sprintf("少于%d列", rankMinColumns)}
+	}
+	if !reFundCode.MatchString(parts[0]) {
+		return RankRow{}, &ParseError{Endpoint: EndpointRanking, Field: "基金代码", Value: parts[0], Reason: "基金代码无效"}
+	}
+
+	row := RankRow{Code: parts[0], Name: parts[1], Returns: make(map[string]float64)}
+	var err error
+	if strings.TrimSpace(parts[3]) != "" {
+		if row.Date, err = parseDateField(EndpointRanking, "日期", "2006-01-02", parts[3], nil); err != nil {
+			return row, err
+		}
+	}
+	if row.NetValue, _, err = parseOptionalField(EndpointRanking, "单位净值", parts[4]); err != nil {
+		return row, err
+	}
+	for period, col := range rankPeriodColumns {
+		value, ok, err := parseOptionalField(EndpointRanking, RankPeriodNames[period], parts[col.column])
+		if err != nil {
+			return row, err
+		}
+		if ok {
+			row.Returns[period] = value
+		}
+	}
+	return row, nil
+}
+
+var (
+	reProfileDate  = regexp.MustCompile(`(\d{4})年(\d{2})月(\d{2})日`)
+	reProfileScale = regexp.MustCompile(`([0-9.]+)亿元`)
+	reProfileFee   = regexp.MustCompile(`([0-9.]+)%`)
+)
+
+// parseF10Fields 解析F10页面中 <th>名称</th><td>值</td> 形式的字段
+func parseF10Fields(body string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range reF10Field.FindAllStringSubmatch(body, -1) {
+		if label := cellText(m[1]); label != "" {
+			if _, ok := fields[label]; !ok {
+				fields[label] = cellText(m[2])
+			}
+		}
+	}
+	return fields
+}
+
+// f10Field 按名称前缀查找字段，如"成立日期"匹配"成立日期/规模"
+func f10Field(fields map[string]string, label string) (string, bool) {
+	if v, ok := fields[label]; ok {
+		return v, true
+	}
+	for k, v := range fields {
+		if strings.HasPrefix(k, label) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// ParseFundProfile 解析基金概况页
+// <th>基金管理人</th><td><a href="...">华夏基金</a></td>
+// <th>资产规模</th><td>44.34亿元（截止至：2024年03月31日）</td>
+// <th>成立日期/规模</th><td>2001年12月18日 / 32.368亿份</td>
+// <th>管理费率</th><td>1.20%（每年）</td>
+func ParseFundProfile(code, body string) (*model.FundProfile, error) {
+	fields := parseF10Fields(body)
+	company, hasCompany := f10Field(fields, "基金管理人")
+	established, hasDate := f10Field(fields, "成立日期")
+	if !hasCompany && !hasDate {
+		return nil, &ParseError{Endpoint: EndpointProfile, Field: "基金管理人/成立日期", Reason: "页面缺少字段"}
+	}
+
+	profile := &model.FundProfile{
+		FundCode:  code,
+		Company:   company,
+		UpdatedAt: time.Now(),
+	}
+
+	// 未成立或已清盘的基金日期、规模可能为"---"
+	if m := reProfileDate.FindStringSubmatch(established); m != nil {
+		date, err := time.Parse("2006-01-02", m[1]+"-"+m[2]+"-"+m[3])
+		if err != nil {
+			return nil, &ParseError{Endpoint: EndpointProfile, Field: "成立日期", Value: established, Reason: "日期格式错误"}
+		}
+		profile.EstablishDate = date
+	}
+	if scale, ok := f10Field(fields, "资产规模"); ok {
+		if m := reProfileScale.FindStringSubmatch(scale); m != nil {
+			v, err := parseField(EndpointProfile, "资产规模", m[1])
+			if err != nil {
+				return nil, err
+			}
+			profile.Scale = v
+		}
+	}
+	if fee, ok := f10Field(fields, "管理费率"); ok {
+		if m := reProfileFee.FindStringSubmatch(fee); m != nil {
+			v, err := parseField(EndpointProfile, "管理费率", m[1])
+			if err != nil {
+				return nil, err
+			}
+			profile.FeeRate = v
+		}
+	}
+
+	if profile.Company == "" && profile.EstablishDate.IsZero() {
+		return nil, &ParseError{Endpoint: EndpointProfile, Field: "基金管理人/成立日期", Reason: "字段为空"}
+	}
+	return profile, nil
+}
+
+// ParseCurrentManager 解析基金经理页中任职至今的经理
+// <td>2012-09-28</td><td>至今</td><td><a href="...">张坤</a></td><td>11年又185天</td>
+func ParseCurrentManager(body string) (string, time.Time, error) {
+	_, rows := parseHTMLTable(body)
+	if len(rows) == 0 {
+		return "", time.Time{}, &ParseError{Endpoint: EndpointManager, Reason: "页面缺少任职表格"}
+	}
+	for _, cells := range rows {
+		if len(cells) < 3 || cells[1] != "至今" {
+			continue
+		}
+		since, err := parseDateField(EndpointManager, "起始期", "2006-01-02", cells[0], nil)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if cells[2] == "" {
+			return "", time.Time{}, &ParseError{Endpoint: EndpointManager, Field: "基金经理", Reason: "为空"}
+		}
+		return cells[2], since, nil
+	}
+	return "", time.Time{}, &ParseError{Endpoint: EndpointManager, Reason: "没有任职至今的基金经理"}
+}
+
+// ParseInstitutionHolding 解析持有人结构表格的最新一期
+// <th>公告日期</th><th>机构持有比例</th><th>个人持有比例</th><th>内部持有比例</th><th>总份额（亿份）</th>
+func ParseInstitutionHolding(code, body string) (*model.InstitutionHolding, error) {
+	headers, rows := parseHTMLTable(body)
+	instCol := headerIndex(headers, "机构持有比例")
+	personCol := headerIndex(headers, "个人持有比例")
+	if instCol < 0 || personCol < 0 {
+		return nil, &ParseError{Endpoint: EndpointInstitution, Field: "表头", Value: strings.Join(headers, ","), Reason: "缺少机构/个人持有比例列"}
+	}
+	if len(rows) == 0 || len(rows[0]) == 1 {
+		return nil, ErrNoData
+	}
+	latest := rows[0]
+	if len(latest) <= max(instCol, personCol) {
+		return nil, &ParseError{Endpoint: EndpointInstitution, Field: "列数", Value: strings.Join(latest, ","), Reason: "与表头不一致"}
+	}
+
+	holding := &model.InstitutionHolding{FundCode: code, ReportDate: time.Now()}
+	var err error
+	if holding.InstitutionRatio, err = parseField(EndpointInstitution, "机构持有比例", latest[instCol]); err != nil {
+		return nil, err
+	}
+	if holding.PersonalRatio, err = parseField(EndpointInstitution, "个人持有比例", latest[personCol]); err != nil {
+		return nil, err
+	}
+	return holding, nil
+}
+
+// validateInstitutionHolding 持有比例须在0~100%之间且合计不超过100%(允许舍入误差)
+func validateInstitutionHolding(h *model.InstitutionHolding) error {
+	for _, f := range []struct {
+		field string
+		value float64
+	}{{"机构持有比例", h.InstitutionRatio}, {"个人持有比例", h.PersonalRatio}} {
+		if f.value < 0 || f.value > 100 {
+			return &ValidationError{Endpoint: EndpointInstitution, FundCode: h.FundCode, Field: f.field, Value: f.value, Reason: "超出0~100%"}
+		}
+	}
+	if sum := h.InstitutionRatio + h.PersonalRatio; sum > 100.5 {
+		return &ValidationError{Endpoint: EndpointInstitution, FundCode: h.FundCode, Field: "持有比例合计", Value: sum, Reason: "超过100%"}
+	}
+	return nil
+}
+
+// StockHoldings 股票持仓接口解析结果(最新一期)
+type StockHoldings struct {
+	ReportDate time.Time
+	Components []EstimateComponent
+	RowErrors  []error // 无法解析的行
+}
+
+var (
+	reHoldingDate = regexp.MustCompile(`截止至：\s*<font[^>]*>([^<]*)</font>`)
+	reCellLink    = regexp.MustCompile(`href=['"]([^'"]*)['"]`)
+	reUnifySecID  = regexp.MustCompile(`unify/r/(\d+\.\w+)`)
+)
+
+// ParseStockHoldings 解析股票持仓，只取第一张表(最新一期)，按表头定位列
+// var apidata={ content:"<div class='box'>...截止至：<font class='px12'>2024-03-31</font>...<table><thead><tr><th>序号</th><th>股票代码</th><th>股票名称</th>...<th>占净值比例</th>...",arryear:[2024,2023],curyear:2024};
+func ParseStockHoldings(body string) (*StockHoldings, error) {
+	start := strings.Index(body, "<table")
+	if start < 0 {
+		if !strings.Contains(body, "content:") {
+			return nil, &ParseError{Endpoint: EndpointStockHoldings, Field: "content", Value: body, Reason: "缺少字段"}
+		}
+		return nil, ErrNoData // 未披露股票持仓(如债券基金、FOF)
+	}
+	end := strings.Index(body[start:], "</table>")
+	if end < 0 {
+		end = len(body) - start
+	}
+	table := body[start : start+end]
+
+	m := reHoldingDate.FindStringSubmatch(body[:start])
+	if m == nil {
+		return nil, &ParseError{Endpoint: EndpointStockHoldings, Field: "截止日期", Reason: "缺少字段"}
+	}
+	reportDate, err := parseDateField(EndpointStockHoldings, "截止日期", "2006-01-02", m[1], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, _ := parseHTMLTable(table)
+	codeCol := headerIndex(headers, "股票代码")
+	nameCol := headerIndex(headers, "股票名称")
+	weightCol := headerIndex(headers, "占净值比例")
+	if codeCol < 0 || nameCol < 0 || weightCol < 0 {
+		return nil, &ParseError{Endpoint: EndpointStockHoldings, Field: "表头", Value: strings.Join(headers, ","), Reason: "缺少股票代码、股票名称或占净值比例列"}
+	}
+	need := max(codeCol, nameCol, weightCol) + 1
+
+	holdings := &StockHoldings{ReportDate: reportDate}
+	for _, tr := range reTableRow.FindAllStringSubmatch(table, -1) {
+		tds := reTableCell.FindAllStringSubmatch(tr[1], -1)
+		if len(tds) == 0 {
+			continue // 表头
+		}
+		if len(tds) < need {
+			holdings.RowErrors = append(holdings.RowErrors, &ParseError{Endpoint: EndpointStockHoldings, Field: "列数",
+				Value: cellText(tr[1]), Reason: fmt.Sprintf("少于%d列", need)})
+			continue
+		}
+		c, err := parseStockHoldingRow(tds[codeCol][1], cellText(tds[nameCol][1]), cellText(tds[weightCol][1]))
+		if err != nil {
+			holdings.RowErrors = append(holdings.RowErrors, err)
+			continue
+		}
+		holdings.Components = append(holdings.Components, c)
+	}
+
+	if len(holdings.Components) == 0 {
+		if len(holdings.RowErrors) > 0 {
+			return nil, holdings.RowErrors[0]
+		}
+		return nil, ErrNoData
+	}
+	return holdings, nil
+}
+
+// parseStockHoldingRow 解析持仓的一行，行情代码优先取自股票代码列的行情链接
+func parseStockHoldingRow(codeCell, name, weight string) (EstimateComponent, error) {
+	c := EstimateComponent{Code: cellText(codeCell), Name: name}
+	var link string
+	if m := reCellLink.FindStringSubmatch(codeCell); m != nil {
+		link = m[1]
+	}
+	if c.SecID = stockSecID(link, c.Code); c.SecID == "" {
+		return c, &ParseError{Endpoint: EndpointStockHoldings, Field: "股票代码", Value: c.Code, Reason: "无法识别行情代码"}
+	}
+	var err error
+	if c.Weight, err = parseField(EndpointStockHoldings, "占净值比例", weight); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// stockSecID 根据行情链接或股票代码推断行情代码
+func stockSecID(link, code string) string {
+	if match := reUnifySecID.FindStringSubmatch(link); len(match) > 1 {
+		return match[1]
+	}
+
+	switch {
+	case strings.Contains(link, "/hk/") || len(code) == 5:
+		return "116." + code
+	case len(code) != 6:
+		return ""
+	case code[0] == '6' || code[0] == '9':
+		return "1." + code
+	default:
+		return "0." + code // 深市及北交所
+	}
+}
+
+// validateStockHoldings 占净值比例须在0~100%之间
+func validateStockHoldings(h *StockHoldings, code string) (valid []EstimateComponent, rejected []error) {
+	for _, c := range h.Components {
+		if c.Weight <= 0 || c.Weight > 100 {
+			rejected = append(rejected, &ValidationError{Endpoint: EndpointStockHoldings, FundCode: code,
+				Field: c.Code + "占净值比例", Value: c.Weight, Reason: "超出0~100%"})
+			continue
+		}
+		valid = append(valid, c)
+	}
+	return valid, rejected
+}
+
+// ParseTrackingIndex 解析基金概况页中的跟踪标的，非指数基金返回空
+// <th>跟踪标的</th><td>沪深300指数</td>
+func ParseTrackingIndex(body string) (string, error) {
+	fields := parseF10Fields(body)
+	name, ok := f10Field(fields, "跟踪标的")
+	if !ok {
+		if _, isProfile := f10Field(fields, "基金管理人"); isProfile {
+			return "", nil
+		}
+		return "", &ParseError{Endpoint: EndpointTrackingIndex, Field: "跟踪标的", Reason: "页面缺少字段"}
+	}
+	if name == "" || strings.Contains(name, "无跟踪标的") || strings.Trim(name, "-") == "" {
+		return "", nil
+	}
+	return name, nil
+}
+
+// Klines 日K线接口解析结果，按日期升序
+type Klines struct {
+	Bars      []DailyBar
+	RowErrors []error // 无法解析的行
+}
+
+// ParseKlines 解析日K线，收盘价或涨跌幅无法解析的行跳过而不按0计入
+// {"data":{"code":"000300","klines":["2024-01-15,3456.78,1.23",...]}}
+func ParseKlines(body string) (*Klines, error) {
+	var result struct {
+		Data *struct {
+			Klines []string `json:"klines"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		return nil, &ParseError{Endpoint: EndpointKline, Value: body, Reason: err.Error()}
+	}
+	if result.Data == nil || len(result.Data.Klines) == 0 {
+		return nil, ErrNoData // 行情代码无效或无交易数据
+	}
+
+	klines := &Klines{Bars: make([]DailyBar, 0, len(result.Data.Klines))}
+	for _, line := range result.Data.Klines {
+		bar, err := parseKlineRow(line)
+		if err != nil {
+			klines.RowErrors = append(klines.RowErrors, err)
+			continue
+		}
+		klines.Bars = append(klines.Bars, bar)
+	}
+	if len(klines.Bars) == 0 {
+		return nil, klines.RowErrors[0]
+	}
+	return klines, nil
+}
+
+// parseKlineRow 数据格式: 日期,收盘,涨跌幅
+func parseKlineRow(line string) (DailyBar, error) {
+	parts := strings.Split(line, ",")
+	if len(parts) < 3 {
+		return DailyBar{}, &ParseError{Endpoint: EndpointKline, Field: "列数", Value: line, Reason: "少于3列"}
+	}
+	bar := DailyBar{}
+	var err error
+	if bar.Date, err = parseDateField(EndpointKline, "日期", "2006-01-02", parts[0], nil); err != nil {
+		return bar, err
+	}
+	if bar.Close, err = parseField(EndpointKline, "收盘", parts[1]); err != nil {
+		return bar, err
+	}
+	if bar.ChangePct, err = parseField(EndpointKline, "涨跌幅", parts[2]); err != nil {
+		return bar, err
+	}
+	if bar.Close <= 0 {
+		return bar, &ParseError{Endpoint: EndpointKline, Field: "收盘", Value: parts[1], Reason: "必须大于0"}
+	}
+	return bar, nil
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fixture 读取testdata中按接口实际格式保存的响应
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func mustDate(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+// wantParseError 期望解析失败并返回ParseError
+func wantParseError(t *testing.T, err error) {
+	t.Helper()
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("want *ParseError, got %v", err)
+	}
+}
+
+func TestParseFundEstimate(t *testing.T) {
+	est, err := ParseFundEstimate(fixture(t, "estimate.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Code != "000001" || est.Name != "华夏成长混合" {
+		t.Fatalf("got code=%q name=%q", est.Code, est.Name)
+	}
+	if est.NetValue != 1.2345 || est.EstValue != 1.24 || est.EstGrowth != 0.45 {
+		t.Fatalf("got nav=%v est=%v growth=%v", est.NetValue, est.EstValue, est.EstGrowth)
+	}
+	if !est.NavDate.Equal(mustDate("2024-01-12")) {
+		t.Fatalf("净值日期应按UTC解析，got %v", est.NavDate)
+	}
+	if est.EstTime.Location() != time.Local || est.EstTime.Hour() != 14 {
+		t.Fatalf("估值时间应按本地时间解析，got %v", est.EstTime)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		noData  bool
+		estNone bool
+	}{
+		{name: "暂无估值", body: `jsonpgz();`, noData: true},
+		{name: "估值为空", body: `jsonpgz({"fundcode":"000001","name":"x","jzrq":"2024-01-12","dwjz":"1.2345","gsz":"","gszzl":"","gztime":""});`, estNone: true},
+		{name: "不是JSONP", body: `<html>404 Not Found</html>`},
+		{name: "JSON损坏", body: `jsonpgz({"fundcode":"000001",);`},
+		{name: "基金代码无效", body: `jsonpgz({"fundcode":"1","dwjz":"1.0","jzrq":"2024-01-12"});`},
+		{name: "净值不是数值", body: `jsonpgz({"fundcode":"000001","jzrq":"2024-01-12","dwjz":"abc"});`},
+		{name: "净值为空", body: `jsonpgz({"fundcode":"000001","jzrq":"2024-01-12","dwjz":""});`},
+		{name: "日期格式变化", body: `jsonpgz({"fundcode":"000001","jzrq":"2024/01/12","dwjz":"1.0"});`},
+		{name: "涨跌幅缺失", body: `jsonpgz({"fundcode":"000001","jzrq":"2024-01-12","dwjz":"1.0","gsz":"1.01","gszzl":"","gztime":"2024-01-15 14:59"});`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est, err := ParseFundEstimate(tt.body)
+			switch {
+			case tt.noData:
+				if !errors.Is(err, ErrNoData) {
+					t.Fatalf("want ErrNoData, got %v", err)
+				}
+			case tt.estNone:
+				if err != nil || est.EstValue != 0 {
+					t.Fatalf("估值为空时应只返回单位净值，got %+v, %v", est, err)
+				}
+			default:
+				wantParseError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseNavTable(t *testing.T) {
+	table, err := ParseNavTable("000001", fixture(t, "navhistory.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Records != 3 || len(table.Rows) != 3 || len(table.RowErrors) != 0 {
+		t.Fatalf("got records=%d rows=%d rowErrors=%v", table.Records, len(table.Rows), table.RowErrors)
+	}
+	first, last := table.Rows[0], table.Rows[2]
+	if !first.Date.Equal(mustDate("2024-01-15")) || first.NetValue != 1.24 || first.TotalValue != 3.56 || first.DayGrowth != 0.45 {
+		t.Fatalf("first row: %+v", first)
+	}
+	if table.Rows[1].DayGrowth != -1.02 {
+		t.Fatalf("want -1.02, got %v", table.Rows[1].DayGrowth)
+	}
+	if last.DayGrowth != 0 || last.FundCode != "000001" {
+		t.Fatalf("日增长率为--时应为0，got %+v", last)
+	}
+
+	yield := `var apidata={ content:"<table><thead><tr><th>净值日期</th><th>每万份收益</th><th>7日年化收益率（%）</th></tr></thead><tbody><tr><td>2024-01-15</td><td>0.5123</td><td>1.8760%</td></tr></tbody></table>",records:1,pages:1,curpage:1};`
+	if table, err := ParseNavTable("000009", yield); err != nil || !table.Yield || table.Rows[0].TotalValue != 1.876 {
+		t.Fatalf("货币基金表格: %+v, %v", table, err)
+	}
+
+	empty := `var apidata={ content:"<table><thead><tr><th>净值日期</th><th>单位净值</th></tr></thead><tbody><tr><td colspan='7'>暂无数据!</td></tr></tbody></table>",records:0,pages:0,curpage:1};`
+	if table, err := ParseNavTable("000001", empty); err != nil || len(table.Rows) != 0 {
+		t.Fatalf("无记录时应返回空表格，got %+v, %v", table, err)
+	}
+
+	badRow := `var apidata={ content:"<table><thead><tr><th>净值日期</th><th>单位净值</th><th>累计净值</th><th>日增长率</th></tr></thead><tbody><tr><td>2024-01-15</td><td>1.2400</td><td>3.56</td><td>0.45%</td></tr><tr><td>2024-01-12</td><td>N/A</td><td>3.55</td><td>0.10%</td></tr><tr><td>2024-01-11</td><td>1.2</td></tr></tbody></table>",records:3,pages:1,curpage:1};`
+	table, err = ParseNavTable("000001", badRow)
+	if err != nil || len(table.Rows) != 1 || len(table.RowErrors) != 2 {
+		t.Fatalf("无法解析的行应跳过并计数，got %+v, %v", table, err)
+	}
+
+	for name, body := range map[string]string{
+		"缺少records": `<html>服务器繁忙</html>`,
+		"表头变化":      `var apidata={ content:"<table><thead><tr><th>日期</th><th>净值</th></tr></thead><tbody><tr><td>2024-01-15</td><td>1.24</td></tr></tbody></table>",records:1};`,
+		"全部行无效":     `var apidata={ content:"<table><thead><tr><th>净值日期</th><th>单位净值</th></tr></thead><tbody><tr><td>15/01/2024</td><td>1.24</td></tr></tbody></table>",records:1};`,
+		"有记录无数据行":   `var apidata={ content:"<table><thead><tr><th>净值日期</th><th>单位净值</th></tr></thead><tbody></tbody></table>",records:5};`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseNavTable("000001", body)
+			wantParseError(t, err)
+		})
+	}
+}
+
+func TestValidateNavRows(t *testing.T) {
+	body := `var apidata={ content:"<table><thead><tr><th>净值日期</th><th>单位净值</th><th>累计净值</th><th>日增长率</th></tr></thead><tbody>` +
+		`<tr><td>2099-01-01</td><td>1.10</td><td>1.10</td><td>0.10%</td></tr>` +
+		`<tr><td>2024-01-15</td><td>0.0000</td><td>1.10</td><td>0.10%</td></tr>` +
+		`<tr><td>2024-01-12</td><td>1.9000</td><td>1.90</td><td>72.73%</td></tr>` +
+		`<tr><td>2024-01-11</td><td>1.1000</td><td>1.10</td><td>-0.05%</td></tr>` +
+		`</tbody></table>",records:4};`
+	table, err := ParseNavTable("000002", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid, rejected := validateNavRows(table, "000002", "债券型-长债")
+	if len(valid) != 1 || len(rejected) != 3 {
+		t.Fatalf("债券基金: want 1 valid 3 rejected, got %d, %d", len(valid), len(rejected))
+	}
+	for _, err := range rejected {
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Fatalf("want *ValidationError, got %v", err)
+		}
+	}
+
+	// 股票基金不限制单日涨跌幅
+	if valid, _ := validateNavRows(table, "000002", "股票型"); len(valid) != 2 {
+		t.Fatalf("股票基金: want 2 valid, got %d", len(valid))
+	}
+}
+
+func TestCheckBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		fundType string
+		nav      float64
+		growth   float64
+		wantErr  bool
+	}{
+		{"正常净值", "股票型", 1.2345, 3.5, false},
+		{"净值为0", "股票型", 0, 0, true},
+		{"净值为负", "混合型-偏股", -1, 0, true},
+		{"股票基金大涨", "股票型", 1.5, 35, false},
+		{"债券基金上限内", "债券型-长债", 1.01, 19.9, false},
+		{"债券基金等于上限", "债券型-长债", 1.01, maxBondDailyMove, false},
+		{"债券基金大涨", "债券型-混合二级", 1.01, 20.5, true},
+		{"债券基金大跌", "固收+", 1.01, -45, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkNav(EndpointNavHistory, "000001", "单位净值", tt.nav)
+			if err == nil {
+				err = checkDailyMove(EndpointNavHistory, "000001", tt.fundType, "日增长率", tt.growth)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("wantErr=%v, got %v", tt.wantErr, err)
+			}
+			var ve *ValidationError
+			if err != nil && !errors.As(err, &ve) {
+				t.Fatalf("want *ValidationError, got %T", err)
+			}
+		})
+	}
+}
+
+func TestParseRankData(t *testing.T) {
+	data, err := ParseRankData(fixture(t, "rankdata.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.AllRecords != 12345 || len(data.Rows) != 2 || len(data.RowErrors) != 1 {
+		t.Fatalf("got all=%d rows=%d rowErrors=%d", data.AllRecords, len(data.Rows), len(data.RowErrors))
+	}
+
+	row := data.Rows[0]
+	if row.Code != "000001" || row.Name != "华夏成长混合" || row.NetValue != 1.24 || !row.Date.Equal(mustDate("2024-01-15")) {
+		t.Fatalf("first row: %+v", row)
+	}
+	want := map[string]float64{RankPeriodDay: 0.45, RankPeriodMonth: -2.35, RankPeriod1Y: -12.33, RankPeriodYTD: -3.10, RankPeriodSince: 356.20}
+	for period, v := range want {
+		if row.Returns[period] != v {
+			t.Fatalf("%s: want %v, got %v", period, v, row.Returns[period])
+		}
+	}
+	// 成立不足一年的基金没有近1年以上的涨幅
+	if _, ok := data.Rows[1].Returns[RankPeriod1Y]; ok {
+		t.Fatal("空白周期不应计入涨幅")
+	}
+
+	tests := map[string]string{
+		"接口返回错误码": `var rankData = {ErrCode:-999,Data:"无访问权限"};`,
+		"缺少datas": `<html></html>`,
+		"全部行无效":   `var rankData = {datas:["12345,坏代码,X,2024-01-15,1,1,1,1,1,1,1,1,1,1,1,1"],allRecords:1};`,
+		"涨幅不是数值":  `var rankData = {datas:["000001,x,X,2024-01-15,1.0,1.0,abc,1,1,1,1,1,1,1,1,1"],allRecords:1};`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRankData(body)
+			wantParseError(t, err)
+		})
+	}
+
+	if data, err := ParseRankData(`var rankData = {datas:[],allRecords:0};`); err != nil || len(data.Rows) != 0 {
+		t.Fatalf("空排行不是错误，got %+v, %v", data, err)
+	}
+}
+
+func TestParseStockHoldings(t *testing.T) {
+	h, err := ParseStockHoldings(fixture(t, "stockholdings.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.ReportDate.Equal(mustDate("2024-03-31")) {
+		t.Fatalf("应取最新一期的截止日期，got %v", h.ReportDate)
+	}
+	// 只取第一张表，占净值比例为---的行跳过
+	if len(h.Components) != 2 || len(h.RowErrors) != 1 {
+		t.Fatalf("got components=%+v rowErrors=%v", h.Components, h.RowErrors)
+	}
+	if c := h.Components[0]; c.SecID != "1.600519" || c.Code != "600519" || c.Name != "贵州茅台" || c.Weight != 9.52 {
+		t.Fatalf("first component: %+v", c)
+	}
+	if c := h.Components[1]; c.SecID != "0.000858" || c.Weight != 6.10 {
+		t.Fatalf("second component: %+v", c)
+	}
+
+	if _, err := ParseStockHoldings(`var apidata={ content:"",arryear:[],curyear:0};`); !errors.Is(err, ErrNoData) {
+		t.Fatalf("未披露持仓时应返回ErrNoData，got %v", err)
+	}
+	for name, body := range map[string]string{
+		"不是接口响应": `<html>502 Bad Gateway</html>`,
+		"缺少截止日期": `var apidata={ content:"<table><thead><tr><th>股票代码</th><th>股票名称</th><th>占净值比例</th></tr></thead></table>"};`,
+		"表头变化":   `var apidata={ content:"截止至：<font>2024-03-31</font><table><thead><tr><th>代码</th><th>名称</th><th>比例</th></tr></thead></table>"};`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseStockHoldings(body)
+			wantParseError(t, err)
+		})
+	}
+
+	// 比例超出0~100%的成分被拒绝
+	h.Components[1].Weight = 120
+	valid, rejected := validateStockHoldings(h, "000001")
+	if len(valid) != 1 || len(rejected) != 1 {
+		t.Fatalf("want 1 valid 1 rejected, got %d, %d", len(valid), len(rejected))
+	}
+}
+
+func TestStockSecID(t *testing.T) {
+	tests := []struct{ link, code, want string }{
+		{"//quote.eastmoney.com/unify/r/1.600519", "600519", "1.600519"},
+		{"", "600519", "1.600519"},
+		{"", "000858", "0.000858"},
+		{"//quote.eastmoney.com/hk/00700.html", "00700", "116.00700"},
+		{"", "AAPL", ""},
+	}
+	for _, tt := range tests {
+		if got := stockSecID(tt.link, tt.code); got != tt.want {
+			t.Errorf("stockSecID(%q, %q) = %q, want %q", tt.link, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestParseTrackingIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "指数基金", body: `<th>基金管理人</th><td><a href="#">华夏基金</a></td><th>跟踪标的</th><td>沪深300指数</td>`, want: "沪深300指数"},
+		{name: "无跟踪标的", body: `<th>基金管理人</th><td>华夏基金</td><th>跟踪标的</th><td>该基金无跟踪标的</td>`},
+		{name: "跟踪标的为--", body: `<th>基金管理人</th><td>华夏基金</td><th>跟踪标的</th><td>--</td>`},
+		{name: "概况页无该字段", body: `<th>基金管理人</th><td>华夏基金</td>`},
+		{name: "不是概况页", body: `<html>访问过于频繁</html>`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrackingIndex(tt.body)
+			if tt.wantErr {
+				wantParseError(t, err)
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("want %q, got %q, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestParseKlines(t *testing.T) {
+	k, err := ParseKlines(fixture(t, "kline.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 收盘价和涨跌幅为"-"的行跳过，不按0计入
+	if len(k.Bars) != 3 || len(k.RowErrors) != 1 {
+		t.Fatalf("got bars=%+v rowErrors=%v", k.Bars, k.RowErrors)
+	}
+	last := k.Bars[2]
+	if !last.Date.Equal(mustDate("2024-01-15")) || last.Close != 3308.47 || last.ChangePct != -0.5 {
+		t.Fatalf("last bar: %+v", last)
+	}
+
+	if _, err := ParseKlines(`{"rc":0,"data":null}`); !errors.Is(err, ErrNoData) {
+		t.Fatalf("无效行情代码应返回ErrNoData，got %v", err)
+	}
+	for name, body := range map[string]string{
+		"不是JSON": `<html></html>`,
+		"全部行无效":  `{"data":{"klines":["2024-01-15,abc,1.0","2024-01-16"]}}`,
+		"收盘价不合理": `{"data":{"klines":["2024-01-15,0,1.0"]}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseKlines(body)
+			wantParseError(t, err)
+		})
+	}
+}
+
+func TestParseStatsRecord(t *testing.T) {
+	api := GetFundAPI()
+	api.ResetParseStats()
+	defer api.ResetParseStats()
+
+	badRow := &ParseError{Endpoint: EndpointKline, Field: "收盘"}
+	parseStats.record(EndpointKline, nil, []error{badRow})
+	for i := 0; i < driftThreshold; i++ {
+		parseStats.record(EndpointKline, &ParseError{Endpoint: EndpointKline}, nil)
+	}
+	parseStats.reject(EndpointKline, &ValidationError{Endpoint: EndpointKline})
+
+	var stat ParseStat
+	for _, s := range api.ParseStats() {
+		if s.Endpoint == EndpointKline {
+			stat = s
+		}
+	}
+	if stat.Responses != driftThreshold+1 || stat.Failures != driftThreshold || stat.BadRows != 1 || stat.Rejected != 1 {
+		t.Fatalf("got %+v", stat)
+	}
+	if !stat.Drifting() || len(api.DriftingEndpoints()) != 1 {
+		t.Fatalf("连续%d次解析失败应提示格式变化", driftThreshold)
+	}
+
+	// 无数据视为正常响应，清零连续失败
+	parseStats.record(EndpointKline, ErrNoData, nil)
+	if len(api.DriftingEndpoints()) != 0 {
+		t.Fatal("正常响应后不应再提示格式变化")
+	}
+}
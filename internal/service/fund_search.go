@@ -0,0 +1,383 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"jijin/internal/model"
+)
+
+// 搜索排序方式
+const (
+	SearchSortRelevance = "relevance" // 相关度
+	SearchSortScale     = "scale"     // 规模从大到小
+	SearchSortEstablish = "establish" // 成立日期从早到晚
+	SearchSortCode      = "code"      // 基金代码
+)
+
+// 相关度得分
+const (
+	scoreExactCode   = 1000
+	scoreExactName   = 950
+	scoreExactAbbr   = 900
+	scoreCodePrefix  = 800
+	scoreNamePrefix  = 750
+	scoreAbbrPrefix  = 700
+	scoreNameContain = 600
+	scoreAbbrContain = 550
+	scorePinyin      = 500
+	scoreSubsequence = 400
+	scoreFuzzy       = 300
+)
+
+// 基金概况补充：本地没有概况的候选每次搜索最多联网获取profileFetchLimit只，并发profileFetchWorkers个请求
+const (
+	profileFetchLimit   = 200
+	profileFetchWorkers = 8
+	profileCacheTTL     = 7 * 24 * time.Hour
+)
+
+// SearchOptions 基金搜索选项
+type SearchOptions struct {
+	FundType      string    // 基金类型关键字(如 股票型/混合型/债券型/指数型/QDII)，空为不限
+	Company       string    // 基金公司关键字，空为不限
+	MinScale      float64   // 最小规模(亿元)，0为不限
+	MaxScale      float64   // 最大规模(亿元)，0为不限
+	EstablishFrom time.Time // 成立日期下限，零值为不限
+	EstablishTo   time.Time // 成立日期上限，零值为不限
+	SortBy        string    // 排序方式，默认按相关度
+	Limit         int       // 返回数量，默认50
+	LocalOnly     bool      // 只用本地已有的概况筛选，不联网获取(输入时的即时搜索用)
+}
+
+// needProfile 是否需要基金概况数据
+func (o SearchOptions) needProfile() bool {
+	return o.Company != "" || o.MinScale > 0 || o.MaxScale > 0 ||
+		!o.EstablishFrom.IsZero() || !o.EstablishTo.IsZero() ||
+		o.SortBy == SearchSortScale || o.SortBy == SearchSortEstablish
+}
+
+// SearchOutcome 搜索结果
+type SearchOutcome struct {
+	Results   []model.FundSearchResult
+	Unchecked int // 缺少基金概况、未参与公司/规模/成立日期筛选的候选数量
+}
+
+// SearchFundWithOptions 按相关度搜索基金，支持筛选和排序
+func (f *FundAPI) SearchFundWithOptions(keyword string, opts SearchOptions) ([]model.FundSearchResult, error) {
+	outcome, err := f.SearchFunds(keyword, opts)
+	if err != nil {
+		return nil, err
+	}
+	return outcome.Results, nil
+}
+
+// SearchFunds 按相关度搜索基金，同时返回因缺少概况未能筛选的候选数量
+func (f *FundAPI) SearchFunds(keyword string, opts SearchOptions) (*SearchOutcome, error) {
+	// 如果没有缓存，先加载
+	if len(f.allFunds) == 0 {
+		if err := f.loadAllFunds(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	var results []model.FundSearchResult
+
+	for _, fund := range f.allFunds {
+		if opts.FundType != "" && !strings.Contains(fund.Type, opts.FundType) {
+			continue
+		}
+		score := 1.0
+		if keyword != "" {
+			score = scoreFund(keyword, fund)
+			if score <= 0 {
+				continue
+			}
+		}
+		fund.Score = score
+		results = append(results, fund)
+	}
+
+	sortByRelevance(results)
+
+	outcome := &SearchOutcome{}
+	if opts.needProfile() {
+		results, outcome.Unchecked = f.filterByProfile(results, opts)
+	}
+
+	switch opts.SortBy {
+	case SearchSortScale:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Scale > results[j].Scale
+		})
+	case SearchSortEstablish:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].EstablishDate.Before(results[j].EstablishDate)
+		})
+	case SearchSortCode:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Code < results[j].Code
+		})
+	}
+
+	if len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	outcome.Results = results
+	return outcome, nil
+}
+
+// filterByProfile 补充基金概况并按公司、规模、成立日期筛选。
+// 本地概况批量读取；缺少概况的候选按相关度联网获取，超出上限的不参与筛选，返回其数量
+func (f *FundAPI) filterByProfile(results []model.FundSearchResult, opts SearchOptions) ([]model.FundSearchResult, int) {
+	// 只按公司筛选时，名称以公司关键字开头的无需概况即可判断
+	companyOnly := opts.Company != "" && opts.MinScale == 0 && opts.MaxScale == 0 &&
+		opts.EstablishFrom.IsZero() && opts.EstablishTo.IsZero() &&
+		opts.SortBy != SearchSortScale && opts.SortBy != SearchSortEstablish
+	byName := func(r model.FundSearchResult) bool {
+		return companyOnly && strings.HasPrefix(r.Name, opts.Company)
+	}
+
+	codes := make([]string, len(results))
+	for i, r := range results {
+		codes[i] = r.Code
+	}
+	profiles, err := f.fundInfo.GetFundProfiles(codes)
+	if err != nil {
+		profiles = make(map[string]model.FundProfile)
+	}
+
+	var missing []string
+	for _, r := range results {
+		if byName(r) {
+			continue
+		}
+		if p, ok := profiles[r.Code]; !ok || time.Since(p.UpdatedAt) >= profileCacheTTL {
+			missing = append(missing, r.Code)
+		}
+	}
+	if opts.LocalOnly {
+		missing = nil
+	}
+	if len(missing) > profileFetchLimit {
+		missing = missing[:profileFetchLimit]
+	}
+	for code, p := range f.fetchProfiles(missing) {
+		profiles[code] = p
+	}
+
+	filtered := results[:0]
+	unchecked := 0
+	for _, r := range results {
+		profile, ok := profiles[r.Code]
+		if !ok {
+			if byName(r) {
+				filtered = append(filtered, r)
+			} else {
+				unchecked++
+			}
+			continue
+		}
+		r.Company = profile.Company
+		r.Scale = profile.Scale
+		r.EstablishDate = profile.EstablishDate
+
+		if opts.Company != "" &&
+			!strings.Contains(r.Company, opts.Company) && !strings.HasPrefix(r.Name, opts.Company) {
+			continue
+		}
+		if opts.MinScale > 0 && r.Scale < opts.MinScale {
+			continue
+		}
+		if opts.MaxScale > 0 && (r.Scale == 0 || r.Scale > opts.MaxScale) {
+			continue
+		}
+		if !opts.EstablishFrom.IsZero() && (r.EstablishDate.IsZero() || r.EstablishDate.Before(opts.EstablishFrom)) {
+			continue
+		}
+		if !opts.EstablishTo.IsZero() && (r.EstablishDate.IsZero() || r.EstablishDate.After(opts.EstablishTo)) {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered, unchecked
+}
+
+// fetchProfiles 并发联网获取基金概况并保存，失败的不返回
+func (f *FundAPI) fetchProfiles(codes []string) map[string]model.FundProfile {
+	profiles := make(map[string]model.FundProfile, len(codes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, profileFetchWorkers)
+	for _, code := range codes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(code string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			profile, err := f.GetFundProfile(code)
+			if err != nil {
+				return
+			}
+			f.fundInfo.SaveFundProfile(profile)
+			mu.Lock()
+			profiles[code] = *profile
+			mu.Unlock()
+		}(code)
+	}
+	wg.Wait()
+	return profiles
+}
+
+// getCachedFundProfile 优先读取本地缓存的基金概况，过期(7天)后重新获取
+func (f *FundAPI) getCachedFundProfile(code string) (*model.FundProfile, error) {
+	profile, err := f.fundInfo.GetFundProfile(code)
+	if err == nil && time.Since(profile.UpdatedAt) < profileCacheTTL {
+		return profile, nil
+	}
+
+	fresh, fetchErr := f.GetFundProfile(code)
+	if fetchErr != nil {
+		if profile != nil {
+			return profile, nil
+		}
+		return nil, fetchErr
+	}
+	f.fundInfo.SaveFundProfile(fresh)
+	return fresh, nil
+}
+
+// sortByRelevance 按得分降序，同分时名称短者优先，再按代码
+func sortByRelevance(results []model.FundSearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		li, lj := utf8.RuneCountInString(results[i].Name), utf8.RuneCountInString(results[j].Name)
+		if li != lj {
+			return li < lj
+		}
+		return results[i].Code < results[j].Code
+	})
+}
+
+// scoreFund 计算关键字与基金的相关度，0表示不匹配
+func scoreFund(keyword string, fund model.FundSearchResult) float64 {
+	name := strings.ToLower(fund.Name)
+	abbr := strings.ToLower(fund.PinyinAbbr)
+	pinyin := strings.ToLower(fund.Pinyin)
+
+	switch {
+	case fund.Code == keyword:
+		return scoreExactCode
+	case name == keyword:
+		return scoreExactName
+	case abbr == keyword:
+		return scoreExactAbbr
+	case strings.HasPrefix(fund.Code, keyword):
+		return scoreCodePrefix
+	case strings.HasPrefix(name, keyword):
+		return scoreNamePrefix
+	case strings.HasPrefix(abbr, keyword):
+		return scoreAbbrPrefix
+	}
+
+	// 包含匹配，位置越靠前得分越高
+	if idx := strings.Index(name, keyword); idx >= 0 {
+		return scoreNameContain - float64(utf8.RuneCountInString(name[:idx]))
+	}
+	if idx := strings.Index(abbr, keyword); idx >= 0 {
+		return scoreAbbrContain - float64(idx)
+	}
+	if idx := strings.Index(pinyin, keyword); idx >= 0 {
+		return scorePinyin - float64(idx)/10
+	}
+
+	// 子序列匹配(如"易方达消费"匹配"易方达中证消费")，间隔越少得分越高
+	if gaps, ok := subsequenceGaps(keyword, name); ok {
+		return scoreSubsequence - float64(gaps)
+	}
+	if gaps, ok := subsequenceGaps(keyword, abbr); ok {
+		return scoreSubsequence - 50 - float64(gaps)
+	}
+
+	// 编辑距离容错(与代码、简拼、名称前缀比较)
+	maxDist := 1
+	if utf8.RuneCountInString(keyword) >= 5 {
+		maxDist = 2
+	}
+	best := -1
+	for _, target := range []string{fund.Code, abbr, name} {
+		d := levenshtein(keyword, runePrefix(target, utf8.RuneCountInString(keyword)))
+		if d <= maxDist && (best < 0 || d < best) {
+			best = d
+		}
+	}
+	if best >= 0 {
+		return scoreFuzzy - float64(best)*50
+	}
+
+	return 0
+}
+
+// subsequenceGaps 判断keyword是否为target的子序列，返回跳过的字符数
+func subsequenceGaps(keyword, target string) (int, bool) {
+	kw := []rune(keyword)
+	if len(kw) < 2 {
+		return 0, false
+	}
+	gaps, k, started := 0, 0, false
+	for _, r := range target {
+		if k == len(kw) {
+			break
+		}
+		if r == kw[k] {
+			k++
+			started = true
+		} else if started {
+			gaps++
+		}
+	}
+	return gaps, k == len(kw)
+}
+
+// runePrefix 取前n个字符
+func runePrefix(s string, n int) string {
+	rs := []rune(s)
+	if len(rs) <= n {
+		return s
+	}
+	return string(rs[:n])
+}
+
+// levenshtein 计算编辑距离
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"jijin/internal/model"
+)
+
+func TestSearchFundsLocalOnly(t *testing.T) {
+	store := newTestStore(t)
+	f := NewFundAPI(store)
+	f.allFunds = []model.FundSearchResult{
+		{Code: "000001", Name: "易方达蓝筹精选", Type: "混合型"},
+		{Code: "000002", Name: "易方达成长", Type: "混合型"},
+		{Code: "000003", Name: "易方达小盘", Type: "混合型"},
+	}
+	for _, p := range []model.FundProfile{
+		{FundCode: "000001", Company: "易方达基金", Scale: 50},
+		{FundCode: "000002", Company: "易方达基金", Scale: 5},
+	} {
+		if err := store.SaveFundProfile(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 输入时的即时搜索只用本地概况，没有概况的不联网获取，计入未筛选数量
+	outcome, err := f.SearchFunds("易方达", SearchOptions{MinScale: 10, LocalOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Results) != 1 || outcome.Results[0].Code != "000001" || outcome.Results[0].Scale != 50 {
+		t.Fatalf("results: %+v", outcome.Results)
+	}
+	if outcome.Unchecked != 1 {
+		t.Fatalf("want 1 unchecked, got %d", outcome.Unchecked)
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"jijin/internal/model"
+)
+
+// 持仓提醒类型常量
+const (
+	AlertTypeTakeProfit   = "take_profit"   // 收益率止盈
+	AlertTypeStopLoss     = "stop_loss"     // 收益率止损
+	AlertTypeProfitAmount = "profit_amount" // 收益金额
+	AlertTypeTrailingStop = "trailing_stop" // 从最高点回撤
+	AlertTypeTargetValue  = "target_value"  // 目标市值
+)
+
+// HoldingAlertTypes 持仓提醒类型(按显示顺序)
+var HoldingAlertTypes = []string{
+	AlertTypeTakeProfit, AlertTypeStopLoss, AlertTypeProfitAmount,
+	AlertTypeTrailingStop, AlertTypeTargetValue,
+}
+
+// IsHoldingAlertType 是否为持仓提醒类型
+func IsHoldingAlertType(alertType string) bool {
+	for _, t := range HoldingAlertTypes {
+		if t == alertType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetHoldingAlertRules 获取持仓的止盈止损规则
+func (a *AlertService) GetHoldingAlertRules(holdingID uint) ([]model.AlertRule, error) {
+	return a.alerts.GetAlertRulesByHoldingID(holdingID)
+}
+
+// SetHoldingAlert 设置持仓提醒，每个持仓每种类型一条规则；threshold为0时删除该规则
+// 止盈/止损/回撤阈值为百分比，收益金额和目标市值为元，收益金额为负表示亏损达到该金额
+func (a *AlertService) SetHoldingAlert(holdingID uint, alertType string, threshold float64) error {
+	if !IsHoldingAlertType(alertType) {
+		return fmt.Errorf("不支持的持仓提醒类型: %s", alertType)
+	}
+	holding, err := a.holdings.GetHolding(holdingID)
+	if err != nil {
+		return err
+	}
+
+	rules, err := a.alerts.GetAlertRulesByHoldingID(holdingID)
+	if err != nil {
+		return err
+	}
+	var rule *model.AlertRule
+	for i := range rules {
+		if rules[i].AlertType == alertType {
+			rule = &rules[i]
+			break
+		}
+	}
+
+	if threshold == 0 {
+		if rule != nil {
+			return a.alerts.DeleteAlertRule(rule.ID)
+		}
+		return nil
+	}
+	if threshold < 0 && alertType != AlertTypeProfitAmount {
+		return errors.New("阈值必须大于0")
+	}
+
+	if rule == nil {
+		rule = &model.AlertRule{
+			FundCode:  holding.FundCode,
+			FundName:  holding.FundName,
+			AlertType: alertType,
+			HoldingID: holdingID,
+			Enabled:   true,
+		}
+	}
+	if rule.Threshold != threshold {
+		rule.Active = false
+	}
+	rule.Threshold = threshold
+	rule.Direction = "up"
+	if threshold < 0 || alertType == AlertTypeStopLoss || alertType == AlertTypeTrailingStop {
+		rule.Direction = "down"
+	}
+	if alertType == AlertTypeTrailingStop && rule.PeakValue == 0 {
+		rule.PeakValue = holding.MarketValue()
+		rule.PeakShares = holding.Shares
+	}
+	return a.alerts.SaveAlertRule(rule)
+}
+
+// CheckHoldingAlerts 检查所有持仓提醒，每次刷新持仓后调用，返回新触发的提醒
+func (a *AlertService) CheckHoldingAlerts() []model.AlertHistory {
+	rules, err := a.alerts.GetEnabledAlertRules()
+	if err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	var triggered []*model.AlertHistory
+	for _, rule := range rules {
+		if !IsHoldingAlertType(rule.AlertType) {
+			continue
+		}
+		alert, margin := a.checkHoldingAlert(&rule)
+		if a.allowAlert(&rule, margin, now) && alert != nil {
+			triggered = append(triggered, alert)
+		}
+	}
+	a.deliver(triggered)
+
+	alerts := make([]model.AlertHistory, 0, len(triggered))
+	for _, alert := range triggered {
+		alerts = append(alerts, *alert)
+	}
+	return alerts
+}
+
+// checkHoldingAlert 检查单条持仓提醒，返回越过阈值的幅度(与阈值同单位)
+func (a *AlertService) checkHoldingAlert(rule *model.AlertRule) (*model.AlertHistory, float64) {
+	holding, err := a.holdings.GetHolding(rule.HoldingID)
+	if err != nil || holding.Shares <= 0 || holding.CurrentNav <= 0 {
+		return nil, math.NaN()
+	}
+
+	var margin, value float64
+	var message string
+
+	switch rule.AlertType {
+	case AlertTypeTakeProfit:
+		value = holding.ProfitRate()
+		margin = value - rule.Threshold
+		message = fmt.Sprintf("%s 持仓收益率 %.2f%%，达到止盈线 %.2f%%", holding.FundName, value, rule.Threshold)
+	case AlertTypeStopLoss:
+		value = holding.ProfitRate()
+		margin = -value - rule.Threshold
+		message = fmt.Sprintf("%s 持仓收益率 %.2f%%，触及止损线 -%.2f%%", holding.FundName, value, rule.Threshold)
+	case AlertTypeProfitAmount:
+		value = holding.Profit()
+		if rule.Threshold >= 0 {
+			margin = value - rule.Threshold
+			message = fmt.Sprintf("%s 持仓收益 %.2f元，达到 %.2f元", holding.FundName, value, rule.Threshold)
+		} else {
+			margin = rule.Threshold - value
+			message = fmt.Sprintf("%s 持仓亏损 %.2f元，达到 %.2f元", holding.FundName, -value, -rule.Threshold)
+		}
+	case AlertTypeTrailingStop:
+		a.updateTrailingPeak(rule, holding)
+		value = (rule.PeakValue - holding.MarketValue()) / rule.PeakValue * 100
+		margin = value - rule.Threshold
+		message = fmt.Sprintf("%s 持仓市值较高点 %.2f元 回撤 %.2f%%，达到 %.2f%%", holding.FundName, rule.PeakValue, value, rule.Threshold)
+	case AlertTypeTargetValue:
+		value = holding.MarketValue()
+		margin = value - rule.Threshold
+		message = fmt.Sprintf("%s 持仓市值 %.2f元，达到目标 %.2f元", holding.FundName, value, rule.Threshold)
+	default:
+		return nil, math.NaN()
+	}
+
+	if margin < 0 {
+		return nil, margin
+	}
+
+	return &model.AlertHistory{
+		RuleID:      rule.ID,
+		FundCode:    holding.FundCode,
+		FundName:    holding.FundName,
+		AlertType:   rule.AlertType,
+		Message:     message,
+		Value:       value,
+		TriggeredAt: time.Now(),
+	}, margin
+}
+
+// updateTrailingPeak 与allowAlert共用规则锁，以数据库中的最高点为准调整，只更新最高点
+func (a *AlertService) updateTrailingPeak(rule *model.AlertRule, holding *model.Holding) {
+	a.ruleMu.Lock()
+	defer a.ruleMu.Unlock()
+	if latest, err := a.alerts.GetAlertRule(rule.ID); err == nil {
+		rule.PeakValue = latest.PeakValue
+		rule.PeakShares = latest.PeakShares
+	}
+	if adjustTrailingPeak(rule, holding) {
+		a.alerts.UpdateAlertPeak(rule.ID, rule.PeakValue, rule.PeakShares)
+	}
+}
+
+// adjustTrailingPeak 按份额变化调整回撤提醒的最高市值，加减仓本身不算作涨跌，返回是否有变化
+// 加仓的份额按当前净值计入最高点，减仓按卖出比例扣减最高点
+func adjustTrailingPeak(rule *model.AlertRule, holding *model.Holding) bool {
+	changed := false
+	if rule.PeakShares != holding.Shares {
+		switch {
+		case rule.PeakShares <= 0: // 旧规则未记录份额
+			rule.PeakValue = holding.MarketValue()
+		case holding.Shares < rule.PeakShares:
+			rule.PeakValue *= holding.Shares / rule.PeakShares
+		default:
+			rule.PeakValue += (holding.Shares - rule.PeakShares) * holding.CurrentNav
+		}
+		rule.PeakShares = holding.Shares
+		changed = true
+	}
+	if value := holding.MarketValue(); value > rule.PeakValue {
+		rule.PeakValue = value
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	"jijin/internal/model"
+)
+
+func TestTrailingStopFollowsPositionChanges(t *testing.T) {
+	store := newTestStore(t)
+	a := NewAlertService(store)
+	holding := &model.Holding{FundCode: "000001", FundName: "测试基金", Shares: 1000, CostPrice: 1, CurrentNav: 1}
+	if err := store.SaveHolding(holding); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetHoldingAlert(holding.ID, AlertTypeTrailingStop, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name      string
+		shares    float64
+		nav       float64
+		wantPeak  float64
+		wantAlert bool
+	}{
+		{name: "上涨刷新最高点", shares: 1000, nav: 1.2, wantPeak: 1200},
+		{name: "减仓一半不算回撤", shares: 500, nav: 1.2, wantPeak: 600},
+		{name: "加仓不抬高回撤基准", shares: 1500, nav: 1.15, wantPeak: 1750},
+		{name: "下跌未达阈值", shares: 1500, nav: 1.1, wantPeak: 1750},
+		{name: "下跌达到阈值", shares: 1500, nav: 1.0, wantPeak: 1750, wantAlert: true},
+	}
+	for _, step := range steps {
+		holding.Shares, holding.CurrentNav = step.shares, step.nav
+		if err := store.SaveHolding(holding); err != nil {
+			t.Fatal(err)
+		}
+		rules, err := a.GetHoldingAlertRules(holding.ID)
+		if err != nil || len(rules) != 1 {
+			t.Fatalf("%s: rules %v, %v", step.name, rules, err)
+		}
+		alert, _ := a.checkHoldingAlert(&rules[0])
+		if (alert != nil) != step.wantAlert {
+			t.Fatalf("%s: want alert %v, got %+v", step.name, step.wantAlert, alert)
+		}
+		saved, _ := a.GetHoldingAlertRules(holding.ID)
+		if !almostEqual(saved[0].PeakValue, step.wantPeak) || !almostEqual(saved[0].PeakShares, step.shares) {
+			t.Fatalf("%s: want peak %v, got %v (%v份)", step.name, step.wantPeak, saved[0].PeakValue, saved[0].PeakShares)
+		}
+	}
+}
+
+func TestTrailingStopConcurrentChecks(t *testing.T) {
+	store := newTestStore(t)
+	a := NewAlertService(store)
+	holding := &model.Holding{FundCode: "000001", FundName: "测试基金", Shares: 1000, CostPrice: 1, CurrentNav: 1}
+	if err := store.SaveHolding(holding); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetHoldingAlert(holding.ID, AlertTypeTrailingStop, 10); err != nil {
+		t.Fatal(err)
+	}
+	rules, _ := a.GetHoldingAlertRules(holding.ID)
+	stale := rules[0]
+
+	// 减仓后定时监控和刷新持仓后的检查各持旧副本同时检查，最高点只能按比例扣减一次
+	holding.Shares = 500
+	if err := store.SaveHolding(holding); err != nil {
+		t.Fatal(err)
+	}
+	// 期间用户修改了回撤阈值
+	if err := a.SetHoldingAlert(holding.ID, AlertTypeTrailingStop, 15); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(r model.AlertRule) {
+			defer wg.Done()
+			a.checkHoldingAlert(&r)
+		}(stale)
+	}
+	wg.Wait()
+
+	saved, err := store.GetAlertRule(stale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(saved.PeakValue, 500) || !almostEqual(saved.PeakShares, 500) {
+		t.Fatalf("want peak 500 (500份), got %v (%v份)", saved.PeakValue, saved.PeakShares)
+	}
+	if saved.Threshold != 15 {
+		t.Fatalf("更新最高点覆盖了用户修改的阈值: %v", saved.Threshold)
+	}
+}
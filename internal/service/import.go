@@ -0,0 +1,543 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// ImportService 文件导入服务
+type ImportService struct {
+	holdings     repository.HoldingRepository
+	transactions repository.TransactionRepository
+	settings     repository.SettingsRepository
+	store        repository.Store
+}
+
+// NewImportService 使用指定仓储创建导入服务
+func NewImportService(store repository.Store) *ImportService {
+	return &ImportService{
+		holdings:     store,
+		transactions: store,
+		settings:     store,
+		store:        store,
+	}
+}
+
+var importService = NewImportService(repository.Default())
+
+// GetImportService 获取导入服务实例
+func GetImportService() *ImportService {
+	return importService
+}
+
+// 导入内容
+const (
+	ImportKindTrades   = "trades"   // 交易记录
+	ImportKindHoldings = "holdings" // 持仓快照
+)
+
+// ImportKindNames 导入内容显示名称
+var ImportKindNames = map[string]string{
+	ImportKindTrades:   "交易记录",
+	ImportKindHoldings: "持仓快照",
+}
+
+// 导入行状态
+const (
+	ImportRowOK        = "ok"
+	ImportRowInvalid   = "invalid"
+	ImportRowDuplicate = "duplicate"
+	ImportRowSkipped   = "skipped"
+)
+
+// ImportRowStatusNames 导入行状态显示名称
+var ImportRowStatusNames = map[string]string{
+	ImportRowOK:        "待导入",
+	ImportRowInvalid:   "错误",
+	ImportRowDuplicate: "重复",
+	ImportRowSkipped:   "忽略",
+}
+
+// ImportRow 预览中的一行，交易记录使用Type/Amount/NetValue/Shares/Fee/TradeDate，持仓快照使用Shares/Cost/NetValue/MarketValue
+type ImportRow struct {
+	Line        int // 文件中的行号，从1开始
+	Status      string
+	Message     string
+	Type        string // buy/sell
+	FundCode    string
+	FundName    string
+	TradeDate   time.Time
+	Amount      float64
+	NetValue    float64
+	Shares      float64
+	Fee         float64
+	Cost        float64
+	MarketValue float64
+}
+
+// ImportPreview 解析结果，确认后写入
+type ImportPreview struct {
+	FileName   string
+	Source     ImportSource
+	Kind       string
+	ImportType string // file/screenshot，对应ImportRecord.ImportType
+	Rows       []ImportRow
+}
+
+// Count 指定状态的行数
+func (p *ImportPreview) Count(status string) int {
+	n := 0
+	for _, r := range p.Rows {
+		if r.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// importDetails ImportRecord.Details 的内容
+type importDetails struct {
+	FileName   string          `json:"fileName"`
+	Kind       string          `json:"kind"`
+	Duplicates int             `json:"duplicates"`
+	Skipped    int             `json:"skipped"`
+	Failures   []importFailure `json:"failures,omitempty"`
+}
+
+type importFailure struct {
+	Line     int    `json:"line"`
+	FundCode string `json:"fundCode"`
+	Reason   string `json:"reason"`
+}
+
+var (
+	fundCodePattern     = regexp.MustCompile(`^\d{6}$`)
+	fundCodeInName      = regexp.MustCompile(`[(（\[]?(\d{6})[)）\]]?`)
+	importNumberCleaner = strings.NewReplacer("¥", "", "￥", "", "元", "", "份", "", ",", "", "，", "", " ", "", "+", "")
+)
+
+// importDateLayouts 支持的日期格式
+var importDateLayouts = []string{
+	"2006-01-02", "2006-01-02 15:04:05", "2006-01-02 15:04",
+	"2006/01/02", "2006/01/02 15:04:05", "2006/01/02 15:04",
+	"2006/1/2", "2006/1/2 15:04:05", "2006/1/2 15:04", "2006-1-2",
+	"2006.01.02", "20060102", "2006年01月02日", "2006年1月2日",
+}
+
+// Parse 解析导入文件并校验、去重，sourceKey为空时自动识别来源
+func (s *ImportService) Parse(filename string, data []byte, sourceKey string) (*ImportPreview, error) {
+	table, err := ReadImportTable(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	source, headerRow, mapping, holdings := detectLayout(table, sourceKey)
+	if headerRow < 0 {
+		return nil, fmt.Errorf("未识别的表头，请选择正确的来源，或按通用模板整理为: 日期,代码,名称,类型,金额,净值,份额,手续费")
+	}
+
+	preview := &ImportPreview{FileName: filename, Source: source, Kind: ImportKindTrades, ImportType: "file"}
+	if holdings {
+		preview.Kind = ImportKindHoldings
+	}
+	resolver := s.newFundResolver()
+	for i := headerRow + 1; i < len(table); i++ {
+		get := func(field string) string {
+			if col, ok := mapping[field]; ok && col < len(table[i]) {
+				return strings.TrimSpace(table[i][col])
+			}
+			return ""
+		}
+		if get(ImportColCode) == "" && get(ImportColName) == "" {
+			continue
+		}
+		var row ImportRow
+		if holdings {
+			row = parseHoldingRow(get)
+		} else {
+			row = parseTradeRow(get)
+		}
+		row.Line = i + 1
+		if row.Status == ImportRowOK && !fundCodePattern.MatchString(row.FundCode) {
+			if code := resolver.resolve(row.FundName); code != "" {
+				row.FundCode = code
+			} else {
+				row.Status, row.Message = ImportRowInvalid, "无法根据名称确定基金代码"
+			}
+		}
+		preview.Rows = append(preview.Rows, row)
+	}
+
+	if holdings {
+		s.markDuplicateHoldings(preview.Rows)
+	} else {
+		s.markDuplicateTrades(preview.Rows)
+	}
+	return preview, nil
+}
+
+// parseTradeRow 解析并校验一行交易记录
+func parseTradeRow(get func(string) string) ImportRow {
+	row := ImportRow{Status: ImportRowOK}
+	row.FundCode, row.FundName = splitFundCode(get(ImportColCode), get(ImportColName))
+
+	typeText := get(ImportColType)
+	tradeType, skip := importTradeType(typeText)
+	if skip != "" {
+		row.Status, row.Message = ImportRowSkipped, skip
+		return row
+	}
+	row.Type = tradeType
+
+	var errs []string
+	invalid := func(msg string) { errs = append(errs, msg) }
+	if row.Type == "" {
+		invalid("未知的交易类型: " + typeText)
+	}
+	date, err := parseImportDate(get(ImportColDate))
+	if err != nil {
+		invalid(err.Error())
+	}
+	row.TradeDate = date
+	for _, f := range []struct {
+		field, name string
+		target      *float64
+	}{
+		{ImportColAmount, "金额", &row.Amount},
+		{ImportColNav, "净值", &row.NetValue},
+		{ImportColShares, "份额", &row.Shares},
+		{ImportColFee, "手续费", &row.Fee},
+	} {
+		v, err := parseImportNumber(get(f.field))
+		if err != nil {
+			invalid(f.name + "格式错误")
+		}
+		*f.target = math.Abs(v)
+	}
+
+	// 按金额、净值、份额三者互相补全
+	switch row.Type {
+	case "buy":
+		if row.Amount <= 0 {
+			invalid("缺少交易金额")
+		} else if row.NetValue <= 0 && row.Shares > 0 {
+			row.NetValue = (row.Amount - row.Fee) / row.Shares
+		}
+	case "sell":
+		if row.Shares <= 0 && row.Amount > 0 && row.NetValue > 0 {
+			row.Shares = (row.Amount + row.Fee) / row.NetValue
+		}
+		if row.NetValue <= 0 && row.Shares > 0 && row.Amount > 0 {
+			row.NetValue = (row.Amount + row.Fee) / row.Shares
+		}
+		if row.Shares <= 0 {
+			invalid("缺少赎回份额")
+		}
+	}
+	if row.NetValue <= 0 && row.Type != "" {
+		invalid("缺少成交净值或份额")
+	}
+
+	if len(errs) > 0 {
+		row.Status, row.Message = ImportRowInvalid, strings.Join(errs, "；")
+	}
+	return row
+}
+
+// parseHoldingRow 解析并校验一行持仓快照
+func parseHoldingRow(get func(string) string) ImportRow {
+	row := ImportRow{Status: ImportRowOK}
+	row.FundCode, row.FundName = splitFundCode(get(ImportColCode), get(ImportColName))
+
+	var errs []string
+	for _, f := range []struct {
+		field, name string
+		target      *float64
+	}{
+		{ImportColShares, "份额", &row.Shares},
+		{ImportColCost, "成本", &row.Cost},
+		{ImportColNav, "净值", &row.NetValue},
+		{ImportColValue, "市值", &row.MarketValue},
+	} {
+		v, err := parseImportNumber(get(f.field))
+		if err != nil {
+			errs = append(errs, f.name+"格式错误")
+		}
+		*f.target = v
+	}
+	if row.Shares <= 0 {
+		errs = append(errs, "缺少持有份额")
+	}
+	if row.Cost <= 0 {
+		errs = append(errs, "缺少持仓成本")
+	}
+	if row.NetValue <= 0 && row.MarketValue > 0 && row.Shares > 0 {
+		row.NetValue = row.MarketValue / row.Shares
+	}
+
+	if len(errs) > 0 {
+		row.Status, row.Message = ImportRowInvalid, strings.Join(errs, "；")
+	}
+	return row
+}
+
+// splitFundCode 规范化基金代码，代码列为空时尝试从名称中提取"(005827)"形式的代码
+func splitFundCode(code, name string) (string, string) {
+	code = strings.TrimSpace(code)
+	// Excel可能把代码存为数字，丢失前导0
+	if n, err := strconv.Atoi(code); err == nil && len(code) < 6 {
+		code = fmt.Sprintf("%06d", n)
+	}
+	if code == "" {
+		if m := fundCodeInName.FindStringSubmatchIndex(name); m != nil {
+			code = name[m[2]:m[3]]
+			name = strings.TrimSpace(name[:m[0]] + name[m[1]:])
+		}
+	}
+	return code, name
+}
+
+// importTradeType 由交易类型文字得到buy/sell；撤单、失败和分红等不需导入的记录返回忽略原因
+func importTradeType(text string) (string, string) {
+	for _, kw := range []string{"撤单", "失败", "已撤", "分红", "红利"} {
+		if strings.Contains(text, kw) {
+			return "", "不导入的记录: " + text
+		}
+	}
+	for _, kw := range []string{"赎回", "卖出", "转换出", "转出", "收入"} {
+		if strings.Contains(text, kw) {
+			return "sell", ""
+		}
+	}
+	for _, kw := range []string{"申购", "认购", "买入", "定投", "转换入", "转入", "购买", "支出"} {
+		if strings.Contains(text, kw) {
+			return "buy", ""
+		}
+	}
+	return "", ""
+}
+
+// parseImportNumber 解析金额/份额，允许货币符号、千分位和单位，空值或"--"为0
+func parseImportNumber(text string) (float64, error) {
+	text = importNumberCleaner.Replace(strings.TrimSpace(text))
+	if text == "" || strings.Trim(text, "-") == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(text, 64)
+}
+
+// parseImportDate 解析日期，支持常见格式和Excel日期序列号
+func parseImportDate(text string) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, fmt.Errorf("缺少交易日期")
+	}
+	if serial, err := strconv.ParseFloat(text, 64); err == nil && serial > 20000 && serial < 80000 {
+		base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.Local)
+		return base.AddDate(0, 0, int(serial)), nil
+	}
+	for _, layout := range importDateLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("日期格式错误: %s", text)
+}
+
+// fundResolver 按名称查找基金代码，优先匹配已有持仓，结果缓存
+type fundResolver struct {
+	byName map[string]string
+}
+
+func (s *ImportService) newFundResolver() *fundResolver {
+	r := &fundResolver{byName: make(map[string]string)}
+	holdings, _ := s.holdings.GetAllHoldings()
+	for _, h := range holdings {
+		r.byName[h.FundName] = h.FundCode
+	}
+	return r
+}
+
+func (r *fundResolver) resolve(name string) string {
+	if name == "" {
+		return ""
+	}
+	if code, ok := r.byName[name]; ok {
+		return code
+	}
+	code := ""
+	results, _ := GetFundAPI().SearchFund(name)
+	for _, f := range results {
+		if f.Name == name {
+			code = f.Code
+			break
+		}
+	}
+	if code == "" && len(results) == 1 {
+		code = results[0].Code
+	}
+	r.byName[name] = code
+	return code
+}
+
+// tradeKey 交易去重键：基金、方向、日期和金额(买入)或份额(卖出)
+func tradeKey(code, tradeType string, date time.Time, amount, shares float64) string {
+	size := amount
+	if tradeType == "sell" {
+		size = shares
+	}
+	return fmt.Sprintf("%s|%s|%s|%.2f", code, tradeType, date.Format("2006-01-02"), size)
+}
+
+// markDuplicateTrades 标记与已有交易或文件内前面的行重复的交易
+func (s *ImportService) markDuplicateTrades(rows []ImportRow) {
+	existing := make(map[string]bool)
+	txs, _ := s.transactions.GetAllTransactions()
+	for _, tx := range txs {
+		// 卖出记录的Amount为扣费后金额，按份额比较
+		existing[tradeKey(tx.FundCode, tx.Type, tx.TradeDate, tx.Amount, tx.Shares)] = true
+	}
+	for i := range rows {
+		r := &rows[i]
+		if r.Status != ImportRowOK {
+			continue
+		}
+		key := tradeKey(r.FundCode, r.Type, r.TradeDate, r.Amount, r.Shares)
+		if existing[key] {
+			r.Status, r.Message = ImportRowDuplicate, "与已有交易或文件中前面的记录重复"
+			continue
+		}
+		existing[key] = true
+	}
+}
+
+// markDuplicateHoldings 标记与现有持仓完全相同的快照，份额或成本不同的将覆盖现有持仓
+func (s *ImportService) markDuplicateHoldings(rows []ImportRow) {
+	holdings, _ := s.holdings.GetAllHoldings()
+	current := make(map[string]model.Holding)
+	for _, h := range holdings {
+		current[h.FundCode] = h
+	}
+	seen := make(map[string]bool)
+	for i := range rows {
+		r := &rows[i]
+		if r.Status != ImportRowOK {
+			continue
+		}
+		if seen[r.FundCode] {
+			r.Status, r.Message = ImportRowDuplicate, "文件中已有该基金的持仓"
+			continue
+		}
+		seen[r.FundCode] = true
+		h, ok := current[r.FundCode]
+		switch {
+		case ok && math.Abs(h.Shares-r.Shares) < 0.01 && math.Abs(h.Cost-r.Cost) < 0.01:
+			r.Status, r.Message = ImportRowDuplicate, "与现有持仓相同"
+		case ok:
+			r.Message = fmt.Sprintf("将覆盖现有持仓(份额%.2f, 成本%.2f)", h.Shares, h.Cost)
+		}
+	}
+}
+
+// Commit 写入预览中待导入的行并保存导入记录；交易按日期先后依次买入/卖出
+func (s *ImportService) Commit(preview *ImportPreview) (*model.ImportRecord, error) {
+	details := importDetails{
+		FileName:   preview.FileName,
+		Kind:       preview.Kind,
+		Duplicates: preview.Count(ImportRowDuplicate),
+		Skipped:    preview.Count(ImportRowSkipped),
+	}
+	var pending []ImportRow
+	for _, r := range preview.Rows {
+		switch r.Status {
+		case ImportRowOK:
+			pending = append(pending, r)
+		case ImportRowInvalid:
+			details.Failures = append(details.Failures, importFailure{Line: r.Line, FundCode: r.FundCode, Reason: r.Message})
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].TradeDate.Before(pending[j].TradeDate) })
+
+	success := 0
+	for _, r := range pending {
+		if err := s.commitRow(preview.Kind, r); err != nil {
+			details.Failures = append(details.Failures, importFailure{Line: r.Line, FundCode: r.FundCode, Reason: err.Error()})
+			continue
+		}
+		success++
+	}
+
+	detailJSON, _ := json.Marshal(details)
+	record := &model.ImportRecord{
+		ImportType:    preview.ImportType,
+		SourceApp:     preview.Source.Name,
+		ImportedCount: len(preview.Rows),
+		SuccessCount:  success,
+		FailedCount:   len(details.Failures),
+		Details:       string(detailJSON),
+		ImportedAt:    time.Now(),
+	}
+	if err := s.settings.SaveImportRecord(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// commitRow 在一个事务中写入一行，失败时整行回滚，不留下新建的空持仓
+func (s *ImportService) commitRow(kind string, r ImportRow) error {
+	return s.store.Transaction(func(tx repository.Store) error {
+		portfolio := NewPortfolioService(tx)
+		if _, err := portfolio.AddHolding(r.FundCode, r.FundName); err != nil {
+			return err
+		}
+		switch {
+		case kind == ImportKindHoldings:
+			return NewImportService(tx).importHoldingSnapshot(r)
+		case r.Type == "buy":
+			return portfolio.Buy(r.FundCode, r.Amount, r.NetValue, r.Fee, r.TradeDate)
+		default:
+			return portfolio.Sell(r.FundCode, r.Shares, r.NetValue, r.Fee, r.TradeDate)
+		}
+	})
+}
+
+// importHoldingSnapshot 按快照覆盖持仓份额和成本
+func (s *ImportService) importHoldingSnapshot(r ImportRow) error {
+	holding, err := s.holdings.GetHoldingByFundCode(r.FundCode)
+	if err != nil {
+		return err
+	}
+	holding.Shares = r.Shares
+	holding.Cost = r.Cost
+	holding.CostPrice = r.Cost / r.Shares
+	if r.NetValue > 0 {
+		holding.CurrentNav = r.NetValue
+	}
+	return s.holdings.SaveHolding(holding)
+}
+
+// GetImportHistory 获取最近的导入记录
+func (s *ImportService) GetImportHistory(limit int) ([]model.ImportRecord, error) {
+	return s.settings.GetImportRecords(limit)
+}
+
+// ImportFailures 解析导入记录中的失败明细，返回"第N行 代码: 原因"形式的文字
+func ImportFailures(record model.ImportRecord) []string {
+	var details importDetails
+	if err := json.Unmarshal([]byte(record.Details), &details); err != nil {
+		return nil
+	}
+	lines := make([]string, len(details.Failures))
+	for i, f := range details.Failures {
+		lines[i] = fmt.Sprintf("第%d行 %s: %s", f.Line, f.FundCode, f.Reason)
+	}
+	return lines
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 导入文件中的字段
+const (
+	ImportColDate   = "date"
+	ImportColCode   = "code"
+	ImportColName   = "name"
+	ImportColType   = "type"
+	ImportColAmount = "amount"
+	ImportColNav    = "nav"
+	ImportColShares = "shares"
+	ImportColFee    = "fee"
+	ImportColCost   = "cost"
+	ImportColValue  = "value"
+)
+
+// importFields 字段匹配顺序，靠前的字段优先占用表头
+var importFields = []string{
+	ImportColDate, ImportColCode, ImportColName, ImportColType, ImportColAmount,
+	ImportColNav, ImportColShares, ImportColFee, ImportColCost, ImportColValue,
+}
+
+// ImportSource 导入来源及其表头映射，Columns为各字段可能的表头名称
+type ImportSource struct {
+	Key     string
+	Name    string
+	Columns map[string][]string
+}
+
+// 导入来源标识
+const (
+	ImportSourceAuto    = ""
+	ImportSourceAlipay  = "alipay"
+	ImportSourceTTFund  = "ttfund"
+	ImportSourceDanjuan = "danjuan"
+	ImportSourceBank    = "bank"
+	ImportSourceGeneric = "generic"
+)
+
+// 各来源通用的持仓字段表头
+var holdingColumns = map[string][]string{
+	ImportColShares: {"持有份额", "持仓份额", "可用份额", "份额"},
+	ImportColCost:   {"持仓成本", "持有成本", "投入金额", "成本金额", "买入金额"},
+	ImportColValue:  {"持仓市值", "参考市值", "最新市值", "持有金额", "市值", "资产"},
+	ImportColNav:    {"最新净值", "单位净值", "参考净值", "净值"},
+}
+
+// ImportSources 支持的导入来源，按界面显示顺序
+var ImportSources = []ImportSource{
+	{Key: ImportSourceAlipay, Name: "支付宝", Columns: map[string][]string{
+		ImportColDate:   {"交易时间", "交易日期", "确认日期"},
+		ImportColCode:   {"基金代码", "产品代码"},
+		ImportColName:   {"基金名称", "产品名称", "商品名称"},
+		ImportColType:   {"交易类型", "业务类型", "收/支"},
+		ImportColAmount: {"交易金额", "确认金额", "金额"},
+		ImportColNav:    {"确认净值", "成交净值"},
+		ImportColShares: {"确认份额", "成交份额"},
+		ImportColFee:    {"手续费", "服务费"},
+	}},
+	{Key: ImportSourceTTFund, Name: "天天基金", Columns: map[string][]string{
+		ImportColDate:   {"确认日期", "申请日期", "交易日期"},
+		ImportColCode:   {"基金代码"},
+		ImportColName:   {"基金名称", "基金简称"},
+		ImportColType:   {"业务类型", "交易类型"},
+		ImportColAmount: {"确认金额", "申请金额", "交易金额"},
+		ImportColNav:    {"确认净值", "成交净值"},
+		ImportColShares: {"确认份额", "申请份额"},
+		ImportColFee:    {"手续费"},
+	}},
+	{Key: ImportSourceDanjuan, Name: "蛋卷基金", Columns: map[string][]string{
+		ImportColDate:   {"交易时间", "确认时间", "交易日期"},
+		ImportColCode:   {"基金代码"},
+		ImportColName:   {"基金名称"},
+		ImportColType:   {"交易类型", "操作类型"},
+		ImportColAmount: {"交易金额", "确认金额"},
+		ImportColNav:    {"确认净值", "成交净值"},
+		ImportColShares: {"确认份额", "交易份额"},
+		ImportColFee:    {"手续费", "交易费用"},
+	}},
+	{Key: ImportSourceBank, Name: "银行APP", Columns: map[string][]string{
+		ImportColDate:   {"交易日期", "确认日期", "交易时间"},
+		ImportColCode:   {"产品代码", "基金代码"},
+		ImportColName:   {"产品名称", "基金名称"},
+		ImportColType:   {"交易类型", "业务名称", "业务类型"},
+		ImportColAmount: {"交易金额", "确认金额", "发生金额"},
+		ImportColNav:    {"单位净值", "确认净值", "成交价格"},
+		ImportColShares: {"确认份额", "交易份额", "成交份额"},
+		ImportColFee:    {"手续费", "费用"},
+	}},
+	{Key: ImportSourceGeneric, Name: "通用模板", Columns: map[string][]string{
+		ImportColDate:   {"日期", "交易日期"},
+		ImportColCode:   {"代码", "基金代码"},
+		ImportColName:   {"名称", "基金名称"},
+		ImportColType:   {"类型", "交易类型"},
+		ImportColAmount: {"金额", "交易金额"},
+		ImportColNav:    {"净值", "成交净值"},
+		ImportColShares: {"份额", "成交份额"},
+		ImportColFee:    {"手续费"},
+	}},
+}
+
+// GetImportSource 按标识查找导入来源
+func GetImportSource(key string) (ImportSource, bool) {
+	for _, s := range ImportSources {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return ImportSource{}, false
+}
+
+// headerNoise 表头中的单位和空白，匹配前去除
+var headerNoise = regexp.MustCompile(`[\s　]|[(（][^)）]*[)）]`)
+
+// normalizeHeader 规范化表头：去掉空白和括号内的单位
+func normalizeHeader(h string) string {
+	return headerNoise.ReplaceAllString(strings.TrimSpace(h), "")
+}
+
+// mapColumns 按来源的表头映射得到字段到列下标的对应，每个字段取第一个匹配的别名；
+// score按匹配字段数计分，命中靠前的别名得分更高，用于在多个来源间识别
+func (s ImportSource) mapColumns(header []string, holdings bool) (map[string]int, int) {
+	columns := s.Columns
+	if holdings {
+		columns = make(map[string][]string)
+		for _, field := range []string{ImportColCode, ImportColName} {
+			columns[field] = s.Columns[field]
+		}
+		for field, aliases := range holdingColumns {
+			columns[field] = aliases
+		}
+	}
+
+	normalized := make([]string, len(header))
+	for i, h := range header {
+		normalized[i] = normalizeHeader(h)
+	}
+	mapping := make(map[string]int)
+	used := make(map[int]bool)
+	score := 0
+	for _, field := range importFields {
+		for rank, alias := range columns[field] {
+			found := false
+			for i, h := range normalized {
+				if h == alias && !used[i] {
+					mapping[field] = i
+					used[i] = true
+					found = true
+					break
+				}
+			}
+			if found {
+				score += 10 - rank
+				break
+			}
+		}
+	}
+	return mapping, score
+}
+
+// detectLayout 在前几行中查找表头，返回来源、表头行号、列映射以及是否为持仓快照
+func detectLayout(rows [][]string, sourceKey string) (ImportSource, int, map[string]int, bool) {
+	candidates := ImportSources
+	if s, ok := GetImportSource(sourceKey); ok {
+		candidates = []ImportSource{s}
+	}
+
+	var best ImportSource
+	bestRow, bestScore := -1, 0
+	var bestMapping map[string]int
+	bestHoldings := false
+	for i := 0; i < len(rows) && i < 20; i++ {
+		for _, s := range candidates {
+			for _, holdings := range []bool{false, true} {
+				mapping, score := s.mapColumns(rows[i], holdings)
+				if !holdings {
+					if _, ok := mapping[ImportColType]; !ok {
+						continue
+					}
+				} else if _, ok := mapping[ImportColShares]; !ok {
+					continue
+				}
+				if _, ok := mapping[ImportColCode]; !ok {
+					if _, ok := mapping[ImportColName]; !ok {
+						continue
+					}
+				}
+				if score > bestScore {
+					best, bestRow, bestScore, bestMapping, bestHoldings = s, i, score, mapping, holdings
+				}
+			}
+		}
+	}
+	return best, bestRow, bestMapping, bestHoldings
+}
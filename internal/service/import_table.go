@@ -0,0 +1,178 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// ReadImportTable 按扩展名读取CSV或XLSX文件为二维表，XLSX只读取第一个工作表
+func ReadImportTable(filename string, data []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".txt":
+		return readCSV(data)
+	case ".xlsx":
+		return readXLSX(data)
+	case ".xls":
+		return nil, fmt.Errorf("不支持旧版Excel(.xls)，请另存为.xlsx或.csv")
+	}
+	return nil, fmt.Errorf("不支持的文件类型: %s", filepath.Ext(filename))
+}
+
+// readCSV 读取CSV，自动去除BOM并识别GBK编码，支持逗号和制表符分隔
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("无法识别文件编码: %v", err)
+		}
+		data = decoded
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(firstLine, []byte("\t")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = '\t'
+	}
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV解析失败: %v", err)
+	}
+	return rows, nil
+}
+
+// xlsxSharedStrings 共享字符串表
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+// xlsxSheet 工作表
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX 解析XLSX的第一个工作表，日期单元格保留为Excel序列号，由日期解析处理
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("XLSX文件损坏: %v", err)
+	}
+
+	files := make(map[string]*zip.File)
+	var sheets []string
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if strings.HasPrefix(f.Name, "xl/worksheets/") && strings.HasSuffix(f.Name, ".xml") {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX中没有工作表")
+	}
+	// sheet1.xml在sheet10.xml之前
+	sort.Slice(sheets, func(i, j int) bool {
+		if len(sheets[i]) != len(sheets[j]) {
+			return len(sheets[i]) < len(sheets[j])
+		}
+		return sheets[i] < sheets[j]
+	})
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst xlsxSharedStrings
+		if err := decodeZipXML(f, &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.Items {
+			text := si.Text
+			for _, r := range si.Runs {
+				text += r.Text
+			}
+			shared = append(shared, text)
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := decodeZipXML(files[sheets[0]], &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, r := range sheet.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			switch c.Type {
+			case "s":
+				if idx, err := strconv.Atoi(c.Value); err == nil && idx < len(shared) {
+					row[col] = shared[idx]
+				}
+			case "inlineStr":
+				row[col] = c.Inline
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeZipXML 解码压缩包内的XML文件
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("XLSX解析失败(%s): %v", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex 单元格引用(如"AB12")对应的列下标，从0开始
+func columnIndex(ref string) int {
+	col := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+	}
+	return col - 1
+}
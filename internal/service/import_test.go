@@ -0,0 +1,187 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func TestParseTradeRow(t *testing.T) {
+	tests := []struct {
+		name       string
+		cols       map[string]string
+		wantStatus string
+		wantMsg    string
+		check      func(t *testing.T, r ImportRow)
+	}{
+		{
+			name:       "买入",
+			cols:       map[string]string{"code": "5827", "type": "基金申购", "date": "2024/1/15", "amount": "¥1,000.00", "nav": "2.0000", "fee": "1.5"},
+			wantStatus: ImportRowOK,
+			check: func(t *testing.T, r ImportRow) {
+				if r.FundCode != "005827" || r.Type != "buy" || r.Amount != 1000 || r.NetValue != 2 || r.Fee != 1.5 {
+					t.Fatalf("row: %+v", r)
+				}
+			},
+		},
+		{
+			name:       "买入按份额补全净值",
+			cols:       map[string]string{"name": "易方达蓝筹(005827)", "type": "定投", "date": "2024-01-15", "amount": "1001", "shares": "500份"},
+			wantStatus: ImportRowOK,
+			check: func(t *testing.T, r ImportRow) {
+				if r.FundCode != "005827" || r.FundName != "易方达蓝筹" || !almostEqual(r.NetValue, 2.002) {
+					t.Fatalf("row: %+v", r)
+				}
+			},
+		},
+		{
+			name:       "赎回按金额补全份额",
+			cols:       map[string]string{"code": "005827", "type": "赎回", "date": "20240115", "amount": "-999", "nav": "2", "fee": "1"},
+			wantStatus: ImportRowOK,
+			check: func(t *testing.T, r ImportRow) {
+				if r.Type != "sell" || r.Amount != 999 || !almostEqual(r.Shares, 500) {
+					t.Fatalf("row: %+v", r)
+				}
+			},
+		},
+		{
+			name:       "撤单忽略",
+			cols:       map[string]string{"code": "005827", "type": "申购撤单", "date": "2024-01-15", "amount": "1000"},
+			wantStatus: ImportRowSkipped,
+			wantMsg:    "不导入的记录",
+		},
+		{
+			name:       "未知类型",
+			cols:       map[string]string{"code": "005827", "type": "转托管", "date": "2024-01-15", "amount": "1000", "nav": "1"},
+			wantStatus: ImportRowInvalid,
+			wantMsg:    "未知的交易类型",
+		},
+		{
+			name:       "金额格式错误",
+			cols:       map[string]string{"code": "005827", "type": "买入", "date": "2024-01-15", "amount": "一千", "nav": "1"},
+			wantStatus: ImportRowInvalid,
+			wantMsg:    "金额格式错误",
+		},
+		{
+			name:       "赎回缺少份额",
+			cols:       map[string]string{"code": "005827", "type": "卖出", "date": "2024-01-15", "amount": "1000"},
+			wantStatus: ImportRowInvalid,
+			wantMsg:    "缺少赎回份额",
+		},
+		{
+			name:       "缺少日期",
+			cols:       map[string]string{"code": "005827", "type": "买入", "amount": "1000", "nav": "1"},
+			wantStatus: ImportRowInvalid,
+			wantMsg:    "缺少交易日期",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := parseTradeRow(func(col string) string { return tt.cols[col] })
+			if r.Status != tt.wantStatus || !strings.Contains(r.Message, tt.wantMsg) {
+				t.Fatalf("want %s %q, got %s %q", tt.wantStatus, tt.wantMsg, r.Status, r.Message)
+			}
+			if tt.check != nil {
+				tt.check(t, r)
+			}
+		})
+	}
+}
+
+func TestParseImportNumber(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    float64
+		wantErr bool
+	}{
+		{text: "1,234.56", want: 1234.56},
+		{text: "￥1，000元", want: 1000},
+		{text: "+12.5份", want: 12.5},
+		{text: "-3.2", want: -3.2},
+		{text: "--", want: 0},
+		{text: " ", want: 0},
+		{text: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseImportNumber(tt.text)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseImportNumber(%q) = %v, %v", tt.text, got, err)
+		}
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	want := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	for _, text := range []string{"2024-01-15", "2024/1/15", "2024.01.15", "20240115", "2024年1月15日", "45306"} {
+		got, err := parseImportDate(text)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseImportDate(%q) = %v, %v", text, got, err)
+		}
+	}
+	if got, err := parseImportDate("2024-01-15 14:30"); err != nil || got.Hour() != 14 {
+		t.Errorf("带时间的日期: %v, %v", got, err)
+	}
+	for _, text := range []string{"", "15/01/2024", "昨天"} {
+		if _, err := parseImportDate(text); err == nil {
+			t.Errorf("parseImportDate(%q) 应返回错误", text)
+		}
+	}
+}
+
+func TestMarkDuplicateTrades(t *testing.T) {
+	store := newTestStore(t)
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	if err := store.SaveTransaction(&model.Transaction{FundCode: "005827", Type: "buy", Amount: 1000, TradeDate: day}); err != nil {
+		t.Fatal(err)
+	}
+	// 卖出按份额比较，已有记录的金额为扣费后金额
+	if err := store.SaveTransaction(&model.Transaction{FundCode: "005827", Type: "sell", Amount: 995, Shares: 500, TradeDate: day}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []ImportRow{
+		{Status: ImportRowOK, FundCode: "005827", Type: "buy", Amount: 1000, TradeDate: day},
+		{Status: ImportRowOK, FundCode: "005827", Type: "sell", Amount: 1000, Shares: 500, TradeDate: day},
+		{Status: ImportRowOK, FundCode: "005827", Type: "buy", Amount: 2000, TradeDate: day},
+		{Status: ImportRowOK, FundCode: "005827", Type: "buy", Amount: 2000, TradeDate: day},
+		{Status: ImportRowInvalid, FundCode: "005827", Type: "buy", Amount: 1000, TradeDate: day},
+	}
+	NewImportService(store).markDuplicateTrades(rows)
+
+	want := []string{ImportRowDuplicate, ImportRowDuplicate, ImportRowOK, ImportRowDuplicate, ImportRowInvalid}
+	for i, r := range rows {
+		if r.Status != want[i] {
+			t.Errorf("row %d: want %s, got %s", i, want[i], r.Status)
+		}
+	}
+}
+
+func TestImportCommitRollsBackFailedRow(t *testing.T) {
+	store := newTestStore(t)
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	preview := &ImportPreview{
+		Kind: ImportKindTrades,
+		Rows: []ImportRow{
+			{Line: 2, Status: ImportRowOK, FundCode: "005827", FundName: "易方达蓝筹", Type: "buy", Amount: 1000, NetValue: 2, TradeDate: day},
+			// 未持有的基金不能卖出
+			{Line: 3, Status: ImportRowOK, FundCode: "110011", FundName: "易方达优质", Type: "sell", Shares: 100, NetValue: 1, TradeDate: day},
+		},
+	}
+
+	record, err := NewImportService(store).Commit(preview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.SuccessCount != 1 || record.FailedCount != 1 {
+		t.Fatalf("want 1 success 1 failed, got %d/%d", record.SuccessCount, record.FailedCount)
+	}
+	if failures := ImportFailures(*record); len(failures) != 1 || !strings.HasPrefix(failures[0], "第3行 110011") {
+		t.Fatalf("failures: %v", failures)
+	}
+	holdings, _ := store.GetAllHoldings()
+	if len(holdings) != 1 || holdings[0].FundCode != "005827" {
+		t.Fatalf("失败的行不应留下空持仓: %+v", holdings)
+	}
+}
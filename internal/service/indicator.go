@@ -2,6 +2,7 @@ package service
 
 import (
 	"math"
+	"sort"
 )
 
 // IndicatorService 技术指标计算服务
@@ -14,13 +15,21 @@ func GetIndicatorService() *IndicatorService {
 	return indicatorService
 }
 
+// tradingDaysPerYear 每年交易日数，用于年化
+const tradingDaysPerYear = 250
+
 // MACDResult MACD计算结果
 type MACDResult struct {
 	MACD   []float64
 	Signal []float64
 	Hist   []float64
+	// DEA 与prices等长的信号线，前macdWarmup个值为NaN
+	DEA []float64
 }
 
+// macdWarmup DEA有效前的数据个数：慢线26日加信号线9日
+const macdWarmup = 26 + 9 - 2
+
 // CalculateEMA 计算指数移动平均线
 func (i *IndicatorService) CalculateEMA(prices []float64, period int) []float64 {
 	if len(prices) < period {
@@ -58,11 +67,15 @@ func (i *IndicatorService) CalculateMACD(prices []float64) *MACDResult {
 
 	signal := i.CalculateEMA(macd[25:], 9)
 	hist := make([]float64, len(prices))
+	dea := warmupSeries(len(prices))
 	for j := 0; j < len(signal); j++ {
 		hist[j+25] = macd[j+25] - signal[j]
+		if j+25 >= macdWarmup {
+			dea[j+25] = signal[j]
+		}
 	}
 
-	return &MACDResult{MACD: macd, Signal: signal, Hist: hist}
+	return &MACDResult{MACD: macd, Signal: signal, Hist: hist, DEA: dea}
 }
 
 // CalculateRSI 计算RSI指标
@@ -189,3 +202,188 @@ func (i *IndicatorService) CalculateBollinger(prices []float64, period int) *Bol
 	}
 	return &BollingerResult{Upper: upper, Middle: middle, Lower: lower}
 }
+
+// 以下指标返回与prices等长的序列，数据不足的预热区间填充NaN，可用IsWarmup判断
+
+// IsWarmup 判断指标值是否处于预热区间(无效)
+func IsWarmup(v float64) bool {
+	return math.IsNaN(v)
+}
+
+// warmupSeries 创建全部为NaN的序列
+func warmupSeries(n int) []float64 {
+	s := make([]float64, n)
+	for j := range s {
+		s[j] = math.NaN()
+	}
+	return s
+}
+
+// CalculateSMA 计算简单移动平均，前period-1个值为NaN
+func (i *IndicatorService) CalculateSMA(prices []float64, period int) []float64 {
+	sma := warmupSeries(len(prices))
+	if period <= 0 {
+		return sma
+	}
+	sum := 0.0
+	for j, p := range prices {
+		sum += p
+		if j >= period {
+			sum -= prices[j-period]
+		}
+		if j >= period-1 {
+			sma[j] = sum / float64(period)
+		}
+	}
+	return sma
+}
+
+// CalculateWMA 计算线性加权移动平均，越近的数据权重越大，前period-1个值为NaN
+func (i *IndicatorService) CalculateWMA(prices []float64, period int) []float64 {
+	wma := warmupSeries(len(prices))
+	if period <= 0 {
+		return wma
+	}
+	denom := float64(period*(period+1)) / 2
+	for j := period - 1; j < len(prices); j++ {
+		sum := 0.0
+		for p := 0; p < period; p++ {
+			sum += prices[j-p] * float64(period-p)
+		}
+		wma[j] = sum / denom
+	}
+	return wma
+}
+
+// CalculateATR 计算净值版ATR：净值无高低价，以日变动绝对值作为真实波幅，按Wilder方法平滑，前period个值为NaN
+func (i *IndicatorService) CalculateATR(prices []float64, period int) []float64 {
+	atr := warmupSeries(len(prices))
+	if period <= 0 || len(prices) <= period {
+		return atr
+	}
+	sum := 0.0
+	for j := 1; j <= period; j++ {
+		sum += math.Abs(prices[j] - prices[j-1])
+	}
+	atr[period] = sum / float64(period)
+	for j := period + 1; j < len(prices); j++ {
+		tr := math.Abs(prices[j] - prices[j-1])
+		atr[j] = (atr[j-1]*float64(period-1) + tr) / float64(period)
+	}
+	return atr
+}
+
+// CalculateRollingReturn 计算滚动收益率(%)：相对period个交易日前的涨跌幅，前period个值为NaN
+func (i *IndicatorService) CalculateRollingReturn(prices []float64, period int) []float64 {
+	ret := warmupSeries(len(prices))
+	if period <= 0 {
+		return ret
+	}
+	for j := period; j < len(prices); j++ {
+		if prices[j-period] > 0 {
+			ret[j] = (prices[j]/prices[j-period] - 1) * 100
+		}
+	}
+	return ret
+}
+
+// CalculateRollingSharpe 计算滚动夏普比率(年化)，riskFree为年化无风险利率(%)，前period个值为NaN，窗口内无波动时也为NaN
+func (i *IndicatorService) CalculateRollingSharpe(prices []float64, period int, riskFree float64) []float64 {
+	sharpe := warmupSeries(len(prices))
+	if period < 2 {
+		return sharpe
+	}
+	daily := make([]float64, len(prices))
+	for j := 1; j < len(prices); j++ {
+		if prices[j-1] > 0 {
+			daily[j] = prices[j]/prices[j-1] - 1
+		}
+	}
+	rf := riskFree / 100 / tradingDaysPerYear
+	for j := period; j < len(prices); j++ {
+		window := daily[j-period+1 : j+1]
+		mean := 0.0
+		for _, r := range window {
+			mean += r
+		}
+		mean /= float64(period)
+		variance := 0.0
+		for _, r := range window {
+			variance += (r - mean) * (r - mean)
+		}
+		std := math.Sqrt(variance / float64(period-1))
+		if std > 0 {
+			sharpe[j] = (mean - rf) / std * math.Sqrt(tradingDaysPerYear)
+		}
+	}
+	return sharpe
+}
+
+// CalculateDrawdown 计算回撤序列(%)：相对此前最高点的跌幅，为0或负数，无预热区间
+func (i *IndicatorService) CalculateDrawdown(prices []float64) []float64 {
+	dd := make([]float64, len(prices))
+	peak := 0.0
+	for j, p := range prices {
+		if p > peak {
+			peak = p
+		}
+		if peak > 0 {
+			dd[j] = (p/peak - 1) * 100
+		}
+	}
+	return dd
+}
+
+// PercentileBandResult 滚动分位数通道
+type PercentileBandResult struct {
+	Upper  []float64
+	Median []float64
+	Lower  []float64
+}
+
+// CalculatePercentileBands 计算滚动分位数通道，lower/upper为分位(0~100)，前period-1个值为NaN
+func (i *IndicatorService) CalculatePercentileBands(prices []float64, period int, lower, upper float64) *PercentileBandResult {
+	n := len(prices)
+	result := &PercentileBandResult{Upper: warmupSeries(n), Median: warmupSeries(n), Lower: warmupSeries(n)}
+	if period <= 0 {
+		return result
+	}
+	window := make([]float64, period)
+	for j := period - 1; j < n; j++ {
+		copy(window, prices[j-period+1:j+1])
+		sort.Float64s(window)
+		result.Upper[j] = percentile(window, upper)
+		result.Median[j] = percentile(window, 50)
+		result.Lower[j] = percentile(window, lower)
+	}
+	return result
+}
+
+// CalculateMomentum 计算动量：当前净值与period个交易日前净值之差，前period个值为NaN
+func (i *IndicatorService) CalculateMomentum(prices []float64, period int) []float64 {
+	mom := warmupSeries(len(prices))
+	if period <= 0 {
+		return mom
+	}
+	for j := period; j < len(prices); j++ {
+		mom[j] = prices[j] - prices[j-period]
+	}
+	return mom
+}
+
+// percentile 已排序序列的分位数(线性插值)
+func percentile(sorted []float64, pct float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	pos := pct / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	if lo < 0 {
+		lo = 0
+	}
+	if hi >= len(sorted) {
+		hi = len(sorted) - 1
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+// linearPrices 从start起每日变动step的净值序列
+func linearPrices(n int, start, step float64) []float64 {
+	prices := make([]float64, n)
+	for j := range prices {
+		prices[j] = start + float64(j)*step
+	}
+	return prices
+}
+
+func TestCalculateMACDAlignsDEA(t *testing.T) {
+	prices := make([]float64, 60)
+	for j := range prices {
+		prices[j] = 1 + 0.05*math.Sin(float64(j)/5)
+	}
+	macd := GetIndicatorService().CalculateMACD(prices)
+	if len(macd.DEA) != len(prices) {
+		t.Fatalf("DEA应与净值等长，got %d", len(macd.DEA))
+	}
+	for j, v := range macd.DEA {
+		if j < macdWarmup {
+			if !IsWarmup(v) {
+				t.Fatalf("DEA[%d]应处于预热区间，got %v", j, v)
+			}
+			continue
+		}
+		// 柱线=DIF-DEA
+		if !almostEqual(macd.MACD[j]-v, macd.Hist[j]) {
+			t.Fatalf("DEA[%d]未对齐: DIF %v DEA %v 柱 %v", j, macd.MACD[j], v, macd.Hist[j])
+		}
+	}
+}
+
+func TestMACDVoteCross(t *testing.T) {
+	// 长期下跌后反弹，DIF在零轴下上穿DEA
+	prices := append(linearPrices(60, 2, -0.01), linearPrices(5, 1.42, 0.03)...)
+	var vote float64
+	var reason string
+	for n := 61; n <= len(prices); n++ {
+		if v, r, ok := macdVote(prices[:n]); ok && v == 1 {
+			vote, reason = v, r
+			break
+		}
+	}
+	if vote != 1 || reason != "MACD零轴下金叉" {
+		t.Fatalf("want 零轴下金叉, got %v %q", vote, reason)
+	}
+
+	if _, _, ok := macdVote(prices[:macdWarmup+crossLookback]); ok {
+		t.Fatal("DEA有效数据不足时不应投票")
+	}
+}
+
+// assertSeries 逐项比较序列，want中的NaN表示预热区间
+func assertSeries(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: want %d values, got %d", name, len(want), len(got))
+	}
+	for j := range want {
+		if IsWarmup(want[j]) != IsWarmup(got[j]) || !IsWarmup(want[j]) && !almostEqual(got[j], want[j]) {
+			t.Fatalf("%s[%d]: want %v, got %v", name, j, want[j], got[j])
+		}
+	}
+}
+
+func TestWarmupIndicators(t *testing.T) {
+	nan := math.NaN()
+	ind := GetIndicatorService()
+	prices := []float64{1, 2, 3, 2, 4}
+
+	assertSeries(t, "SMA", ind.CalculateSMA(prices, 3), []float64{nan, nan, 2, 7.0 / 3, 3})
+	assertSeries(t, "WMA", ind.CalculateWMA(prices, 3), []float64{nan, nan, 14.0 / 6, 14.0 / 6, 19.0 / 6})
+	// 日变动1,1,1,2：首值为均值1，其后Wilder平滑
+	assertSeries(t, "ATR", ind.CalculateATR(prices, 2), []float64{nan, nan, 1, 1, 1.5})
+	assertSeries(t, "RollingReturn", ind.CalculateRollingReturn(prices, 2), []float64{nan, nan, 200, 0, 100.0 / 3})
+	assertSeries(t, "Momentum", ind.CalculateMomentum(prices, 2), []float64{nan, nan, 2, 0, 1})
+	assertSeries(t, "Drawdown", ind.CalculateDrawdown(prices), []float64{0, 0, 0, -100.0 / 3, 0})
+
+	band := ind.CalculatePercentileBands(prices, 3, 0, 100)
+	assertSeries(t, "Lower", band.Lower, []float64{nan, nan, 1, 2, 2})
+	assertSeries(t, "Median", band.Median, []float64{nan, nan, 2, 2, 3})
+	assertSeries(t, "Upper", band.Upper, []float64{nan, nan, 3, 3, 4})
+
+	if atr := ind.CalculateATR(prices[:2], 2); !IsWarmup(atr[1]) {
+		t.Fatalf("数据不足时ATR应全部为NaN: %v", atr)
+	}
+}
+
+func TestCalculateRollingSharpe(t *testing.T) {
+	ind := GetIndicatorService()
+	// 日收益率交替为+2%和-1%
+	prices := []float64{1}
+	for j := 0; j < 10; j++ {
+		step := 1.02
+		if j%2 == 1 {
+			step = 0.99
+		}
+		prices = append(prices, prices[len(prices)-1]*step)
+	}
+
+	sharpe := ind.CalculateRollingSharpe(prices, 4, 0)
+	for j := 0; j < 4; j++ {
+		if !IsWarmup(sharpe[j]) {
+			t.Fatalf("sharpe[%d]应处于预热区间", j)
+		}
+	}
+	// 4日窗口均值0.5%，样本标准差sqrt(0.0003)
+	want := 0.005 / math.Sqrt(0.0003) * math.Sqrt(tradingDaysPerYear)
+	if !almostEqual(sharpe[10], want) {
+		t.Fatalf("want %v, got %v", want, sharpe[10])
+	}
+	if withRf := ind.CalculateRollingSharpe(prices, 4, 2.5); withRf[10] >= sharpe[10] {
+		t.Fatalf("无风险利率应降低夏普比率: %v >= %v", withRf[10], sharpe[10])
+	}
+
+	flat := ind.CalculateRollingSharpe(linearPrices(10, 1, 0), 4, 0)
+	if !IsWarmup(flat[9]) {
+		t.Fatalf("无波动时应为NaN, got %v", flat[9])
+	}
+}
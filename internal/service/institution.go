@@ -6,9 +6,20 @@ import (
 )
 
 // InstitutionService 主力动向服务
-type InstitutionService struct{}
+type InstitutionService struct {
+	holdings repository.HoldingRepository
+	fundInfo repository.FundInfoRepository
+}
+
+// NewInstitutionService 使用指定仓储创建主力动向服务
+func NewInstitutionService(store repository.Store) *InstitutionService {
+	return &InstitutionService{
+		holdings: store,
+		fundInfo: store,
+	}
+}
 
-var institutionService = &InstitutionService{}
+var institutionService = NewInstitutionService(repository.Default())
 
 // GetInstitutionService 获取主力动向服务实例
 func GetInstitutionService() *InstitutionService {
@@ -17,7 +28,7 @@ func GetInstitutionService() *InstitutionService {
 
 // GetInstitutionHolding 获取机构持仓
 func (i *InstitutionService) GetInstitutionHolding(fundCode string) (*model.InstitutionHolding, error) {
-	return repository.GetInstitutionHolding(fundCode)
+	return i.fundInfo.GetInstitutionHolding(fundCode)
 }
 
 // RefreshInstitutionHolding 从API刷新机构持仓数据
@@ -29,7 +40,7 @@ func (i *InstitutionService) RefreshInstitutionHolding(fundCode string) (*model.
 	}
 
 	// 保存到数据库
-	if err := repository.SaveInstitutionHolding(holding); err != nil {
+	if err := i.fundInfo.SaveInstitutionHolding(holding); err != nil {
 		return nil, err
 	}
 
@@ -38,7 +49,7 @@ func (i *InstitutionService) RefreshInstitutionHolding(fundCode string) (*model.
 
 // GetAllHoldingsInstitution 获取所有持仓的机构持仓数据
 func (i *InstitutionService) GetAllHoldingsInstitution() ([]model.InstitutionHolding, error) {
-	holdings, err := repository.GetAllHoldings()
+	holdings, err := i.holdings.GetAllHoldings()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// IntradayService 盘中估值序列服务
+type IntradayService struct {
+	funds    repository.FundRepository
+	holdings repository.HoldingRepository
+	history  repository.HistoryRepository
+}
+
+// NewIntradayService 使用指定仓储创建盘中估值服务
+func NewIntradayService(store repository.Store) *IntradayService {
+	return &IntradayService{
+		funds:    store,
+		holdings: store,
+		history:  store,
+	}
+}
+
+var intradayService = NewIntradayService(repository.Default())
+
+// GetIntradayService 获取盘中估值服务实例
+func GetIntradayService() *IntradayService {
+	return intradayService
+}
+
+// PortfolioIntradayPoint 组合盘中估算收益点
+type PortfolioIntradayPoint struct {
+	Time       time.Time
+	Profit     float64 // 估算当日盈亏(元)
+	ProfitRate float64 // 估算当日收益率(%)
+}
+
+// EstimateComparison 单日估值与实际净值对比
+type EstimateComparison struct {
+	Date         time.Time
+	EstValue     float64 // 收盘前最后一次估值
+	EstGrowth    float64
+	ActualNav    float64 // 公布净值
+	ActualGrowth float64
+	Error        float64 // 估算涨跌幅 - 实际涨跌幅(百分点)
+}
+
+// EstimateAccuracy 估值准确度统计
+type EstimateAccuracy struct {
+	FundCode         string
+	FundName         string
+	Samples          int
+	MeanAbsError     float64 // 平均绝对误差(百分点)
+	MaxAbsError      float64 // 最大绝对误差(百分点)
+	DirectionHitRate float64 // 涨跌方向一致率(%)
+	Comparisons      []EstimateComparison
+}
+
+// GetFundIntraday 获取基金某一天的盘中估值序列
+func (s *IntradayService) GetFundIntraday(fundCode string, date time.Time) ([]model.IntradayEstimate, error) {
+	return s.history.GetIntradayEstimates(fundCode, date)
+}
+
+// GetPortfolioIntradayPnL 按持仓份额汇总各基金的盘中估值，得到组合当日估算盈亏序列
+func (s *IntradayService) GetPortfolioIntradayPnL(date time.Time) ([]PortfolioIntradayPoint, error) {
+	holdings, err := s.holdings.GetAllHoldings()
+	if err != nil {
+		return nil, err
+	}
+
+	type fundSeries struct {
+		shares    float64
+		estimates []model.IntradayEstimate
+	}
+	var series []fundSeries
+	timeSet := make(map[int64]time.Time)
+	for _, h := range holdings {
+		if h.Shares <= 0 {
+			continue
+		}
+		estimates, err := s.history.GetIntradayEstimates(h.FundCode, date)
+		if err != nil || len(estimates) == 0 {
+			continue
+		}
+		series = append(series, fundSeries{shares: h.Shares, estimates: estimates})
+		for _, e := range estimates {
+			timeSet[e.EstTime.Unix()] = e.EstTime
+		}
+	}
+
+	times := make([]time.Time, 0, len(timeSet))
+	for _, t := range timeSet {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	// 各基金取不晚于该时刻的最近一次估值，尚无估值的基金按未变动计
+	cursors := make([]int, len(series))
+	points := make([]PortfolioIntradayPoint, 0, len(times))
+	for _, t := range times {
+		profit, baseValue := 0.0, 0.0
+		for i, fs := range series {
+			for cursors[i]+1 < len(fs.estimates) && !fs.estimates[cursors[i]+1].EstTime.After(t) {
+				cursors[i]++
+			}
+			e := fs.estimates[cursors[i]]
+			baseValue += fs.shares * e.BaseNav
+			if !e.EstTime.After(t) {
+				profit += fs.shares * (e.EstValue - e.BaseNav)
+			}
+		}
+
+		point := PortfolioIntradayPoint{Time: t, Profit: math.Round(profit*100) / 100}
+		if baseValue > 0 {
+			point.ProfitRate = profit / baseValue * 100
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// EvaluateAccuracy 对比最近若干交易日的收盘估值与公布净值，统计估值准确度
+func (s *IntradayService) EvaluateAccuracy(fundCode string, days int) (*EstimateAccuracy, error) {
+	finals, err := s.history.GetFinalEstimates(fundCode, days)
+	if err != nil {
+		return nil, err
+	}
+
+	accuracy := &EstimateAccuracy{FundCode: fundCode}
+	if fund, err := s.funds.GetFund(fundCode); err == nil {
+		accuracy.FundName = fund.Name
+	}
+	if len(finals) == 0 {
+		return accuracy, nil
+	}
+
+	// 确保本地有对应日期的净值
+	if _, err := s.history.GetNetValueOnDate(fundCode, finals[0].EstTime); err != nil {
+		if histories, err := GetFundAPI().GetFundHistory(fundCode, days+5); err == nil {
+			s.history.SaveNewNetValueHistories(fundCode, histories)
+		}
+	}
+
+	hits := 0
+	totalAbs := 0.0
+	for _, e := range finals {
+		actual, err := s.history.GetNetValueOnDate(fundCode, e.EstTime)
+		if err != nil {
+			continue // 净值尚未公布
+		}
+
+		comparison := EstimateComparison{
+			Date:         actual.Date,
+			EstValue:     e.EstValue,
+			EstGrowth:    e.EstGrowth,
+			ActualNav:    actual.NetValue,
+			ActualGrowth: actual.DayGrowth,
+			Error:        e.EstGrowth - actual.DayGrowth,
+		}
+		accuracy.Comparisons = append(accuracy.Comparisons, comparison)
+
+		absErr := math.Abs(comparison.Error)
+		totalAbs += absErr
+		if absErr > accuracy.MaxAbsError {
+			accuracy.MaxAbsError = absErr
+		}
+		if (e.EstGrowth >= 0) == (actual.DayGrowth >= 0) {
+			hits++
+		}
+	}
+
+	accuracy.Samples = len(accuracy.Comparisons)
+	if accuracy.Samples > 0 {
+		accuracy.MeanAbsError = totalAbs / float64(accuracy.Samples)
+		accuracy.DirectionHitRate = float64(hits) / float64(accuracy.Samples) * 100
+	}
+	return accuracy, nil
+}
+
+// GetHoldingsAccuracy 统计所有持仓基金的估值准确度
+func (s *IntradayService) GetHoldingsAccuracy(days int) ([]EstimateAccuracy, error) {
+	holdings, err := s.holdings.GetAllHoldings()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []EstimateAccuracy
+	for _, h := range holdings {
+		accuracy, err := s.EvaluateAccuracy(h.FundCode, days)
+		if err != nil {
+			continue
+		}
+		if accuracy.FundName == "" {
+			accuracy.FundName = h.FundName
+		}
+		results = append(results, *accuracy)
+	}
+	return results, nil
+}
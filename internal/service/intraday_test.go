@@ -0,0 +1,91 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func TestPortfolioIntradayPnL(t *testing.T) {
+	store := newTestStore(t)
+	s := NewIntradayService(store)
+	day := time.Date(2024, 3, 8, 0, 0, 0, 0, time.Local)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	for _, h := range []model.Holding{{FundCode: "000001", Shares: 1000}, {FundCode: "000002", Shares: 500}} {
+		if err := store.SaveHolding(&h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []model.IntradayEstimate{
+		{FundCode: "000001", EstTime: at(9, 30), EstValue: 1.01, BaseNav: 1},
+		{FundCode: "000001", EstTime: at(10, 30), EstValue: 1.02, BaseNav: 1},
+		{FundCode: "000002", EstTime: at(10, 0), EstValue: 1.9, BaseNav: 2},
+		// 其他日期的估值不计入
+		{FundCode: "000002", EstTime: at(-14, 0), EstValue: 3, BaseNav: 2},
+	} {
+		if err := store.SaveIntradayEstimate(&e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	points, err := s.GetPortfolioIntradayPnL(day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 9:30时000002尚无估值按未变动计，基准市值为1000*1+500*2
+	want := []struct {
+		time   time.Time
+		profit float64
+	}{{at(9, 30), 10}, {at(10, 0), -40}, {at(10, 30), -30}}
+	if len(points) != len(want) {
+		t.Fatalf("want %d points, got %+v", len(want), points)
+	}
+	for i, w := range want {
+		if !points[i].Time.Equal(w.time) || !almostEqual(points[i].Profit, w.profit) || !almostEqual(points[i].ProfitRate, w.profit/2000*100) {
+			t.Errorf("point %d: want %v %v, got %+v", i, w.time, w.profit, points[i])
+		}
+	}
+}
+
+func TestEvaluateAccuracy(t *testing.T) {
+	store := newTestStore(t)
+	s := NewIntradayService(store)
+	today := time.Now()
+	navDate := func(daysAgo int) time.Time {
+		d := today.AddDate(0, 0, -daysAgo)
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	estTime := func(daysAgo int) time.Time {
+		d := today.AddDate(0, 0, -daysAgo)
+		return time.Date(d.Year(), d.Month(), d.Day(), 14, 50, 0, 0, time.Local)
+	}
+
+	if err := store.SaveNetValueHistories([]model.NetValueHistory{
+		{FundCode: "000001", Date: navDate(1), NetValue: 1.01, DayGrowth: 1},
+		{FundCode: "000001", Date: navDate(2), NetValue: 1, DayGrowth: -0.5},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []model.IntradayEstimate{
+		{FundCode: "000001", EstTime: estTime(2).Add(-time.Hour), EstGrowth: 1},
+		{FundCode: "000001", EstTime: estTime(2), EstGrowth: 0.3},
+		{FundCode: "000001", EstTime: estTime(1), EstGrowth: 1.5},
+	} {
+		if err := store.SaveIntradayEstimate(&e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	accuracy, err := s.EvaluateAccuracy("000001", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每天取收盘前最后一次估值：误差0.5和0.8，方向一致1天
+	if accuracy.Samples != 2 || !almostEqual(accuracy.MeanAbsError, 0.65) || !almostEqual(accuracy.MaxAbsError, 0.8) || accuracy.DirectionHitRate != 50 {
+		t.Fatalf("accuracy: %+v", accuracy)
+	}
+}
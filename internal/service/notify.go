@@ -0,0 +1,572 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// 通知渠道类型
+const (
+	ChannelDesktop = "desktop" // 桌面通知
+	ChannelEmail   = "email"   // 邮件(SMTP)
+	ChannelWebhook = "webhook" // HTTP Webhook
+	ChannelLog     = "log"     // 写入日志文件
+)
+
+// ChannelTypes 通知渠道类型(按显示顺序)
+var ChannelTypes = []string{ChannelDesktop, ChannelEmail, ChannelWebhook, ChannelLog}
+
+// ChannelTypeNames 通知渠道类型显示名称
+var ChannelTypeNames = map[string]string{
+	ChannelDesktop: "桌面通知",
+	ChannelEmail:   "邮件",
+	ChannelWebhook: "Webhook",
+	ChannelLog:     "日志文件",
+}
+
+// Webhook消息格式
+const (
+	WebhookGeneric  = "generic"  // 通用JSON
+	WebhookWeCom    = "wecom"    // 企业微信群机器人
+	WebhookDingTalk = "dingtalk" // 钉钉群机器人
+	WebhookFeishu   = "feishu"   // 飞书群机器人
+)
+
+// WebhookFormats Webhook消息格式(按显示顺序)
+var WebhookFormats = []string{WebhookGeneric, WebhookWeCom, WebhookDingTalk, WebhookFeishu}
+
+// WebhookFormatNames Webhook消息格式显示名称
+var WebhookFormatNames = map[string]string{
+	WebhookGeneric:  "通用JSON",
+	WebhookWeCom:    "企业微信",
+	WebhookDingTalk: "钉钉",
+	WebhookFeishu:   "飞书",
+}
+
+// 投递状态
+const (
+	DeliveryPending   = "pending" // 等待推送(免打扰或汇总中)
+	DeliverySent      = "sent"    // 全部渠道成功
+	DeliveryPartial   = "partial" // 部分渠道失败
+	DeliveryFailed    = "failed"  // 全部渠道失败
+	DeliveryNoChannel = "none"    // 无可用渠道
+)
+
+// DeliveryStatusNames 投递状态显示名称
+var DeliveryStatusNames = map[string]string{
+	DeliveryPending:   "待推送",
+	DeliverySent:      "已送达",
+	DeliveryPartial:   "部分失败",
+	DeliveryFailed:    "失败",
+	DeliveryNoChannel: "无渠道",
+}
+
+// 发送失败时的重试次数和首次重试间隔(之后翻倍)
+var (
+	notifyMaxAttempts = 3
+	notifyRetryDelay  = 2 * time.Second
+)
+
+// smtpTimeout 邮件服务器连接和单次发送会话的超时
+var smtpTimeout = 15 * time.Second
+
+// Notifier 通知渠道发送接口
+type Notifier interface {
+	Notify(title, message string, alert *model.AlertHistory) error
+}
+
+// NotifyService 通知渠道服务
+type NotifyService struct {
+	alerts repository.AlertRepository
+	notify repository.NotifyRepository
+
+	mu            sync.Mutex
+	desktopSender func(title, message string)
+}
+
+// NewNotifyService 使用指定仓储创建通知服务
+func NewNotifyService(store repository.Store) *NotifyService {
+	return &NotifyService{
+		alerts: store,
+		notify: store,
+	}
+}
+
+var notifyService = NewNotifyService(repository.Default())
+
+// GetNotifyService 获取通知服务实例
+func GetNotifyService() *NotifyService {
+	return notifyService
+}
+
+// SetDesktopSender 设置桌面通知的发送函数，由界面层提供
+func (n *NotifyService) SetDesktopSender(sender func(title, message string)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.desktopSender = sender
+}
+
+// GetChannels 获取所有通知渠道
+func (n *NotifyService) GetChannels() ([]model.NotifyChannel, error) {
+	return n.notify.GetAllNotifyChannels()
+}
+
+// SaveChannel 校验并保存通知渠道
+func (n *NotifyService) SaveChannel(channel *model.NotifyChannel) error {
+	if err := validateChannel(channel); err != nil {
+		return err
+	}
+	return n.notify.SaveNotifyChannel(channel)
+}
+
+// DeleteChannel 删除通知渠道
+func (n *NotifyService) DeleteChannel(id uint) error {
+	return n.notify.DeleteNotifyChannel(id)
+}
+
+// TestChannel 向渠道发送一条测试消息(不重试)
+func (n *NotifyService) TestChannel(channel *model.NotifyChannel) error {
+	if err := validateChannel(channel); err != nil {
+		return err
+	}
+	notifier, err := n.notifierFor(channel)
+	if err != nil {
+		return err
+	}
+	alert := &model.AlertHistory{
+		FundName:    "测试",
+		AlertType:   AlertTypeNavUpdate,
+		Message:     "这是一条测试提醒，收到说明通知渠道配置正确",
+		TriggeredAt: time.Now(),
+	}
+	return notifier.Notify("基金助手测试提醒", alert.Message, alert)
+}
+
+// validateChannel 校验渠道必填配置
+func validateChannel(channel *model.NotifyChannel) error {
+	if strings.TrimSpace(channel.Name) == "" {
+		return errors.New("请输入渠道名称")
+	}
+	switch channel.Type {
+	case ChannelDesktop, ChannelLog:
+	case ChannelEmail:
+		if channel.SMTPHost == "" || channel.SMTPPort <= 0 {
+			return errors.New("请填写SMTP服务器和端口")
+		}
+		if len(splitList(channel.To)) == 0 {
+			return errors.New("请填写收件人")
+		}
+	case ChannelWebhook:
+		u, err := url.Parse(channel.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("Webhook地址无效")
+		}
+		if _, ok := WebhookFormatNames[channel.WebhookFormat]; !ok {
+			return fmt.Errorf("不支持的Webhook格式: %s", channel.WebhookFormat)
+		}
+	default:
+		return fmt.Errorf("不支持的渠道类型: %s", channel.Type)
+	}
+	return nil
+}
+
+// ChannelIDs 解析规则的渠道ID列表
+func ChannelIDs(channels string) []uint {
+	var ids []uint
+	for _, s := range splitList(channels) {
+		if id, err := strconv.ParseUint(s, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// JoinChannelIDs 将渠道ID列表格式化为规则的渠道字段
+func JoinChannelIDs(ids []uint) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// channelsFor 获取一组提醒应推送的渠道：各规则指定渠道的并集，
+// 规则未指定时为全部启用渠道；未配置任何渠道时使用桌面通知
+func (n *NotifyService) channelsFor(alerts []model.AlertHistory) []model.NotifyChannel {
+	all, _ := n.notify.GetAllNotifyChannels()
+	if len(all) == 0 {
+		return []model.NotifyChannel{{Name: ChannelTypeNames[ChannelDesktop], Type: ChannelDesktop, Enabled: true}}
+	}
+
+	wanted := make(map[uint]bool)
+	useAll := false
+	for _, alert := range alerts {
+		rule, err := n.alerts.GetAlertRule(alert.RuleID)
+		if err != nil || rule.Channels == "" {
+			useAll = true
+			break
+		}
+		for _, id := range ChannelIDs(rule.Channels) {
+			wanted[id] = true
+		}
+	}
+
+	var channels []model.NotifyChannel
+	for _, c := range all {
+		if c.Enabled && (useAll || wanted[c.ID]) {
+			channels = append(channels, c)
+		}
+	}
+	return channels
+}
+
+// Deliver 将通知发往各渠道，失败时按间隔重试，返回投递状态、失败原因和发送次数
+func (n *NotifyService) Deliver(channels []model.NotifyChannel, alert *model.AlertHistory) (string, string, int) {
+	if len(channels) == 0 {
+		return DeliveryNoChannel, "", 0
+	}
+
+	title := "基金提醒 - " + AlertTypeNames[alert.AlertType]
+	attempts, failed := 0, 0
+	var errs []string
+	for i := range channels {
+		channel := &channels[i]
+		tries, err := n.sendWithRetry(channel, title, alert.Message, alert)
+		attempts += tries
+		if err != nil {
+			failed++
+			errs = append(errs, fmt.Sprintf("%s: %v", channel.Name, err))
+		}
+	}
+
+	status := DeliverySent
+	switch {
+	case failed == len(channels):
+		status = DeliveryFailed
+	case failed > 0:
+		status = DeliveryPartial
+	}
+	errMsg := strings.Join(errs, "; ")
+	if len([]rune(errMsg)) > 500 {
+		errMsg = string([]rune(errMsg)[:500])
+	}
+	return status, errMsg, attempts
+}
+
+// SendMessage 将提醒以外的消息(如持仓报告)发往指定渠道，邮件和日志发送完整内容，
+// 其余渠道发送摘要，返回投递状态和失败原因
+func (n *NotifyService) SendMessage(ids []uint, title, summary, full string) (string, string) {
+	var channels []model.NotifyChannel
+	for _, id := range ids {
+		if channel, err := n.notify.GetNotifyChannel(id); err == nil && channel.Enabled {
+			channels = append(channels, *channel)
+		}
+	}
+	if len(channels) == 0 {
+		return DeliveryNoChannel, ""
+	}
+
+	alert := &model.AlertHistory{FundName: title, Message: summary, TriggeredAt: time.Now()}
+	failed := 0
+	var errs []string
+	for i := range channels {
+		channel := &channels[i]
+		message := summary
+		if channel.Type == ChannelEmail || channel.Type == ChannelLog {
+			message = full
+		}
+		if _, err := n.sendWithRetry(channel, title, message, alert); err != nil {
+			failed++
+			errs = append(errs, fmt.Sprintf("%s: %v", channel.Name, err))
+		}
+	}
+
+	switch {
+	case failed == len(channels):
+		return DeliveryFailed, strings.Join(errs, "; ")
+	case failed > 0:
+		return DeliveryPartial, strings.Join(errs, "; ")
+	}
+	return DeliverySent, ""
+}
+
+// sendWithRetry 向单个渠道发送，失败时按间隔翻倍重试，返回发送次数
+func (n *NotifyService) sendWithRetry(channel *model.NotifyChannel, title, message string, alert *model.AlertHistory) (int, error) {
+	notifier, err := n.notifierFor(channel)
+	if err != nil {
+		return 0, err
+	}
+	delay := notifyRetryDelay
+	for try := 1; ; try++ {
+		if err = notifier.Notify(title, message, alert); err == nil || try >= notifyMaxAttempts {
+			return try, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// notifierFor 根据渠道配置创建发送器
+func (n *NotifyService) notifierFor(channel *model.NotifyChannel) (Notifier, error) {
+	switch channel.Type {
+	case ChannelDesktop:
+		n.mu.Lock()
+		sender := n.desktopSender
+		n.mu.Unlock()
+		if sender == nil {
+			return nil, errors.New("桌面通知不可用")
+		}
+		return desktopNotifier{send: sender}, nil
+	case ChannelEmail:
+		return emailNotifier{channel: channel}, nil
+	case ChannelWebhook:
+		return webhookNotifier{channel: channel}, nil
+	case ChannelLog:
+		return logNotifier{path: channel.LogPath}, nil
+	}
+	return nil, fmt.Errorf("不支持的渠道类型: %s", channel.Type)
+}
+
+// desktopNotifier 桌面通知
+type desktopNotifier struct {
+	send func(title, message string)
+}
+
+func (d desktopNotifier) Notify(title, message string, alert *model.AlertHistory) error {
+	d.send(title, message)
+	return nil
+}
+
+// emailNotifier SMTP邮件通知
+type emailNotifier struct {
+	channel *model.NotifyChannel
+}
+
+func (e emailNotifier) Notify(title, message string, alert *model.AlertHistory) error {
+	c := e.channel
+	from := c.From
+	if from == "" {
+		from = c.Username
+	}
+	to := splitList(c.To)
+
+	var msg strings.Builder
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", title) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	body := base64.StdEncoding.EncodeToString([]byte(formatAlertText(message, alert)))
+	for len(body) > 76 {
+		msg.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	msg.WriteString(body + "\r\n")
+
+	// 465端口为SSL直连，其余端口服务器支持时升级STARTTLS
+	addr := net.JoinHostPort(c.SMTPHost, strconv.Itoa(c.SMTPPort))
+	var conn net.Conn
+	var err error
+	if c.SMTPPort == 465 {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpTimeout}, "tcp", addr, &tls.Config{ServerName: c.SMTPHost})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, smtpTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	// 整个会话的读写期限，服务器无响应时不会一直阻塞提醒发送
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, c.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if c.SMTPPort != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: c.SMTPHost}); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Username != "" {
+		auth := smtp.PlainAuth("", c.Username, c.Password, c.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg.String())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// webhookNotifier HTTP Webhook通知
+type webhookNotifier struct {
+	channel *model.NotifyChannel
+}
+
+func (w webhookNotifier) Notify(title, message string, alert *model.AlertHistory) error {
+	c := w.channel
+	target := c.WebhookURL
+	text := title + "\n" + formatAlertText(message, alert)
+
+	var payload map[string]interface{}
+	switch c.WebhookFormat {
+	case WebhookWeCom, WebhookDingTalk:
+		payload = map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": text},
+		}
+		if c.WebhookFormat == WebhookDingTalk && c.Secret != "" {
+			ts := time.Now().UnixMilli()
+			sign := hmacBase64(c.Secret, fmt.Sprintf("%d\n%s", ts, c.Secret))
+			sep := "?"
+			if strings.Contains(target, "?") {
+				sep = "&"
+			}
+			target += fmt.Sprintf("%stimestamp=%d&sign=%s", sep, ts, url.QueryEscape(sign))
+		}
+	case WebhookFeishu:
+		payload = map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]string{"text": text},
+		}
+		if c.Secret != "" {
+			ts := time.Now().Unix()
+			payload["timestamp"] = strconv.FormatInt(ts, 10)
+			payload["sign"] = hmacBase64(fmt.Sprintf("%d\n%s", ts, c.Secret), "")
+		}
+	default:
+		payload = map[string]interface{}{
+			"title":       title,
+			"message":     message,
+			"fundCode":    alert.FundCode,
+			"fundName":    alert.FundName,
+			"alertType":   alert.AlertType,
+			"value":       alert.Value,
+			"triggeredAt": alert.TriggeredAt.Format(time.RFC3339),
+		}
+	}
+
+	resp, err := client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(payload).
+		Post(target)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() < 200 || resp.StatusCode() >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode())
+	}
+	if c.WebhookFormat == WebhookGeneric {
+		return nil
+	}
+
+	// 机器人接口HTTP状态为200时通过返回码表示失败
+	var result struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return nil
+	}
+	if result.ErrCode != nil && *result.ErrCode != 0 {
+		return fmt.Errorf("错误码%d: %s", *result.ErrCode, result.ErrMsg)
+	}
+	if result.Code != nil && *result.Code != 0 {
+		return fmt.Errorf("错误码%d: %s", *result.Code, result.Msg)
+	}
+	return nil
+}
+
+// logNotifier 追加写入日志文件
+type logNotifier struct {
+	path string
+}
+
+func (l logNotifier) Notify(title, message string, alert *model.AlertHistory) error {
+	path := l.path
+	if path == "" {
+		path = filepath.Join(repository.DataDir(), "alerts.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line := strings.ReplaceAll(message, "\n", " | ")
+	_, err = fmt.Fprintf(f, "%s [%s] %s\n", alert.TriggeredAt.Format("2006-01-02 15:04:05"), title, line)
+	return err
+}
+
+// formatAlertText 通知正文：提醒内容和触发时间
+func formatAlertText(message string, alert *model.AlertHistory) string {
+	return fmt.Sprintf("%s\n触发时间: %s", message, alert.TriggeredAt.Format("2006-01-02 15:04:05"))
+}
+
+// hmacBase64 HMAC-SHA256签名并Base64编码
+func hmacBase64(key, data string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// splitList 按中英文逗号、分号拆分并去除空项
+func splitList(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '，' || r == ';' || r == '；'
+	})
+	var items []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			items = append(items, f)
+		}
+	}
+	return items
+}
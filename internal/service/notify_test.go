@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func testAlert() *model.AlertHistory {
+	return &model.AlertHistory{
+		FundCode:    "000001",
+		FundName:    "测试基金",
+		AlertType:   "price_below",
+		Value:       1.2345,
+		TriggeredAt: time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC),
+	}
+}
+
+// fakeSMTP 在本地端口上模拟SMTP服务器，silent为true时接受连接后不响应
+type fakeSMTP struct {
+	ln       net.Listener
+	silent   bool
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func startFakeSMTP(t *testing.T, silent bool) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln, silent: silent, done: make(chan struct{})}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTP) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	if s.silent {
+		io.Copy(io.Discard, conn) // 直到客户端超时断开
+		return
+	}
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		s.commands = append(s.commands, cmd)
+		switch strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]) {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	server := startFakeSMTP(t, false)
+	channel := &model.NotifyChannel{
+		Type:     ChannelEmail,
+		SMTPHost: "127.0.0.1",
+		SMTPPort: server.port(),
+		From:     "jijin@example.com",
+		To:       "a@example.com, b@example.com",
+	}
+
+	if err := (emailNotifier{channel: channel}).Notify("净值提醒", "跌破1.25", testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	<-server.done
+
+	want := []string{"MAIL FROM:<jijin@example.com>", "RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>", "DATA", "QUIT"}
+	got := strings.Join(server.commands, "\n")
+	for _, cmd := range want {
+		if !strings.Contains(got, cmd) {
+			t.Fatalf("缺少命令 %q，got:\n%s", cmd, got)
+		}
+	}
+	if !strings.Contains(server.data, "To: a@example.com, b@example.com\r\n") || !strings.Contains(server.data, "Subject: =?UTF-8?b?") {
+		t.Fatalf("邮件头不正确:\n%s", server.data)
+	}
+	body := server.data[strings.Index(server.data, "\r\n\r\n")+4:]
+	text, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	if err != nil || !strings.Contains(string(text), "跌破1.25") {
+		t.Fatalf("正文应为base64编码的提醒内容，got %q, %v", text, err)
+	}
+}
+
+func TestEmailNotifierTimeout(t *testing.T) {
+	defer func(d time.Duration) { smtpTimeout = d }(smtpTimeout)
+	smtpTimeout = 200 * time.Millisecond
+
+	server := startFakeSMTP(t, true)
+	channel := &model.NotifyChannel{Type: ChannelEmail, SMTPHost: "127.0.0.1", SMTPPort: server.port(), From: "a@example.com", To: "b@example.com"}
+
+	start := time.Now()
+	err := (emailNotifier{channel: channel}).Notify("净值提醒", "跌破1.25", testAlert())
+	if err == nil {
+		t.Fatal("服务器无响应时应返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("应在超时后返回，耗时%v", elapsed)
+	}
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Fatalf("want timeout error, got %v", err)
+	}
+}
+
+func TestEmailNotifierUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	channel := &model.NotifyChannel{Type: ChannelEmail, SMTPHost: "127.0.0.1", SMTPPort: port, From: "a@example.com", To: "b@example.com"}
+	if err := (emailNotifier{channel: channel}).Notify("净值提醒", "跌破1.25", testAlert()); err == nil {
+		t.Fatal("连接被拒绝时应返回错误")
+	}
+}
+
+// webhookRequest 测试服务器收到的请求
+type webhookRequest struct {
+	query url.Values
+	body  map[string]interface{}
+}
+
+func startWebhook(t *testing.T, status int, response string) (*httptest.Server, chan webhookRequest) {
+	t.Helper()
+	requests := make(chan webhookRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests <- webhookRequest{query: r.URL.Query(), body: body}
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		secret   string
+		status   int
+		response string
+		wantErr  string
+		check    func(t *testing.T, req webhookRequest)
+	}{
+		{
+			name: "通用JSON", format: WebhookGeneric, status: http.StatusOK,
+			check: func(t *testing.T, req webhookRequest) {
+				if req.body["fundCode"] != "000001" || req.body["title"] != "净值提醒" || req.body["value"] != 1.2345 {
+					t.Fatalf("payload: %v", req.body)
+				}
+				if req.body["triggeredAt"] != "2024-01-15T14:30:00Z" {
+					t.Fatalf("triggeredAt: %v", req.body["triggeredAt"])
+				}
+			},
+		},
+		{
+			name: "通用JSON服务器错误", format: WebhookGeneric, status: http.StatusInternalServerError,
+			wantErr: "HTTP 500",
+		},
+		{
+			name: "企业微信", format: WebhookWeCom, status: http.StatusOK, response: `{"errcode":0,"errmsg":"ok"}`,
+			check: func(t *testing.T, req webhookRequest) {
+				text, _ := req.body["text"].(map[string]interface{})
+				if req.body["msgtype"] != "text" || !strings.HasPrefix(text["content"].(string), "净值提醒\n") {
+					t.Fatalf("payload: %v", req.body)
+				}
+			},
+		},
+		{
+			name: "企业微信返回错误码", format: WebhookWeCom, status: http.StatusOK, response: `{"errcode":93000,"errmsg":"invalid webhook url"}`,
+			wantErr: "错误码93000",
+		},
+		{
+			name: "钉钉加签", format: WebhookDingTalk, secret: "SECabc", status: http.StatusOK, response: `{"errcode":0}`,
+			check: func(t *testing.T, req webhookRequest) {
+				ts, err := strconv.ParseInt(req.query["timestamp"][0], 10, 64)
+				if err != nil || time.Since(time.UnixMilli(ts)) > time.Minute {
+					t.Fatalf("timestamp: %v", req.query)
+				}
+				want := hmacBase64("SECabc", req.query["timestamp"][0]+"\nSECabc")
+				if req.query["sign"][0] != want {
+					t.Fatalf("sign: want %q, got %q", want, req.query["sign"][0])
+				}
+			},
+		},
+		{
+			name: "飞书返回错误码", format: WebhookFeishu, status: http.StatusOK, response: `{"code":19021,"msg":"sign match fail"}`,
+			wantErr: "错误码19021",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := startWebhook(t, tt.status, tt.response)
+			channel := &model.NotifyChannel{Type: ChannelWebhook, WebhookURL: server.URL + "/hook", WebhookFormat: tt.format, Secret: tt.secret}
+
+			err := (webhookNotifier{channel: channel}).Notify("净值提醒", "跌破1.25", testAlert())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("want error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, <-requests)
+		})
+	}
+}
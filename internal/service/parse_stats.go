@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// driftThreshold 连续解析失败达到该次数视为接口格式已变化
+const driftThreshold = 3
+
+// ParseStat 单个数据接口的解析统计
+type ParseStat struct {
+	Endpoint    string
+	Responses   int    // 解析的响应数
+	Failures    int    // 整体解析失败的响应数
+	BadRows     int    // 无法解析而跳过的数据行
+	Rejected    int    // 数值不合理被拒绝的记录数
+	Consecutive int    // 连续解析失败的响应数
+	LastError   string // 最近一次解析失败或拒绝的原因
+	LastErrorAt time.Time
+	LastOKAt    time.Time
+}
+
+// Drifting 连续解析失败，接口格式可能已变化
+func (s ParseStat) Drifting() bool {
+	return s.Consecutive >= driftThreshold
+}
+
+// parseMonitor 解析失败计数，数据源格式变化时能及时发现而不是把错误数据写入数据库
+type parseMonitor struct {
+	mu    sync.Mutex
+	stats map[string]*ParseStat
+}
+
+var parseStats = &parseMonitor{stats: make(map[string]*ParseStat)}
+
+func (m *parseMonitor) stat(endpoint string) *ParseStat {
+	s, ok := m.stats[endpoint]
+	if !ok {
+		s = &ParseStat{Endpoint: endpoint}
+		m.stats[endpoint] = s
+	}
+	return s
+}
+
+// record 记录一次响应的解析结果，rowErrs为跳过的数据行。ErrNoData视为正常响应
+func (m *parseMonitor) record(endpoint string, err error, rowErrs []error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.stat(endpoint)
+	now := time.Now()
+	s.Responses++
+	s.BadRows += len(rowErrs)
+	if err != nil && !errors.Is(err, ErrNoData) {
+		s.Failures++
+		s.Consecutive++
+		s.LastError, s.LastErrorAt = err.Error(), now
+		return
+	}
+	s.Consecutive = 0
+	s.LastOKAt = now
+	if len(rowErrs) > 0 {
+		s.LastError, s.LastErrorAt = rowErrs[0].Error(), now
+	}
+}
+
+// reject 记录数值不合理被拒绝的记录
+func (m *parseMonitor) reject(endpoint string, errs ...error) {
+	if len(errs) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.stat(endpoint)
+	s.Rejected += len(errs)
+	s.LastError, s.LastErrorAt = errs[len(errs)-1].Error(), time.Now()
+}
+
+// ParseStats 各数据接口的解析统计
+func (f *FundAPI) ParseStats() []ParseStat {
+	parseStats.mu.Lock()
+	defer parseStats.mu.Unlock()
+
+	stats := make([]ParseStat, len(Endpoints))
+	for i, endpoint := range Endpoints {
+		stats[i] = *parseStats.stat(endpoint)
+	}
+	return stats
+}
+
+// DriftingEndpoints 连续解析失败的接口名称
+func (f *FundAPI) DriftingEndpoints() []string {
+	var names []string
+	for _, s := range f.ParseStats() {
+		if s.Drifting() {
+			names = append(names, EndpointNames[s.Endpoint])
+		}
+	}
+	return names
+}
+
+// ResetParseStats 清零解析统计
+func (f *FundAPI) ResetParseStats() {
+	parseStats.mu.Lock()
+	defer parseStats.mu.Unlock()
+	parseStats.stats = make(map[string]*ParseStat)
+}
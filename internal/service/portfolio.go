@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"math"
+	"sort"
 	"time"
 
 	"jijin/internal/model"
@@ -9,9 +11,26 @@ import (
 )
 
 // PortfolioService 持仓服务
-type PortfolioService struct{}
+type PortfolioService struct {
+	holdings     repository.HoldingRepository
+	transactions repository.TransactionRepository
+	funds        repository.FundRepository
+	alerts       repository.AlertRepository
+	history      repository.HistoryRepository
+}
 
-var portfolioService = &PortfolioService{}
+// NewPortfolioService 使用指定仓储创建持仓服务
+func NewPortfolioService(store repository.Store) *PortfolioService {
+	return &PortfolioService{
+		holdings:     store,
+		transactions: store,
+		funds:        store,
+		alerts:       store,
+		history:      store,
+	}
+}
+
+var portfolioService = NewPortfolioService(repository.Default())
 
 // GetPortfolioService 获取持仓服务实例
 func GetPortfolioService() *PortfolioService {
@@ -21,7 +40,7 @@ func GetPortfolioService() *PortfolioService {
 // AddHolding 添加持仓
 func (p *PortfolioService) AddHolding(fundCode, fundName string) (*model.Holding, error) {
 	// 检查是否已存在
-	existing, _ := repository.GetHoldingByFundCode(fundCode)
+	existing, _ := p.holdings.GetHoldingByFundCode(fundCode)
 	if existing != nil {
 		return existing, nil
 	}
@@ -33,7 +52,7 @@ func (p *PortfolioService) AddHolding(fundCode, fundName string) (*model.Holding
 		Cost:     0,
 	}
 
-	if err := repository.SaveHolding(holding); err != nil {
+	if err := p.holdings.SaveHolding(holding); err != nil {
 		return nil, err
 	}
 
@@ -42,7 +61,15 @@ func (p *PortfolioService) AddHolding(fundCode, fundName string) (*model.Holding
 
 // Buy 买入
 func (p *PortfolioService) Buy(fundCode string, amount, netValue, fee float64, tradeDate time.Time) error {
-	holding, err := repository.GetHoldingByFundCode(fundCode)
+	switch {
+	case amount <= 0:
+		return errors.New("买入金额必须大于0")
+	case netValue <= 0:
+		return errors.New("净值必须大于0")
+	case fee < 0 || fee >= amount:
+		return errors.New("手续费必须在0和买入金额之间")
+	}
+	holding, err := p.holdings.GetHoldingByFundCode(fundCode)
 	if err != nil {
 		return errors.New("持仓不存在，请先添加持仓")
 	}
@@ -59,7 +86,7 @@ func (p *PortfolioService) Buy(fundCode string, amount, netValue, fee float64, t
 	}
 	holding.CurrentNav = netValue
 
-	if err := repository.SaveHolding(holding); err != nil {
+	if err := p.holdings.SaveHolding(holding); err != nil {
 		return err
 	}
 
@@ -75,12 +102,12 @@ func (p *PortfolioService) Buy(fundCode string, amount, netValue, fee float64, t
 		TradeDate: tradeDate,
 	}
 
-	return repository.SaveTransaction(tx)
+	return p.transactions.SaveTransaction(tx)
 }
 
 // Sell 卖出
 func (p *PortfolioService) Sell(fundCode string, shares, netValue, fee float64, tradeDate time.Time) error {
-	holding, err := repository.GetHoldingByFundCode(fundCode)
+	holding, err := p.holdings.GetHoldingByFundCode(fundCode)
 	if err != nil {
 		return errors.New("持仓不存在")
 	}
@@ -104,7 +131,7 @@ func (p *PortfolioService) Sell(fundCode string, shares, netValue, fee float64,
 	}
 	holding.CurrentNav = netValue
 
-	if err := repository.SaveHolding(holding); err != nil {
+	if err := p.holdings.SaveHolding(holding); err != nil {
 		return err
 	}
 
@@ -120,35 +147,38 @@ func (p *PortfolioService) Sell(fundCode string, shares, netValue, fee float64,
 		TradeDate: tradeDate,
 	}
 
-	return repository.SaveTransaction(tx)
+	return p.transactions.SaveTransaction(tx)
 }
 
 // GetAllHoldings 获取所有持仓
 func (p *PortfolioService) GetAllHoldings() ([]model.Holding, error) {
-	return repository.GetAllHoldings()
+	return p.holdings.GetAllHoldings()
 }
 
 // GetHolding 获取持仓详情
 func (p *PortfolioService) GetHolding(id uint) (*model.Holding, error) {
-	return repository.GetHolding(id)
+	return p.holdings.GetHolding(id)
 }
 
 // DeleteHolding 删除持仓
 func (p *PortfolioService) DeleteHolding(id uint) error {
-	return repository.DeleteHolding(id)
+	if err := p.holdings.DeleteHolding(id); err != nil {
+		return err
+	}
+	return p.alerts.DeleteAlertRulesByHoldingID(id)
 }
 
 // GetTransactions 获取交易记录
 func (p *PortfolioService) GetTransactions(fundCode string) ([]model.Transaction, error) {
 	if fundCode == "" {
-		return repository.GetAllTransactions()
+		return p.transactions.GetAllTransactions()
 	}
-	return repository.GetTransactionsByFundCode(fundCode)
+	return p.transactions.GetTransactionsByFundCode(fundCode)
 }
 
 // GetPortfolioSummary 获取持仓汇总
 func (p *PortfolioService) GetPortfolioSummary() (totalCost, totalValue, totalProfit float64, profitRate float64) {
-	holdings, err := repository.GetAllHoldings()
+	holdings, err := p.holdings.GetAllHoldings()
 	if err != nil {
 		return
 	}
@@ -165,3 +195,123 @@ func (p *PortfolioService) GetPortfolioSummary() (totalCost, totalValue, totalPr
 
 	return
 }
+
+// PortfolioValuePoint 组合某个净值日的市值和累计净投入
+type PortfolioValuePoint struct {
+	Date     time.Time
+	Value    float64
+	Invested float64
+}
+
+// FundValueSeries 单只持仓在各净值日的市值和累计净投入，与日期序列等长
+type FundValueSeries struct {
+	FundCode string
+	FundName string
+	Values   []float64
+	Invested []float64
+}
+
+// GetValueHistory 按交易记录和本地净值历史回溯最近days个净值日的组合市值
+func (p *PortfolioService) GetValueHistory(days int) ([]PortfolioValuePoint, error) {
+	dates, funds, err := p.GetFundValueHistory(days)
+	if err != nil {
+		return nil, err
+	}
+	return SumFundValues(dates, funds), nil
+}
+
+// SumFundValues 汇总各持仓的市值序列为组合市值序列
+func SumFundValues(dates []time.Time, funds []FundValueSeries) []PortfolioValuePoint {
+	points := make([]PortfolioValuePoint, len(dates))
+	for i, d := range dates {
+		points[i].Date = d
+		for _, f := range funds {
+			points[i].Value += f.Values[i]
+			points[i].Invested += f.Invested[i]
+		}
+	}
+	return points
+}
+
+// GetFundValueHistory 按交易记录和本地净值历史回溯最近days个净值日各持仓的市值；无交易记录的持仓按当前份额和成本计算
+func (p *PortfolioService) GetFundValueHistory(days int) ([]time.Time, []FundValueSeries, error) {
+	holdings, err := p.holdings.GetAllHoldings()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type fundTrack struct {
+		holding  model.Holding
+		navs     []model.NetValueHistory // 升序
+		txs      []model.Transaction     // 升序
+		navIdx   int
+		txIdx    int
+		shares   float64
+		invested float64
+		nav      float64
+	}
+	var tracks []*fundTrack
+	dateSet := make(map[string]time.Time)
+	for _, h := range holdings {
+		navs := ascendingHistory(p.history, h.FundCode)
+		if len(navs) > days {
+			navs = navs[len(navs)-days:]
+		}
+		if len(navs) == 0 {
+			continue
+		}
+		txs, _ := p.transactions.GetTransactionsByFundCode(h.FundCode)
+		sort.Slice(txs, func(i, j int) bool { return txs[i].TradeDate.Before(txs[j].TradeDate) })
+		tracks = append(tracks, &fundTrack{holding: h, navs: navs, txs: txs})
+		for _, n := range navs {
+			dateSet[dateKey(n.Date)] = n.Date
+		}
+	}
+
+	keys := make([]string, 0, len(dateSet))
+	for k := range dateSet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) > days {
+		keys = keys[len(keys)-days:]
+	}
+
+	dates := make([]time.Time, len(keys))
+	funds := make([]FundValueSeries, len(tracks))
+	for j, t := range tracks {
+		funds[j] = FundValueSeries{
+			FundCode: t.holding.FundCode,
+			FundName: t.holding.FundName,
+			Values:   make([]float64, len(keys)),
+			Invested: make([]float64, len(keys)),
+		}
+	}
+	for i, key := range keys {
+		dates[i] = dateSet[key]
+		for j, t := range tracks {
+			for t.navIdx < len(t.navs) && dateKey(t.navs[t.navIdx].Date) <= key {
+				t.nav = t.navs[t.navIdx].NetValue
+				t.navIdx++
+			}
+			if len(t.txs) == 0 {
+				t.shares = t.holding.Shares
+				t.invested = t.holding.Cost
+			}
+			for t.txIdx < len(t.txs) && dateKey(t.txs[t.txIdx].TradeDate) <= key {
+				tx := t.txs[t.txIdx]
+				if tx.Type == "sell" {
+					t.shares -= tx.Shares
+					t.invested -= tx.Amount
+				} else {
+					t.shares += tx.Shares
+					t.invested += tx.Amount
+				}
+				t.txIdx++
+			}
+			funds[j].Values[i] = math.Max(t.shares, 0) * t.nav
+			funds[j].Invested[i] = t.invested
+		}
+	}
+	return dates, funds, nil
+}
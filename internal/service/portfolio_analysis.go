@@ -0,0 +1,177 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 持仓分布维度
+const (
+	AllocationByFund    = "fund"
+	AllocationByType    = "type"
+	AllocationByAccount = "account"
+)
+
+// 未设置分类时的名称
+const (
+	unknownFundType = "未知类型"
+	defaultAccount  = "默认账户"
+)
+
+// AllocationItem 持仓分布中的一项
+type AllocationItem struct {
+	Label   string
+	Value   float64
+	Percent float64
+}
+
+// ProfitContribution 单只持仓对总盈亏的贡献
+type ProfitContribution struct {
+	FundCode string
+	FundName string
+	Profit   float64
+	Share    float64 // 占总盈亏绝对值之和的比例(%)
+}
+
+// MonthlyReturn 组合某月的时间加权收益
+type MonthlyReturn struct {
+	Year   int
+	Month  time.Month
+	Return float64 // %
+	Profit float64 // 月内盈亏(扣除买卖现金流)
+}
+
+// SetHoldingAccount 设置持仓所属账户
+func (p *PortfolioService) SetHoldingAccount(id uint, account string) error {
+	return p.holdings.UpdateHoldingAccount(id, strings.TrimSpace(account))
+}
+
+// GetAccounts 获取已使用的账户名称
+func (p *PortfolioService) GetAccounts() []string {
+	holdings, _ := p.holdings.GetAllHoldings()
+	seen := make(map[string]bool)
+	var accounts []string
+	for _, h := range holdings {
+		if h.Account != "" && !seen[h.Account] {
+			seen[h.Account] = true
+			accounts = append(accounts, h.Account)
+		}
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// GetAllocation 按基金、基金类型或账户汇总当前市值分布，按市值降序
+func (p *PortfolioService) GetAllocation(by string) ([]AllocationItem, error) {
+	holdings, err := p.holdings.GetAllHoldings()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64)
+	var order []string
+	total := 0.0
+	for _, h := range holdings {
+		value := h.MarketValue()
+		if value <= 0 {
+			continue
+		}
+		label := h.FundName
+		switch by {
+		case AllocationByType:
+			label = unknownFundType
+			if fund, err := p.funds.GetFund(h.FundCode); err == nil && fund.Type != "" {
+				label = fund.Type
+			}
+		case AllocationByAccount:
+			label = defaultAccount
+			if h.Account != "" {
+				label = h.Account
+			}
+		}
+		if _, ok := values[label]; !ok {
+			order = append(order, label)
+		}
+		values[label] += value
+		total += value
+	}
+
+	items := make([]AllocationItem, len(order))
+	for i, label := range order {
+		items[i] = AllocationItem{Label: label, Value: values[label], Percent: values[label] / total * 100}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Value > items[j].Value })
+	return items, nil
+}
+
+// GetProfitContributions 各持仓对总盈亏的贡献，盈利在前、亏损在后，均按绝对值降序
+func (p *PortfolioService) GetProfitContributions() ([]ProfitContribution, error) {
+	holdings, err := p.holdings.GetAllHoldings()
+	if err != nil {
+		return nil, err
+	}
+	sumAbs := 0.0
+	items := make([]ProfitContribution, 0, len(holdings))
+	for _, h := range holdings {
+		profit := h.Profit()
+		items = append(items, ProfitContribution{FundCode: h.FundCode, FundName: h.FundName, Profit: profit})
+		sumAbs += math.Abs(profit)
+	}
+	if sumAbs > 0 {
+		for i := range items {
+			items[i].Share = math.Abs(items[i].Profit) / sumAbs * 100
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if (items[i].Profit >= 0) != (items[j].Profit >= 0) {
+			return items[i].Profit >= 0
+		}
+		return math.Abs(items[i].Profit) > math.Abs(items[j].Profit)
+	})
+	return items, nil
+}
+
+// MonthlyReturns 按组合市值序列计算各月的时间加权收益：日收益扣除当日买卖现金流后逐日连乘
+func MonthlyReturns(points []PortfolioValuePoint) []MonthlyReturn {
+	var months []MonthlyReturn
+	growth := 1.0
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		flow := cur.Invested - prev.Invested
+		year, month, _ := cur.Date.Date()
+		if len(months) == 0 || months[len(months)-1].Year != year || months[len(months)-1].Month != month {
+			if len(months) > 0 {
+				months[len(months)-1].Return = (growth - 1) * 100
+			}
+			months = append(months, MonthlyReturn{Year: year, Month: month})
+			growth = 1
+		}
+		profit := cur.Value - prev.Value - flow
+		months[len(months)-1].Profit += profit
+		// 当日买入按当日净值计入，作为期初资金的一部分
+		if base := prev.Value + math.Max(flow, 0); base > 0 {
+			growth *= 1 + profit/base
+		}
+	}
+	if len(months) > 0 {
+		months[len(months)-1].Return = (growth - 1) * 100
+	}
+	return months
+}
+
+// YearlyReturns 由月度收益连乘得到各年收益(%)
+func YearlyReturns(months []MonthlyReturn) map[int]float64 {
+	growth := make(map[int]float64)
+	for _, m := range months {
+		if _, ok := growth[m.Year]; !ok {
+			growth[m.Year] = 1
+		}
+		growth[m.Year] *= 1 + m.Return/100
+	}
+	years := make(map[int]float64, len(growth))
+	for year, g := range growth {
+		years[year] = (g - 1) * 100
+	}
+	return years
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func TestAllocationAndContributions(t *testing.T) {
+	store := newTestStore(t)
+	p := NewPortfolioService(store)
+	for _, h := range []model.Holding{
+		{FundCode: "000001", FundName: "股票A", Shares: 1000, CurrentNav: 2, Cost: 1500, Account: "支付宝"},
+		{FundCode: "000002", FundName: "债券B", Shares: 500, CurrentNav: 2, Cost: 1200},
+		{FundCode: "000003", FundName: "股票C", Shares: 1000, CurrentNav: 1, Cost: 900, Account: "支付宝"},
+		// 已清仓的持仓不计入分布
+		{FundCode: "000004", FundName: "清仓D", CurrentNav: 1},
+	} {
+		if err := store.SaveHolding(&h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []model.Fund{{Code: "000001", Type: "股票型"}, {Code: "000003", Type: "股票型"}} {
+		if err := store.SaveFund(&f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		by   string
+		want []AllocationItem
+	}{
+		{by: AllocationByFund, want: []AllocationItem{{"股票A", 2000, 50}, {"债券B", 1000, 25}, {"股票C", 1000, 25}}},
+		{by: AllocationByType, want: []AllocationItem{{"股票型", 3000, 75}, {unknownFundType, 1000, 25}}},
+		{by: AllocationByAccount, want: []AllocationItem{{"支付宝", 3000, 75}, {defaultAccount, 1000, 25}}},
+	}
+	for _, tt := range tests {
+		items, err := p.GetAllocation(tt.by)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != len(tt.want) {
+			t.Fatalf("%s: want %v, got %v", tt.by, tt.want, items)
+		}
+		for i := range items {
+			if items[i].Label != tt.want[i].Label || !almostEqual(items[i].Value, tt.want[i].Value) || !almostEqual(items[i].Percent, tt.want[i].Percent) {
+				t.Errorf("%s item %d: want %+v, got %+v", tt.by, i, tt.want[i], items[i])
+			}
+		}
+	}
+
+	contributions, err := p.GetProfitContributions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 盈利在前，亏损在后
+	wantCodes := []string{"000001", "000003", "000004", "000002"}
+	wantShares := []float64{62.5, 12.5, 0, 25}
+	for i, c := range contributions {
+		if c.FundCode != wantCodes[i] || !almostEqual(c.Share, wantShares[i]) {
+			t.Errorf("contribution %d: want %s %.1f%%, got %+v", i, wantCodes[i], wantShares[i], c)
+		}
+	}
+
+	if accounts := p.GetAccounts(); len(accounts) != 1 || accounts[0] != "支付宝" {
+		t.Fatalf("accounts: %v", accounts)
+	}
+}
+
+func TestMonthlyReturns(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+	points := []PortfolioValuePoint{
+		{Date: day(1, 31), Value: 1000, Invested: 1000},
+		{Date: day(2, 1), Value: 1100, Invested: 1000},
+		// 当日买入500，扣除现金流后盈利50
+		{Date: day(2, 2), Value: 1650, Invested: 1500},
+		{Date: day(3, 1), Value: 1500, Invested: 1500},
+	}
+	months := MonthlyReturns(points)
+	if len(months) != 2 {
+		t.Fatalf("want 2 months, got %+v", months)
+	}
+	if months[0].Month != time.February || !almostEqual(months[0].Profit, 150) || !almostEqual(months[0].Return, (1.1*(1+50.0/1600)-1)*100) {
+		t.Errorf("二月: %+v", months[0])
+	}
+	if months[1].Month != time.March || !almostEqual(months[1].Profit, -150) || !almostEqual(months[1].Return, -150.0/1650*100) {
+		t.Errorf("三月: %+v", months[1])
+	}
+	if years := YearlyReturns(months); !almostEqual(years[2024], 3.125) {
+		t.Errorf("年收益: %v", years)
+	}
+}
+
+func TestFundValueHistory(t *testing.T) {
+	store := newTestStore(t)
+	p := NewPortfolioService(store)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	for _, h := range []model.Holding{
+		{FundCode: "000001", Shares: 600},
+		// 无交易记录按当前份额和成本计算
+		{FundCode: "000002", Shares: 100, Cost: 150},
+	} {
+		if err := store.SaveHolding(&h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tx := range []model.Transaction{
+		{FundCode: "000001", Type: "sell", Shares: 400, Amount: 500, TradeDate: day(6)},
+		{FundCode: "000001", Type: "buy", Shares: 1000, Amount: 1000, TradeDate: day(4)},
+	} {
+		if err := store.SaveTransaction(&tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SaveNetValueHistories([]model.NetValueHistory{
+		{FundCode: "000001", Date: day(1), NetValue: 1},
+		{FundCode: "000001", Date: day(4), NetValue: 1},
+		{FundCode: "000001", Date: day(5), NetValue: 1.1},
+		{FundCode: "000001", Date: day(6), NetValue: 1.25},
+		{FundCode: "000002", Date: day(5), NetValue: 1.5},
+		{FundCode: "000002", Date: day(6), NetValue: 1.6},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	points, err := p.GetValueHistory(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PortfolioValuePoint{
+		{Date: day(1), Value: 0, Invested: 150},
+		{Date: day(4), Value: 1000, Invested: 1150},
+		{Date: day(5), Value: 1250, Invested: 1150},
+		{Date: day(6), Value: 910, Invested: 650},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("want %d points, got %+v", len(want), points)
+	}
+	for i := range want {
+		if !points[i].Date.Equal(want[i].Date) || !almostEqual(points[i].Value, want[i].Value) || !almostEqual(points[i].Invested, want[i].Invested) {
+			t.Errorf("point %d: want %+v, got %+v", i, want[i], points[i])
+		}
+	}
+}
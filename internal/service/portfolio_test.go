@@ -0,0 +1,90 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// newTestStore 独立的内存数据库仓储，不依赖应用数据库
+func newTestStore(t *testing.T) repository.Store {
+	t.Helper()
+	db, err := repository.OpenMemory()
+	if err != nil {
+		t.Fatalf("打开内存数据库失败: %v", err)
+	}
+	return repository.NewGormStore(db)
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestPortfolioBuySell(t *testing.T) {
+	store := newTestStore(t)
+	p := NewPortfolioService(store)
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	if _, err := p.AddHolding("000001", "测试基金"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Buy("000001", 1000, 2.0, 10, date); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Sell("000001", 99, 2.5, 0, date.AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := store.GetHoldingByFundCode("000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(h.Shares, 396) || !almostEqual(h.Cost, 800) || !almostEqual(h.CurrentNav, 2.5) {
+		t.Fatalf("got shares=%v cost=%v nav=%v", h.Shares, h.Cost, h.CurrentNav)
+	}
+	txs, _ := p.GetTransactions("000001")
+	if len(txs) != 2 {
+		t.Fatalf("want 2 transactions, got %d", len(txs))
+	}
+	if err := p.Sell("000001", 1000, 2.5, 0, date); err == nil {
+		t.Fatal("卖出份额超过持有份额时应返回错误")
+	}
+}
+
+func TestGetFundValueHistoryUsesInjectedStore(t *testing.T) {
+	store := newTestStore(t)
+	p := NewPortfolioService(store)
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	if _, err := p.AddHolding("000001", "测试基金"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Buy("000001", 1000, 1.0, 0, day(3)); err != nil {
+		t.Fatal(err)
+	}
+	err := store.SaveNetValueHistories([]model.NetValueHistory{
+		{FundCode: "000001", Date: day(2), NetValue: 0.9},
+		{FundCode: "000001", Date: day(3), NetValue: 1.0},
+		{FundCode: "000001", Date: day(4), NetValue: 1.1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dates, funds, err := p.GetFundValueHistory(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dates) != 3 || len(funds) != 1 {
+		t.Fatalf("want 3 dates and 1 fund, got %d, %d", len(dates), len(funds))
+	}
+	want := []float64{0, 1000, 1100}
+	for i, v := range want {
+		if !almostEqual(funds[0].Values[i], v) {
+			t.Fatalf("day %d: want value %v, got %v", i, v, funds[0].Values[i])
+		}
+	}
+}
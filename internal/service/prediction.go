@@ -9,9 +9,20 @@ import (
 )
 
 // PredictionService 预测分析服务
-type PredictionService struct{}
+type PredictionService struct {
+	holdings repository.HoldingRepository
+	history  repository.HistoryRepository
+}
+
+// NewPredictionService 使用指定仓储创建预测服务
+func NewPredictionService(store repository.Store) *PredictionService {
+	return &PredictionService{
+		holdings: store,
+		history:  store,
+	}
+}
 
-var predictionService = &PredictionService{}
+var predictionService = NewPredictionService(repository.Default())
 
 // GetPredictionService 获取预测服务实例
 func GetPredictionService() *PredictionService {
@@ -40,7 +51,7 @@ type ProbabilityResult struct {
 
 // PredictRecoveryTime 预测回本时间
 func (p *PredictionService) PredictRecoveryTime(holdingID uint) (*RecoveryResult, error) {
-	holding, err := repository.GetHolding(holdingID)
+	holding, err := p.holdings.GetHolding(holdingID)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +71,7 @@ func (p *PredictionService) PredictRecoveryTime(holdingID uint) (*RecoveryResult
 		return nil, err
 	}
 
-	days, confidence := p.monteCarloSimulation(-lossRate, mean, std, 1000)
+	days, confidence := p.monteCarloSimulation(-lossRate, mean, std, GetSettingsService().Get().MCSimulations)
 
 	suggestion := "建议继续持有"
 	if days > 365 {
@@ -80,7 +91,7 @@ func (p *PredictionService) PredictRecoveryTime(holdingID uint) (*RecoveryResult
 
 // calculateHistoricalReturn 计算历史收益率统计
 func (p *PredictionService) calculateHistoricalReturn(fundCode string, days int) (mean, std float64, err error) {
-	histories, err := repository.GetNetValueHistory(fundCode, days)
+	histories, err := p.history.GetNetValueHistory(fundCode, days)
 	if err != nil || len(histories) < 30 {
 		return 0, 0, err
 	}
@@ -145,12 +156,13 @@ func (p *PredictionService) CalculateProfitProbability(fundCode string, periods
 		return nil, err
 	}
 
+	simulations := GetSettingsService().Get().MCSimulations
 	results := make([]ProbabilityResult, len(periods))
 	for i, period := range periods {
 		profitCount := 0
-		returns := make([]float64, 1000)
+		returns := make([]float64, simulations)
 
-		for j := 0; j < 1000; j++ {
+		for j := 0; j < simulations; j++ {
 			cumReturn := 0.0
 			for d := 0; d < period; d++ {
 				cumReturn += rand.NormFloat64()*std + mean
@@ -176,8 +188,8 @@ func (p *PredictionService) CalculateProfitProbability(fundCode string, periods
 
 		results[i] = ProbabilityResult{
 			Period:         period,
-			ProfitProb:     float64(profitCount) / 10,
-			ExpectedReturn: sum / 1000,
+			ProfitProb:     float64(profitCount) / float64(simulations) * 100,
+			ExpectedReturn: sum / float64(simulations),
 			WorstCase:      minR,
 			BestCase:       maxR,
 		}
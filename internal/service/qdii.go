@@ -1,21 +1,326 @@
 package service
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"jijin/internal/model"
 	"jijin/internal/repository"
 )
 
 // QDIIService QDII基金服务
-type QDIIService struct{}
+type QDIIService struct {
+	fundInfo repository.FundInfoRepository
+}
+
+// NewQDIIService 使用指定仓储创建QDII服务
+func NewQDIIService(store repository.Store) *QDIIService {
+	return &QDIIService{fundInfo: store}
+}
 
-var qdiiService = &QDIIService{}
+var qdiiService = NewQDIIService(repository.Default())
 
 // GetQDIIService 获取QDII服务实例
 func GetQDIIService() *QDIIService {
 	return qdiiService
 }
 
+// QDII市场类型
+const (
+	QDIIMarketUS     = "us"
+	QDIIMarketHK     = "hk"
+	QDIIMarketGlobal = "global"
+)
+
+// QDIIMarketNames QDII市场显示名称
+var QDIIMarketNames = map[string]string{
+	QDIIMarketUS:     "美国",
+	QDIIMarketHK:     "香港",
+	QDIIMarketGlobal: "全球",
+}
+
+// fxSecIDs 人民币汇率中间价行情代码
+var fxSecIDs = map[string]string{
+	"USD": "120.USDCNYC",
+	"HKD": "120.HKDCNYC",
+}
+
+// QDIIAttribution QDII收益归因(%)
+type QDIIAttribution struct {
+	Days        int
+	StartDate   time.Time
+	EndDate     time.Time
+	FundReturn  float64 // 基金净值收益
+	IndexReturn float64 // 跟踪指数或持仓(本币)收益
+	FXReturn    float64 // 汇率变动贡献
+	Residual    float64 // 其他(仓位、费用、跟踪误差)
+}
+
 // GetQDIIFund 获取QDII基金信息
 func (q *QDIIService) GetQDIIFund(fundCode string) (*model.QDIIFund, error) {
-	return repository.GetQDIIFund(fundCode)
+	return q.fundInfo.GetQDIIFund(fundCode)
+}
+
+// GetAllQDIIFunds 获取所有已识别的QDII基金
+func (q *QDIIService) GetAllQDIIFunds() ([]model.QDIIFund, error) {
+	return q.fundInfo.GetAllQDIIFunds()
+}
+
+// IsQDII 根据基金类型判断是否为QDII基金
+func IsQDII(fundType string) bool {
+	return strings.Contains(strings.ToUpper(fundType), "QDII")
+}
+
+// DetectQDII 识别QDII基金的市场、币种和跟踪标的并保存
+func (q *QDIIService) DetectQDII(fundCode string) (*model.QDIIFund, error) {
+	fundName, fundType := fundCode, ""
+	results, _ := GetFundAPI().SearchFund(fundCode)
+	for _, r := range results {
+		if r.Code == fundCode {
+			fundName, fundType = r.Name, r.Type
+			break
+		}
+	}
+	if !IsQDII(fundType) {
+		return nil, fmt.Errorf("%s 不是QDII基金", fundCode)
+	}
+
+	qdii, err := q.fundInfo.GetQDIIFund(fundCode)
+	if err != nil {
+		qdii = &model.QDIIFund{FundCode: fundCode}
+	}
+	qdii.FundName = fundName
+
+	var components []EstimateComponent
+	if m, err := GetEstimationService().GetModel(fundCode); err == nil {
+		qdii.TrackingIndex = m.IndexName
+		json.Unmarshal([]byte(m.Components), &components)
+	}
+	qdii.MarketType = detectQDIIMarket(fundName+qdii.TrackingIndex, components)
+	qdii.Currency = "USD"
+	if qdii.MarketType == QDIIMarketHK {
+		qdii.Currency = "HKD"
+	}
+
+	qdii.UpdatedAt = time.Now()
+	if err := q.fundInfo.SaveQDIIFund(qdii); err != nil {
+		return nil, err
+	}
+	return qdii, nil
+}
+
+// detectQDIIMarket 根据持仓行情代码和名称关键字判断投资市场
+func detectQDIIMarket(name string, components []EstimateComponent) string {
+	hk, us := 0.0, 0.0
+	for _, c := range components {
+		switch {
+		case strings.HasPrefix(c.SecID, "116."):
+			hk += c.Weight
+		case strings.HasPrefix(c.SecID, "105."), strings.HasPrefix(c.SecID, "106."), strings.HasPrefix(c.SecID, "107."):
+			us += c.Weight
+		}
+	}
+	switch {
+	case hk > us:
+		return QDIIMarketHK
+	case us > hk:
+		return QDIIMarketUS
+	}
+
+	for _, kw := range []string{"恒生", "港股", "香港", "H股", "中概互联"} {
+		if strings.Contains(name, kw) {
+			return QDIIMarketHK
+		}
+	}
+	for _, kw := range []string{"纳斯达克", "纳指", "标普", "道琼斯", "美国", "美股"} {
+		if strings.Contains(name, kw) {
+			return QDIIMarketUS
+		}
+	}
+	return QDIIMarketGlobal
+}
+
+// RefreshExchangeRates 拉取并保存近若干交易日的汇率中间价
+func (q *QDIIService) RefreshExchangeRates(currency string, days int) error {
+	secid, ok := fxSecIDs[currency]
+	if !ok {
+		return fmt.Errorf("不支持的币种: %s", currency)
+	}
+
+	bars, err := GetFundAPI().GetDailyKlines(secid, days)
+	if err != nil {
+		return err
+	}
+
+	rates := make([]model.ExchangeRate, 0, len(bars))
+	for _, bar := range bars {
+		if bar.Close > 0 {
+			rates = append(rates, model.ExchangeRate{Currency: currency, Date: bar.Date, Rate: bar.Close})
+		}
+	}
+	return q.fundInfo.SaveExchangeRates(rates)
+}
+
+// rateOn 获取指定日期的汇率，无数据时返回0
+func (q *QDIIService) rateOn(currency string, date time.Time) float64 {
+	rate, err := q.fundInfo.GetExchangeRateOn(currency, date)
+	if err != nil {
+		return 0
+	}
+	return rate.Rate
+}
+
+// RefreshQDII 刷新QDII估值：以最新公布净值为基准，
+// 按其后境外交易日的指数(或持仓)涨跌和汇率变动依次推算T+1、T+2估值
+func (q *QDIIService) RefreshQDII(fundCode string) (*model.QDIIFund, error) {
+	qdii, err := q.fundInfo.GetQDIIFund(fundCode)
+	if err != nil {
+		if qdii, err = q.DetectQDII(fundCode); err != nil {
+			return nil, err
+		}
+	}
+
+	histories, err := GetFundAPI().GetFundHistory(fundCode, 1)
+	if err != nil || len(histories) == 0 {
+		return nil, fmt.Errorf("无法获取基金净值: %s", fundCode)
+	}
+	base := histories[0]
+
+	if err := q.RefreshExchangeRates(qdii.Currency, 30); err == nil {
+		qdii.ExchangeRate = q.rateOn(qdii.Currency, time.Now())
+	}
+
+	qdii.NavDate = base.Date
+	qdii.LastTradeDate = base.Date
+	qdii.T1EstValue, qdii.T2EstValue = 0, 0
+
+	m, err := GetEstimationService().GetModel(fundCode)
+	if err == nil {
+		var components []EstimateComponent
+		json.Unmarshal([]byte(m.Components), &components)
+
+		// 净值日之后的境外交易日涨跌
+		weighted := make(map[time.Time]float64)
+		for _, c := range components {
+			bars, err := GetFundAPI().GetDailyKlines(c.SecID, 10)
+			if err != nil {
+				continue
+			}
+			for _, bar := range bars {
+				if bar.Date.After(base.Date) {
+					weighted[bar.Date] += c.Weight / 100 * bar.ChangePct
+				}
+			}
+		}
+		q.projectNav(qdii, base, m.Beta, weighted)
+	}
+
+	qdii.UpdatedAt = time.Now()
+	if err := q.fundInfo.SaveQDIIFund(qdii); err != nil {
+		return nil, err
+	}
+	return qdii, nil
+}
+
+// projectNav 从公布净值出发，按净值日之后前两个境外交易日的加权涨跌(%)和汇率变动推算T+1、T+2估值
+func (q *QDIIService) projectNav(qdii *model.QDIIFund, base model.NetValueHistory, beta float64, changes map[time.Time]float64) {
+	dates := make([]time.Time, 0, len(changes))
+	for d := range changes {
+		dates = append(dates, d)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	// 校准截距主要反映历史汇率漂移，此处汇率单独计入，只用系数
+	nav, prevRate := base.NetValue, q.rateOn(qdii.Currency, base.Date)
+	for i, d := range dates {
+		if i >= 2 {
+			break
+		}
+		nav *= 1 + beta*changes[d]/100
+		if rate := q.rateOn(qdii.Currency, d); rate > 0 && prevRate > 0 {
+			nav *= rate / prevRate
+			prevRate = rate
+		}
+		if i == 0 {
+			qdii.T1EstValue = nav
+		} else {
+			qdii.T2EstValue = nav
+		}
+		qdii.LastTradeDate = d
+	}
+}
+
+// ValuationNav 组合估值使用的净值及其日期：优先使用最新的T+2/T+1估值，否则使用公布净值
+func (q *QDIIService) ValuationNav(qdii *model.QDIIFund, publishedNav float64) (float64, time.Time) {
+	switch {
+	case qdii.T2EstValue > 0:
+		return qdii.T2EstValue, qdii.LastTradeDate
+	case qdii.T1EstValue > 0:
+		return qdii.T1EstValue, qdii.LastTradeDate
+	}
+	return publishedNav, qdii.NavDate
+}
+
+// Attribution 将近若干个净值日的收益拆分为指数(本币)贡献和汇率贡献
+func (q *QDIIService) Attribution(fundCode string, days int) (*QDIIAttribution, error) {
+	qdii, err := q.fundInfo.GetQDIIFund(fundCode)
+	if err != nil {
+		if qdii, err = q.DetectQDII(fundCode); err != nil {
+			return nil, err
+		}
+	}
+
+	histories, err := GetFundAPI().GetFundHistory(fundCode, days+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(histories) < 2 {
+		return nil, fmt.Errorf("净值数据不足: %s", fundCode)
+	}
+	end, start := histories[0], histories[len(histories)-1]
+
+	attr := &QDIIAttribution{
+		Days:       len(histories) - 1,
+		StartDate:  start.Date,
+		EndDate:    end.Date,
+		FundReturn: (navValue(end)/navValue(start) - 1) * 100,
+	}
+
+	// 汇率贡献
+	q.RefreshExchangeRates(qdii.Currency, days+30)
+	if startRate, endRate := q.rateOn(qdii.Currency, start.Date), q.rateOn(qdii.Currency, end.Date); startRate > 0 && endRate > 0 {
+		attr.FXReturn = (endRate/startRate - 1) * 100
+	}
+
+	// 指数贡献(本币)
+	m, err := GetEstimationService().GetModel(fundCode)
+	if err == nil {
+		var components []EstimateComponent
+		json.Unmarshal([]byte(m.Components), &components)
+
+		weighted := make(map[time.Time]float64)
+		for _, c := range components {
+			bars, err := GetFundAPI().GetDailyKlines(c.SecID, days+30)
+			if err != nil {
+				continue
+			}
+			for _, bar := range bars {
+				// 按成分合计比例归一，持仓估值时代表整体持仓的本币收益
+				if bar.Date.After(start.Date) && !bar.Date.After(end.Date) && m.Coverage > 0 {
+					weighted[bar.Date] += c.Weight / m.Coverage * bar.ChangePct
+				}
+			}
+		}
+		growth := 1.0
+		for _, pct := range weighted {
+			growth *= 1 + pct/100
+		}
+		attr.IndexReturn = (growth - 1) * 100
+	}
+
+	attr.Residual = ((1+attr.FundReturn/100)/((1+attr.IndexReturn/100)*(1+attr.FXReturn/100)) - 1) * 100
+	return attr, nil
 }
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func TestDetectQDIIMarket(t *testing.T) {
+	tests := []struct {
+		name       string
+		fundName   string
+		components []EstimateComponent
+		want       string
+	}{
+		{name: "美股持仓为主", fundName: "全球精选", components: []EstimateComponent{{SecID: "105.AAPL", Weight: 8}, {SecID: "116.00700", Weight: 5}}, want: QDIIMarketUS},
+		{name: "港股持仓为主", fundName: "纳斯达克", components: []EstimateComponent{{SecID: "116.00700", Weight: 9}, {SecID: "106.BABA", Weight: 3}}, want: QDIIMarketHK},
+		{name: "按名称识别港股", fundName: "恒生科技ETF联接", want: QDIIMarketHK},
+		{name: "按名称识别美股", fundName: "标普500指数", want: QDIIMarketUS},
+		{name: "无法识别", fundName: "全球配置", want: QDIIMarketGlobal},
+	}
+	for _, tt := range tests {
+		if got := detectQDIIMarket(tt.fundName, tt.components); got != tt.want {
+			t.Errorf("%s: want %s, got %s", tt.name, tt.want, got)
+		}
+	}
+	if !IsQDII("QDII-指数") || IsQDII("混合型") {
+		t.Error("IsQDII按类型名称判断")
+	}
+}
+
+func TestQDIIProjectNav(t *testing.T) {
+	store := newTestStore(t)
+	q := NewQDIIService(store)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	if err := store.SaveExchangeRates([]model.ExchangeRate{
+		{Currency: "USD", Date: day(7), Rate: 7},
+		{Currency: "USD", Date: day(8), Rate: 7.07},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	qdii := &model.QDIIFund{FundCode: "000001", Currency: "USD"}
+	base := model.NetValueHistory{Date: day(7), NetValue: 2}
+	// 3月11日无汇率沿用前一日，只推算前两个交易日
+	q.projectNav(qdii, base, 0.9, map[time.Time]float64{day(12): 5, day(8): 1, day(11): -2})
+
+	t1 := 2 * 1.009 * 1.01
+	if !almostEqual(qdii.T1EstValue, t1) || !almostEqual(qdii.T2EstValue, t1*0.982) || !qdii.LastTradeDate.Equal(day(11)) {
+		t.Fatalf("qdii: T1 %v T2 %v 交易日 %v", qdii.T1EstValue, qdii.T2EstValue, qdii.LastTradeDate)
+	}
+
+	nav, date := q.ValuationNav(qdii, 2)
+	if nav != qdii.T2EstValue || !date.Equal(day(11)) {
+		t.Fatalf("组合估值应使用T+2估值: %v %v", nav, date)
+	}
+	nav, _ = q.ValuationNav(&model.QDIIFund{NavDate: day(7)}, 2)
+	if nav != 2 {
+		t.Fatalf("无估值时使用公布净值: %v", nav)
+	}
+}
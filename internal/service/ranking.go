@@ -1,13 +1,29 @@
 package service
 
 import (
+	"time"
+
+	"jijin/internal/model"
 	"jijin/internal/repository"
 )
 
 // RankingService 基金排行服务
-type RankingService struct{}
+type RankingService struct {
+	holdings  repository.HoldingRepository
+	watchlist repository.WatchlistRepository
+	rankings  repository.RankingRepository
+}
+
+// NewRankingService 使用指定仓储创建排行服务
+func NewRankingService(store repository.Store) *RankingService {
+	return &RankingService{
+		holdings:  store,
+		watchlist: store,
+		rankings:  store,
+	}
+}
 
-var rankingService = &RankingService{}
+var rankingService = NewRankingService(repository.Default())
 
 // GetRankingService 获取排行服务实例
 func GetRankingService() *RankingService {
@@ -20,38 +36,99 @@ const (
 	RankTypeLoss = "loss"
 )
 
+// 排行周期常量
+const (
+	RankPeriodDay   = "day"
+	RankPeriodWeek  = "1w"
+	RankPeriodMonth = "1m"
+	RankPeriod3M    = "3m"
+	RankPeriod6M    = "6m"
+	RankPeriodYTD   = "ytd"
+	RankPeriod1Y    = "1y"
+	RankPeriod2Y    = "2y"
+	RankPeriod3Y    = "3y"
+	RankPeriodSince = "since"
+)
+
+// RankPeriodNames 排行周期显示名称
+var RankPeriodNames = map[string]string{
+	RankPeriodDay:   "日涨幅",
+	RankPeriodWeek:  "近1周",
+	RankPeriodMonth: "近1月",
+	RankPeriod3M:    "近3月",
+	RankPeriod6M:    "近6月",
+	RankPeriodYTD:   "今年来",
+	RankPeriod1Y:    "近1年",
+	RankPeriod2Y:    "近2年",
+	RankPeriod3Y:    "近3年",
+	RankPeriodSince: "成立来",
+}
+
+// 基金类别常量(与天天基金排行接口ft参数一致)
+const (
+	RankCategoryAll   = "all"
+	RankCategoryStock = "gp"
+	RankCategoryMixed = "hh"
+	RankCategoryBond  = "zq"
+	RankCategoryIndex = "zs"
+	RankCategoryQDII  = "qdii"
+	RankCategoryFOF   = "fof"
+)
+
+// RankCategoryNames 基金类别显示名称
+var RankCategoryNames = map[string]string{
+	RankCategoryAll:   "全部",
+	RankCategoryStock: "股票型",
+	RankCategoryMixed: "混合型",
+	RankCategoryBond:  "债券型",
+	RankCategoryIndex: "指数型",
+	RankCategoryQDII:  "QDII",
+	RankCategoryFOF:   "FOF",
+}
+
 // RankingItem 排行项
 type RankingItem struct {
-	Rank     int
-	FundCode string
-	FundName string
-	Value    float64
-	Period   string
+	Rank       int
+	FundCode   string
+	FundName   string
+	Value      float64
+	Period     string
+	Category   string
+	TotalCount int
+	Change     int // 较上一期名次变化，正数为上升
+	StatDate   time.Time
 }
 
-// GetRanking 获取排行榜
-func (r *RankingService) GetRanking(rankType, period string, limit int) ([]RankingItem, error) {
-	rankings, err := repository.GetFundRankingByType(rankType, period, limit)
+// RankChange 关注基金的排名变化
+type RankChange struct {
+	FundCode     string
+	FundName     string
+	Period       string
+	Category     string
+	Rank         int
+	PreviousRank int
+	Change       int // 正数为名次上升
+	TotalCount   int
+	Value        float64
+	StatDate     time.Time
+	PreviousDate time.Time
+}
+
+// GetRanking 获取最新一期排行榜
+func (r *RankingService) GetRanking(rankType, period, category string, limit int) ([]RankingItem, error) {
+	if category == "" {
+		category = RankCategoryAll
+	}
+	rankings, err := r.rankings.GetFundRankingByType(rankType, period, category, limit)
 	if err != nil {
 		return nil, err
 	}
-
-	items := make([]RankingItem, len(rankings))
-	for i, rank := range rankings {
-		items[i] = RankingItem{
-			Rank:     rank.RankPosition,
-			FundCode: rank.FundCode,
-			FundName: rank.FundName,
-			Value:    rank.RankValue,
-			Period:   rank.Period,
-		}
-	}
-	return items, nil
+	return r.toRankingItems(rankings), nil
 }
 
 // GetHoldingRanking 获取持仓基金排行
 func (r *RankingService) GetHoldingRanking() ([]RankingItem, error) {
-	holdings, err := repository.GetAllHoldings()
+	holdings, err := r.holdings.GetAllHoldings()
 	if err != nil {
 		return nil, err
 	}
@@ -70,27 +147,132 @@ func (r *RankingService) GetHoldingRanking() ([]RankingItem, error) {
 
 // RefreshRankingFromAPI 从API刷新排行数据
 func (r *RankingService) RefreshRankingFromAPI(rankType string, limit int) ([]RankingItem, error) {
-	// 从API获取最新排行数据
-	rankings, err := GetFundAPI().GetFundRanking(rankType, limit)
+	period, direction := legacyRankParams(rankType)
+	return r.RefreshRanking(RankCategoryAll, period, direction, limit)
+}
+
+// RefreshRanking 拉取指定类别和周期的完整排行，保存前limit名及关注基金的排名
+func (r *RankingService) RefreshRanking(category, period, direction string, limit int) ([]RankingItem, error) {
+	all, err := GetFundAPI().GetFundRankingByPeriod(category, period, direction, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	// 保存到数据库
-	for i := range rankings {
-		repository.SaveFundRanking(&rankings[i])
+	watched := r.watchedFundCodes()
+	var rankings []model.FundRanking
+	for _, rank := range all {
+		if rank.RankPosition <= limit || watched[rank.FundCode] {
+			rankings = append(rankings, rank)
+		}
+	}
+
+	if err := r.rankings.SaveFundRankings(rankings); err != nil {
+		return nil, err
+	}
+
+	top := rankings
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return r.toRankingItems(top), nil
+}
+
+// GetWatchedRankChanges 获取关注基金(持仓和自选)在指定类别和周期下的排名变化
+func (r *RankingService) GetWatchedRankChanges(category, period string) ([]RankChange, error) {
+	if category == "" {
+		category = RankCategoryAll
+	}
+
+	var changes []RankChange
+	for code := range r.watchedFundCodes() {
+		history, err := r.rankings.GetFundRankHistory(code, RankTypeGain, period, category, 2)
+		if err != nil || len(history) == 0 {
+			continue
+		}
+
+		current := history[0]
+		change := RankChange{
+			FundCode:   current.FundCode,
+			FundName:   current.FundName,
+			Period:     period,
+			Category:   category,
+			Rank:       current.RankPosition,
+			TotalCount: current.TotalCount,
+			Value:      current.RankValue,
+			StatDate:   current.StatDate,
+		}
+		if len(history) > 1 {
+			change.PreviousRank = history[1].RankPosition
+			change.PreviousDate = history[1].StatDate
+			change.Change = history[1].RankPosition - current.RankPosition
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// GetRankHistory 获取基金的排名历史
+func (r *RankingService) GetRankHistory(fundCode, period, category string, limit int) ([]RankingItem, error) {
+	if category == "" {
+		category = RankCategoryAll
+	}
+	rankings, err := r.rankings.GetFundRankHistory(fundCode, RankTypeGain, period, category, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.toRankingItems(rankings), nil
+}
+
+// toRankingItems 转换为RankingItem，并计算较上一期的名次变化
+func (r *RankingService) toRankingItems(rankings []model.FundRanking) []RankingItem {
+	// 按榜单批量读取上一期排名
+	type listKey struct {
+		rankType, period, category string
+		statDate                   time.Time
+	}
+	lists := make(map[listKey][]string)
+	for _, rank := range rankings {
+		key := listKey{rank.RankType, rank.Period, rank.Category, rank.StatDate}
+		lists[key] = append(lists[key], rank.FundCode)
+	}
+	previous := make(map[listKey]map[string]model.FundRanking, len(lists))
+	for key, codes := range lists {
+		if ranks, err := r.rankings.GetPreviousFundRanks(codes, key.rankType, key.period, key.category, key.statDate); err == nil {
+			previous[key] = ranks
+		}
 	}
 
-	// 转换为RankingItem返回
 	items := make([]RankingItem, len(rankings))
 	for i, rank := range rankings {
 		items[i] = RankingItem{
-			Rank:     rank.RankPosition,
-			FundCode: rank.FundCode,
-			FundName: rank.FundName,
-			Value:    rank.RankValue,
-			Period:   rank.Period,
+			Rank:       rank.RankPosition,
+			FundCode:   rank.FundCode,
+			FundName:   rank.FundName,
+			Value:      rank.RankValue,
+			Period:     rank.Period,
+			Category:   rank.Category,
+			TotalCount: rank.TotalCount,
+			StatDate:   rank.StatDate,
+		}
+
+		key := listKey{rank.RankType, rank.Period, rank.Category, rank.StatDate}
+		if prev, ok := previous[key][rank.FundCode]; ok {
+			items[i].Change = prev.RankPosition - rank.RankPosition
 		}
 	}
-	return items, nil
+	return items
+}
+
+// watchedFundCodes 需要跟踪排名的基金(持仓和自选)
+func (r *RankingService) watchedFundCodes() map[string]bool {
+	codes := make(map[string]bool)
+	holdings, _ := r.holdings.GetAllHoldings()
+	for _, h := range holdings {
+		codes[h.FundCode] = true
+	}
+	items, _ := r.watchlist.GetWatchlistItems("")
+	for _, item := range items {
+		codes[item.FundCode] = true
+	}
+	return codes
 }
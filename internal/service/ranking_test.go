@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+// saveRankList 保存一期日涨幅排行，codes按名次排列
+func saveRankList(t *testing.T, r *RankingService, statDate time.Time, codes ...string) {
+	t.Helper()
+	var rankings []model.FundRanking
+	for i, code := range codes {
+		rankings = append(rankings, model.FundRanking{
+			FundCode: code, FundName: "基金" + code, RankType: RankTypeGain, Category: RankCategoryAll,
+			Period: RankPeriodDay, RankPosition: i + 1, TotalCount: len(codes), StatDate: statDate,
+		})
+	}
+	if err := r.rankings.SaveFundRankings(rankings); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRankingChanges(t *testing.T) {
+	store := newTestStore(t)
+	r := NewRankingService(store)
+	day1 := time.Date(2024, 3, 7, 0, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+
+	saveRankList(t, r, day1, "000001", "000002", "000003")
+	// 同一统计日重复保存时覆盖旧数据
+	saveRankList(t, r, day2, "000001", "000002")
+	saveRankList(t, r, day2, "000003", "000001", "000004")
+
+	items, err := r.GetRanking(RankTypeGain, RankPeriodDay, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		code   string
+		change int
+	}{{"000003", 2}, {"000001", -1}, {"000004", 0}}
+	if len(items) != len(want) {
+		t.Fatalf("want %d items, got %+v", len(want), items)
+	}
+	for i, w := range want {
+		if items[i].FundCode != w.code || items[i].Change != w.change || !items[i].StatDate.Equal(day2) {
+			t.Errorf("item %d: want %s %+d, got %+v", i, w.code, w.change, items[i])
+		}
+	}
+
+	if err := store.SaveWatchlistItem(&model.WatchlistItem{FundCode: "000003"}); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := r.GetWatchedRankChanges("", RankPeriodDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Rank != 1 || changes[0].PreviousRank != 3 || changes[0].Change != 2 || !changes[0].PreviousDate.Equal(day1) {
+		t.Fatalf("changes: %+v", changes)
+	}
+}
+
+func TestLegacyRankParams(t *testing.T) {
+	tests := []struct {
+		rankType, period, direction string
+	}{
+		{RankTypeLoss, RankPeriodDay, RankTypeLoss},
+		{"year", RankPeriod1Y, RankTypeGain},
+		{"month", RankPeriodMonth, RankTypeGain},
+		{RankPeriod3M, RankPeriod3M, RankTypeGain},
+		{"unknown", RankPeriodDay, RankTypeGain},
+	}
+	for _, tt := range tests {
+		if period, direction := legacyRankParams(tt.rankType); period != tt.period || direction != tt.direction {
+			t.Errorf("legacyRankParams(%q) = %s, %s", tt.rankType, period, direction)
+		}
+	}
+}
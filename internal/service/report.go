@@ -0,0 +1,747 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// 报告周期
+const (
+	ReportDaily  = "daily"
+	ReportWeekly = "weekly"
+)
+
+// ReportPeriodNames 报告周期显示名称
+var ReportPeriodNames = map[string]string{
+	ReportDaily:  "日报",
+	ReportWeekly: "周报",
+}
+
+// 报告保存格式
+const (
+	ReportFormatMarkdown = "md"
+	ReportFormatHTML     = "html"
+	ReportFormatPDF      = "pdf"
+)
+
+// ReportFundLine 单只基金的区间表现
+type ReportFundLine struct {
+	FundCode    string
+	FundName    string
+	StartNav    float64
+	EndNav      float64
+	Return      float64 // 区间净值涨跌(%)
+	Profit      float64 // 区间盈亏(扣除区间内买卖现金流)
+	MarketValue float64
+}
+
+// ReportStrategyAction 下一周期待执行的定投
+type ReportStrategyAction struct {
+	Name     string
+	FundName string
+	Date     time.Time
+	Amount   float64
+	Reason   string
+}
+
+// ReportRiskChange 相比上期报告的风险变化
+type ReportRiskChange struct {
+	FundCode      string
+	FundName      string
+	OldLevel      int
+	NewLevel      int
+	OldDrawdown   float64
+	NewDrawdown   float64
+	OldVolatility float64
+	NewVolatility float64
+}
+
+// reportRisk 报告保存的单只基金风险快照
+type reportRisk struct {
+	MaxDrawdown float64 `json:"maxDrawdown"`
+	Volatility  float64 `json:"volatility"`
+	Level       int     `json:"level"`
+}
+
+// PortfolioReportData 报告内容
+type PortfolioReportData struct {
+	Period       string
+	StartDate    time.Time
+	EndDate      time.Time
+	TotalCost    float64
+	TotalValue   float64
+	TotalProfit  float64
+	ProfitRate   float64
+	PeriodProfit float64
+	PeriodReturn float64
+	Funds        []ReportFundLine // 按区间盈亏降序
+	Gainers      []ReportFundLine
+	Losers       []ReportFundLine
+	Alerts       []model.AlertHistory
+	Signals      []model.TradingSignal
+	Actions      []ReportStrategyAction
+	RiskChanges  []ReportRiskChange
+	risk         map[string]reportRisk
+}
+
+// 风险变化的提示阈值(百分点)
+const (
+	reportDrawdownChange   = 2.0
+	reportVolatilityChange = 3.0
+	reportMovers           = 3
+)
+
+// ReportService 持仓报告服务
+type ReportService struct {
+	holdings     repository.HoldingRepository
+	transactions repository.TransactionRepository
+	strategies   repository.StrategyRepository
+	alerts       repository.AlertRepository
+	history      repository.HistoryRepository
+	signals      repository.SignalRepository
+	reports      repository.ReportRepository
+
+	mu        sync.Mutex
+	isRunning bool
+	stopChan  chan bool
+}
+
+// NewReportService 使用指定仓储创建报告服务
+func NewReportService(store repository.Store) *ReportService {
+	return &ReportService{
+		holdings:     store,
+		transactions: store,
+		strategies:   store,
+		alerts:       store,
+		history:      store,
+		signals:      store,
+		reports:      store,
+	}
+}
+
+var reportService = NewReportService(repository.Default())
+
+// GetReportService 获取报告服务实例
+func GetReportService() *ReportService {
+	return reportService
+}
+
+// GetSettings 获取报告设置
+func (r *ReportService) GetSettings() (*model.ReportSettings, error) {
+	return r.reports.GetReportSettings()
+}
+
+// SaveSettings 保存报告设置
+func (r *ReportService) SaveSettings(settings *model.ReportSettings) error {
+	if _, err := parseClock(settings.GenerateAt); err != nil {
+		return fmt.Errorf("生成时间格式错误: %s", settings.GenerateAt)
+	}
+	for _, f := range splitList(settings.Formats) {
+		if f != ReportFormatMarkdown && f != ReportFormatHTML && f != ReportFormatPDF {
+			return fmt.Errorf("不支持的报告格式: %s", f)
+		}
+	}
+	return r.reports.SaveReportSettings(settings)
+}
+
+// GetReports 获取最近的报告
+func (r *ReportService) GetReports(limit int) ([]model.PortfolioReport, error) {
+	return r.reports.GetPortfolioReports(limit)
+}
+
+// Generate 生成报告，按设置保存文件并推送
+func (r *ReportService) Generate(period string) (*model.PortfolioReport, error) {
+	data, err := r.Build(period)
+	if err != nil {
+		return nil, err
+	}
+	return r.save(data)
+}
+
+// save 保存报告记录和文件，并推送到设置的渠道
+func (r *ReportService) save(data *PortfolioReportData) (*model.PortfolioReport, error) {
+	settings, _ := r.reports.GetReportSettings()
+	snapshot, _ := json.Marshal(data.risk)
+	report := &model.PortfolioReport{
+		Period:       data.Period,
+		StartDate:    data.StartDate,
+		EndDate:      data.EndDate,
+		TotalValue:   data.TotalValue,
+		TotalProfit:  data.TotalProfit,
+		PeriodProfit: data.PeriodProfit,
+		PeriodReturn: data.PeriodReturn,
+		RiskSnapshot: string(snapshot),
+		Markdown:     RenderReportMarkdown(data),
+		GeneratedAt:  time.Now(),
+	}
+
+	var saveErr error
+	if settings != nil && settings.Formats != "" {
+		report.FilePath, saveErr = writeReportFiles(report, data, settings)
+	}
+	if err := r.reports.SavePortfolioReport(report); err != nil {
+		return nil, err
+	}
+
+	if settings != nil {
+		if ids := ChannelIDs(settings.Channels); len(ids) > 0 {
+			status, _ := GetNotifyService().SendMessage(ids, reportTitle(data), reportSummary(data), report.Markdown)
+			report.DeliveryStatus = status
+			r.reports.UpdateReportDelivery(report.ID, status)
+		}
+	}
+	return report, saveErr
+}
+
+// Build 汇总持仓在最近一个净值日(日报)或最近一周(周报)的表现
+func (r *ReportService) Build(period string) (*PortfolioReportData, error) {
+	if _, ok := ReportPeriodNames[period]; !ok {
+		return nil, fmt.Errorf("不支持的报告周期: %s", period)
+	}
+	holdings, err := r.holdings.GetAllHoldings()
+	if err != nil {
+		return nil, err
+	}
+
+	// 补齐近期净值，并确定期末净值日
+	histories := make(map[string][]model.NetValueHistory)
+	var endDate time.Time
+	for _, h := range holdings {
+		if h.Shares <= 0 {
+			continue
+		}
+		list := r.ensureHistory(h.FundCode, 250)
+		if len(list) == 0 {
+			continue
+		}
+		histories[h.FundCode] = list
+		if list[0].Date.After(endDate) {
+			endDate = list[0].Date
+		}
+	}
+	if len(histories) == 0 {
+		return nil, errors.New("暂无持仓净值数据")
+	}
+
+	data := &PortfolioReportData{Period: period, EndDate: endDate, risk: make(map[string]reportRisk)}
+	data.TotalCost, data.TotalValue, data.TotalProfit, data.ProfitRate = GetPortfolioService().GetPortfolioSummary()
+
+	invested := 0.0
+	for _, h := range holdings {
+		list, ok := histories[h.FundCode]
+		if !ok {
+			continue
+		}
+		end, start := reportNavRange(list, endDate, period)
+		if end == nil || start == nil {
+			continue
+		}
+		if data.StartDate.IsZero() || start.Date.Before(data.StartDate) {
+			data.StartDate = start.Date
+		}
+
+		// 区间内买卖调整期初份额和现金流
+		bought, sold, buyAmount, sellAmount := 0.0, 0.0, 0.0, 0.0
+		txs, _ := r.transactions.GetTransactionsByFundCode(h.FundCode)
+		for _, tx := range txs {
+			if !tx.TradeDate.After(start.Date) || tx.TradeDate.After(end.Date.AddDate(0, 0, 1)) {
+				continue
+			}
+			if tx.Type == "sell" {
+				sold += tx.Shares
+				sellAmount += tx.Amount
+			} else {
+				bought += tx.Shares
+				buyAmount += tx.Amount
+			}
+		}
+		startShares := math.Max(h.Shares-bought+sold, 0)
+		line := ReportFundLine{
+			FundCode:    h.FundCode,
+			FundName:    h.FundName,
+			StartNav:    start.NetValue,
+			EndNav:      end.NetValue,
+			Profit:      h.Shares*end.NetValue - startShares*start.NetValue - buyAmount + sellAmount,
+			MarketValue: h.Shares * end.NetValue,
+		}
+		if navValue(*start) > 0 {
+			line.Return = (navValue(*end)/navValue(*start) - 1) * 100
+		}
+		data.Funds = append(data.Funds, line)
+		data.PeriodProfit += line.Profit
+		invested += startShares*start.NetValue + buyAmount
+
+		maxDrawdown, _ := GetRiskService().CalculateMaxDrawdown(h.FundCode, 250)
+		volatility, _ := GetRiskService().CalculateVolatility(h.FundCode, 250)
+		_, level := riskScoreLevel(maxDrawdown, volatility)
+		data.risk[h.FundCode] = reportRisk{MaxDrawdown: maxDrawdown, Volatility: volatility, Level: level}
+	}
+	if invested > 0 {
+		data.PeriodReturn = data.PeriodProfit / invested * 100
+	}
+
+	sort.Slice(data.Funds, func(i, j int) bool { return data.Funds[i].Profit > data.Funds[j].Profit })
+	movers := append([]ReportFundLine(nil), data.Funds...)
+	sort.Slice(movers, func(i, j int) bool { return movers[i].Return > movers[j].Return })
+	for i := 0; i < len(movers) && len(data.Gainers) < reportMovers && movers[i].Return > 0; i++ {
+		data.Gainers = append(data.Gainers, movers[i])
+	}
+	for i := len(movers) - 1; i >= 0 && len(data.Losers) < reportMovers && movers[i].Return < 0; i-- {
+		data.Losers = append(data.Losers, movers[i])
+	}
+
+	// 期初净值日次日零点至今的提醒和信号
+	now := time.Now()
+	from := time.Date(data.StartDate.Year(), data.StartDate.Month(), data.StartDate.Day()+1, 0, 0, 0, 0, time.Local)
+	data.Alerts, _ = r.alerts.GetAlertHistoryBetween(from, now)
+	data.Signals, _ = r.signals.GetSignalsBetween(from, now)
+
+	data.Actions = r.dueActions(period, now)
+	data.RiskChanges = r.riskChanges(data)
+	return data, nil
+}
+
+// ensureHistory 读取本地净值历史，不足时从接口补齐，按日期降序
+func (r *ReportService) ensureHistory(fundCode string, days int) []model.NetValueHistory {
+	fetch := 10
+	if local, _ := r.history.GetNetValueHistory(fundCode, days); len(local) < 60 {
+		fetch = days
+	}
+	if fetched, err := GetFundAPI().GetFundHistory(fundCode, fetch); err == nil {
+		r.history.SaveNewNetValueHistories(fundCode, fetched)
+	}
+	list, _ := r.history.GetNetValueHistory(fundCode, days)
+	return list
+}
+
+// reportNavRange 期末为不晚于endDate的最新净值；日报期初为其前一条，周报期初为一周前或更早的最近一条
+func reportNavRange(list []model.NetValueHistory, endDate time.Time, period string) (end, start *model.NetValueHistory) {
+	for i := range list {
+		if list[i].Date.After(endDate) {
+			continue
+		}
+		if end == nil {
+			end = &list[i]
+			if period == ReportDaily {
+				if i+1 < len(list) {
+					start = &list[i+1]
+				}
+				return
+			}
+			continue
+		}
+		if !list[i].Date.After(endDate.AddDate(0, 0, -7)) {
+			start = &list[i]
+			return
+		}
+	}
+	return
+}
+
+// dueActions 下一周期(日报为下一交易日，周报为未来一周)待执行的定投策略
+func (r *ReportService) dueActions(period string, now time.Time) []ReportStrategyAction {
+	strategies, err := r.strategies.GetActiveStrategies()
+	if err != nil {
+		return nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	horizon := today.AddDate(0, 0, 7)
+	if period == ReportDaily {
+		horizon = today.AddDate(0, 0, 1)
+		for horizon.Weekday() == time.Saturday || horizon.Weekday() == time.Sunday {
+			horizon = horizon.AddDate(0, 0, 1)
+		}
+	}
+
+	var actions []ReportStrategyAction
+	for _, st := range strategies {
+		next := GetStrategyService().NextRunDate(st, today)
+		if next.After(horizon) {
+			continue
+		}
+		action := ReportStrategyAction{Name: st.Name, FundName: st.FundName, Date: next, Amount: st.BaseAmount}
+		if result, err := GetStrategyService().Suggest(st); err == nil {
+			action.Amount = result.SuggestAmount
+			action.Reason = result.Reason
+		}
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Date.Before(actions[j].Date) })
+	return actions
+}
+
+// riskChanges 与上一期同周期报告的风险快照对比
+func (r *ReportService) riskChanges(data *PortfolioReportData) []ReportRiskChange {
+	prev, err := r.reports.GetLatestPortfolioReport(data.Period, data.EndDate)
+	if err != nil {
+		return nil
+	}
+	var old map[string]reportRisk
+	if json.Unmarshal([]byte(prev.RiskSnapshot), &old) != nil {
+		return nil
+	}
+
+	var changes []ReportRiskChange
+	for _, line := range data.Funds {
+		o, ok := old[line.FundCode]
+		if !ok {
+			continue
+		}
+		n := data.risk[line.FundCode]
+		if o.Level == n.Level &&
+			math.Abs(n.MaxDrawdown-o.MaxDrawdown) < reportDrawdownChange &&
+			math.Abs(n.Volatility-o.Volatility) < reportVolatilityChange {
+			continue
+		}
+		changes = append(changes, ReportRiskChange{
+			FundCode: line.FundCode, FundName: line.FundName,
+			OldLevel: o.Level, NewLevel: n.Level,
+			OldDrawdown: o.MaxDrawdown, NewDrawdown: n.MaxDrawdown,
+			OldVolatility: o.Volatility, NewVolatility: n.Volatility,
+		})
+	}
+	return changes
+}
+
+// reportTitle 报告标题
+func reportTitle(data *PortfolioReportData) string {
+	return fmt.Sprintf("持仓%s %s", ReportPeriodNames[data.Period], data.EndDate.Format("2006-01-02"))
+}
+
+// reportSummary 推送用的简短摘要
+func reportSummary(data *PortfolioReportData) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "区间盈亏 %+.2f元 (%+.2f%%)，总市值 %.2f元，累计收益 %+.2f元 (%+.2f%%)",
+		data.PeriodProfit, data.PeriodReturn, data.TotalValue, data.TotalProfit, data.ProfitRate)
+	if len(data.Gainers) > 0 {
+		fmt.Fprintf(&b, "\n涨幅最大: %s %+.2f%%", data.Gainers[0].FundName, data.Gainers[0].Return)
+	}
+	if len(data.Losers) > 0 {
+		fmt.Fprintf(&b, "\n跌幅最大: %s %+.2f%%", data.Losers[0].FundName, data.Losers[0].Return)
+	}
+	fmt.Fprintf(&b, "\n提醒%d条，信号%d条，待执行定投%d笔", len(data.Alerts), len(data.Signals), len(data.Actions))
+	if len(data.RiskChanges) > 0 {
+		fmt.Fprintf(&b, "，风险变化%d只", len(data.RiskChanges))
+	}
+	return b.String()
+}
+
+// RenderReportMarkdown 渲染Markdown报告
+func RenderReportMarkdown(data *PortfolioReportData) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", reportTitle(data))
+	fmt.Fprintf(&b, "区间: %s ~ %s\n\n", data.StartDate.Format("2006-01-02"), data.EndDate.Format("2006-01-02"))
+
+	b.WriteString("## 总览\n\n| 项目 | 数值 |\n| --- | --- |\n")
+	fmt.Fprintf(&b, "| 区间盈亏 | %+.2f元 (%+.2f%%) |\n", data.PeriodProfit, data.PeriodReturn)
+	fmt.Fprintf(&b, "| 总市值 | %.2f元 |\n", data.TotalValue)
+	fmt.Fprintf(&b, "| 总成本 | %.2f元 |\n", data.TotalCost)
+	fmt.Fprintf(&b, "| 累计收益 | %+.2f元 (%+.2f%%) |\n\n", data.TotalProfit, data.ProfitRate)
+
+	b.WriteString("## 持仓盈亏\n\n| 基金 | 期初净值 | 期末净值 | 涨跌 | 区间盈亏 | 市值 |\n| --- | --- | --- | --- | --- | --- |\n")
+	for _, f := range data.Funds {
+		fmt.Fprintf(&b, "| %s(%s) | %.4f | %.4f | %+.2f%% | %+.2f | %.2f |\n",
+			f.FundName, f.FundCode, f.StartNav, f.EndNav, f.Return, f.Profit, f.MarketValue)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## 涨跌榜\n\n")
+	if len(data.Gainers) == 0 && len(data.Losers) == 0 {
+		b.WriteString("无\n")
+	}
+	for _, f := range data.Gainers {
+		fmt.Fprintf(&b, "- 涨 %s %+.2f%%\n", f.FundName, f.Return)
+	}
+	for _, f := range data.Losers {
+		fmt.Fprintf(&b, "- 跌 %s %+.2f%%\n", f.FundName, f.Return)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## 触发提醒\n\n")
+	if len(data.Alerts) == 0 {
+		b.WriteString("无\n")
+	}
+	for _, a := range data.Alerts {
+		fmt.Fprintf(&b, "- %s %s\n", a.TriggeredAt.Format("01-02 15:04"), a.Message)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## 交易信号\n\n")
+	if len(data.Signals) == 0 {
+		b.WriteString("无\n")
+	}
+	for _, s := range data.Signals {
+		fmt.Fprintf(&b, "- %s %s %s(%s) 强度%.0f：%s\n", s.GeneratedAt.Format("01-02"), SignalTypeNames[s.SignalType],
+			s.FundName, s.FundCode, s.SignalStrength, s.Reason)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## 待执行定投\n\n")
+	if len(data.Actions) == 0 {
+		b.WriteString("无\n")
+	}
+	for _, a := range data.Actions {
+		fmt.Fprintf(&b, "- %s %s(%s) 建议 %.2f元", a.Date.Format("01-02"), a.Name, a.FundName, a.Amount)
+		if a.Reason != "" {
+			b.WriteString("，" + a.Reason)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## 风险变化\n\n")
+	if len(data.RiskChanges) == 0 {
+		b.WriteString("无明显变化\n")
+	}
+	for _, c := range data.RiskChanges {
+		fmt.Fprintf(&b, "- %s(%s) 风险等级 %d→%d，最大回撤 %.2f%%→%.2f%%，波动率 %.2f%%→%.2f%%\n",
+			c.FundName, c.FundCode, c.OldLevel, c.NewLevel, c.OldDrawdown, c.NewDrawdown, c.OldVolatility, c.NewVolatility)
+	}
+	return b.String()
+}
+
+// RenderReportHTML 渲染HTML报告
+func RenderReportHTML(data *PortfolioReportData) string {
+	esc := html.EscapeString
+	signed := func(v float64, format string) string {
+		class := "up"
+		if v < 0 {
+			class = "down"
+		}
+		return fmt.Sprintf(`<span class="%s">`+format+`</span>`, class, v)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\">")
+	fmt.Fprintf(&b, "<title>%s</title>", esc(reportTitle(data)))
+	b.WriteString(`<style>body{font-family:sans-serif;max-width:900px;margin:24px auto;color:#333}` +
+		`table{border-collapse:collapse;width:100%}th,td{border:1px solid #ddd;padding:6px 8px;text-align:right}` +
+		`th:first-child,td:first-child{text-align:left}th{background:#f5f5f5}.up{color:#d9363e}.down{color:#2e9e4f}` +
+		`.muted{color:#999}</style></head><body>`)
+	fmt.Fprintf(&b, "<h1>%s</h1><p class=\"muted\">区间: %s ~ %s</p>", esc(reportTitle(data)),
+		data.StartDate.Format("2006-01-02"), data.EndDate.Format("2006-01-02"))
+
+	b.WriteString("<h2>总览</h2><table>")
+	fmt.Fprintf(&b, "<tr><td>区间盈亏</td><td>%s元 (%s)</td></tr>", signed(data.PeriodProfit, "%+.2f"), signed(data.PeriodReturn, "%+.2f%%"))
+	fmt.Fprintf(&b, "<tr><td>总市值</td><td>%.2f元</td></tr>", data.TotalValue)
+	fmt.Fprintf(&b, "<tr><td>总成本</td><td>%.2f元</td></tr>", data.TotalCost)
+	fmt.Fprintf(&b, "<tr><td>累计收益</td><td>%s元 (%s)</td></tr></table>", signed(data.TotalProfit, "%+.2f"), signed(data.ProfitRate, "%+.2f%%"))
+
+	b.WriteString("<h2>持仓盈亏</h2><table><tr><th>基金</th><th>期初净值</th><th>期末净值</th><th>涨跌</th><th>区间盈亏</th><th>市值</th></tr>")
+	for _, f := range data.Funds {
+		fmt.Fprintf(&b, "<tr><td>%s(%s)</td><td>%.4f</td><td>%.4f</td><td>%s</td><td>%s</td><td>%.2f</td></tr>",
+			esc(f.FundName), esc(f.FundCode), f.StartNav, f.EndNav, signed(f.Return, "%+.2f%%"), signed(f.Profit, "%+.2f"), f.MarketValue)
+	}
+	b.WriteString("</table>")
+
+	list := func(title string, items []string, empty string) {
+		fmt.Fprintf(&b, "<h2>%s</h2>", title)
+		if len(items) == 0 {
+			fmt.Fprintf(&b, "<p class=\"muted\">%s</p>", empty)
+			return
+		}
+		b.WriteString("<ul>")
+		for _, item := range items {
+			b.WriteString("<li>" + item + "</li>")
+		}
+		b.WriteString("</ul>")
+	}
+
+	var movers []string
+	for _, f := range data.Gainers {
+		movers = append(movers, fmt.Sprintf("涨 %s %s", esc(f.FundName), signed(f.Return, "%+.2f%%")))
+	}
+	for _, f := range data.Losers {
+		movers = append(movers, fmt.Sprintf("跌 %s %s", esc(f.FundName), signed(f.Return, "%+.2f%%")))
+	}
+	list("涨跌榜", movers, "无")
+
+	var alerts []string
+	for _, a := range data.Alerts {
+		alerts = append(alerts, fmt.Sprintf("%s %s", a.TriggeredAt.Format("01-02 15:04"), esc(a.Message)))
+	}
+	list("触发提醒", alerts, "无")
+
+	var signals []string
+	for _, s := range data.Signals {
+		signals = append(signals, fmt.Sprintf("%s %s %s(%s) 强度%.0f：%s", s.GeneratedAt.Format("01-02"),
+			SignalTypeNames[s.SignalType], esc(s.FundName), esc(s.FundCode), s.SignalStrength, esc(s.Reason)))
+	}
+	list("交易信号", signals, "无")
+
+	var actions []string
+	for _, a := range data.Actions {
+		item := fmt.Sprintf("%s %s(%s) 建议 %.2f元", a.Date.Format("01-02"), esc(a.Name), esc(a.FundName), a.Amount)
+		if a.Reason != "" {
+			item += "，" + esc(a.Reason)
+		}
+		actions = append(actions, item)
+	}
+	list("待执行定投", actions, "无")
+
+	var risks []string
+	for _, c := range data.RiskChanges {
+		risks = append(risks, fmt.Sprintf("%s(%s) 风险等级 %d→%d，最大回撤 %.2f%%→%.2f%%，波动率 %.2f%%→%.2f%%",
+			esc(c.FundName), esc(c.FundCode), c.OldLevel, c.NewLevel, c.OldDrawdown, c.NewDrawdown, c.OldVolatility, c.NewVolatility))
+	}
+	list("风险变化", risks, "无明显变化")
+
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
+
+// writeReportFiles 按设置的格式保存报告文件，返回不含扩展名的路径
+func writeReportFiles(report *model.PortfolioReport, data *PortfolioReportData, settings *model.ReportSettings) (string, error) {
+	dir := settings.SaveDir
+	if dir == "" {
+		dir = filepath.Join(repository.DataDir(), "reports")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	base := filepath.Join(dir, fmt.Sprintf("%s-%s", data.EndDate.Format("2006-01-02"), data.Period))
+
+	formats := splitList(settings.Formats)
+	htmlContent := RenderReportHTML(data)
+	for _, f := range formats {
+		var err error
+		switch f {
+		case ReportFormatMarkdown:
+			err = os.WriteFile(base+".md", []byte(report.Markdown), 0644)
+		case ReportFormatHTML:
+			err = os.WriteFile(base+".html", []byte(htmlContent), 0644)
+		case ReportFormatPDF:
+			err = writeReportPDF(base, htmlContent)
+		}
+		if err != nil {
+			return base, err
+		}
+	}
+	return base, nil
+}
+
+// writeReportPDF 调用本机的wkhtmltopdf或Chrome/Edge将HTML转换为PDF
+func writeReportPDF(base, htmlContent string) error {
+	htmlPath := base + ".html"
+	if _, err := os.Stat(htmlPath); err != nil {
+		if err := os.WriteFile(htmlPath, []byte(htmlContent), 0644); err != nil {
+			return err
+		}
+	}
+	absHTML, _ := filepath.Abs(htmlPath)
+	pdfPath := base + ".pdf"
+
+	if tool, err := exec.LookPath("wkhtmltopdf"); err == nil {
+		return exec.Command(tool, "--encoding", "utf-8", "--quiet", absHTML, pdfPath).Run()
+	}
+	for _, name := range []string{"chromium", "chromium-browser", "google-chrome", "chrome", "msedge"} {
+		if tool, err := exec.LookPath(name); err == nil {
+			return exec.Command(tool, "--headless", "--disable-gpu", "--no-pdf-header-footer",
+				"--print-to-pdf="+pdfPath, fileURL(absHTML)).Run()
+		}
+	}
+	return errors.New("未找到PDF转换工具(wkhtmltopdf或Chrome)，已保存HTML")
+}
+
+// fileURL 本地绝对路径转为file URL，Windows盘符路径为file:///C:/...
+func fileURL(absPath string) string {
+	return (&url.URL{Scheme: "file", Path: "/" + strings.TrimPrefix(filepath.ToSlash(absPath), "/")}).String()
+}
+
+// StartScheduler 启动定时生成：交易日在设定时间后、当天净值公布后生成日报，周五同时生成周报
+func (r *ReportService) StartScheduler() {
+	r.mu.Lock()
+	if r.isRunning {
+		r.mu.Unlock()
+		return
+	}
+	r.isRunning = true
+	r.stopChan = make(chan bool)
+	r.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.runScheduled(time.Now())
+			case <-r.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// StopScheduler 停止定时生成
+func (r *ReportService) StopScheduler() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.isRunning {
+		r.stopChan <- true
+		r.isRunning = false
+	}
+}
+
+// pauseScheduler 停止定时生成(等待进行中的任务完成)，返回恢复的函数
+func (r *ReportService) pauseScheduler() (resume func()) {
+	r.mu.Lock()
+	running := r.isRunning
+	r.mu.Unlock()
+	if !running {
+		return func() {}
+	}
+	r.StopScheduler()
+	return r.StartScheduler
+}
+
+// runScheduled 检查并生成当天应出的报告
+func (r *ReportService) runScheduled(now time.Time) {
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		return
+	}
+	settings, err := r.reports.GetReportSettings()
+	if err != nil || (!settings.DailyEnabled && !settings.WeeklyEnabled) {
+		return
+	}
+	at, err := parseClock(settings.GenerateAt)
+	if err != nil || now.Hour()*60+now.Minute() < at {
+		return
+	}
+
+	var periods []string
+	if settings.DailyEnabled {
+		periods = append(periods, ReportDaily)
+	}
+	if settings.WeeklyEnabled && now.Weekday() == time.Friday {
+		periods = append(periods, ReportWeekly)
+	}
+
+	// 净值日按UTC零点存储
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, period := range periods {
+		if latest, err := r.reports.GetLatestPortfolioReport(period, today.AddDate(0, 0, 1)); err == nil && !latest.EndDate.Before(today) {
+			continue
+		}
+		data, err := r.Build(period)
+		if err != nil || data.EndDate.Before(today) {
+			// 当天净值尚未公布，下次再试
+			continue
+		}
+		r.save(data)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func TestFileURL(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/home/user/报告/daily.html", want: "file:///home/user/%E6%8A%A5%E5%91%8A/daily.html"},
+		{path: "/tmp/my report.html", want: "file:///tmp/my%20report.html"},
+		// Windows路径经ToSlash后以盘符开头
+		{path: "C:/Users/me/daily.html", want: "file:///C:/Users/me/daily.html"},
+	}
+	for _, tt := range tests {
+		if got := fileURL(tt.path); got != tt.want {
+			t.Errorf("fileURL(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReportNavRange(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.Local) }
+	// 按日期降序，3月8日为周五
+	list := []model.NetValueHistory{
+		{Date: day(11), NetValue: 1.5},
+		{Date: day(8), NetValue: 1.4},
+		{Date: day(7), NetValue: 1.3},
+		{Date: day(4), NetValue: 1.2},
+		{Date: day(1), NetValue: 1.1},
+	}
+
+	end, start := reportNavRange(list, day(8), ReportDaily)
+	if end == nil || start == nil || end.NetValue != 1.4 || start.NetValue != 1.3 {
+		t.Fatalf("日报: end %+v start %+v", end, start)
+	}
+	end, start = reportNavRange(list, day(8), ReportWeekly)
+	if end == nil || start == nil || end.NetValue != 1.4 || start.NetValue != 1.1 {
+		t.Fatalf("周报: end %+v start %+v", end, start)
+	}
+	if end, start = reportNavRange(list, day(1), ReportDaily); end == nil || start != nil {
+		t.Fatalf("最早一天没有期初: end %+v start %+v", end, start)
+	}
+}
+
+func TestReportRiskChanges(t *testing.T) {
+	store := newTestStore(t)
+	r := NewReportService(store)
+	endDate := time.Date(2024, 3, 8, 0, 0, 0, 0, time.Local)
+	prev := &model.PortfolioReport{
+		Period:       ReportDaily,
+		EndDate:      endDate.AddDate(0, 0, -1),
+		RiskSnapshot: `{"000001":{"maxDrawdown":10,"volatility":15,"level":2},"000002":{"maxDrawdown":10,"volatility":15,"level":2}}`,
+	}
+	if err := store.SavePortfolioReport(prev); err != nil {
+		t.Fatal(err)
+	}
+
+	data := &PortfolioReportData{
+		Period:  ReportDaily,
+		EndDate: endDate,
+		Funds: []ReportFundLine{
+			{FundCode: "000001", FundName: "波动变大"},
+			{FundCode: "000002", FundName: "变化很小"},
+			{FundCode: "000003", FundName: "新增持仓"},
+		},
+		risk: map[string]reportRisk{
+			"000001": {MaxDrawdown: 13, Volatility: 15, Level: 3},
+			"000002": {MaxDrawdown: 11, Volatility: 16, Level: 2},
+			"000003": {MaxDrawdown: 30, Volatility: 30, Level: 5},
+		},
+	}
+	changes := r.riskChanges(data)
+	if len(changes) != 1 || changes[0].FundCode != "000001" || changes[0].OldLevel != 2 || changes[0].NewLevel != 3 {
+		t.Fatalf("changes: %+v", changes)
+	}
+}
+
+func TestRenderReportEscapesHTML(t *testing.T) {
+	data := &PortfolioReportData{
+		Period:  ReportWeekly,
+		EndDate: time.Date(2024, 3, 8, 0, 0, 0, 0, time.Local),
+		Funds:   []ReportFundLine{{FundCode: "000001", FundName: "<b>基金</b>", Return: 1.5, Profit: 15}},
+	}
+	if md := RenderReportMarkdown(data); !strings.Contains(md, "<b>基金</b>") {
+		t.Fatalf("Markdown应包含基金名称:\n%s", md)
+	}
+	if page := RenderReportHTML(data); strings.Contains(page, "<b>基金</b>") || !strings.Contains(page, "&lt;b&gt;基金&lt;/b&gt;") {
+		t.Fatalf("HTML中的基金名称应转义:\n%s", page)
+	}
+	if title := reportTitle(data); title != "持仓周报 2024-03-08" {
+		t.Fatalf("title: %s", title)
+	}
+}
@@ -7,9 +7,20 @@ import (
 )
 
 // RiskService 风险分析服务
-type RiskService struct{}
+type RiskService struct {
+	funds   repository.FundRepository
+	history repository.HistoryRepository
+}
+
+// NewRiskService 使用指定仓储创建风险服务
+func NewRiskService(store repository.Store) *RiskService {
+	return &RiskService{
+		funds:   store,
+		history: store,
+	}
+}
 
-var riskService = &RiskService{}
+var riskService = NewRiskService(repository.Default())
 
 // GetRiskService 获取风险服务实例
 func GetRiskService() *RiskService {
@@ -29,7 +40,7 @@ type RiskResult struct {
 
 // AnalyzeFundRisk 分析基金风险
 func (r *RiskService) AnalyzeFundRisk(fundCode string) (*RiskResult, error) {
-	fund, err := repository.GetFund(fundCode)
+	fund, err := r.funds.GetFund(fundCode)
 	if err != nil {
 		return nil, err
 	}
@@ -37,11 +48,7 @@ func (r *RiskService) AnalyzeFundRisk(fundCode string) (*RiskResult, error) {
 	maxDrawdown, _ := r.CalculateMaxDrawdown(fundCode, 250)
 	volatility, _ := r.CalculateVolatility(fundCode, 250)
 
-	riskScore := maxDrawdown*0.4 + volatility*0.6
-	riskLevel := int(riskScore/20) + 1
-	if riskLevel > 5 {
-		riskLevel = 5
-	}
+	riskScore, riskLevel := riskScoreLevel(maxDrawdown, volatility)
 
 	suggestion := "风险适中"
 	if riskLevel >= 4 {
@@ -61,9 +68,19 @@ func (r *RiskService) AnalyzeFundRisk(fundCode string) (*RiskResult, error) {
 	}, nil
 }
 
+// riskScoreLevel 由最大回撤和波动率计算风险评分和1-5级风险等级
+func riskScoreLevel(maxDrawdown, volatility float64) (float64, int) {
+	riskScore := maxDrawdown*0.4 + volatility*0.6
+	riskLevel := int(riskScore/20) + 1
+	if riskLevel > 5 {
+		riskLevel = 5
+	}
+	return riskScore, riskLevel
+}
+
 // CalculateMaxDrawdown 计算最大回撤
 func (r *RiskService) CalculateMaxDrawdown(fundCode string, days int) (float64, error) {
-	histories, err := repository.GetNetValueHistory(fundCode, days)
+	histories, err := r.history.GetNetValueHistory(fundCode, days)
 	if err != nil || len(histories) < 2 {
 		return 0, err
 	}
@@ -85,7 +102,7 @@ func (r *RiskService) CalculateMaxDrawdown(fundCode string, days int) (float64,
 
 // CalculateVolatility 计算波动率
 func (r *RiskService) CalculateVolatility(fundCode string, days int) (float64, error) {
-	histories, err := repository.GetNetValueHistory(fundCode, days)
+	histories, err := r.history.GetNetValueHistory(fundCode, days)
 	if err != nil || len(histories) < 2 {
 		return 0, err
 	}
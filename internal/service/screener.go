@@ -0,0 +1,467 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// ScreenerService 基金筛选服务
+type ScreenerService struct {
+	history  repository.HistoryRepository
+	screener repository.ScreenerRepository
+}
+
+// NewScreenerService 使用指定仓储创建筛选服务
+func NewScreenerService(store repository.Store) *ScreenerService {
+	return &ScreenerService{
+		history:  store,
+		screener: store,
+	}
+}
+
+var screenerService = NewScreenerService(repository.Default())
+
+// GetScreenerService 获取筛选服务实例
+func GetScreenerService() *ScreenerService {
+	return screenerService
+}
+
+// 筛选字段
+const (
+	ScreenFieldReturn1Y      = "return_1y"
+	ScreenFieldReturn3Y      = "return_3y"
+	ScreenFieldReturn5Y      = "return_5y"
+	ScreenFieldMaxDrawdown   = "max_drawdown"
+	ScreenFieldSharpe        = "sharpe"
+	ScreenFieldScale         = "scale"
+	ScreenFieldFeeRate       = "fee_rate"
+	ScreenFieldManagerTenure = "manager_tenure"
+)
+
+// ScreenFieldNames 筛选字段显示名称
+var ScreenFieldNames = map[string]string{
+	ScreenFieldReturn1Y:      "近1年收益(%)",
+	ScreenFieldReturn3Y:      "近3年收益(%)",
+	ScreenFieldReturn5Y:      "近5年收益(%)",
+	ScreenFieldMaxDrawdown:   "最大回撤(%)",
+	ScreenFieldSharpe:        "夏普比率",
+	ScreenFieldScale:         "规模(亿元)",
+	ScreenFieldFeeRate:       "管理费率(%)",
+	ScreenFieldManagerTenure: "经理任职(年)",
+}
+
+// ScreenCondition 单个筛选条件
+type ScreenCondition struct {
+	Field string  `json:"field"`
+	Op    string  `json:"op"` // >= <= > < =
+	Value float64 `json:"value"`
+}
+
+// ScreenQuery 筛选条件组合(条件之间为AND关系，基金类型之间为OR关系)
+type ScreenQuery struct {
+	FundTypes  []string          `json:"fundTypes"`
+	Conditions []ScreenCondition `json:"conditions"`
+	SortField  string            `json:"sortField"`
+	SortDesc   bool              `json:"sortDesc"`
+	Limit      int               `json:"limit"`
+}
+
+// Where 追加筛选条件
+func (q ScreenQuery) Where(field, op string, value float64) ScreenQuery {
+	q.Conditions = append(append([]ScreenCondition{}, q.Conditions...), ScreenCondition{Field: field, Op: op, Value: value})
+	return q
+}
+
+// Validate 校验筛选条件
+func (q ScreenQuery) Validate() error {
+	for _, c := range q.Conditions {
+		if _, ok := ScreenFieldNames[c.Field]; !ok {
+			return fmt.Errorf("未知的筛选字段: %s", c.Field)
+		}
+		switch c.Op {
+		case ">=", "<=", ">", "<", "=":
+		default:
+			return fmt.Errorf("不支持的比较运算符: %s", c.Op)
+		}
+	}
+	if q.SortField != "" {
+		if _, ok := ScreenFieldNames[q.SortField]; !ok {
+			return fmt.Errorf("未知的排序字段: %s", q.SortField)
+		}
+	}
+	return nil
+}
+
+// Match 判断基金指标是否满足全部条件
+func (q ScreenQuery) Match(m *model.FundMetric) bool {
+	if len(q.FundTypes) > 0 {
+		matched := false
+		for _, t := range q.FundTypes {
+			if strings.Contains(m.FundType, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, c := range q.Conditions {
+		value, ok := metricValue(m, c.Field)
+		if !ok || !compare(value, c.Op, c.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// metricValue 读取指标值，历史数据不足时返回false
+func metricValue(m *model.FundMetric, field string) (float64, bool) {
+	switch field {
+	case ScreenFieldReturn1Y:
+		return m.Return1Y, m.HistoryDays >= 365
+	case ScreenFieldReturn3Y:
+		return m.Return3Y, m.HistoryDays >= 3*365
+	case ScreenFieldReturn5Y:
+		return m.Return5Y, m.HistoryDays >= 5*365
+	case ScreenFieldMaxDrawdown:
+		return m.MaxDrawdown, m.HistoryDays > 0
+	case ScreenFieldSharpe:
+		return m.SharpeRatio, m.HistoryDays >= 365
+	case ScreenFieldScale:
+		return m.Scale, m.Scale > 0
+	case ScreenFieldFeeRate:
+		return m.FeeRate, m.FeeRate > 0
+	case ScreenFieldManagerTenure:
+		return m.ManagerTenure, m.Manager != ""
+	}
+	return 0, false
+}
+
+func compare(a float64, op string, b float64) bool {
+	switch op {
+	case ">=":
+		return a >= b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case "<":
+		return a < b
+	case "=":
+		return math.Abs(a-b) < 1e-9
+	}
+	return false
+}
+
+// Screen 按条件筛选本地基金指标
+func (s *ScreenerService) Screen(query ScreenQuery) ([]model.FundMetric, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	metrics, err := s.screener.GetAllFundMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []model.FundMetric
+	for i := range metrics {
+		if query.Match(&metrics[i]) {
+			results = append(results, metrics[i])
+		}
+	}
+
+	sortField := query.SortField
+	if sortField == "" {
+		sortField = ScreenFieldReturn1Y
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		vi, oki := metricValue(&results[i], sortField)
+		vj, okj := metricValue(&results[j], sortField)
+		if oki != okj {
+			return oki // 缺少数据的排在最后
+		}
+		if query.SortDesc {
+			return vi > vj
+		}
+		return vi < vj
+	})
+
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// RefreshMetrics 以排行榜为种子更新基金指标，返回成功更新的数量
+func (s *ScreenerService) RefreshMetrics(limit int, progress func(done, total int)) (int, error) {
+	seeds, err := GetFundAPI().GetFundRanking("year", limit)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for i, seed := range seeds {
+		if _, err := s.BuildFundMetric(seed.FundCode, seed.FundName); err == nil {
+			updated++
+		}
+		if progress != nil {
+			progress(i+1, len(seeds))
+		}
+	}
+	return updated, nil
+}
+
+// 指标最长回看5年，按每年交易日数取净值
+const (
+	metricHorizonYears = 5
+	metricHistoryRows  = metricHorizonYears*tradingDaysPerYear + 10
+)
+
+// historyCovers 本地净值(按日期降序)是否覆盖since至今，基金成立晚于since时覆盖到成立日即可。
+// 节假日导致的首尾缺口按7天容忍
+func historyCovers(histories []model.NetValueHistory, profile *model.FundProfile, since time.Time) bool {
+	if len(histories) < 2 {
+		return false
+	}
+	const slack = 7 * 24 * time.Hour
+	if time.Since(histories[0].Date) > slack {
+		return false
+	}
+	oldest := histories[len(histories)-1].Date
+	if !oldest.After(since.Add(slack)) {
+		return true
+	}
+	return profile != nil && !profile.EstablishDate.IsZero() && !oldest.After(profile.EstablishDate.Add(slack))
+}
+
+// BuildFundMetric 计算并保存单只基金的筛选指标
+func (s *ScreenerService) BuildFundMetric(code, name string) (*model.FundMetric, error) {
+	api := GetFundAPI()
+	profile, _ := api.getCachedFundProfile(code)
+
+	histories, err := s.history.GetNetValueHistory(code, metricHistoryRows)
+	if err != nil || !historyCovers(histories, profile, time.Now().AddDate(-metricHorizonYears, 0, 0)) {
+		// 本地数据未覆盖最长的5年区间，从网络获取
+		histories, err = api.GetFundHistory(code, metricHistoryRows)
+		if err != nil {
+			return nil, err
+		}
+		s.history.SaveNewNetValueHistories(code, histories)
+	}
+	if len(histories) < 2 {
+		return nil, fmt.Errorf("净值历史不足: %s", code)
+	}
+
+	metric := &model.FundMetric{
+		FundCode: code,
+		FundName: name,
+	}
+
+	// 基金类型(从基金列表)
+	if fund, ok := api.findFund(code); ok {
+		metric.FundType = fund.Type
+		if metric.FundName == "" {
+			metric.FundName = fund.Name
+		}
+	}
+
+	// 历史按日期降序排列
+	latest := histories[0]
+	metric.HistoryDays = int(latest.Date.Sub(histories[len(histories)-1].Date).Hours() / 24)
+	metric.Return1Y = periodReturn(histories, latest.Date.AddDate(-1, 0, 0))
+	metric.Return3Y = periodReturn(histories, latest.Date.AddDate(-3, 0, 0))
+	metric.Return5Y = periodReturn(histories, latest.Date.AddDate(-5, 0, 0))
+	metric.MaxDrawdown = maxDrawdownSince(histories, latest.Date.AddDate(-3, 0, 0))
+	metric.SharpeRatio = sharpeSince(histories, latest.Date.AddDate(-1, 0, 0))
+
+	if profile != nil {
+		metric.Scale = profile.Scale
+		metric.FeeRate = profile.FeeRate
+	}
+
+	if manager, since, err := api.GetCurrentManager(code); err == nil {
+		metric.Manager = manager
+		if !since.IsZero() {
+			metric.ManagerTenure = math.Round(time.Since(since).Hours()/24/365*10) / 10
+		}
+	}
+
+	if err := s.screener.SaveFundMetric(metric); err != nil {
+		return nil, err
+	}
+	return metric, nil
+}
+
+// periodReturn 计算从指定日期至今的收益率(%)，使用累计净值以包含分红
+func periodReturn(histories []model.NetValueHistory, from time.Time) float64 {
+	base := navOnOrBefore(histories, from)
+	if base <= 0 {
+		return 0
+	}
+	return (navValue(histories[0])/base - 1) * 100
+}
+
+// navOnOrBefore 获取指定日期(含)之前最近一天的净值，histories按日期降序
+func navOnOrBefore(histories []model.NetValueHistory, date time.Time) float64 {
+	for _, h := range histories {
+		if !h.Date.After(date) {
+			return navValue(h)
+		}
+	}
+	return 0
+}
+
+func navValue(h model.NetValueHistory) float64 {
+	if h.TotalValue > 0 {
+		return h.TotalValue
+	}
+	return h.NetValue
+}
+
+// maxDrawdownSince 计算指定日期以来的最大回撤(%)
+func maxDrawdownSince(histories []model.NetValueHistory, from time.Time) float64 {
+	maxDrawdown, peak := 0.0, 0.0
+	for i := len(histories) - 1; i >= 0; i-- {
+		if histories[i].Date.Before(from) {
+			continue
+		}
+		nav := navValue(histories[i])
+		if nav > peak {
+			peak = nav
+		}
+		if peak > 0 {
+			if dd := (peak - nav) / peak * 100; dd > maxDrawdown {
+				maxDrawdown = dd
+			}
+		}
+	}
+	return maxDrawdown
+}
+
+// sharpeSince 计算指定日期以来的年化夏普比率
+func sharpeSince(histories []model.NetValueHistory, from time.Time) float64 {
+	var returns []float64
+	for i := 0; i < len(histories)-1; i++ {
+		if histories[i].Date.Before(from) {
+			break
+		}
+		prev := navValue(histories[i+1])
+		if prev > 0 {
+			returns = append(returns, navValue(histories[i])/prev-1)
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+
+	dailyRf := GetSettingsService().Get().RiskFreeRate / 100 / tradingDaysPerYear
+	return math.Round((mean-dailyRf)/std*math.Sqrt(tradingDaysPerYear)*100) / 100
+}
+
+// SaveScreen 保存筛选方案
+func (s *ScreenerService) SaveScreen(name string, query ScreenQuery) (*model.FundScreen, error) {
+	if name == "" {
+		return nil, fmt.Errorf("请输入方案名称")
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	queryJSON, _ := json.Marshal(query)
+	screen := &model.FundScreen{
+		Name:  name,
+		Query: string(queryJSON),
+	}
+	if err := s.screener.SaveFundScreen(screen); err != nil {
+		return nil, err
+	}
+	return screen, nil
+}
+
+// GetScreens 获取保存的筛选方案
+func (s *ScreenerService) GetScreens() ([]model.FundScreen, error) {
+	return s.screener.GetAllFundScreens()
+}
+
+// DeleteScreen 删除筛选方案
+func (s *ScreenerService) DeleteScreen(id uint) error {
+	return s.screener.DeleteFundScreen(id)
+}
+
+// ParseScreenQuery 解析保存的筛选条件
+func (s *ScreenerService) ParseScreenQuery(screen *model.FundScreen) (ScreenQuery, error) {
+	var query ScreenQuery
+	err := json.Unmarshal([]byte(screen.Query), &query)
+	return query, err
+}
+
+// ExportCSV 导出筛选结果为CSV(带BOM，便于Excel打开)
+func (s *ScreenerService) ExportCSV(metrics []model.FundMetric, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	file.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(file)
+	w.Write([]string{"基金代码", "基金名称", "基金类型", "近1年收益(%)", "近3年收益(%)", "近5年收益(%)",
+		"最大回撤(%)", "夏普比率", "规模(亿元)", "管理费率(%)", "基金经理", "任职年限", "更新时间"})
+
+	for i := range metrics {
+		m := &metrics[i]
+		w.Write([]string{
+			m.FundCode,
+			m.FundName,
+			m.FundType,
+			FormatMetric(m, ScreenFieldReturn1Y),
+			FormatMetric(m, ScreenFieldReturn3Y),
+			FormatMetric(m, ScreenFieldReturn5Y),
+			FormatMetric(m, ScreenFieldMaxDrawdown),
+			FormatMetric(m, ScreenFieldSharpe),
+			FormatMetric(m, ScreenFieldScale),
+			FormatMetric(m, ScreenFieldFeeRate),
+			m.Manager,
+			FormatMetric(m, ScreenFieldManagerTenure),
+			m.UpdatedAt.Format("2006-01-02 15:04"),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// FormatMetric 格式化指标值，缺少数据时显示"-"
+func FormatMetric(m *model.FundMetric, field string) string {
+	value, ok := metricValue(m, field)
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", value)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+// dailyHistory 从今天往前days天每天一条净值，按日期降序
+func dailyHistory(days int) []model.NetValueHistory {
+	today := time.Now().Truncate(24 * time.Hour)
+	histories := make([]model.NetValueHistory, days)
+	for i := range histories {
+		histories[i] = model.NetValueHistory{FundCode: "000001", Date: today.AddDate(0, 0, -i), NetValue: 1}
+	}
+	return histories
+}
+
+func TestHistoryCovers(t *testing.T) {
+	since := time.Now().AddDate(-metricHorizonYears, 0, 0)
+	full := dailyHistory(5*365 + 2)
+	// 行数达到metricHistoryRows但只覆盖约3.5年
+	partial := dailyHistory(metricHistoryRows)
+	oldest := partial[len(partial)-1].Date
+
+	tests := []struct {
+		name      string
+		histories []model.NetValueHistory
+		profile   *model.FundProfile
+		want      bool
+	}{
+		{name: "覆盖5年", histories: full, want: true},
+		{name: "行数足够但未覆盖区间", histories: partial, want: false},
+		{name: "成立日之后全部覆盖", histories: partial, profile: &model.FundProfile{EstablishDate: oldest.AddDate(0, 0, -3)}, want: true},
+		{name: "成立较早但缺少早期数据", histories: partial, profile: &model.FundProfile{EstablishDate: oldest.AddDate(-1, 0, 0)}, want: false},
+		{name: "最新净值过旧", histories: full[30:], want: false},
+		{name: "数据不足", histories: full[:1], want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := historyCovers(tt.histories, tt.profile, since); got != tt.want {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
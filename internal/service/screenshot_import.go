@@ -0,0 +1,387 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// OCREngine 文字识别引擎，可替换为内嵌的Tesseract绑定等实现
+type OCREngine interface {
+	Recognize(imagePath string) (string, error)
+}
+
+// CommandOCR 调用外部OCR命令，模板中的{image}替换为截图路径，识别结果从标准输出读取
+type CommandOCR struct {
+	Template string
+	Timeout  time.Duration
+}
+
+// Recognize 实现OCREngine
+func (c CommandOCR) Recognize(imagePath string) (string, error) {
+	args := strings.Fields(c.Template)
+	if len(args) == 0 {
+		return "", fmt.Errorf("未配置OCR命令，请安装Tesseract或填写OCR命令")
+	}
+	for i, a := range args {
+		args[i] = strings.ReplaceAll(a, "{image}", imagePath)
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("OCR失败: %v: %s", err, msg)
+		}
+		return "", fmt.Errorf("OCR失败: %v", err)
+	}
+	return stdout.String(), nil
+}
+
+// DefaultOCRCommand 默认OCR命令：环境变量JIJIN_OCR_COMMAND，否则使用PATH中的tesseract
+func DefaultOCRCommand() string {
+	if cmd := os.Getenv("JIJIN_OCR_COMMAND"); cmd != "" {
+		return cmd
+	}
+	if _, err := exec.LookPath("tesseract"); err == nil {
+		return "tesseract {image} stdout -l chi_sim+eng --psm 6"
+	}
+	return ""
+}
+
+// ScreenshotHolding 从截图识别出的一条持仓，确认前可编辑
+type ScreenshotHolding struct {
+	FundName    string  // 识别出的名称
+	FundCode    string  // 匹配到的代码，未匹配为空
+	MatchedName string  // 代码对应的基金全称
+	Amount      float64 // 持有金额(市值)
+	Profit      float64 // 持有收益
+	Exact       bool    // 名称与基金全称完全一致
+}
+
+// ScreenshotService 截图导入服务
+type ScreenshotService struct {
+	ocr OCREngine
+}
+
+var screenshotService = &ScreenshotService{ocr: CommandOCR{Template: DefaultOCRCommand()}}
+
+// GetScreenshotService 获取截图导入服务实例
+func GetScreenshotService() *ScreenshotService {
+	return screenshotService
+}
+
+// SetOCREngine 替换OCR引擎
+func (s *ScreenshotService) SetOCREngine(engine OCREngine) {
+	s.ocr = engine
+}
+
+// OCRCommand 当前使用的OCR命令模板，非命令引擎返回空
+func (s *ScreenshotService) OCRCommand() string {
+	if c, ok := s.ocr.(CommandOCR); ok {
+		return c.Template
+	}
+	return ""
+}
+
+// SetOCRCommand 使用外部命令作为OCR引擎
+func (s *ScreenshotService) SetOCRCommand(template string) {
+	s.ocr = CommandOCR{Template: strings.TrimSpace(template)}
+}
+
+// Recognize 识别截图并解析持仓，返回识别原文便于核对
+func (s *ScreenshotService) Recognize(imagePath string) ([]ScreenshotHolding, string, error) {
+	text, err := s.ocr.Recognize(imagePath)
+	if err != nil {
+		return nil, "", err
+	}
+	holdings := ParseHoldingScreenshot(text)
+	for i := range holdings {
+		s.MatchFund(&holdings[i])
+	}
+	return holdings, text, nil
+}
+
+// MatchFund 按名称在基金列表中匹配代码，名称中带代码时直接使用
+func (s *ScreenshotService) MatchFund(h *ScreenshotHolding) {
+	if code, name := splitFundCode("", h.FundName); code != "" {
+		h.FundCode, h.FundName = code, name
+	}
+	keyword := h.FundCode
+	if keyword == "" {
+		keyword = h.FundName
+	}
+	results, _ := GetFundAPI().SearchFund(keyword)
+	if len(results) == 0 {
+		return
+	}
+	h.FundCode = results[0].Code
+	h.MatchedName = results[0].Name
+	h.Exact = results[0].Name == h.FundName || results[0].Code == keyword
+}
+
+// 截图中的标签
+const (
+	labelAmount    = "金额"
+	labelYesterday = "昨日收益"
+	labelProfit    = "持有收益"
+)
+
+var (
+	ocrNumber = regexp.MustCompile(`[+-]?\d[\d,]*(?:\.\d+)?%?`)
+	// ocrFundWords 基金名称中常见的词
+	ocrFundWords = regexp.MustCompile(`混合|股票|债|指数|ETF|联接|LOF|QDII|FOF|基金|精选|成长|价值|配置|货币|主题|行业|优选|[AC]类?$`)
+	// ocrLabels 页面中的标签和按钮文字，所在行不作为基金名称
+	ocrLabels = []string{"金额", "收益", "资产", "市值", "持仓", "我的", "全部", "排序", "总额", "份额"}
+	// ocrReplacer 全角符号转半角
+	ocrReplacer = strings.NewReplacer("，", ",", "．", ".", "－", "-", "−", "-", "—", "-", "＋", "+", "％", "%", "¥", "", "￥", "", "…", "", "...", "")
+)
+
+// normalizeOCRLine 统一符号并去掉汉字之间的空格(中文OCR常在字间插入空格)
+func normalizeOCRLine(line string) string {
+	line = ocrReplacer.Replace(strings.TrimSpace(line))
+	runes := []rune(line)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsSpace(r) && i > 0 && i < len(runes)-1 &&
+			(unicode.Is(unicode.Han, runes[i-1]) || unicode.Is(unicode.Han, runes[i+1])) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// hanCount 汉字数
+func hanCount(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			n++
+		}
+	}
+	return n
+}
+
+// fundNameOfLine 行首的基金名称部分，非名称行返回空
+func fundNameOfLine(line string) string {
+	name := line
+	// 名称后直接跟金额的情况，金额带小数；名称中的代码和"纳斯达克100"等整数保留
+	for _, loc := range ocrNumber.FindAllStringIndex(line, -1) {
+		if loc[0] > 0 && strings.Contains(line[loc[0]:loc[1]], ".") {
+			name = strings.TrimSpace(line[:loc[0]])
+			break
+		}
+	}
+	if hanCount(name) < 4 {
+		return ""
+	}
+	if ocrFundWords.MatchString(name) {
+		return name
+	}
+	for _, label := range ocrLabels {
+		if strings.Contains(name, label) {
+			return ""
+		}
+	}
+	if hanCount(name) >= 6 {
+		return name
+	}
+	return ""
+}
+
+// ParseHoldingScreenshot 从持仓页OCR文字中解析基金名称、持有金额和持有收益。
+// 名称行之后到下一个名称行之间的数字归属该基金；带标签的数字按标签取值，
+// 否则按表头中"金额/昨日收益/持有收益"的顺序，没有表头时按支付宝的顺序
+func ParseHoldingScreenshot(text string) []ScreenshotHolding {
+	order := []string{labelAmount, labelYesterday, labelProfit}
+	var holdings []ScreenshotHolding
+	var numbers []float64
+	labeled := make(map[string]float64)
+
+	flush := func() {
+		if len(holdings) == 0 {
+			return
+		}
+		h := &holdings[len(holdings)-1]
+		for i, v := range numbers {
+			if i >= len(order) {
+				break
+			}
+			switch order[i] {
+			case labelAmount:
+				h.Amount = v
+			case labelProfit:
+				h.Profit = v
+			}
+		}
+		// 只有两个数字时视为金额和持有收益
+		if len(numbers) == 2 && len(order) == 3 {
+			h.Profit = numbers[1]
+		}
+		if v, ok := labeled[labelAmount]; ok {
+			h.Amount = v
+		}
+		if v, ok := labeled[labelProfit]; ok {
+			h.Profit = v
+		}
+		numbers = nil
+		labeled = make(map[string]float64)
+	}
+
+	for _, raw := range strings.Split(text, "\n") {
+		line := normalizeOCRLine(raw)
+		if line == "" {
+			continue
+		}
+		if header := headerOrder(line); header != nil && len(holdings) == 0 {
+			order = header
+			continue
+		}
+		if name := fundNameOfLine(line); name != "" {
+			flush()
+			holdings = append(holdings, ScreenshotHolding{FundName: name})
+			line = strings.TrimSpace(strings.TrimPrefix(line, name))
+		}
+		if len(holdings) == 0 {
+			continue
+		}
+		for _, seg := range labeledSegments(line) {
+			v, ok := parseOCRNumber(seg.text)
+			if !ok {
+				continue
+			}
+			if seg.label != "" {
+				labeled[seg.label] = v
+			} else {
+				numbers = append(numbers, v)
+			}
+		}
+	}
+	flush()
+
+	valid := holdings[:0]
+	for _, h := range holdings {
+		if h.Amount > 0 {
+			valid = append(valid, h)
+		}
+	}
+	return valid
+}
+
+// headerOrder 表头行中金额和收益标签的顺序，不是表头返回nil
+func headerOrder(line string) []string {
+	if !strings.Contains(line, labelAmount) || !strings.Contains(line, labelProfit) || ocrNumber.MatchString(line) {
+		return nil
+	}
+	type pos struct {
+		label string
+		idx   int
+	}
+	var found []pos
+	for _, label := range []string{labelAmount, labelYesterday, labelProfit} {
+		if idx := strings.Index(line, label); idx >= 0 {
+			found = append(found, pos{label, idx})
+		}
+	}
+	for i := 1; i < len(found); i++ {
+		for j := i; j > 0 && found[j].idx < found[j-1].idx; j-- {
+			found[j], found[j-1] = found[j-1], found[j]
+		}
+	}
+	order := make([]string, len(found))
+	for i, p := range found {
+		order[i] = p.label
+	}
+	return order
+}
+
+type ocrSegment struct {
+	label string
+	text  string
+}
+
+// labeledSegments 拆分行中的数字，紧跟在标签后的数字带上标签；百分比不计入
+func labeledSegments(line string) []ocrSegment {
+	var segs []ocrSegment
+	last := 0
+	for _, loc := range ocrNumber.FindAllStringIndex(line, -1) {
+		text := line[loc[0]:loc[1]]
+		prefix := line[last:loc[0]]
+		last = loc[1]
+		if strings.HasSuffix(text, "%") || strings.Contains(prefix, "率") {
+			continue
+		}
+		label := ""
+		for _, l := range []string{labelYesterday, labelProfit, labelAmount} {
+			if strings.Contains(prefix, l) {
+				label = l
+				break
+			}
+		}
+		segs = append(segs, ocrSegment{label: label, text: text})
+	}
+	return segs
+}
+
+// parseOCRNumber 解析OCR数字，排除年份、代码等不含小数点的长整数
+func parseOCRNumber(text string) (float64, bool) {
+	if !strings.Contains(text, ".") && len(strings.Trim(text, "+-,")) >= 6 {
+		return 0, false
+	}
+	v, err := parseImportNumber(text)
+	return v, err == nil
+}
+
+// PrepareScreenshotImport 将确认后的截图持仓转为导入预览：按最新净值折算份额，成本为金额减持有收益
+func (s *ScreenshotService) PrepareScreenshotImport(sourceName string, holdings []ScreenshotHolding) *ImportPreview {
+	preview := &ImportPreview{
+		FileName:   "截图",
+		Source:     ImportSource{Key: "screenshot", Name: sourceName},
+		Kind:       ImportKindHoldings,
+		ImportType: "screenshot",
+	}
+	for i, h := range holdings {
+		row := ImportRow{Line: i + 1, Status: ImportRowOK, FundCode: h.FundCode, FundName: h.FundName, MarketValue: h.Amount}
+		if h.MatchedName != "" {
+			row.FundName = h.MatchedName
+		}
+		row.Cost = h.Amount - h.Profit
+		switch {
+		case !fundCodePattern.MatchString(h.FundCode):
+			row.Status, row.Message = ImportRowInvalid, "基金代码无效"
+		case h.Amount <= 0:
+			row.Status, row.Message = ImportRowInvalid, "持有金额必须大于0"
+		case row.Cost <= 0:
+			row.Status, row.Message = ImportRowInvalid, "持有收益不能超过持有金额"
+		default:
+			fund, err := GetFundAPI().GetFundNetValue(h.FundCode)
+			if err != nil || fund.NetValue <= 0 {
+				row.Status, row.Message = ImportRowInvalid, "获取最新净值失败，无法折算份额"
+				break
+			}
+			row.NetValue = fund.NetValue
+			row.Shares = h.Amount / fund.NetValue
+			if row.FundName == "" {
+				row.FundName = fund.Name
+			}
+		}
+		preview.Rows = append(preview.Rows, row)
+	}
+	GetImportService().markDuplicateHoldings(preview.Rows)
+	return preview
+}
@@ -0,0 +1,86 @@
+package service
+
+import "testing"
+
+func TestParseHoldingScreenshot(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []ScreenshotHolding
+	}{
+		{
+			name: "支付宝按表头顺序",
+			text: `总资产(元) 20,000.00
+金额/昨日收益   持有收益/率
+易方达蓝筹精选混合
+12,345.67   +23.45   +1,234.56
++11.11%
+招 商 中 证 白 酒 指 数 (LOF) A
+5,000.00 -12.30 -800.00 -13.79%
+易方达消费行业股票
+3,000.00 +200.00
+华宝中证100指数A 1,500.00 +50.00`,
+			want: []ScreenshotHolding{
+				{FundName: "易方达蓝筹精选混合", Amount: 12345.67, Profit: 1234.56},
+				{FundName: "招商中证白酒指数(LOF) A", Amount: 5000, Profit: -800},
+				// 只有两个数字时为金额和持有收益
+				{FundName: "易方达消费行业股票", Amount: 3000, Profit: 200},
+				// 名称与金额在同一行
+				{FundName: "华宝中证100指数A", Amount: 1500, Profit: 50},
+			},
+		},
+		{
+			name: "表头为持有收益在前",
+			text: `持有收益  金额
+广发纳斯达克100ETF联接人民币(QDII)A
++320.50  4,800.00`,
+			want: []ScreenshotHolding{{FundName: "广发纳斯达克100ETF联接人民币(QDII)A", Amount: 4800, Profit: 320.5}},
+		},
+		{
+			name: "带标签的数字",
+			text: `华夏成长混合(000001)
+持有收益 -150.00 持有金额 2,000.00
+昨日收益 +3.20 收益率 -6.98%
+我的持仓
+持有份额 1,234.00`,
+			want: []ScreenshotHolding{{FundName: "华夏成长混合(000001)", Amount: 2000, Profit: -150}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseHoldingScreenshot(tt.text)
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %d holdings, got %+v", len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("holding %d: want %+v, got %+v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseOCRNumber(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{text: "12,345.67", want: 12345.67, ok: true},
+		{text: "-800.00", want: -800, ok: true},
+		{text: "2024", want: 2024, ok: true},
+		// 不含小数点的长整数为代码或日期
+		{text: "000001", ok: false},
+		{text: "20240115", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseOCRNumber(tt.text)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseOCRNumber(%q) = %v, %v", tt.text, got, ok)
+		}
+	}
+	if got := normalizeOCRLine(" 易 方 达 蓝筹 ￥1，000．00 "); got != "易方达蓝筹1,000.00" {
+		t.Errorf("normalizeOCRLine: %q", got)
+	}
+}
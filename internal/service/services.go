@@ -0,0 +1,27 @@
+package service
+
+import "jijin/internal/repository"
+
+// Init 使用指定仓储重新创建全部服务实例，需在启动定时任务和注册回调之前调用
+func Init(store repository.Store) {
+	fundAPI = NewFundAPI(store)
+	portfolioService = NewPortfolioService(store)
+	strategyService = NewStrategyService(store)
+	watchlistService = NewWatchlistService(store)
+	alertService = NewAlertService(store)
+	notifyService = NewNotifyService(store)
+	signalService = NewSignalService(store)
+	reportService = NewReportService(store)
+	backupService = NewBackupService(store)
+	settingsService = NewSettingsService(store)
+	importService = NewImportService(store)
+	calculatorService = NewCalculatorService(store)
+	predictionService = NewPredictionService(store)
+	riskService = NewRiskService(store)
+	rankingService = NewRankingService(store)
+	screenerService = NewScreenerService(store)
+	estimationService = NewEstimationService(store)
+	institutionService = NewInstitutionService(store)
+	intradayService = NewIntradayService(store)
+	qdiiService = NewQDIIService(store)
+}
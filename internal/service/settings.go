@@ -0,0 +1,251 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"jijin/internal/config"
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// 自动刷新时段
+const (
+	RefreshTrading = "trading" // 仅交易时间
+	RefreshAlways  = "always"  // 全天
+	RefreshManual  = "manual"  // 仅手动刷新
+)
+
+// RefreshPolicies 自动刷新时段选项
+var RefreshPolicies = []string{RefreshTrading, RefreshAlways, RefreshManual}
+
+// RefreshPolicyNames 自动刷新时段显示名称
+var RefreshPolicyNames = map[string]string{
+	RefreshTrading: "仅交易时间(工作日9:30-15:00)",
+	RefreshAlways:  "全天",
+	RefreshManual:  "仅手动刷新",
+}
+
+// 主题
+const (
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+	ThemeSystem = "system"
+)
+
+// Themes 主题选项
+var Themes = []string{ThemeLight, ThemeDark, ThemeSystem}
+
+// ThemeNames 主题显示名称
+var ThemeNames = map[string]string{
+	ThemeLight:  "浅色",
+	ThemeDark:   "深色",
+	ThemeSystem: "跟随系统",
+}
+
+// SettingsService 应用设置服务，缓存设置供各服务读取
+type SettingsService struct {
+	repo repository.SettingsRepository
+
+	mu        sync.RWMutex
+	settings  *model.AppSettings
+	listeners []func(model.AppSettings)
+}
+
+// NewSettingsService 使用指定仓储创建设置服务
+func NewSettingsService(store repository.Store) *SettingsService {
+	return &SettingsService{repo: store}
+}
+
+var settingsService = NewSettingsService(repository.Default())
+
+// GetSettingsService 获取设置服务实例
+func GetSettingsService() *SettingsService {
+	return settingsService
+}
+
+// Get 当前设置，数据库未打开时为默认值
+func (s *SettingsService) Get() model.AppSettings {
+	s.mu.RLock()
+	cached := s.settings
+	s.mu.RUnlock()
+	if cached != nil {
+		return *cached
+	}
+	if !s.repo.Opened() {
+		return *repository.DefaultAppSettings()
+	}
+	s.Reload()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return *s.settings
+}
+
+// Reload 从数据库重新读取设置并应用，恢复备份后调用
+func (s *SettingsService) Reload() {
+	settings, err := s.repo.GetAppSettings()
+	if err != nil {
+		settings = repository.DefaultAppSettings()
+	}
+	s.mu.Lock()
+	s.settings = settings
+	listeners := s.listeners
+	s.mu.Unlock()
+	applySettings(*settings)
+	for _, fn := range listeners {
+		fn(*settings)
+	}
+}
+
+// Save 校验并保存设置，立即应用并通知监听者
+func (s *SettingsService) Save(settings *model.AppSettings) error {
+	if err := ValidateSettings(settings); err != nil {
+		return err
+	}
+	if err := s.repo.SaveAppSettings(settings); err != nil {
+		return err
+	}
+	saved := *settings
+	s.mu.Lock()
+	s.settings = &saved
+	listeners := s.listeners
+	s.mu.Unlock()
+	applySettings(saved)
+	for _, fn := range listeners {
+		fn(saved)
+	}
+	return nil
+}
+
+// OnChange 注册设置变更回调
+func (s *SettingsService) OnChange(fn func(model.AppSettings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// ValidateSettings 校验设置
+func ValidateSettings(settings *model.AppSettings) error {
+	switch {
+	case settings.RefreshMinutes < 1:
+		return errors.New("刷新间隔至少1分钟")
+	case settings.MonitorMinutes < 1:
+		return errors.New("提醒监控间隔至少1分钟")
+	case RefreshPolicyNames[settings.RefreshPolicy] == "":
+		return fmt.Errorf("不支持的刷新时段: %s", settings.RefreshPolicy)
+	case settings.RiskFreeRate < 0 || settings.RiskFreeRate > 20:
+		return errors.New("无风险利率应在0-20%之间")
+	case settings.MCSimulations < 100 || settings.MCSimulations > 100000:
+		return errors.New("模拟次数应在100-100000之间")
+	case settings.BuyFeeRate < 0 || settings.BuyFeeRate > 5 || settings.SellFeeRate < 0 || settings.SellFeeRate > 5:
+		return errors.New("默认费率应在0-5%之间")
+	case ThemeNames[settings.Theme] == "":
+		return fmt.Errorf("不支持的主题: %s", settings.Theme)
+	}
+	if settings.ProxyURL != "" {
+		u, err := url.Parse(settings.ProxyURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return fmt.Errorf("代理地址格式错误，应为 http://主机:端口 或 socks5://主机:端口")
+		}
+	}
+	return nil
+}
+
+// applySettings 应用需要立即生效的设置
+func applySettings(settings model.AppSettings) {
+	if settings.ProxyURL != "" {
+		client.SetProxy(settings.ProxyURL)
+	} else {
+		client.RemoveProxy()
+	}
+}
+
+// AutoRefreshInterval 自动刷新间隔
+func (s *SettingsService) AutoRefreshInterval() time.Duration {
+	return time.Duration(s.Get().RefreshMinutes) * time.Minute
+}
+
+// MonitorInterval 提醒监控间隔
+func (s *SettingsService) MonitorInterval() time.Duration {
+	return time.Duration(s.Get().MonitorMinutes) * time.Minute
+}
+
+// ShouldAutoRefresh 按刷新时段判断此时是否自动刷新
+func (s *SettingsService) ShouldAutoRefresh(now time.Time) bool {
+	switch s.Get().RefreshPolicy {
+	case RefreshAlways:
+		return true
+	case RefreshManual:
+		return false
+	}
+	return IsTradingTime(now)
+}
+
+// DefaultFee 按默认费率估算手续费，sell为赎回
+func (s *SettingsService) DefaultFee(amount float64, sell bool) float64 {
+	settings := s.Get()
+	rate := settings.BuyFeeRate
+	if sell {
+		rate = settings.SellFeeRate
+	}
+	if amount <= 0 || rate <= 0 {
+		return 0
+	}
+	if sell {
+		return math.Round(amount*rate/100*100) / 100
+	}
+	// 申购费按净额计算: 金额-金额/(1+费率)
+	return math.Round((amount-amount/(1+rate/100))*100) / 100
+}
+
+// DataDir 当前数据目录
+func (s *SettingsService) DataDir() string {
+	return repository.DataDir()
+}
+
+// SchemaVersion 数据库当前结构版本和程序支持的最新版本
+func (s *SettingsService) SchemaVersion() (current, latest int) {
+	current, _ = s.repo.CurrentSchemaVersion()
+	return current, repository.SchemaVersion
+}
+
+// ChangeDataDir 修改数据目录，重启后生效。migrate为true时将当前数据库复制到新目录
+func (s *SettingsService) ChangeDataDir(dir string, migrate bool) error {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		dir = config.DefaultDataDir()
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return fmt.Errorf("无法创建数据目录: %v", err)
+	}
+	if migrate && abs != s.DataDir() {
+		target := filepath.Join(abs, filepath.Base(repository.DBPath()))
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("新目录中已有数据库%s，请先移走或不迁移数据", target)
+		}
+		if err := s.repo.SnapshotDB(target); err != nil {
+			return fmt.Errorf("迁移数据失败: %v", err)
+		}
+	}
+
+	f, err := config.Load()
+	if err != nil {
+		return err
+	}
+	f.DataDir = abs
+	if abs == config.DefaultDataDir() {
+		f.DataDir = ""
+	}
+	return config.Save(f)
+}
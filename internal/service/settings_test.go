@@ -0,0 +1,109 @@
+package service
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"jijin/internal/config"
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(s *model.AppSettings)
+		wantErr string
+	}{
+		{name: "默认设置", modify: func(s *model.AppSettings) {}},
+		{name: "socks5代理", modify: func(s *model.AppSettings) { s.ProxyURL = "socks5://127.0.0.1:1080" }},
+		{name: "刷新间隔", modify: func(s *model.AppSettings) { s.RefreshMinutes = 0 }, wantErr: "刷新间隔"},
+		{name: "刷新时段", modify: func(s *model.AppSettings) { s.RefreshPolicy = "night" }, wantErr: "刷新时段"},
+		{name: "无风险利率", modify: func(s *model.AppSettings) { s.RiskFreeRate = -1 }, wantErr: "无风险利率"},
+		{name: "模拟次数", modify: func(s *model.AppSettings) { s.MCSimulations = 10 }, wantErr: "模拟次数"},
+		{name: "费率", modify: func(s *model.AppSettings) { s.SellFeeRate = 6 }, wantErr: "费率"},
+		{name: "主题", modify: func(s *model.AppSettings) { s.Theme = "blue" }, wantErr: "主题"},
+		{name: "代理缺少协议", modify: func(s *model.AppSettings) { s.ProxyURL = "127.0.0.1:1080" }, wantErr: "代理地址"},
+		{name: "代理协议不支持", modify: func(s *model.AppSettings) { s.ProxyURL = "ftp://127.0.0.1" }, wantErr: "代理地址"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := repository.DefaultAppSettings()
+			tt.modify(settings)
+			err := ValidateSettings(settings)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("want error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSettingsSaveNotifiesListeners(t *testing.T) {
+	store := newTestStore(t)
+	s := NewSettingsService(store)
+	if got := s.Get(); got.RefreshMinutes != 5 || got.RefreshPolicy != RefreshTrading {
+		t.Fatalf("未保存过时使用默认设置: %+v", got)
+	}
+
+	var notified model.AppSettings
+	s.OnChange(func(settings model.AppSettings) { notified = settings })
+
+	settings := s.Get()
+	settings.RefreshMinutes = 0
+	if err := s.Save(&settings); err == nil {
+		t.Fatal("无效设置不应保存")
+	}
+	settings.RefreshMinutes, settings.RefreshPolicy, settings.BuyFeeRate = 10, RefreshManual, 1.5
+	if err := s.Save(&settings); err != nil {
+		t.Fatal(err)
+	}
+	if notified.RefreshMinutes != 10 || s.AutoRefreshInterval() != 10*time.Minute {
+		t.Fatalf("保存后应通知并更新缓存: %+v", notified)
+	}
+	if s.ShouldAutoRefresh(time.Date(2024, 3, 8, 10, 0, 0, 0, time.Local)) {
+		t.Fatal("仅手动刷新时不自动刷新")
+	}
+
+	// 重新读取数据库中的设置
+	reloaded := NewSettingsService(store)
+	if got := reloaded.Get(); got.RefreshMinutes != 10 || got.BuyFeeRate != 1.5 {
+		t.Fatalf("reloaded: %+v", got)
+	}
+	// 申购费按净额计算，赎回费按金额计算
+	if fee := reloaded.DefaultFee(1015, false); fee != 15 {
+		t.Fatalf("申购费: %v", fee)
+	}
+	if fee := reloaded.DefaultFee(1000, true); fee != 5 {
+		t.Fatalf("赎回费: %v", fee)
+	}
+}
+
+func TestChangeDataDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.DataDirEnv, "")
+	s := NewSettingsService(newTestStore(t))
+
+	dir := filepath.Join(home, "data")
+	if err := s.ChangeDataDir(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.DataDir(); got != dir {
+		t.Fatalf("want %s, got %s", dir, got)
+	}
+	// 改回默认目录时清空配置
+	if err := s.ChangeDataDir("", false); err != nil {
+		t.Fatal(err)
+	}
+	if f, _ := config.Load(); f.DataDir != "" || config.DataDir() != config.DefaultDataDir() {
+		t.Fatalf("config: %+v", f)
+	}
+}
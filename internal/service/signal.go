@@ -1,6 +1,10 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"jijin/internal/model"
@@ -8,9 +12,26 @@ import (
 )
 
 // SignalService 波段信号服务
-type SignalService struct{}
+type SignalService struct {
+	funds     repository.FundRepository
+	holdings  repository.HoldingRepository
+	history   repository.HistoryRepository
+	watchlist repository.WatchlistRepository
+	signals   repository.SignalRepository
+}
+
+// NewSignalService 使用指定仓储创建信号服务
+func NewSignalService(store repository.Store) *SignalService {
+	return &SignalService{
+		funds:     store,
+		holdings:  store,
+		history:   store,
+		watchlist: store,
+		signals:   store,
+	}
+}
 
-var signalService = &SignalService{}
+var signalService = NewSignalService(repository.Default())
 
 // GetSignalService 获取信号服务实例
 func GetSignalService() *SignalService {
@@ -24,9 +45,134 @@ const (
 	SignalHold = "hold"
 )
 
-// GenerateSignal 生成交易信号
+// SignalTypeNames 信号类型显示名称
+var SignalTypeNames = map[string]string{
+	SignalBuy:  "买入",
+	SignalSell: "卖出",
+	SignalHold: "观望",
+}
+
+// 信号状态
+const (
+	SignalStatusActive     = "active"
+	SignalStatusSuperseded = "superseded"
+	SignalStatusExpired    = "expired"
+	SignalStatusTarget     = "target"
+	SignalStatusStopped    = "stopped"
+)
+
+// SignalStatusNames 信号状态显示名称
+var SignalStatusNames = map[string]string{
+	SignalStatusActive:     "有效",
+	SignalStatusSuperseded: "已被取代",
+	SignalStatusExpired:    "已过期",
+	SignalStatusTarget:     "已达目标",
+	SignalStatusStopped:    "已止损",
+}
+
+// 信号有效期(自然日)
+const (
+	signalValidDays     = 10
+	holdSignalValidDays = 3
+)
+
+// 参与投票的指标
+const (
+	IndicatorMACD = "MACD"
+	IndicatorKDJ  = "KDJ"
+	IndicatorBoll = "BOLL"
+	IndicatorMA   = "MA"
+	IndicatorRSI  = "RSI"
+)
+
+// crossLookback 金叉/死叉在最近几个交易日内发生才计入
+const crossLookback = 3
+
+// SignalVote 单个指标的投票
+type SignalVote struct {
+	Indicator string
+	Vote      float64 // -1~1，正为看多
+	Weight    float64
+	Reason    string
+}
+
+// SignalEvaluation 综合评估结果
+type SignalEvaluation struct {
+	SignalType  string
+	Score       float64 // 加权评分 -100~100
+	Votes       []SignalVote
+	Price       float64
+	TargetPrice float64
+	StopLoss    float64
+}
+
+// DefaultSignalStrategy 内置默认信号策略
+func DefaultSignalStrategy() *model.SignalStrategy {
+	return &model.SignalStrategy{
+		Name:          "默认",
+		WeightMACD:    1,
+		WeightKDJ:     1,
+		WeightBoll:    1,
+		WeightMA:      1,
+		WeightRSI:     1,
+		BuyThreshold:  30,
+		SellThreshold: 30,
+		TargetMult:    3,
+		StopMult:      2,
+	}
+}
+
+// GetSignalStrategies 获取所有信号策略
+func (s *SignalService) GetSignalStrategies() ([]model.SignalStrategy, error) {
+	return s.signals.GetAllSignalStrategies()
+}
+
+// GetActiveSignalStrategy 获取默认信号策略，未设置时使用内置默认
+func (s *SignalService) GetActiveSignalStrategy() *model.SignalStrategy {
+	if strategy, err := s.signals.GetDefaultSignalStrategy(); err == nil {
+		return strategy
+	}
+	return DefaultSignalStrategy()
+}
+
+// SaveSignalStrategy 校验并保存信号策略
+func (s *SignalService) SaveSignalStrategy(strategy *model.SignalStrategy) error {
+	if strategy.Name == "" {
+		return errors.New("请输入策略名称")
+	}
+	weights := []float64{strategy.WeightMACD, strategy.WeightKDJ, strategy.WeightBoll, strategy.WeightMA, strategy.WeightRSI}
+	total := 0.0
+	for _, w := range weights {
+		if w < 0 {
+			return errors.New("权重不能为负")
+		}
+		total += w
+	}
+	if total == 0 {
+		return errors.New("至少需要一个指标权重大于0")
+	}
+	if strategy.BuyThreshold <= 0 || strategy.BuyThreshold > 100 || strategy.SellThreshold <= 0 || strategy.SellThreshold > 100 {
+		return errors.New("买卖阈值应在0~100之间")
+	}
+	if strategy.TargetMult <= 0 || strategy.StopMult <= 0 {
+		return errors.New("目标和止损倍数必须大于0")
+	}
+	return s.signals.SaveSignalStrategy(strategy)
+}
+
+// DeleteSignalStrategy 删除信号策略
+func (s *SignalService) DeleteSignalStrategy(id uint) error {
+	return s.signals.DeleteSignalStrategy(id)
+}
+
+// GenerateSignal 按默认信号策略生成交易信号
 func (s *SignalService) GenerateSignal(fundCode string) (*model.TradingSignal, error) {
-	histories, err := repository.GetNetValueHistory(fundCode, 60)
+	return s.GenerateSignalWith(fundCode, s.GetActiveSignalStrategy())
+}
+
+// GenerateSignalWith 按指定信号策略生成交易信号并保存
+func (s *SignalService) GenerateSignalWith(fundCode string, strategy *model.SignalStrategy) (*model.TradingSignal, error) {
+	histories, err := s.history.GetNetValueHistory(fundCode, 120)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +182,7 @@ func (s *SignalService) GenerateSignal(fundCode string) (*model.TradingSignal, e
 			FundCode:       fundCode,
 			SignalType:     SignalHold,
 			SignalStrength: 50,
-			Indicator:      "RSI",
+			Indicator:      IndicatorRSI,
 			Reason:         "历史数据不足，暂无信号",
 			GeneratedAt:    time.Now(),
 			IsValid:        true,
@@ -48,36 +194,306 @@ func (s *SignalService) GenerateSignal(fundCode string) (*model.TradingSignal, e
 		prices[len(histories)-1-i] = h.NetValue
 	}
 
-	indicator := GetIndicatorService()
-	rsi := indicator.CalculateRSI(prices, 14)
-
-	signalType := SignalHold
-	reason := "观望"
-	strength := 50.0
-
-	if len(rsi) > 0 {
-		lastRSI := rsi[len(rsi)-1]
-		if lastRSI < 30 {
-			signalType = SignalBuy
-			reason = "RSI超卖，建议买入"
-			strength = 80
-		} else if lastRSI > 70 {
-			signalType = SignalSell
-			reason = "RSI超买，建议卖出"
-			strength = 80
+	eval := s.Evaluate(prices, strategy)
+	var indicators, reasons []string
+	for _, v := range eval.Votes {
+		if v.Vote == 0 {
+			continue
 		}
+		indicators = append(indicators, v.Indicator)
+		reasons = append(reasons, fmt.Sprintf("%s(%+.1f×%.1f)", v.Reason, v.Vote, v.Weight))
+	}
+	reason := fmt.Sprintf("综合评分%+.0f，%s", eval.Score, SignalTypeNames[eval.SignalType])
+	if len(reasons) > 0 {
+		reason = strings.Join(reasons, "；") + "。" + reason
+	} else {
+		reason = "各指标均无明确信号。" + reason
 	}
 
 	signal := &model.TradingSignal{
 		FundCode:       fundCode,
-		SignalType:     signalType,
-		SignalStrength: strength,
-		Indicator:      "RSI",
-		Price:          prices[len(prices)-1],
+		FundName:       s.fundNameOf(fundCode),
+		SignalType:     eval.SignalType,
+		SignalStrength: 50 + math.Abs(eval.Score)/2,
+		Indicator:      strings.Join(indicators, ","),
+		Price:          eval.Price,
+		TargetPrice:    eval.TargetPrice,
+		StopLoss:       eval.StopLoss,
 		Reason:         reason,
+		Score:          eval.Score,
+		StrategyID:     strategy.ID,
 		GeneratedAt:    time.Now(),
 		IsValid:        true,
+		Status:         SignalStatusActive,
+	}
+	signal.ExpiresAt = signalExpiry(signal)
+	if err := s.signals.SaveTradingSignal(signal); err == nil {
+		s.signals.SupersedeSignals(fundCode, signal.ID, signal.GeneratedAt)
 	}
-	repository.SaveTradingSignal(signal)
 	return signal, nil
 }
+
+// RefreshSignals 检查有效信号，过期或净值已触及目标价/止损价的标记为失效，返回失效数量
+func (s *SignalService) RefreshSignals(now time.Time) int {
+	signals, err := s.signals.GetActiveSignals()
+	if err != nil {
+		return 0
+	}
+	closed := 0
+	for _, sig := range signals {
+		status := ""
+		if now.After(signalExpiry(&sig)) {
+			status = SignalStatusExpired
+		} else if latest, err := s.history.GetLatestNetValue(sig.FundCode); err == nil &&
+			dateKey(latest.Date) > dateKey(sig.GeneratedAt) {
+			status = signalExitStatus(&sig, latest.NetValue)
+		}
+		if status != "" {
+			s.signals.CloseSignal(sig.ID, status, now)
+			closed++
+		}
+	}
+	return closed
+}
+
+// signalExpiry 信号到期时间，兼容未记录到期时间的旧信号
+func signalExpiry(sig *model.TradingSignal) time.Time {
+	if !sig.ExpiresAt.IsZero() {
+		return sig.ExpiresAt
+	}
+	if sig.SignalType == SignalHold {
+		return sig.GeneratedAt.AddDate(0, 0, holdSignalValidDays)
+	}
+	return sig.GeneratedAt.AddDate(0, 0, signalValidDays)
+}
+
+// signalExitStatus 净值触及目标价或止损价时返回对应状态
+func signalExitStatus(sig *model.TradingSignal, nav float64) string {
+	if sig.TargetPrice <= 0 || sig.StopLoss <= 0 {
+		return ""
+	}
+	switch sig.SignalType {
+	case SignalBuy:
+		if nav >= sig.TargetPrice {
+			return SignalStatusTarget
+		}
+		if nav <= sig.StopLoss {
+			return SignalStatusStopped
+		}
+	case SignalSell:
+		if nav <= sig.TargetPrice {
+			return SignalStatusTarget
+		}
+		if nav >= sig.StopLoss {
+			return SignalStatusStopped
+		}
+	}
+	return ""
+}
+
+// Evaluate 对按日期升序的净值序列进行多指标投票评估
+func (s *SignalService) Evaluate(prices []float64, strategy *model.SignalStrategy) *SignalEvaluation {
+	eval := &SignalEvaluation{SignalType: SignalHold}
+	if len(prices) == 0 {
+		return eval
+	}
+	eval.Price = prices[len(prices)-1]
+
+	candidates := []struct {
+		weight float64
+		vote   func([]float64) (float64, string, bool)
+		name   string
+	}{
+		{strategy.WeightMACD, macdVote, IndicatorMACD},
+		{strategy.WeightKDJ, kdjVote, IndicatorKDJ},
+		{strategy.WeightBoll, bollVote, IndicatorBoll},
+		{strategy.WeightMA, maVote, IndicatorMA},
+		{strategy.WeightRSI, rsiVote, IndicatorRSI},
+	}
+
+	// 无法计算的指标不参与加权
+	sum, totalWeight := 0.0, 0.0
+	for _, c := range candidates {
+		if c.weight <= 0 {
+			continue
+		}
+		vote, reason, ok := c.vote(prices)
+		if !ok {
+			continue
+		}
+		eval.Votes = append(eval.Votes, SignalVote{Indicator: c.name, Vote: vote, Weight: c.weight, Reason: reason})
+		sum += vote * c.weight
+		totalWeight += c.weight
+	}
+	if totalWeight > 0 {
+		eval.Score = math.Round(sum/totalWeight*100*10) / 10
+	}
+
+	atr := GetIndicatorService().CalculateATR(prices, 14)[len(prices)-1]
+	if IsWarmup(atr) {
+		atr = 0
+	}
+	switch {
+	case eval.Score >= strategy.BuyThreshold:
+		eval.SignalType = SignalBuy
+		eval.TargetPrice = eval.Price + strategy.TargetMult*atr
+		eval.StopLoss = eval.Price - strategy.StopMult*atr
+	case eval.Score <= -strategy.SellThreshold:
+		eval.SignalType = SignalSell
+		eval.TargetPrice = eval.Price - strategy.TargetMult*atr
+		eval.StopLoss = eval.Price + strategy.StopMult*atr
+	}
+	return eval
+}
+
+// macdVote DIF与DEA金叉/死叉，零轴下方金叉、上方死叉权重更高；无交叉时看柱线方向
+func macdVote(prices []float64) (float64, string, bool) {
+	macd := GetIndicatorService().CalculateMACD(prices)
+	n := len(prices)
+	if macd == nil || n <= macdWarmup+crossLookback {
+		return 0, "", false
+	}
+	last := n - 1
+	switch crossWithin(macd.MACD, macd.DEA, crossLookback) {
+	case 1:
+		if macd.MACD[last] < 0 {
+			return 1, "MACD零轴下金叉", true
+		}
+		return 0.7, "MACD金叉", true
+	case -1:
+		if macd.MACD[last] > 0 {
+			return -1, "MACD零轴上死叉", true
+		}
+		return -0.7, "MACD死叉", true
+	}
+	hist, prev := macd.Hist[last], macd.Hist[last-1]
+	switch {
+	case hist > 0 && hist > prev:
+		return 0.3, "MACD红柱放大", true
+	case hist < 0 && hist < prev:
+		return -0.3, "MACD绿柱放大", true
+	}
+	return 0, "", true
+}
+
+// kdjVote K与D金叉/死叉，超卖区金叉、超买区死叉权重更高；无交叉时看J值极端
+func kdjVote(prices []float64) (float64, string, bool) {
+	kdj := GetIndicatorService().CalculateKDJ(prices, 9)
+	if kdj == nil || len(prices) < 9+crossLookback {
+		return 0, "", false
+	}
+	last := len(prices) - 1
+	k, j := kdj.K[last], kdj.J[last]
+	switch crossWithin(kdj.K, kdj.D, crossLookback) {
+	case 1:
+		if k < 30 {
+			return 1, "KDJ超卖区金叉", true
+		}
+		return 0.6, "KDJ金叉", true
+	case -1:
+		if k > 70 {
+			return -1, "KDJ超买区死叉", true
+		}
+		return -0.6, "KDJ死叉", true
+	}
+	switch {
+	case j < 0:
+		return 0.4, fmt.Sprintf("KDJ J值%.0f超卖", j), true
+	case j > 100:
+		return -0.4, fmt.Sprintf("KDJ J值%.0f超买", j), true
+	}
+	return 0, "", true
+}
+
+// bollVote 触及布林下轨/上轨，或向上/向下穿越中轨
+func bollVote(prices []float64) (float64, string, bool) {
+	boll := GetIndicatorService().CalculateBollinger(prices, 20)
+	if boll == nil || len(prices) < 21 {
+		return 0, "", false
+	}
+	last := len(prices) - 1
+	price := prices[last]
+	switch {
+	case price <= boll.Lower[last]:
+		return 1, "触及布林下轨", true
+	case price >= boll.Upper[last]:
+		return -1, "触及布林上轨", true
+	case prices[last-1] < boll.Middle[last-1] && price > boll.Middle[last]:
+		return 0.3, "上穿布林中轨", true
+	case prices[last-1] > boll.Middle[last-1] && price < boll.Middle[last]:
+		return -0.3, "下穿布林中轨", true
+	}
+	return 0, "", true
+}
+
+// maVote MA5与MA20金叉/死叉；无交叉时看多头/空头排列
+func maVote(prices []float64) (float64, string, bool) {
+	if len(prices) < 20+crossLookback {
+		return 0, "", false
+	}
+	ind := GetIndicatorService()
+	ma5, ma20 := ind.CalculateSMA(prices, 5), ind.CalculateSMA(prices, 20)
+	last := len(prices) - 1
+	switch crossWithin(ma5, ma20, crossLookback) {
+	case 1:
+		return 1, "MA5上穿MA20", true
+	case -1:
+		return -1, "MA5下穿MA20", true
+	}
+	price := prices[last]
+	switch {
+	case price > ma5[last] && ma5[last] > ma20[last]:
+		return 0.3, "均线多头排列", true
+	case price < ma5[last] && ma5[last] < ma20[last]:
+		return -0.3, "均线空头排列", true
+	}
+	return 0, "", true
+}
+
+// rsiVote RSI(14)超买超卖
+func rsiVote(prices []float64) (float64, string, bool) {
+	rsi := GetIndicatorService().CalculateRSI(prices, 14)
+	if len(rsi) == 0 {
+		return 0, "", false
+	}
+	v := rsi[len(rsi)-1]
+	switch {
+	case v < 30:
+		return 1, fmt.Sprintf("RSI %.0f超卖", v), true
+	case v > 70:
+		return -1, fmt.Sprintf("RSI %.0f超买", v), true
+	case v < 40:
+		return 0.3, fmt.Sprintf("RSI %.0f偏弱", v), true
+	case v > 60:
+		return -0.3, fmt.Sprintf("RSI %.0f偏强", v), true
+	}
+	return 0, "", true
+}
+
+// crossWithin 最近bars个交易日内a相对b的最近一次交叉：1为上穿，-1为下穿，0为无
+func crossWithin(a, b []float64, bars int) int {
+	last := len(a) - 1
+	for j := last; j > last-bars && j > 0; j-- {
+		if a[j-1] <= b[j-1] && a[j] > b[j] {
+			return 1
+		}
+		if a[j-1] >= b[j-1] && a[j] < b[j] {
+			return -1
+		}
+	}
+	return 0
+}
+
+// fundNameOf 从持仓、自选或基金信息中查找基金名称
+func (s *SignalService) fundNameOf(fundCode string) string {
+	if h, err := s.holdings.GetHoldingByFundCode(fundCode); err == nil && h.FundName != "" {
+		return h.FundName
+	}
+	if item, err := s.watchlist.GetWatchlistItemByFundCode(fundCode); err == nil && item.FundName != "" {
+		return item.FundName
+	}
+	if fund, err := s.funds.GetFund(fundCode); err == nil {
+		return fund.Name
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// 信号表现评估
+const (
+	DefaultSignalHorizon = 10  // 默认观察N个交易日后的净值
+	falseSignalLoss      = 1.0 // 未设置止损的信号，反向波动超过该幅度(%)视为假信号
+)
+
+// SignalOutcome 单个信号在观察期结束时的结果
+type SignalOutcome struct {
+	Signal  model.TradingSignal
+	ExitNav float64
+	Return  float64 // 按信号方向计算的收益(%)，卖出信号为回避的跌幅
+	Hit     bool    // 先触及目标价，或观察期末方向收益为正
+	False   bool    // 先触及止损价，或反向波动超过阈值
+}
+
+// SignalStats 一组信号的统计
+type SignalStats struct {
+	Key       string // 指标名或基金代码
+	Name      string
+	Total     int // 买卖信号数
+	Evaluated int // 已满观察期的信号数
+	Hits      int
+	Falses    int
+	HitRate   float64 // %
+	FalseRate float64 // %
+	AvgReturn float64 // %
+	sumReturn float64
+}
+
+// SignalPerformance 信号表现报告
+type SignalPerformance struct {
+	Horizon     int
+	Overall     SignalStats
+	ByIndicator []SignalStats
+	ByFund      []SignalStats
+	Outcomes    []SignalOutcome
+	byIndicator map[string]*SignalStats
+	byFund      map[string]*SignalStats
+}
+
+// EvaluatePerformance 统计since以来保存的买卖信号在horizon个交易日后的表现
+func (s *SignalService) EvaluatePerformance(horizon int, since time.Time) (*SignalPerformance, error) {
+	if horizon <= 0 {
+		return nil, errors.New("观察天数必须大于0")
+	}
+	signals, err := s.signals.GetSignalHistory("", since)
+	if err != nil {
+		return nil, err
+	}
+
+	navs := make(map[string][]model.NetValueHistory)
+	perf := newSignalPerformance(horizon)
+	for _, sig := range signals {
+		if sig.SignalType != SignalBuy && sig.SignalType != SignalSell {
+			continue
+		}
+		list, ok := navs[sig.FundCode]
+		if !ok {
+			list = ascendingHistory(s.history, sig.FundCode)
+			navs[sig.FundCode] = list
+		}
+		perf.add(sig, splitList(sig.Indicator), signalOutcome(sig, list, horizon))
+	}
+	perf.finish()
+	return perf, nil
+}
+
+// Backtest 用信号策略逐日回放基金净值历史，统计每次新出现的买卖信号在horizon个交易日后的表现
+func (s *SignalService) Backtest(fundCodes []string, horizon int, strategy *model.SignalStrategy) (*SignalPerformance, error) {
+	if horizon <= 0 {
+		return nil, errors.New("观察天数必须大于0")
+	}
+	perf := newSignalPerformance(horizon)
+	for _, code := range fundCodes {
+		list := ascendingHistory(s.history, code)
+		if len(list) < 60+horizon {
+			continue
+		}
+		name := s.fundNameOf(code)
+		prices := make([]float64, len(list))
+		for i, h := range list {
+			prices[i] = h.NetValue
+		}
+
+		last := SignalHold
+		for i := 59; i < len(list); i++ {
+			eval := s.Evaluate(prices[:i+1], strategy)
+			// 连续同向信号只统计第一次
+			if eval.SignalType == last {
+				continue
+			}
+			last = eval.SignalType
+			if eval.SignalType == SignalHold {
+				continue
+			}
+
+			sig := model.TradingSignal{
+				FundCode:    code,
+				FundName:    name,
+				SignalType:  eval.SignalType,
+				Price:       eval.Price,
+				TargetPrice: eval.TargetPrice,
+				StopLoss:    eval.StopLoss,
+				Score:       eval.Score,
+				StrategyID:  strategy.ID,
+				GeneratedAt: list[i].Date,
+			}
+			// 只计入与信号同向投票的指标
+			var indicators []string
+			for _, v := range eval.Votes {
+				if (v.Vote > 0) == (eval.SignalType == SignalBuy) && v.Vote != 0 {
+					indicators = append(indicators, v.Indicator)
+				}
+			}
+			sig.Indicator = strings.Join(indicators, ",")
+			perf.add(sig, indicators, signalOutcome(sig, list, horizon))
+		}
+	}
+	perf.finish()
+	return perf, nil
+}
+
+// signalOutcome 计算信号在horizon个交易日内的结果，观察期未满时返回nil
+func signalOutcome(sig model.TradingSignal, list []model.NetValueHistory, horizon int) *SignalOutcome {
+	// 信号对应的净值日为不晚于生成日期的最近一条
+	day := dateKey(sig.GeneratedAt)
+	entry := sort.Search(len(list), func(i int) bool { return dateKey(list[i].Date) > day }) - 1
+	if entry < 0 || entry+horizon >= len(list) {
+		return nil
+	}
+	price := sig.Price
+	if price <= 0 {
+		price = list[entry].NetValue
+	}
+	if price <= 0 {
+		return nil
+	}
+	direction := 1.0
+	if sig.SignalType == SignalSell {
+		direction = -1
+	}
+
+	outcome := &SignalOutcome{Signal: sig}
+	for i := entry + 1; i <= entry+horizon; i++ {
+		switch signalExitStatus(&sig, list[i].NetValue) {
+		case SignalStatusTarget:
+			outcome.Hit = true
+		case SignalStatusStopped:
+			outcome.False = true
+		default:
+			continue
+		}
+		outcome.ExitNav = list[i].NetValue
+		outcome.Return = direction * (outcome.ExitNav/price - 1) * 100
+		return outcome
+	}
+
+	outcome.ExitNav = list[entry+horizon].NetValue
+	outcome.Return = direction * (outcome.ExitNav/price - 1) * 100
+	outcome.Hit = outcome.Return > 0
+	outcome.False = sig.StopLoss <= 0 && outcome.Return < -falseSignalLoss
+	return outcome
+}
+
+func newSignalPerformance(horizon int) *SignalPerformance {
+	return &SignalPerformance{
+		Horizon:     horizon,
+		Overall:     SignalStats{Key: "all", Name: "全部"},
+		byIndicator: make(map[string]*SignalStats),
+		byFund:      make(map[string]*SignalStats),
+	}
+}
+
+// add 将信号计入总体、各指标和所属基金的统计
+func (p *SignalPerformance) add(sig model.TradingSignal, indicators []string, outcome *SignalOutcome) {
+	p.Overall.add(outcome)
+	for _, ind := range indicators {
+		statsEntry(p.byIndicator, ind, ind).add(outcome)
+	}
+	name := sig.FundName
+	if name == "" {
+		name = sig.FundCode
+	}
+	statsEntry(p.byFund, sig.FundCode, name).add(outcome)
+	if outcome != nil {
+		p.Outcomes = append(p.Outcomes, *outcome)
+	}
+}
+
+// finish 计算比率，分组按命中率排序
+func (p *SignalPerformance) finish() {
+	p.Overall.finish()
+	p.ByIndicator = sortedStats(p.byIndicator)
+	p.ByFund = sortedStats(p.byFund)
+}
+
+// statsEntry 获取或创建分组统计
+func statsEntry(group map[string]*SignalStats, key, name string) *SignalStats {
+	st, ok := group[key]
+	if !ok {
+		st = &SignalStats{Key: key, Name: name}
+		group[key] = st
+	}
+	return st
+}
+
+// sortedStats 计算各组比率并按命中率、样本数降序排列
+func sortedStats(group map[string]*SignalStats) []SignalStats {
+	list := make([]SignalStats, 0, len(group))
+	for _, st := range group {
+		st.finish()
+		list = append(list, *st)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].HitRate != list[j].HitRate {
+			return list[i].HitRate > list[j].HitRate
+		}
+		if list[i].Evaluated != list[j].Evaluated {
+			return list[i].Evaluated > list[j].Evaluated
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+func (st *SignalStats) add(outcome *SignalOutcome) {
+	st.Total++
+	if outcome == nil {
+		return
+	}
+	st.Evaluated++
+	st.sumReturn += outcome.Return
+	if outcome.Hit {
+		st.Hits++
+	}
+	if outcome.False {
+		st.Falses++
+	}
+}
+
+func (st *SignalStats) finish() {
+	if st.Evaluated == 0 {
+		return
+	}
+	n := float64(st.Evaluated)
+	st.HitRate = float64(st.Hits) / n * 100
+	st.FalseRate = float64(st.Falses) / n * 100
+	st.AvgReturn = st.sumReturn / n
+}
+
+// ascendingHistory 本地净值历史，按日期升序
+func ascendingHistory(history repository.HistoryRepository, fundCode string) []model.NetValueHistory {
+	list, _ := history.GetNetValueHistory(fundCode, 1000)
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list
+}
+
+// dateKey 日期字符串，用于比较净值日期与信号生成日期
+func dateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
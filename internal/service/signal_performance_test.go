@@ -0,0 +1,125 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+// navList 从3月1日起每天一条的净值，按日期升序
+func navList(values ...float64) []model.NetValueHistory {
+	list := make([]model.NetValueHistory, len(values))
+	for i, v := range values {
+		list[i] = model.NetValueHistory{FundCode: "000001", Date: time.Date(2024, 3, 1+i, 0, 0, 0, 0, time.UTC), NetValue: v}
+	}
+	return list
+}
+
+func TestSignalOutcome(t *testing.T) {
+	list := navList(1, 1.01, 1.03, 1.06, 1.02, 0.98)
+	on := func(i int) time.Time { return list[i].Date.Add(15 * time.Hour) }
+	tests := []struct {
+		name       string
+		sig        model.TradingSignal
+		horizon    int
+		wantNil    bool
+		wantReturn float64
+		wantHit    bool
+		wantFalse  bool
+	}{
+		{name: "先触及目标价", sig: model.TradingSignal{SignalType: SignalBuy, TargetPrice: 1.05, StopLoss: 0.95, GeneratedAt: on(0)}, horizon: 4, wantReturn: 6, wantHit: true},
+		{name: "先触及止损价", sig: model.TradingSignal{SignalType: SignalSell, Price: 1.01, TargetPrice: 0.9, StopLoss: 1.05, GeneratedAt: on(1)}, horizon: 4, wantReturn: -(1.06/1.01 - 1) * 100, wantFalse: true},
+		{name: "卖出后下跌", sig: model.TradingSignal{SignalType: SignalSell, GeneratedAt: on(2)}, horizon: 3, wantReturn: -(0.98/1.03 - 1) * 100, wantHit: true},
+		{name: "无止损反向波动", sig: model.TradingSignal{SignalType: SignalBuy, GeneratedAt: on(2)}, horizon: 3, wantReturn: (0.98/1.03 - 1) * 100, wantFalse: true},
+		{name: "观察期未满", sig: model.TradingSignal{SignalType: SignalBuy, GeneratedAt: on(3)}, horizon: 3, wantNil: true},
+		{name: "早于净值历史", sig: model.TradingSignal{SignalType: SignalBuy, GeneratedAt: on(0).AddDate(0, 0, -3)}, horizon: 1, wantNil: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := signalOutcome(tt.sig, list, tt.horizon)
+			if tt.wantNil {
+				if outcome != nil {
+					t.Fatalf("want nil, got %+v", outcome)
+				}
+				return
+			}
+			if outcome == nil || !almostEqual(outcome.Return, tt.wantReturn) || outcome.Hit != tt.wantHit || outcome.False != tt.wantFalse {
+				t.Fatalf("want %.4f hit=%v false=%v, got %+v", tt.wantReturn, tt.wantHit, tt.wantFalse, outcome)
+			}
+		})
+	}
+}
+
+func TestRefreshSignals(t *testing.T) {
+	store := newTestStore(t)
+	s := NewSignalService(store)
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+
+	if err := store.SaveNetValueHistories([]model.NetValueHistory{
+		{FundCode: "000001", Date: time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, time.UTC), NetValue: 1.12},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	signals := []*model.TradingSignal{
+		{FundCode: "000002", SignalType: SignalBuy, GeneratedAt: now.AddDate(0, 0, -signalValidDays-1)},
+		{FundCode: "000001", SignalType: SignalBuy, TargetPrice: 1.1, StopLoss: 0.9, GeneratedAt: now.AddDate(0, 0, -3)},
+		// 生成后还没有新净值，不按旧净值判断
+		{FundCode: "000001", SignalType: SignalSell, TargetPrice: 1, StopLoss: 1.1, GeneratedAt: now},
+	}
+	for _, sig := range signals {
+		sig.IsValid, sig.Status = true, SignalStatusActive
+		if err := store.SaveTradingSignal(sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if closed := s.RefreshSignals(now); closed != 2 {
+		t.Fatalf("want 2 closed, got %d", closed)
+	}
+	want := []string{SignalStatusExpired, SignalStatusTarget, SignalStatusActive}
+	history, _ := store.GetSignalHistory("", now.AddDate(0, 0, -30))
+	for i, sig := range history {
+		if sig.Status != want[i] || sig.IsValid != (want[i] == SignalStatusActive) {
+			t.Errorf("signal %d: want %s, got %s (valid=%v)", i, want[i], sig.Status, sig.IsValid)
+		}
+	}
+}
+
+func TestEvaluatePerformance(t *testing.T) {
+	store := newTestStore(t)
+	s := NewSignalService(store)
+	list := navList(1, 1.02, 1.04, 1.01, 0.99)
+	if err := store.SaveNetValueHistories(list); err != nil {
+		t.Fatal(err)
+	}
+	for _, sig := range []*model.TradingSignal{
+		{FundCode: "000001", FundName: "测试基金", SignalType: SignalBuy, Indicator: "MACD,RSI", GeneratedAt: list[0].Date},
+		{FundCode: "000001", FundName: "测试基金", SignalType: SignalBuy, Indicator: "MACD", GeneratedAt: list[2].Date},
+		{FundCode: "000001", SignalType: SignalHold, GeneratedAt: list[3].Date},
+		{FundCode: "000001", FundName: "测试基金", SignalType: SignalSell, Indicator: "KDJ", GeneratedAt: list[4].Date},
+	} {
+		if err := store.SaveTradingSignal(sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	perf, err := s.EvaluatePerformance(2, list[0].Date.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 观望信号不计；最后的卖出信号观察期未满
+	if perf.Overall.Total != 3 || perf.Overall.Evaluated != 2 || perf.Overall.Hits != 1 || perf.Overall.HitRate != 50 {
+		t.Fatalf("overall: %+v", perf.Overall)
+	}
+	if len(perf.ByIndicator) != 3 || perf.ByIndicator[0].Key != "RSI" || perf.ByIndicator[0].HitRate != 100 {
+		t.Fatalf("by indicator: %+v", perf.ByIndicator)
+	}
+	if len(perf.ByFund) != 1 || perf.ByFund[0].Name != "测试基金" || perf.ByFund[0].Total != 3 {
+		t.Fatalf("by fund: %+v", perf.ByFund)
+	}
+	if _, err := s.EvaluatePerformance(0, time.Time{}); err == nil {
+		t.Fatal("观察天数为0应返回错误")
+	}
+}
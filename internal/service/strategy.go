@@ -2,22 +2,61 @@ package service
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
+	"time"
 
 	"jijin/internal/model"
 	"jijin/internal/repository"
 )
 
 // StrategyService 策略服务
-type StrategyService struct{}
+type StrategyService struct {
+	strategies repository.StrategyRepository
+	holdings   repository.HoldingRepository
+}
+
+// NewStrategyService 使用指定仓储创建策略服务
+func NewStrategyService(store repository.Store) *StrategyService {
+	return &StrategyService{strategies: store, holdings: store}
+}
 
-var strategyService = &StrategyService{}
+var strategyService = NewStrategyService(repository.Default())
 
 // GetStrategyService 获取策略服务实例
 func GetStrategyService() *StrategyService {
 	return strategyService
 }
 
+// 定投策略类型
+const (
+	StrategyTypeNormal      = "normal"
+	StrategyTypeMADeviation = "ma_deviation"
+	StrategyTypeValuation   = "valuation"
+	StrategyTypeTargetValue = "target_value"
+)
+
+// StrategyTypes 策略类型，按界面显示顺序
+var StrategyTypes = []string{StrategyTypeNormal, StrategyTypeMADeviation, StrategyTypeValuation, StrategyTypeTargetValue}
+
+// StrategyTypeNames 策略类型显示名称
+var StrategyTypeNames = map[string]string{
+	StrategyTypeNormal:      "普通定投",
+	StrategyTypeMADeviation: "均线偏离",
+	StrategyTypeValuation:   "估值定投",
+	StrategyTypeTargetValue: "目标市值",
+}
+
+// Frequencies 定投频率，按界面显示顺序
+var Frequencies = []string{"daily", "weekly", "monthly"}
+
+// FrequencyNames 定投频率显示名称
+var FrequencyNames = map[string]string{
+	"daily":   "每日",
+	"weekly":  "每周",
+	"monthly": "每月",
+}
+
 // MADeviationParams 均线偏离策略参数
 type MADeviationParams struct {
 	MAPeriod      int     `json:"maPeriod"`      // 均线周期(天)
@@ -31,6 +70,7 @@ type ValuationParams struct {
 	HighPE        float64 `json:"highPE"`        // 高估PE
 	MaxMultiplier float64 `json:"maxMultiplier"` // 最大倍数
 	MinMultiplier float64 `json:"minMultiplier"` // 最小倍数
+	CurrentPE     float64 `json:"currentPE"`     // 当前PE，暂无估值数据源，由用户填写
 }
 
 // TargetValueParams 目标市值策略参数
@@ -47,6 +87,109 @@ type StrategyResult struct {
 	Reason        string  `json:"reason"`        // 原因说明
 }
 
+// DefaultStrategyParams 策略类型的默认参数，普通定投无参数返回nil
+func DefaultStrategyParams(strategyType string) interface{} {
+	switch strategyType {
+	case StrategyTypeMADeviation:
+		return &MADeviationParams{MAPeriod: 250, MaxMultiplier: 2.0, MinMultiplier: 0.5}
+	case StrategyTypeValuation:
+		return &ValuationParams{LowPE: 10, HighPE: 20, MaxMultiplier: 2.0, MinMultiplier: 0.5, CurrentPE: 15}
+	case StrategyTypeTargetValue:
+		return &TargetValueParams{TargetValue: 10000, GrowthRate: 1}
+	}
+	return nil
+}
+
+// DecodeStrategyParams 解析策略参数，缺失或无效的字段使用默认值；参数无法解析时返回默认参数和错误
+func DecodeStrategyParams(st model.Strategy) (interface{}, error) {
+	params := DefaultStrategyParams(st.StrategyType)
+	if params == nil || st.Params == "" {
+		return params, nil
+	}
+	if err := json.Unmarshal([]byte(st.Params), params); err != nil {
+		return DefaultStrategyParams(st.StrategyType), fmt.Errorf("策略参数无法解析: %w", err)
+	}
+	switch p := params.(type) {
+	case *MADeviationParams:
+		if p.MAPeriod <= 0 {
+			p.MAPeriod = 250
+		}
+	case *ValuationParams:
+		if p.HighPE <= p.LowPE {
+			p.LowPE, p.HighPE = 10, 20
+		}
+	}
+	return params, nil
+}
+
+// ValidateStrategy 校验策略基本字段和参数
+func ValidateStrategy(st *model.Strategy, params interface{}) error {
+	if st.Name == "" || st.FundCode == "" {
+		return fmt.Errorf("请填写策略名称和基金代码")
+	}
+	if st.BaseAmount <= 0 && st.StrategyType != StrategyTypeTargetValue {
+		return fmt.Errorf("基准金额必须大于0")
+	}
+	if _, ok := FrequencyNames[st.Frequency]; !ok {
+		return fmt.Errorf("未知的定投频率: %s", st.Frequency)
+	}
+	switch p := params.(type) {
+	case *MADeviationParams:
+		if p.MAPeriod <= 0 {
+			return fmt.Errorf("均线周期必须大于0")
+		}
+		if p.MinMultiplier < 0 || p.MaxMultiplier < p.MinMultiplier {
+			return fmt.Errorf("倍数范围无效")
+		}
+	case *ValuationParams:
+		if p.LowPE <= 0 || p.HighPE <= p.LowPE {
+			return fmt.Errorf("高估PE必须大于低估PE")
+		}
+		if p.MinMultiplier < 0 || p.MaxMultiplier < p.MinMultiplier {
+			return fmt.Errorf("倍数范围无效")
+		}
+	case *TargetValueParams:
+		if p.TargetValue <= 0 {
+			return fmt.Errorf("目标市值必须大于0")
+		}
+	case nil:
+		if st.StrategyType != StrategyTypeNormal {
+			return fmt.Errorf("未知的策略类型: %s", st.StrategyType)
+		}
+	}
+	return nil
+}
+
+// SaveStrategyWithParams 校验并保存策略(新建或更新)，参数按类型序列化
+func (s *StrategyService) SaveStrategyWithParams(st *model.Strategy, params interface{}) error {
+	if err := ValidateStrategy(st, params); err != nil {
+		return err
+	}
+	st.Params = ""
+	if params != nil {
+		data, _ := json.Marshal(params)
+		st.Params = string(data)
+	}
+	return s.strategies.SaveStrategy(st)
+}
+
+// DuplicateStrategy 复制策略，副本默认停用
+func (s *StrategyService) DuplicateStrategy(id uint) (*model.Strategy, error) {
+	st, err := s.strategies.GetStrategy(id)
+	if err != nil {
+		return nil, err
+	}
+	dup := *st
+	dup.ID = 0
+	dup.CreatedAt, dup.UpdatedAt = time.Time{}, time.Time{}
+	dup.Name = st.Name + " 副本"
+	dup.Active = false
+	if err := s.strategies.SaveStrategy(&dup); err != nil {
+		return nil, err
+	}
+	return &dup, nil
+}
+
 // CreateStrategy 创建策略
 func (s *StrategyService) CreateStrategy(name, fundCode, fundName, frequency, strategyType string, baseAmount float64, params interface{}) (*model.Strategy, error) {
 	paramsJSON, _ := json.Marshal(params)
@@ -62,7 +205,7 @@ func (s *StrategyService) CreateStrategy(name, fundCode, fundName, frequency, st
 		Active:       true,
 	}
 
-	if err := repository.SaveStrategy(strategy); err != nil {
+	if err := s.strategies.SaveStrategy(strategy); err != nil {
 		return nil, err
 	}
 
@@ -89,9 +232,19 @@ func (s *StrategyService) CalculateMADeviation(fundCode string, baseAmount float
 	}
 	ma := sum / float64(params.MAPeriod)
 
-	// 当前净值
-	currentNav := histories[0].NetValue
+	multiplier, reason := maDeviationMultiplier(histories[0].NetValue, ma, params)
+	suggestAmount := baseAmount * multiplier
 
+	return &StrategyResult{
+		BaseAmount:    baseAmount,
+		SuggestAmount: math.Round(suggestAmount*100) / 100,
+		Multiplier:    math.Round(multiplier*100) / 100,
+		Reason:        reason,
+	}, nil
+}
+
+// maDeviationMultiplier 按当前净值相对均线的偏离度计算定投倍数
+func maDeviationMultiplier(currentNav, ma float64, params MADeviationParams) (float64, string) {
 	// 计算偏离度
 	deviation := (currentNav - ma) / ma
 
@@ -106,8 +259,6 @@ func (s *StrategyService) CalculateMADeviation(fundCode string, baseAmount float
 		multiplier = params.MinMultiplier
 	}
 
-	suggestAmount := baseAmount * multiplier
-
 	var reason string
 	if deviation < -0.1 {
 		reason = "低于均线10%以上，建议加倍定投"
@@ -118,13 +269,7 @@ func (s *StrategyService) CalculateMADeviation(fundCode string, baseAmount float
 	} else {
 		reason = "接近均线，正常定投"
 	}
-
-	return &StrategyResult{
-		BaseAmount:    baseAmount,
-		SuggestAmount: math.Round(suggestAmount*100) / 100,
-		Multiplier:    math.Round(multiplier*100) / 100,
-		Reason:        reason,
-	}, nil
+	return multiplier, reason
 }
 
 // CalculateValuation 计算估值定投策略建议金额
@@ -186,40 +331,116 @@ func (s *StrategyService) CalculateTargetValue(holding *model.Holding, params Ta
 	}, nil
 }
 
+// Suggest 按策略类型计算本期建议金额
+func (s *StrategyService) Suggest(st model.Strategy) (*StrategyResult, error) {
+	decoded, err := DecodeStrategyParams(st)
+	if err != nil {
+		return nil, err
+	}
+	switch params := decoded.(type) {
+	case *MADeviationParams:
+		return s.CalculateMADeviation(st.FundCode, st.BaseAmount, *params)
+
+	case *ValuationParams:
+		// 暂无估值数据源，使用参数中填写的当前PE
+		return s.CalculateValuation(st.FundCode, st.BaseAmount, params.CurrentPE, *params)
+
+	case *TargetValueParams:
+		holding, err := s.holdings.GetHoldingByFundCode(st.FundCode)
+		if err != nil {
+			holding = &model.Holding{FundCode: st.FundCode}
+		}
+		return s.CalculateTargetValue(holding, *params, s.periodsSince(st, time.Now()))
+	}
+
+	return &StrategyResult{
+		BaseAmount:    st.BaseAmount,
+		SuggestAmount: st.BaseAmount,
+		Multiplier:    1.0,
+		Reason:        "普通定投，按基准金额投入",
+	}, nil
+}
+
+// NextRunDate 策略在after之后的下一个执行日：每周按创建日的星期，每月按创建日的日期，遇周末顺延
+func (s *StrategyService) NextRunDate(st model.Strategy, after time.Time) time.Time {
+	anchor := st.CreatedAt
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location()).AddDate(0, 0, 1)
+
+	var next time.Time
+	switch st.Frequency {
+	case "daily":
+		next = day
+	case "weekly":
+		weekday := anchor.Weekday()
+		if weekday == time.Saturday || weekday == time.Sunday {
+			weekday = time.Monday
+		}
+		next = day.AddDate(0, 0, (int(weekday)-int(day.Weekday())+7)%7)
+	default:
+		next = monthlyRunDate(day.Year(), day.Month(), anchor.Day(), day.Location())
+		if next.Before(day) {
+			next = monthlyRunDate(day.Year(), day.Month()+1, anchor.Day(), day.Location())
+		}
+	}
+
+	for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// periodsSince 策略创建后到now为止已经过的定投期数
+func (s *StrategyService) periodsSince(st model.Strategy, now time.Time) int {
+	periods := 0
+	for next := s.NextRunDate(st, st.CreatedAt); !next.After(now); next = s.NextRunDate(st, next) {
+		periods++
+	}
+	return periods
+}
+
+// monthlyRunDate 某月的定投日，超过当月天数时取月末
+func monthlyRunDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
 // GetAllStrategies 获取所有策略
 func (s *StrategyService) GetAllStrategies() ([]model.Strategy, error) {
-	return repository.GetAllStrategies()
+	return s.strategies.GetAllStrategies()
 }
 
 // GetStrategy 获取策略详情
 func (s *StrategyService) GetStrategy(id uint) (*model.Strategy, error) {
-	return repository.GetStrategy(id)
+	return s.strategies.GetStrategy(id)
 }
 
 // UpdateStrategy 更新策略
 func (s *StrategyService) UpdateStrategy(strategy *model.Strategy) error {
-	return repository.SaveStrategy(strategy)
+	return s.strategies.SaveStrategy(strategy)
 }
 
 // DeleteStrategy 删除策略
 func (s *StrategyService) DeleteStrategy(id uint) error {
-	return repository.DeleteStrategy(id)
+	return s.strategies.DeleteStrategy(id)
 }
 
 // ToggleStrategy 切换策略状态
 func (s *StrategyService) ToggleStrategy(id uint) error {
-	strategy, err := repository.GetStrategy(id)
+	strategy, err := s.strategies.GetStrategy(id)
 	if err != nil {
 		return err
 	}
 
 	strategy.Active = !strategy.Active
-	return repository.SaveStrategy(strategy)
+	return s.strategies.SaveStrategy(strategy)
 }
 
 func formatMoney(amount float64) string {
 	if amount >= 10000 {
-		return string(rune('¥')) + string(rune(int(amount/10000))) + "万"
+		return fmt.Sprintf("¥%.2f万", amount/10000)
 	}
-	return string(rune('¥')) + string(rune(int(amount)))
+	return fmt.Sprintf("¥%.2f", amount)
 }
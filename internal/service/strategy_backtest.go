@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"jijin/internal/model"
+)
+
+// StrategyBacktest 策略回测结果，Baseline为同期按基准金额普通定投的对照
+type StrategyBacktest struct {
+	Result   *InvestmentResult
+	Baseline *InvestmentResult
+	Note     string
+}
+
+// Backtest 按历史净值回测策略：每个定投日按当日之前的数据计算投入金额，与普通定投对照
+func (s *StrategyService) Backtest(st model.Strategy, startDate, endDate time.Time) (*StrategyBacktest, error) {
+	if !endDate.After(startDate) {
+		return nil, fmt.Errorf("结束日期必须晚于开始日期")
+	}
+	params, err := DecodeStrategyParams(st)
+	if err != nil {
+		return nil, err
+	}
+
+	// 均线策略需要额外的预热数据
+	days := int(endDate.Sub(startDate).Hours()/24) + 30
+	if p, ok := params.(*MADeviationParams); ok {
+		days += p.MAPeriod * 3 / 2
+	}
+	histories, err := GetFundAPI().GetFundHistory(st.FundCode, days)
+	if err != nil {
+		return nil, err
+	}
+	return s.backtestHistory(st, params, histories, startDate, endDate)
+}
+
+// backtestHistory 在给定的历史净值(按日期降序)上回测策略
+func (s *StrategyService) backtestHistory(st model.Strategy, params interface{}, histories []model.NetValueHistory, startDate, endDate time.Time) (*StrategyBacktest, error) {
+	if len(histories) == 0 {
+		return nil, fmt.Errorf("没有 %s 的历史净值", st.FundCode)
+	}
+
+	// 历史数据按日期降序，转为升序
+	n := len(histories)
+	dates := make([]time.Time, n)
+	navs := make([]float64, n)
+	for i, h := range histories {
+		dates[n-1-i] = h.Date
+		navs[n-1-i] = h.NetValue
+	}
+	// navIndex 日期当天或之前最近的净值下标，没有返回-1
+	navIndex := func(d time.Time) int {
+		return sort.Search(n, func(i int) bool { return dates[i].After(d) }) - 1
+	}
+
+	baseAmount := st.BaseAmount
+	backtest := &StrategyBacktest{
+		Result:   &InvestmentResult{StartDate: startDate, EndDate: endDate},
+		Baseline: &InvestmentResult{StartDate: startDate, EndDate: endDate},
+	}
+	switch p := params.(type) {
+	case *ValuationParams:
+		backtest.Note = fmt.Sprintf("暂无历史估值数据，按当前PE %.1f 的倍数回测", p.CurrentPE)
+	case *TargetValueParams:
+		baseAmount = p.TargetValue
+		backtest.Note = "对照组按目标市值作为每期金额普通定投"
+	}
+
+	period := 0
+	for current := startDate; !current.After(endDate); current = nextInvestDate(current, st.Frequency) {
+		idx := navIndex(current)
+		if idx < 0 {
+			continue
+		}
+		nav := navs[idx]
+		amount := st.BaseAmount
+		switch p := params.(type) {
+		case *MADeviationParams:
+			if idx+1 >= p.MAPeriod {
+				sum := 0.0
+				for _, v := range navs[idx+1-p.MAPeriod : idx+1] {
+					sum += v
+				}
+				multiplier, _ := maDeviationMultiplier(nav, sum/float64(p.MAPeriod), *p)
+				amount = st.BaseAmount * multiplier
+			}
+		case *ValuationParams:
+			r, _ := s.CalculateValuation(st.FundCode, st.BaseAmount, p.CurrentPE, *p)
+			amount = r.SuggestAmount
+		case *TargetValueParams:
+			target := p.TargetValue * math.Pow(1+p.GrowthRate/100, float64(period))
+			amount = math.Max(0, target-backtest.Result.TotalShares*nav)
+		}
+		period++
+
+		addInvestment(backtest.Result, current, math.Round(amount*100)/100, nav)
+		addInvestment(backtest.Baseline, current, baseAmount, nav)
+	}
+
+	if idx := navIndex(endDate); idx >= 0 {
+		finishInvestment(backtest.Result, navs[idx])
+		finishInvestment(backtest.Baseline, navs[idx])
+	}
+	return backtest, nil
+}
+
+// nextInvestDate 按频率计算下一次定投日期
+func nextInvestDate(current time.Time, frequency string) time.Time {
+	switch frequency {
+	case "daily":
+		return current.AddDate(0, 0, 1)
+	case "weekly":
+		return current.AddDate(0, 0, 7)
+	}
+	return current.AddDate(0, 1, 0)
+}
+
+// addInvestment 按净值买入一期并记录曲线点，金额为0时只记录市值
+func addInvestment(result *InvestmentResult, date time.Time, amount, nav float64) {
+	if amount > 0 {
+		result.TotalInvest += amount
+		result.TotalShares += amount / nav
+		result.InvestCount++
+	}
+	result.Points = append(result.Points, InvestmentPoint{
+		Date:   date,
+		Invest: result.TotalInvest,
+		Value:  result.TotalShares * nav,
+		Amount: amount,
+	})
+}
+
+// finishInvestment 按期末净值计算市值、收益和年化收益
+func finishInvestment(result *InvestmentResult, nav float64) {
+	if result.TotalShares <= 0 {
+		return
+	}
+	result.CurrentValue = result.TotalShares * nav
+	result.TotalProfit = result.CurrentValue - result.TotalInvest
+	result.AvgCost = result.TotalInvest / result.TotalShares
+	if result.TotalInvest > 0 {
+		result.ProfitRate = result.TotalProfit / result.TotalInvest * 100
+	}
+	years := result.EndDate.Sub(result.StartDate).Hours() / 24 / 365
+	if years > 0 && result.TotalInvest > 0 {
+		result.AnnualReturn = (math.Pow(result.CurrentValue/result.TotalInvest, 1/years) - 1) * 100
+	}
+}
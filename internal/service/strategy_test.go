@@ -0,0 +1,159 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"jijin/internal/model"
+)
+
+func TestDecodeStrategyParams(t *testing.T) {
+	st := model.Strategy{StrategyType: StrategyTypeMADeviation, Params: `{"maPeriod":0,"maxMultiplier":3}`}
+	params, err := DecodeStrategyParams(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := params.(*MADeviationParams); p.MAPeriod != 250 || p.MaxMultiplier != 3 || p.MinMultiplier != 0.5 {
+		t.Fatalf("无效字段应使用默认值: %+v", p)
+	}
+
+	st = model.Strategy{StrategyType: StrategyTypeValuation, Params: `{"lowPE":`}
+	params, err = DecodeStrategyParams(st)
+	if err == nil {
+		t.Fatal("无法解析的参数应返回错误")
+	}
+	if p := params.(*ValuationParams); *p != *DefaultStrategyParams(StrategyTypeValuation).(*ValuationParams) {
+		t.Fatalf("解析失败时应返回默认参数: %+v", p)
+	}
+
+	if params, err := DecodeStrategyParams(model.Strategy{StrategyType: StrategyTypeNormal, Params: "null"}); params != nil || err != nil {
+		t.Fatalf("普通定投无参数: %v, %v", params, err)
+	}
+}
+
+func TestValidateStrategy(t *testing.T) {
+	valid := func(strategyType string) *model.Strategy {
+		return &model.Strategy{Name: "定投", FundCode: "000001", BaseAmount: 100, Frequency: "weekly", StrategyType: strategyType}
+	}
+	tests := []struct {
+		name    string
+		st      *model.Strategy
+		params  interface{}
+		wantErr string
+	}{
+		{name: "普通定投", st: valid(StrategyTypeNormal)},
+		{name: "缺少名称", st: &model.Strategy{FundCode: "000001", BaseAmount: 100, Frequency: "weekly"}, wantErr: "策略名称"},
+		{name: "基准金额为0", st: &model.Strategy{Name: "定投", FundCode: "000001", Frequency: "weekly", StrategyType: StrategyTypeNormal}, wantErr: "基准金额"},
+		{name: "目标市值不需要基准金额", st: &model.Strategy{Name: "定投", FundCode: "000001", Frequency: "monthly", StrategyType: StrategyTypeTargetValue}, params: &TargetValueParams{TargetValue: 1000}},
+		{name: "未知频率", st: &model.Strategy{Name: "定投", FundCode: "000001", BaseAmount: 100, Frequency: "yearly"}, wantErr: "定投频率"},
+		{name: "均线周期", st: valid(StrategyTypeMADeviation), params: &MADeviationParams{MaxMultiplier: 2}, wantErr: "均线周期"},
+		{name: "倍数范围", st: valid(StrategyTypeMADeviation), params: &MADeviationParams{MAPeriod: 20, MaxMultiplier: 0.5, MinMultiplier: 1}, wantErr: "倍数范围"},
+		{name: "PE区间", st: valid(StrategyTypeValuation), params: &ValuationParams{LowPE: 20, HighPE: 10, MaxMultiplier: 2}, wantErr: "PE"},
+		{name: "目标市值", st: valid(StrategyTypeTargetValue), params: &TargetValueParams{}, wantErr: "目标市值"},
+		{name: "未知类型", st: valid("grid"), wantErr: "未知的策略类型"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStrategy(tt.st, tt.params)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("want error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMADeviationMultiplier(t *testing.T) {
+	params := MADeviationParams{MAPeriod: 20, MaxMultiplier: 2, MinMultiplier: 0.5}
+	tests := []struct {
+		nav        float64
+		want       float64
+		wantReason string
+	}{
+		{nav: 1, want: 1, wantReason: "接近均线"},
+		{nav: 0.95, want: 1.1, wantReason: "低于均线，"},
+		{nav: 0.5, want: 2, wantReason: "低于均线10%以上"},
+		{nav: 1.2, want: 0.6, wantReason: "高于均线10%以上"},
+		{nav: 2, want: 0.5, wantReason: "高于均线10%以上"},
+	}
+	for _, tt := range tests {
+		got, reason := maDeviationMultiplier(tt.nav, 1, params)
+		if !almostEqual(got, tt.want) || !strings.Contains(reason, tt.wantReason) {
+			t.Errorf("nav %v: want %v %q, got %v %q", tt.nav, tt.want, tt.wantReason, got, reason)
+		}
+	}
+}
+
+func TestPeriodsSince(t *testing.T) {
+	s := NewStrategyService(newTestStore(t))
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.Local) }
+	tests := []struct {
+		name      string
+		frequency string
+		created   time.Time
+		now       time.Time
+		want      int
+	}{
+		{name: "每周", frequency: "weekly", created: date(1, 15), now: date(2, 5), want: 3},
+		{name: "创建当天不计", frequency: "weekly", created: date(1, 15), now: date(1, 21), want: 0},
+		{name: "月末顺延", frequency: "monthly", created: date(1, 31), now: date(4, 1), want: 2},
+		{name: "每日跳过周末", frequency: "daily", created: date(1, 12), now: date(1, 19), want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := model.Strategy{Frequency: tt.frequency}
+			st.CreatedAt = tt.created
+			if got := s.periodsSince(st, tt.now); got != tt.want {
+				t.Fatalf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBacktestHistory(t *testing.T) {
+	s := NewStrategyService(newTestStore(t))
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.Local) }
+	navs := func(values map[time.Time]float64, order ...time.Time) []model.NetValueHistory {
+		var list []model.NetValueHistory
+		for i := len(order) - 1; i >= 0; i-- {
+			list = append(list, model.NetValueHistory{Date: order[i], NetValue: values[order[i]]})
+		}
+		return list
+	}
+
+	// 普通定投每月按基准金额买入
+	st := model.Strategy{FundCode: "000001", BaseAmount: 100, Frequency: "monthly", StrategyType: StrategyTypeNormal}
+	histories := navs(map[time.Time]float64{date(1, 1): 1, date(2, 1): 2, date(3, 1): 4}, date(1, 1), date(2, 1), date(3, 1))
+	bt, err := s.backtestHistory(st, nil, histories, date(1, 1), date(3, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bt.Result.TotalInvest != 300 || !almostEqual(bt.Result.TotalShares, 175) || !almostEqual(bt.Result.CurrentValue, 700) {
+		t.Fatalf("result: %+v", bt.Result)
+	}
+	if bt.Baseline.TotalInvest != bt.Result.TotalInvest || len(bt.Result.Points) != 3 {
+		t.Fatalf("baseline: %+v", bt.Baseline)
+	}
+
+	// 均线策略在低于均线时加大投入，对照组仍按基准金额
+	st = model.Strategy{FundCode: "000001", BaseAmount: 100, Frequency: "daily", StrategyType: StrategyTypeMADeviation}
+	histories = navs(map[time.Time]float64{date(1, 1): 1, date(1, 2): 1, date(1, 3): 0.8}, date(1, 1), date(1, 2), date(1, 3))
+	params := &MADeviationParams{MAPeriod: 2, MaxMultiplier: 2, MinMultiplier: 0.5}
+	bt, err = s.backtestHistory(st, params, histories, date(1, 2), date(1, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(bt.Result.TotalInvest, 222.22) || bt.Baseline.TotalInvest != 200 {
+		t.Fatalf("want 222.22 vs 200, got %v vs %v", bt.Result.TotalInvest, bt.Baseline.TotalInvest)
+	}
+
+	if _, err := s.backtestHistory(st, params, nil, date(1, 2), date(1, 3)); err == nil {
+		t.Fatal("没有历史净值时应返回错误")
+	}
+}
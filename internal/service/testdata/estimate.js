@@ -0,0 +1 @@
+jsonpgz({"fundcode":"000001","name":"华夏成长混合","jzrq":"2024-01-12","dwjz":"1.2345","gsz":"1.2400","gszzl":"0.45","gztime":"2024-01-15 14:59"});
@@ -0,0 +1 @@
+var apidata={ content:"<table class='w782 comm lsjz'><thead><tr><th class='first'>净值日期</th><th>单位净值</th><th>累计净值</th><th>日增长率</th><th>申购状态</th><th>赎回状态</th><th class='tor last'>分红送配</th></tr></thead><tbody><tr><td>2024-01-15</td><td class='tor bold'>1.2400</td><td class='tor bold'>3.5600</td><td class='tor bold red'>0.45%</td><td>开放申购</td><td>开放赎回</td><td class='red unbold'></td></tr><tr><td>2024-01-12</td><td class='tor bold'>1.2345</td><td class='tor bold'>3.5545</td><td class='tor bold grn'>-1.02%</td><td>开放申购</td><td>开放赎回</td><td class='red unbold'></td></tr><tr><td>2024-01-11</td><td class='tor bold'>1.2472</td><td class='tor bold'>3.5672</td><td class='tor bold'>--</td><td>开放申购</td><td>开放赎回</td><td class='red unbold'></td></tr></tbody></table>",records:3,pages:1,curpage:1};
@@ -0,0 +1 @@
+var rankData = {datas:["000001,华夏成长混合,HXCZHH,2024-01-15,1.2400,3.5600,0.45,1.20,-2.35,-5.10,-8.02,-12.33,-20.10,-25.70,-3.10,356.20,2001-12-18,1,-12.33,1.50%,0.15%,1,0.15%,1,","000002,华夏成长混合C,HXCZHHC,2024-01-15,1.1000,1.1000,0.44,1.19,-2.40,-5.22,,,,,-3.12,10.00,2023-05-10,1,,1.50%,0.00%,1,0.00%,1,","000003,坏行,HH,2024-01-15"],allRecords:12345,pageIndex:1,pageNum:50,allPages:247,allNum:12345,gpNum:2000,hhNum:5000,zqNum:3000,zsNum:1500,bbNum:0,qdiiNum:300,etfNum:0,lofNum:0,fofNum:500};
@@ -0,0 +1 @@
+var apidata={ content:"<div class='box'><div class='boxitem w790'><h4 class='t'><label class='left'><a href='http://fund.eastmoney.com/000001.html'>华夏成长混合</a>&nbsp;&nbsp;2024年1季度股票投资明细</label><label class='right lab2 xq505'>&nbsp;&nbsp;&nbsp;&nbsp;来源：天天基金&nbsp;&nbsp;&nbsp;&nbsp;截止至：<font class='px12'>2024-03-31</font></label></h4><div class='space0'></div><table class='w782 comm tzxq'><thead><tr><th class='first'>序号</th><th>股票代码</th><th>股票名称</th><th>相关资讯</th><th>占净值<br />比例</th><th class='cgs'>持股数<br />（万股）</th><th class='last ccs'>持仓市值<br />（万元）</th></tr></thead><tbody><tr><td>1</td><td><a href='//quote.eastmoney.com/unify/r/1.600519'>600519</a></td><td class='tol'><a href='//quote.eastmoney.com/unify/r/1.600519'>贵州茅台</a></td><td class='xglj'><a href='ccbdxq_000001_600519.html'>变动详情</a></td><td class='tor'>9.52%</td><td class='tor'>12.30</td><td class='tor'>21,019.33</td></tr><tr><td>2</td><td><a href='//quote.eastmoney.com/unify/r/0.000858'>000858</a></td><td class='tol'><a href='//quote.eastmoney.com/unify/r/0.000858'>五粮液</a></td><td class='xglj'><a href='ccbdxq_000001_000858.html'>变动详情</a></td><td class='tor'>6.10%</td><td class='tor'>80.00</td><td class='tor'>13,460.00</td></tr><tr><td>3</td><td><a href='//quote.eastmoney.com/hk/00700.html'>00700</a></td><td class='tol'><a href='//quote.eastmoney.com/hk/00700.html'>腾讯控股</a></td><td class='xglj'><a href='ccbdxq_000001_00700.html'>变动详情</a></td><td class='tor'>---</td><td class='tor'>5.00</td><td class='tor'>1,400.00</td></tr></tbody></table></div></div><div class='box'><div class='boxitem w790'><h4 class='t'><label class='left'>2023年4季度股票投资明细</label><label class='right'>截止至：<font class='px12'>2023-12-31</font></label></h4><table class='w782 comm tzxq'><thead><tr><th>序号</th><th>股票代码</th><th>股票名称</th><th>占净值<br />比例</th></tr></thead><tbody><tr><td>1</td><td><a href='//quote.eastmoney.com/unify/r/1.601318'>601318</a></td><td class='tol'><a href='#'>中国平安</a></td><td class='tor'>8.00%</td></tr></tbody></table></div></div>",arryear:[2024,2023],curyear:2024};
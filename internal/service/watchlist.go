@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/repository"
+)
+
+// WatchlistService 自选基金服务
+type WatchlistService struct {
+	funds     repository.FundRepository
+	holdings  repository.HoldingRepository
+	history   repository.HistoryRepository
+	watchlist repository.WatchlistRepository
+	signals   repository.SignalRepository
+	store     repository.Store
+}
+
+// NewWatchlistService 使用指定仓储创建自选服务
+func NewWatchlistService(store repository.Store) *WatchlistService {
+	return &WatchlistService{
+		funds:     store,
+		holdings:  store,
+		history:   store,
+		watchlist: store,
+		signals:   store,
+		store:     store,
+	}
+}
+
+var watchlistService = NewWatchlistService(repository.Default())
+
+// GetWatchlistService 获取自选服务实例
+func GetWatchlistService() *WatchlistService {
+	return watchlistService
+}
+
+// DefaultWatchGroup 默认分组
+const DefaultWatchGroup = "默认"
+
+// WatchItemView 自选基金展示数据
+type WatchItemView struct {
+	Item      model.WatchlistItem
+	Fund      *model.Fund          // 最新估值，可能为空
+	Return1W  float64              // 近1周收益率(%)
+	Return1M  float64              // 近1月收益率(%)
+	Return3M  float64              // 近3月收益率(%)
+	Signal    *model.TradingSignal // 最新信号，可能为空
+	IsHolding bool                 // 是否已持仓
+}
+
+// AddToWatchlist 添加自选基金
+func (w *WatchlistService) AddToWatchlist(fundCode, fundName, group, note string) (*model.WatchlistItem, error) {
+	if fundCode == "" {
+		return nil, errors.New("请输入基金代码")
+	}
+
+	existing, _ := w.watchlist.GetWatchlistItemByFundCode(fundCode)
+	if existing != nil {
+		return existing, nil
+	}
+
+	if group == "" {
+		group = DefaultWatchGroup
+	}
+
+	// 补充基金名称
+	if fundName == "" || fundName == fundCode {
+		fundName = fundCode
+		results, _ := GetFundAPI().SearchFund(fundCode)
+		for _, r := range results {
+			if r.Code == fundCode {
+				fundName = r.Name
+				break
+			}
+		}
+	}
+
+	item := &model.WatchlistItem{
+		FundCode:  fundCode,
+		FundName:  fundName,
+		GroupName: group,
+		Note:      note,
+	}
+	if err := w.watchlist.SaveWatchlistItem(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// UpdateWatchlistItem 更新自选基金的分组和备注
+func (w *WatchlistService) UpdateWatchlistItem(id uint, group, note string) error {
+	item, err := w.watchlist.GetWatchlistItem(id)
+	if err != nil {
+		return err
+	}
+	if group == "" {
+		group = DefaultWatchGroup
+	}
+	item.GroupName = group
+	item.Note = note
+	return w.watchlist.SaveWatchlistItem(item)
+}
+
+// RemoveFromWatchlist 删除自选基金
+func (w *WatchlistService) RemoveFromWatchlist(id uint) error {
+	return w.watchlist.DeleteWatchlistItem(id)
+}
+
+// GetWatchlist 获取自选基金，group为空时返回全部
+func (w *WatchlistService) GetWatchlist(group string) ([]model.WatchlistItem, error) {
+	return w.watchlist.GetWatchlistItems(group)
+}
+
+// GetGroups 获取所有分组
+func (w *WatchlistService) GetGroups() ([]string, error) {
+	return w.watchlist.GetWatchlistGroups()
+}
+
+// GetWatchlistViews 获取自选基金及其估值、近期收益和信号
+func (w *WatchlistService) GetWatchlistViews(group string) ([]WatchItemView, error) {
+	items, err := w.watchlist.GetWatchlistItems(group)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]WatchItemView, len(items))
+	for i, item := range items {
+		view := WatchItemView{Item: item}
+		view.Fund, _ = w.funds.GetFund(item.FundCode)
+		view.Signal, _ = w.signals.GetLatestSignal(item.FundCode)
+
+		if holding, _ := w.holdings.GetHoldingByFundCode(item.FundCode); holding != nil {
+			view.IsHolding = true
+		}
+
+		histories, _ := w.history.GetNetValueHistory(item.FundCode, 70)
+		if len(histories) > 0 {
+			latest := histories[0].Date
+			view.Return1W = periodReturn(histories, latest.AddDate(0, 0, -7))
+			view.Return1M = periodReturn(histories, latest.AddDate(0, -1, 0))
+			view.Return3M = periodReturn(histories, latest.AddDate(0, -3, 0))
+		}
+		views[i] = view
+	}
+	return views, nil
+}
+
+// RefreshWatchlist 刷新所有自选基金的估值、净值历史和信号
+func (w *WatchlistService) RefreshWatchlist() error {
+	items, err := w.watchlist.GetWatchlistItems("")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	defer GetSignalService().RefreshSignals(now)
+	for _, item := range items {
+		if _, err := GetFundAPI().RefreshFund(item.FundCode); err != nil {
+			continue // 跳过失败的
+		}
+
+		// 补充近期净值历史(用于计算近期收益和信号)
+		latest, err := w.history.GetLatestNetValue(item.FundCode)
+		if err != nil || latest.Date.Before(today.AddDate(0, 0, -1)) {
+			if histories, err := GetFundAPI().GetFundHistory(item.FundCode, 70); err == nil {
+				w.history.SaveNewNetValueHistories(item.FundCode, histories)
+			}
+		}
+
+		// 每天最多生成一次信号
+		signal, err := w.signals.GetLatestSignal(item.FundCode)
+		if err != nil || signal.GeneratedAt.Before(today) {
+			GetSignalService().GenerateSignal(item.FundCode)
+		}
+	}
+	return nil
+}
+
+// ConvertToHolding 将自选基金转为持仓(买入)，成功后从自选中移除
+func (w *WatchlistService) ConvertToHolding(id uint, amount, netValue, fee float64) error {
+	item, err := w.watchlist.GetWatchlistItem(id)
+	if err != nil {
+		return err
+	}
+
+	// 添加持仓、买入和移出自选在同一事务中完成，买入失败时不留下空持仓
+	return w.store.Transaction(func(tx repository.Store) error {
+		portfolio := NewPortfolioService(tx)
+		if _, err := portfolio.AddHolding(item.FundCode, item.FundName); err != nil {
+			return err
+		}
+		if err := portfolio.Buy(item.FundCode, amount, netValue, fee, time.Now()); err != nil {
+			return err
+		}
+		return tx.DeleteWatchlistItem(id)
+	})
+}
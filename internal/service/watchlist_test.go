@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"jijin/internal/model"
+)
+
+func TestConvertToHolding(t *testing.T) {
+	store := newTestStore(t)
+	w := NewWatchlistService(store)
+	item := &model.WatchlistItem{FundCode: "000001", FundName: "测试基金"}
+	if err := store.SaveWatchlistItem(item); err != nil {
+		t.Fatal(err)
+	}
+
+	// 买入失败时整体回滚，不留下空持仓，自选保留
+	if err := w.ConvertToHolding(item.ID, 1000, 0, 0); err == nil {
+		t.Fatal("净值为0时应返回错误")
+	}
+	if holdings, _ := store.GetAllHoldings(); len(holdings) != 0 {
+		t.Fatalf("买入失败后不应留下持仓，got %d", len(holdings))
+	}
+	if _, err := store.GetWatchlistItem(item.ID); err != nil {
+		t.Fatalf("买入失败后自选应保留: %v", err)
+	}
+
+	if err := w.ConvertToHolding(item.ID, 1000, 2.0, 0); err != nil {
+		t.Fatal(err)
+	}
+	h, err := store.GetHoldingByFundCode("000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(h.Shares, 500) {
+		t.Fatalf("want 500 shares, got %v", h.Shares)
+	}
+	if _, err := store.GetWatchlistItem(item.ID); err == nil {
+		t.Fatal("转为持仓后应从自选中移除")
+	}
+}
@@ -36,8 +36,55 @@ var (
 	borderColor    = color.RGBA{R: 226, G: 232, B: 240, A: 255}
 )
 
+// 深色模式颜色
+var (
+	darkBgColor        = color.RGBA{R: 15, G: 23, B: 42, A: 255}
+	darkCardBgColor    = color.RGBA{R: 30, G: 41, B: 59, A: 255}
+	darkTextColor      = color.RGBA{R: 226, G: 232, B: 240, A: 255}
+	darkTextMutedColor = color.RGBA{R: 148, G: 163, B: 184, A: 255}
+	darkBorderColor    = color.RGBA{R: 51, G: 65, B: 85, A: 255}
+)
+
+// 主题模式
+const (
+	ModeLight  = "light"
+	ModeDark   = "dark"
+	ModeSystem = "system"
+)
+
+// mode 当前主题模式
+var mode = ModeLight
+
+// SetMode 设置主题模式，设置后需重新应用主题
+func SetMode(m string) {
+	mode = m
+}
+
+// isDark 是否使用深色
+func isDark(variant fyne.ThemeVariant) bool {
+	switch mode {
+	case ModeDark:
+		return true
+	case ModeSystem:
+		return variant == theme.VariantDark
+	}
+	return false
+}
+
+// currentDark 当前是否为深色，跟随系统时读取系统设置
+func currentDark() bool {
+	variant := theme.VariantLight
+	if app := fyne.CurrentApp(); app != nil {
+		variant = app.Settings().ThemeVariant()
+	}
+	return isDark(variant)
+}
+
 // Color 返回主题颜色
 func (t *AppTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if isDark(variant) {
+		return darkColor(name)
+	}
 	switch name {
 	case theme.ColorNamePrimary:
 		return primaryColor
@@ -72,6 +119,36 @@ func (t *AppTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) co
 	}
 }
 
+// darkColor 深色模式颜色
+func darkColor(name fyne.ThemeColorName) color.Color {
+	switch name {
+	case theme.ColorNamePrimary, theme.ColorNameButton, theme.ColorNameFocus:
+		return primaryColor
+	case theme.ColorNameBackground:
+		return darkBgColor
+	case theme.ColorNameForeground:
+		return darkTextColor
+	case theme.ColorNameDisabled, theme.ColorNamePlaceHolder:
+		return darkTextMutedColor
+	case theme.ColorNameHover:
+		return color.RGBA{R: 51, G: 65, B: 85, A: 255}
+	case theme.ColorNameSelection:
+		return color.RGBA{R: 48, G: 96, B: 200, A: 160}
+	case theme.ColorNameInputBackground:
+		return darkCardBgColor
+	case theme.ColorNameSeparator:
+		return darkBorderColor
+	case theme.ColorNameSuccess:
+		return successColor
+	case theme.ColorNameWarning:
+		return warningColor
+	case theme.ColorNameError:
+		return dangerColor
+	default:
+		return theme.DefaultTheme().Color(name, theme.VariantDark)
+	}
+}
+
 // Font 返回字体
 func (t *AppTheme) Font(style fyne.TextStyle) fyne.Resource {
 	return loadChineseFont()
@@ -153,10 +230,16 @@ func GetPrimaryColor() color.Color {
 
 // GetMutedColor 获取次要文字颜色
 func GetMutedColor() color.Color {
+	if currentDark() {
+		return darkTextMutedColor
+	}
 	return textMutedColor
 }
 
 // GetCardBgColor 获取卡片背景色
 func GetCardBgColor() color.Color {
+	if currentDark() {
+		return darkCardBgColor
+	}
 	return cardBgColor
 }
@@ -33,12 +33,16 @@ func (u *AlertUI) build() {
 	title.TextStyle = fyne.TextStyle{Bold: true}
 
 	addBtn := widget.NewButton("添加提醒规则", u.showAddDialog)
+	settingsBtn := widget.NewButton("免打扰与汇总", u.showSettingsDialog)
+	channelsBtn := widget.NewButton("通知渠道", u.ShowChannelsDialog)
+	historyBtn := widget.NewButton("提醒历史", u.showHistoryDialog)
 
 	u.ruleList = widget.NewList(
 		func() int { return len(u.rules) },
 		func() fyne.CanvasObject {
 			return container.NewHBox(
 				widget.NewLabel("规则名称"),
+				widget.NewButton("重置", nil),
 				widget.NewButton("删除", nil),
 			)
 		},
@@ -49,13 +53,24 @@ func (u *AlertUI) build() {
 			rule := u.rules[id]
 			box := obj.(*fyne.Container)
 			label := box.Objects[0].(*widget.Label)
-			label.SetText(fmt.Sprintf("%s - %s (阈值%.1f%%)",
-				rule.FundName, rule.AlertType, rule.Threshold))
+			text := formatAlertRule(rule)
+			if rule.Active {
+				text += " · 已触发"
+			}
+			label.SetText(text)
+			resetBtn := box.Objects[1].(*widget.Button)
+			resetBtn.OnTapped = func() {
+				if err := service.GetAlertService().ResetAlertRule(rule.ID); err != nil {
+					dialog.ShowError(err, u.window)
+					return
+				}
+				u.Refresh()
+			}
 		},
 	)
 
 	u.content = container.NewBorder(
-		container.NewVBox(title, addBtn, widget.NewSeparator()),
+		container.NewVBox(title, container.NewHBox(addBtn, settingsBtn, channelsBtn, historyBtn), widget.NewSeparator()),
 		nil, nil, nil,
 		u.ruleList,
 	)
@@ -67,44 +82,213 @@ func (u *AlertUI) showAddDialog() {
 	codeEntry.SetPlaceHolder("基金代码")
 
 	thresholdEntry := widget.NewEntry()
-	thresholdEntry.SetPlaceHolder("涨跌幅阈值(%)")
+	thresholdEntry.SetPlaceHolder("涨跌幅阈值(%)/连续天数")
 	thresholdEntry.SetText("3")
 
-	typeSelect := widget.NewSelect(
-		[]string{"盘中涨跌", "连涨连跌"},
-		nil,
-	)
-	typeSelect.SetSelected("盘中涨跌")
+	alertTypes := []string{
+		service.AlertTypePriceChange, service.AlertTypeConsecutive,
+		service.AlertTypeNavUpdate, service.AlertTypeExpression,
+	}
+	typeOptions := make([]string, len(alertTypes))
+	for i, t := range alertTypes {
+		typeOptions[i] = service.AlertTypeNames[t]
+	}
+	typeSelect := widget.NewSelect(typeOptions, nil)
+
+	exprEntry := widget.NewMultiLineEntry()
+	exprEntry.SetPlaceHolder("如: est_growth < -2 AND rsi14 < 30")
+	exprEntry.SetMinRowsVisible(2)
+	exprEntry.Validator = func(text string) error {
+		if alertTypes[typeSelect.SelectedIndex()] != service.AlertTypeExpression {
+			return nil
+		}
+		return service.ValidateAlertExpression(text)
+	}
+
+	helpLabel := widget.NewLabel(alertVariablesHelp())
+	helpLabel.Wrapping = fyne.TextWrapWord
+	helpLabel.Importance = widget.LowImportance
+	helpScroll := container.NewVScroll(helpLabel)
+	helpScroll.SetMinSize(fyne.NewSize(360, 100))
+
+	cooldownOptions := make([]string, len(service.CooldownModes))
+	for i, m := range service.CooldownModes {
+		cooldownOptions[i] = service.CooldownModeNames[m]
+	}
+	cooldownSelect := widget.NewSelect(cooldownOptions, nil)
+	minutesEntry := widget.NewEntry()
+	minutesEntry.SetText("60")
+	hysteresisEntry := widget.NewEntry()
+	hysteresisEntry.SetPlaceHolder("回落超过该幅度才再次提醒")
+	hysteresisEntry.SetText("0")
+	cooldownSelect.OnChanged = func(string) {
+		switch service.CooldownModes[cooldownSelect.SelectedIndex()] {
+		case service.CooldownInterval:
+			minutesEntry.Enable()
+			hysteresisEntry.Disable()
+		case service.CooldownUntilReset:
+			minutesEntry.Disable()
+			hysteresisEntry.Enable()
+		default:
+			minutesEntry.Disable()
+			hysteresisEntry.Disable()
+		}
+	}
+	cooldownSelect.SetSelectedIndex(0)
+
+	channels, _ := service.GetNotifyService().GetChannels()
+	channelNames := make([]string, len(channels))
+	for i, ch := range channels {
+		channelNames[i] = ch.Name
+	}
+	channelGroup := widget.NewCheckGroup(channelNames, nil)
+	channelGroup.Horizontal = true
+
+	typeSelect.OnChanged = func(string) {
+		isExpr := alertTypes[typeSelect.SelectedIndex()] == service.AlertTypeExpression
+		if isExpr {
+			thresholdEntry.Disable()
+			exprEntry.Enable()
+		} else {
+			thresholdEntry.Enable()
+			exprEntry.Disable()
+		}
+		exprEntry.Validate()
+	}
+	typeSelect.SetSelectedIndex(0)
 
 	form := dialog.NewForm("添加提醒规则", "添加", "取消",
 		[]*widget.FormItem{
 			widget.NewFormItem("基金代码", codeEntry),
 			widget.NewFormItem("提醒类型", typeSelect),
 			widget.NewFormItem("阈值", thresholdEntry),
+			widget.NewFormItem("条件表达式", exprEntry),
+			widget.NewFormItem("重复提醒", cooldownSelect),
+			widget.NewFormItem("冷却(分钟)", minutesEntry),
+			widget.NewFormItem("回差", hysteresisEntry),
+			widget.NewFormItem("通知渠道", channelGroup),
+			widget.NewFormItem("可用变量", helpScroll),
 		},
 		func(ok bool) {
 			if !ok || codeEntry.Text == "" {
 				return
 			}
 			threshold, _ := strconv.ParseFloat(thresholdEntry.Text, 64)
-			alertType := service.AlertTypePriceChange
-			if typeSelect.Selected == "连涨连跌" {
-				alertType = service.AlertTypeConsecutive
+			minutes, _ := strconv.Atoi(minutesEntry.Text)
+			var channelIDs []uint
+			for _, selected := range channelGroup.Selected {
+				for _, ch := range channels {
+					if ch.Name == selected {
+						channelIDs = append(channelIDs, ch.ID)
+					}
+				}
 			}
+			hysteresis, _ := strconv.ParseFloat(hysteresisEntry.Text, 64)
 			rule := &model.AlertRule{
-				FundCode:  codeEntry.Text,
-				FundName:  codeEntry.Text,
-				AlertType: alertType,
-				Threshold: threshold,
-				Direction: "both",
-				Enabled:   true,
+				FundCode:        codeEntry.Text,
+				FundName:        codeEntry.Text,
+				AlertType:       alertTypes[typeSelect.SelectedIndex()],
+				Threshold:       threshold,
+				Direction:       "both",
+				CooldownMode:    service.CooldownModes[cooldownSelect.SelectedIndex()],
+				CooldownMinutes: minutes,
+				Hysteresis:      hysteresis,
+				Channels:        service.JoinChannelIDs(channelIDs),
+				Enabled:         true,
+			}
+			switch rule.AlertType {
+			case service.AlertTypeConsecutive:
+				rule.ConsecutiveDays = int(threshold)
+			case service.AlertTypeExpression:
+				rule.Expression = exprEntry.Text
+			}
+			if err := service.GetAlertService().CreateAlertRule(rule); err != nil {
+				dialog.ShowError(err, u.window)
+				return
 			}
-			service.GetAlertService().CreateAlertRule(rule)
 			u.Refresh()
 		}, u.window)
+	form.Resize(fyne.NewSize(560, 640))
 	form.Show()
 }
 
+// showSettingsDialog 显示免打扰与汇总设置对话框
+func (u *AlertUI) showSettingsDialog() {
+	settings, err := service.GetAlertService().GetAlertSettings()
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+
+	quietCheck := widget.NewCheck("启用免打扰", nil)
+	quietCheck.SetChecked(settings.QuietEnabled)
+	startEntry := widget.NewEntry()
+	startEntry.SetText(settings.QuietStart)
+	endEntry := widget.NewEntry()
+	endEntry.SetText(settings.QuietEnd)
+
+	digestCheck := widget.NewCheck("启用汇总推送", nil)
+	digestCheck.SetChecked(settings.DigestEnabled)
+	digestEntry := widget.NewEntry()
+	digestEntry.SetText(strconv.Itoa(settings.DigestMinutes))
+
+	note := widget.NewLabel("免打扰时段内的提醒仍会记录，结束后合并推送；汇总模式下间隔内的提醒合并为一条")
+	note.Wrapping = fyne.TextWrapWord
+	note.Importance = widget.LowImportance
+
+	form := dialog.NewForm("免打扰与汇总", "保存", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("", quietCheck),
+			widget.NewFormItem("开始时间", startEntry),
+			widget.NewFormItem("结束时间", endEntry),
+			widget.NewFormItem("", digestCheck),
+			widget.NewFormItem("汇总间隔(分钟)", digestEntry),
+			widget.NewFormItem("", note),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			settings.QuietEnabled = quietCheck.Checked
+			settings.QuietStart = startEntry.Text
+			settings.QuietEnd = endEntry.Text
+			settings.DigestEnabled = digestCheck.Checked
+			settings.DigestMinutes, _ = strconv.Atoi(digestEntry.Text)
+			if err := service.GetAlertService().SaveAlertSettings(settings); err != nil {
+				dialog.ShowError(err, u.window)
+			}
+		}, u.window)
+	form.Resize(fyne.NewSize(420, 380))
+	form.Show()
+}
+
+// formatAlertRule 格式化规则描述
+func formatAlertRule(rule model.AlertRule) string {
+	typeName := service.AlertTypeNames[rule.AlertType]
+	switch rule.AlertType {
+	case service.AlertTypeExpression:
+		return fmt.Sprintf("%s - %s: %s", rule.FundName, typeName, rule.Expression)
+	case service.AlertTypeConsecutive:
+		return fmt.Sprintf("%s - %s (%d天)", rule.FundName, typeName, rule.ConsecutiveDays)
+	case service.AlertTypeNavUpdate:
+		return fmt.Sprintf("%s - %s", rule.FundName, typeName)
+	case service.AlertTypeProfitAmount, service.AlertTypeTargetValue:
+		return fmt.Sprintf("%s - %s (%.2f元)", rule.FundName, typeName, rule.Threshold)
+	case service.AlertTypeTakeProfit, service.AlertTypeStopLoss, service.AlertTypeTrailingStop:
+		return fmt.Sprintf("%s - 持仓%s (%.2f%%)", rule.FundName, typeName, rule.Threshold)
+	}
+	return fmt.Sprintf("%s - %s (阈值%.1f%%)", rule.FundName, typeName, rule.Threshold)
+}
+
+// alertVariablesHelp 条件表达式帮助说明
+func alertVariablesHelp() string {
+	help := "支持 AND/OR/NOT、括号、+ - * / 及 < <= > >= == !=\n"
+	for _, name := range service.AlertVariableNames() {
+		help += fmt.Sprintf("\n%s: %s", name, service.AlertVariables[name])
+	}
+	return help
+}
+
 // Content 返回界面内容
 func (u *AlertUI) Content() fyne.CanvasObject {
 	return u.content
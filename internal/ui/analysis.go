@@ -2,9 +2,9 @@ package ui
 
 import (
 	"fmt"
-	"image/color"
 
 	"jijin/internal/service"
+	"jijin/internal/ui/chart"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -12,9 +12,12 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
+// analysisHistoryDays 资产走势和月度收益回溯的净值日数
+const analysisHistoryDays = 800
+
 // AnalysisUI 收益分析UI
 type AnalysisUI struct {
-	content *fyne.Container
+	content fyne.CanvasObject
 
 	// 汇总
 	totalCostLabel   *widget.Label
@@ -23,27 +26,24 @@ type AnalysisUI struct {
 	profitRateLabel  *widget.Label
 
 	// 持仓分布
-	distributionList *widget.List
-	distributions    []distributionItem
+	allocations []*allocationView
 
-	// 收益明细
-	profitList *widget.List
-	profits    []profitItem
-}
+	// 资产走势
+	valueChart *chart.TimeChart
+
+	// 盈亏贡献
+	profitChart *chart.WaterfallChart
 
-type distributionItem struct {
-	Name    string
-	Value   float64
-	Percent float64
-	Color   color.Color
+	// 月度收益
+	monthHeatmap *chart.MonthHeatmap
 }
 
-type profitItem struct {
-	Name       string
-	Cost       float64
-	Value      float64
-	Profit     float64
-	ProfitRate float64
+// allocationView 某一维度的持仓分布环形图和图例
+type allocationView struct {
+	by     string
+	title  string
+	pie    *chart.PieChart
+	legend *fyne.Container
 }
 
 // NewAnalysisUI 创建收益分析UI
@@ -77,79 +77,46 @@ func (a *AnalysisUI) build() {
 
 	summaryCard := widget.NewCard("资产汇总", "", summaryContent)
 
-	// 持仓分布
-	a.distributionList = widget.NewList(
-		func() int {
-			return len(a.distributions)
-		},
-		func() fyne.CanvasObject {
-			colorBox := canvas.NewRectangle(color.RGBA{100, 149, 237, 255})
-			colorBox.SetMinSize(fyne.NewSize(20, 20))
-			return container.NewHBox(
-				colorBox,
-				widget.NewLabel("基金名称基金名称"),
-				widget.NewLabel("¥10000.00"),
-				widget.NewLabel("50.00%"),
-			)
-		},
-		func(id widget.ListItemID, obj fyne.CanvasObject) {
-			if id >= len(a.distributions) {
-				return
-			}
-			item := a.distributions[id]
-			box := obj.(*fyne.Container)
-
-			colorBox := box.Objects[0].(*canvas.Rectangle)
-			colorBox.FillColor = item.Color
-			colorBox.Refresh()
-
-			box.Objects[1].(*widget.Label).SetText(item.Name)
-			box.Objects[2].(*widget.Label).SetText(fmt.Sprintf("¥%.2f", item.Value))
-			box.Objects[3].(*widget.Label).SetText(fmt.Sprintf("%.2f%%", item.Percent))
-		},
-	)
+	// 持仓分布：按基金、基金类型、账户
+	a.allocations = []*allocationView{
+		{by: service.AllocationByFund, title: "按基金"},
+		{by: service.AllocationByType, title: "按类型"},
+		{by: service.AllocationByAccount, title: "按账户"},
+	}
+	pies := container.NewGridWithColumns(len(a.allocations))
+	for _, v := range a.allocations {
+		v.pie = chart.NewPieChart(160, true)
+		v.legend = container.NewVBox()
+		title := widget.NewLabel(v.title)
+		title.Alignment = fyne.TextAlignCenter
+		pies.Add(container.NewVBox(title, v.pie, v.legend))
+	}
+	distributionCard := widget.NewCard("持仓分布", "", pies)
 
-	distributionCard := widget.NewCard("持仓分布", "", a.distributionList)
-
-	// 收益明细
-	a.profitList = widget.NewList(
-		func() int {
-			return len(a.profits)
-		},
-		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewLabel("基金名称基金名称"),
-				widget.NewLabel("成本:¥10000"),
-				widget.NewLabel("市值:¥12000"),
-				widget.NewLabel("盈亏:¥2000"),
-				widget.NewLabel("收益率:20%"),
-			)
-		},
-		func(id widget.ListItemID, obj fyne.CanvasObject) {
-			if id >= len(a.profits) {
-				return
-			}
-			item := a.profits[id]
-			box := obj.(*fyne.Container)
-
-			box.Objects[0].(*widget.Label).SetText(item.Name)
-			box.Objects[1].(*widget.Label).SetText(fmt.Sprintf("成本:¥%.2f", item.Cost))
-			box.Objects[2].(*widget.Label).SetText(fmt.Sprintf("市值:¥%.2f", item.Value))
-			box.Objects[3].(*widget.Label).SetText(fmt.Sprintf("盈亏:¥%.2f", item.Profit))
-			box.Objects[4].(*widget.Label).SetText(fmt.Sprintf("收益率:%.2f%%", item.ProfitRate))
-		},
-	)
+	// 资产走势
+	a.valueChart = chart.NewTimeChart(600, 220)
+	a.valueChart.SetValueFormat("%.0f")
+	a.valueChart.SetStacked(true)
+	valueCard := widget.NewCard("资产走势", "按交易记录和净值历史回溯的各基金市值(堆叠)与累计投入",
+		container.NewVBox(chart.NewRangeBar(chart.Range1Y, a.valueChart), a.valueChart))
+
+	// 盈亏贡献
+	a.profitChart = chart.NewWaterfallChart(600, 200, "总盈亏")
+	profitCard := widget.NewCard("盈亏贡献", "各基金对总盈亏的贡献，盈利红、亏损绿", a.profitChart)
 
-	profitCard := widget.NewCard("收益明细", "", a.profitList)
+	// 月度收益
+	a.monthHeatmap = chart.NewMonthHeatmap(600)
+	a.monthHeatmap.SetValueFormat("%.1f")
+	monthCard := widget.NewCard("月度收益(%)", "扣除买卖现金流的时间加权收益", a.monthHeatmap)
 
 	// 布局
-	a.content = container.NewVBox(
+	a.content = container.NewVScroll(container.NewVBox(
 		summaryCard,
-		container.NewGridWithColumns(2,
-			distributionCard,
-			profitCard,
-		),
-	)
+		distributionCard,
+		valueCard,
+		profitCard,
+		monthCard,
+	))
 }
 
 // Content 获取内容
@@ -159,53 +126,67 @@ func (a *AnalysisUI) Content() fyne.CanvasObject {
 
 // Refresh 刷新数据
 func (a *AnalysisUI) Refresh() {
+	portfolioService := service.GetPortfolioService()
+
 	// 获取汇总数据
-	totalCost, totalValue, totalProfit, profitRate := service.GetPortfolioService().GetPortfolioSummary()
+	totalCost, totalValue, totalProfit, profitRate := portfolioService.GetPortfolioSummary()
 
 	a.totalCostLabel.SetText(fmt.Sprintf("¥%.2f", totalCost))
 	a.totalValueLabel.SetText(fmt.Sprintf("¥%.2f", totalValue))
 	a.totalProfitLabel.SetText(fmt.Sprintf("¥%.2f", totalProfit))
 	a.profitRateLabel.SetText(fmt.Sprintf("%.2f%%", profitRate))
 
-	// 获取持仓数据
-	holdings, _ := service.GetPortfolioService().GetAllHoldings()
-
-	// 计算分布
-	colors := []color.Color{
-		color.RGBA{100, 149, 237, 255}, // 蓝色
-		color.RGBA{60, 179, 113, 255},  // 绿色
-		color.RGBA{255, 165, 0, 255},   // 橙色
-		color.RGBA{238, 130, 238, 255}, // 紫色
-		color.RGBA{255, 99, 71, 255},   // 红色
-		color.RGBA{64, 224, 208, 255},  // 青色
+	// 持仓分布
+	for _, v := range a.allocations {
+		items, _ := portfolioService.GetAllocation(v.by)
+		slices := make([]chart.PieSlice, len(items))
+		v.legend.RemoveAll()
+		for i, item := range items {
+			slices[i] = chart.PieSlice{Label: item.Label, Value: item.Value, Color: chart.PaletteColor(i)}
+			v.legend.Add(legendRow(slices[i], item.Percent))
+		}
+		v.pie.SetSlices(slices)
+		v.legend.Refresh()
 	}
 
-	a.distributions = make([]distributionItem, len(holdings))
-	a.profits = make([]profitItem, len(holdings))
-
-	for i, h := range holdings {
-		value := h.MarketValue()
-		percent := 0.0
-		if totalValue > 0 {
-			percent = value / totalValue * 100
-		}
+	// 盈亏贡献
+	contributions, _ := portfolioService.GetProfitContributions()
+	items := make([]chart.WaterfallItem, len(contributions))
+	for i, c := range contributions {
+		items[i] = chart.WaterfallItem{Label: c.FundName, Value: c.Profit}
+	}
+	a.profitChart.SetItems(items)
 
-		a.distributions[i] = distributionItem{
-			Name:    h.FundName,
-			Value:   value,
-			Percent: percent,
-			Color:   colors[i%len(colors)],
-		}
+	// 资产走势和月度收益
+	dates, funds, err := portfolioService.GetFundValueHistory(analysisHistoryDays)
+	if err != nil {
+		return
+	}
+	points := service.SumFundValues(dates, funds)
+	series := make([]chart.Series, 0, len(funds)+1)
+	for i, f := range funds {
+		series = append(series, chart.Series{Name: f.FundName, Values: f.Values, Color: chart.PaletteColor(i), Kind: chart.KindArea, Width: 1})
+	}
+	invested := make([]float64, len(points))
+	for i, pt := range points {
+		invested[i] = pt.Invested
+	}
+	series = append(series, chart.Series{Name: "累计投入", Values: invested, Color: chart.ColorOrange, Width: 2})
+	a.valueChart.SetSeries(dates, series...)
 
-		a.profits[i] = profitItem{
-			Name:       h.FundName,
-			Cost:       h.Cost,
-			Value:      value,
-			Profit:     h.Profit(),
-			ProfitRate: h.ProfitRate(),
-		}
+	months := service.MonthlyReturns(points)
+	cells := make([]chart.MonthCell, len(months))
+	for i, m := range months {
+		cells[i] = chart.MonthCell{Year: m.Year, Month: m.Month, Value: m.Return}
 	}
+	a.monthHeatmap.SetData(cells, service.YearlyReturns(months))
+}
 
-	a.distributionList.Refresh()
-	a.profitList.Refresh()
+// legendRow 图例行：色块、名称和占比
+func legendRow(slice chart.PieSlice, percent float64) fyne.CanvasObject {
+	box := canvas.NewRectangle(slice.Color)
+	box.SetMinSize(fyne.NewSize(12, 12))
+	label := widget.NewLabel(fmt.Sprintf("%s  %.1f%%", slice.Label, percent))
+	label.Truncation = fyne.TextTruncateEllipsis
+	return container.NewBorder(nil, nil, container.NewCenter(box), nil, label)
 }
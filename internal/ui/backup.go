@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// exportFormatNames 导出格式显示名称
+var exportFormatNames = map[string]string{
+	service.ExportCSV:  "CSV(每类数据一个文件)",
+	service.ExportJSON: "JSON",
+	service.ExportXLSX: "Excel(XLSX)",
+}
+
+// showExportDialog 选择数据集和格式后导出到目录
+func (u *ToolsUI) showExportDialog() {
+	formatOptions := make([]string, len(service.ExportFormats))
+	for i, f := range service.ExportFormats {
+		formatOptions[i] = exportFormatNames[f]
+	}
+	formatRadio := widget.NewRadioGroup(formatOptions, nil)
+	formatRadio.SetSelected(formatOptions[0])
+
+	names := make([]string, len(service.ExportDatasets))
+	for i, ds := range service.ExportDatasets {
+		names[i] = ds.Name
+	}
+	dataGroup := widget.NewCheckGroup(names, nil)
+	dataGroup.SetSelected(names)
+
+	dialog.ShowForm("导出数据", "选择目录...", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("格式", formatRadio),
+			widget.NewFormItem("数据", dataGroup),
+		},
+		func(ok bool) {
+			if !ok || len(dataGroup.Selected) == 0 {
+				return
+			}
+			format := service.ExportCSV
+			for f, name := range exportFormatNames {
+				if name == formatRadio.Selected {
+					format = f
+				}
+			}
+			keys := append([]string{}, dataGroup.Selected...)
+			dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+				if err != nil || dir == nil {
+					return
+				}
+				files, err := service.GetBackupService().Export(dir.Path(), format, keys)
+				if err != nil {
+					dialog.ShowError(err, u.window)
+					return
+				}
+				u.resultArea.SetText("已导出:\n" + strings.Join(files, "\n"))
+			}, u.window)
+		}, u.window)
+}
+
+// backupNow 立即备份到备份目录
+func (u *ToolsUI) backupNow() {
+	u.resultArea.SetText("正在备份...")
+	go func() {
+		path, err := service.GetBackupService().Backup("")
+		if err != nil {
+			u.resultArea.SetText("备份失败: " + err.Error())
+			return
+		}
+		u.resultArea.SetText("已备份: " + path)
+	}()
+}
+
+// showRestoreDialog 选择备份包，显示清单确认后恢复
+func (u *ToolsUI) showRestoreDialog() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+
+		manifest, err := service.ReadBackupManifest(path)
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		var counts []string
+		for _, ds := range service.ExportDatasets {
+			if n, ok := manifest.Tables[ds.Key]; ok {
+				counts = append(counts, fmt.Sprintf("%s %d条", ds.Name, n))
+			}
+		}
+		msg := fmt.Sprintf("备份时间: %s\n数据结构版本: %d\n%s\n\n恢复将覆盖当前全部数据，恢复前会自动备份当前数据。确定恢复吗？",
+			manifest.CreatedAt.Format("2006-01-02 15:04:05"), manifest.SchemaVersion, strings.Join(counts, "，"))
+		dialog.ShowConfirm("恢复备份", msg, func(ok bool) {
+			if !ok {
+				return
+			}
+			safety, err := service.GetBackupService().Restore(path)
+			if err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			u.resultArea.SetText("已恢复备份，恢复前的数据已备份到: " + safety + "\n切换页面即可看到恢复后的数据")
+		}, u.window)
+	}, u.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+	if dir, err := storage.ListerForURI(storage.NewFileURI(service.GetBackupService().BackupDir())); err == nil {
+		open.SetLocation(dir)
+	}
+	open.Show()
+}
+
+// showBackupSettingsDialog 自动备份设置
+func (u *ToolsUI) showBackupSettingsDialog() {
+	backup := service.GetBackupService()
+	settings, err := backup.GetSettings()
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+
+	autoCheck := widget.NewCheck("定时自动备份", nil)
+	autoCheck.SetChecked(settings.AutoEnabled)
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetText(strconv.Itoa(settings.IntervalHours))
+	keepEntry := widget.NewEntry()
+	keepEntry.SetText(strconv.Itoa(settings.Keep))
+	dirEntry := widget.NewEntry()
+	dirEntry.SetPlaceHolder("为空时保存到数据目录 backups")
+	dirEntry.SetText(settings.SaveDir)
+
+	last := "从未"
+	if !settings.LastBackupAt.IsZero() {
+		last = settings.LastBackupAt.Format("2006-01-02 15:04")
+	}
+	files, _ := backup.ListBackups()
+	note := widget.NewLabel(fmt.Sprintf("上次自动备份: %s，备份目录中共%d个备份。保留份数只清理自动备份，0为不清理", last, len(files)))
+	note.Wrapping = fyne.TextWrapWord
+	note.Importance = widget.LowImportance
+
+	form := dialog.NewForm("备份设置", "保存", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("", autoCheck),
+			widget.NewFormItem("间隔(小时)", intervalEntry),
+			widget.NewFormItem("保留份数", keepEntry),
+			widget.NewFormItem("备份目录", dirEntry),
+			widget.NewFormItem("", note),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			interval, err := strconv.Atoi(strings.TrimSpace(intervalEntry.Text))
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("备份间隔格式错误: %s", intervalEntry.Text), u.window)
+				return
+			}
+			keep, err := strconv.Atoi(strings.TrimSpace(keepEntry.Text))
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("保留份数格式错误: %s", keepEntry.Text), u.window)
+				return
+			}
+			settings.AutoEnabled = autoCheck.Checked
+			settings.IntervalHours = interval
+			settings.Keep = keep
+			settings.SaveDir = strings.TrimSpace(dirEntry.Text)
+			if err := backup.SaveSettings(settings); err != nil {
+				dialog.ShowError(err, u.window)
+			}
+		}, u.window)
+	form.Resize(fyne.NewSize(480, 380))
+	form.Show()
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"jijin/internal/service"
+	"jijin/internal/ui/chart"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -33,6 +34,7 @@ type CalculatorUI struct {
 	annualLabel    *widget.Label
 	countLabel     *widget.Label
 	avgCostLabel   *widget.Label
+	curveChart     *chart.TimeChart
 }
 
 // NewCalculatorUI 创建定投计算器UI
@@ -102,7 +104,10 @@ func (c *CalculatorUI) build() {
 		widget.NewLabel("平均成本:"), c.avgCostLabel,
 	)
 
-	c.resultCard = widget.NewCard("计算结果", "", resultContent)
+	c.curveChart = chart.NewTimeChart(500, 180)
+	c.curveChart.SetValueFormat("%.0f")
+
+	c.resultCard = widget.NewCard("计算结果", "", container.NewVBox(resultContent, c.curveChart))
 
 	// 说明
 	helpText := `定投计算器说明：
@@ -189,6 +194,19 @@ func (c *CalculatorUI) calculate() {
 		c.annualLabel.SetText(fmt.Sprintf("%.2f%%", result.AnnualReturn))
 		c.countLabel.SetText(fmt.Sprintf("%d次", result.InvestCount))
 		c.avgCostLabel.SetText(fmt.Sprintf("¥%.4f", result.AvgCost))
+
+		dates := make([]time.Time, len(result.Points))
+		invest := make([]float64, len(result.Points))
+		values := make([]float64, len(result.Points))
+		for i, pt := range result.Points {
+			dates[i] = pt.Date
+			invest[i] = pt.Invest
+			values[i] = pt.Value
+		}
+		c.curveChart.SetSeries(dates,
+			chart.Series{Name: "市值", Values: values, Color: chart.ColorBlue, Kind: chart.KindArea, Width: 2},
+			chart.Series{Name: "累计投入", Values: invest, Color: chart.ColorOrange, Width: 1},
+		)
 	}()
 }
 
@@ -0,0 +1,115 @@
+// Package chart 提供可复用的Fyne图表控件：时间序列(折线/面积/堆叠面积/柱状/K线)、饼图/环形图、瀑布图和月度热力图
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Kind 序列绘制方式
+type Kind int
+
+const (
+	KindLine Kind = iota // 折线
+	KindArea             // 面积，以零轴(或最近的纵轴边界)为基准
+	KindBar              // 柱状，以零轴为基准
+)
+
+// Series 时间图中的一条序列，Values与图表日期等长，NaN值不绘制
+type Series struct {
+	Name   string
+	Values []float64
+	Color  color.Color // 柱状序列为nil时正值红、负值绿
+	Kind   Kind
+	Width  float32
+
+	base []float64 // 堆叠面积的底部累计值
+}
+
+// 常用颜色
+var (
+	ColorBlue   = color.RGBA{R: 64, G: 128, B: 255, A: 255}
+	ColorOrange = color.RGBA{R: 245, G: 158, B: 11, A: 230}
+	ColorPurple = color.RGBA{R: 139, G: 92, B: 246, A: 230}
+	ColorGray   = color.RGBA{R: 148, G: 163, B: 184, A: 230}
+	ColorUp     = color.RGBA{R: 220, G: 38, B: 38, A: 220}
+	ColorDown   = color.RGBA{R: 22, G: 163, B: 74, A: 220}
+)
+
+// Palette 多序列或饼图的默认配色
+var Palette = []color.Color{
+	color.RGBA{100, 149, 237, 255}, // 蓝色
+	color.RGBA{60, 179, 113, 255},  // 绿色
+	color.RGBA{255, 165, 0, 255},   // 橙色
+	color.RGBA{238, 130, 238, 255}, // 紫色
+	color.RGBA{255, 99, 71, 255},   // 红色
+	color.RGBA{64, 224, 208, 255},  // 青色
+}
+
+// PaletteColor 按序号取配色，超出Palette后按黄金角旋转色相生成，保证相邻颜色可区分
+func PaletteColor(i int) color.Color {
+	if i < len(Palette) {
+		return Palette[i]
+	}
+	hue := math.Mod(float64(i-len(Palette))*137.508+15, 360)
+	return hsvColor(hue, 0.55, 0.9)
+}
+
+// hsvColor HSV转RGB，h取[0,360)，s、v取[0,1]
+func hsvColor(h, s, v float64) color.Color {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	return color.RGBA{R: uint8((r + m) * 255), G: uint8((g + m) * 255), B: uint8((b + m) * 255), A: 255}
+}
+
+// withAlpha 调整颜色透明度
+func withAlpha(c color.Color, a uint8) color.Color {
+	r, g, b, _ := c.RGBA()
+	return color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: a}
+}
+
+// niceTicks 在[min,max]内生成约count个整齐的刻度
+func niceTicks(min, max float64, count int) []float64 {
+	if max <= min || count < 2 {
+		return []float64{min}
+	}
+	raw := (max - min) / float64(count-1)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := mag
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if m*mag >= raw {
+			step = m * mag
+			break
+		}
+	}
+	var ticks []float64
+	for v := math.Ceil(min/step) * step; v <= max+step*1e-9; v += step {
+		ticks = append(ticks, v)
+	}
+	return ticks
+}
+
+// formatValue 按格式输出数值，NaN输出"-"
+func formatValue(format string, v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf(format, v)
+}
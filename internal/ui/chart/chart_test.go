@@ -0,0 +1,58 @@
+package chart
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWeeklyOHLC(t *testing.T) {
+	// 2024-03-04为周一
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	dates := []time.Time{day(4), day(5), day(6), day(8), day(11), day(12)}
+	values := []float64{1.0, 1.2, 0.9, 1.1, 1.05, 1.3}
+
+	candles := WeeklyOHLC(dates, values)
+	want := []OHLC{
+		{Date: day(8), Open: 1.0, High: 1.2, Low: 0.9, Close: 1.1},
+		// 开盘为上周收盘
+		{Date: day(12), Open: 1.1, High: 1.3, Low: 1.05, Close: 1.3},
+	}
+	if len(candles) != len(want) {
+		t.Fatalf("want %d candles, got %+v", len(want), candles)
+	}
+	for i := range want {
+		if candles[i] != want[i] {
+			t.Errorf("candle %d: want %+v, got %+v", i, want[i], candles[i])
+		}
+	}
+}
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		min, max float64
+		count    int
+		want     []float64
+	}{
+		{min: 0, max: 10, count: 5, want: []float64{0, 2.5, 5, 7.5, 10}},
+		{min: 0.93, max: 1.27, count: 4, want: []float64{1, 1.2}},
+		{min: -3, max: 7, count: 3, want: []float64{0, 5}},
+		{min: 1, max: 1, count: 5, want: []float64{1}},
+	}
+	for _, tt := range tests {
+		got := niceTicks(tt.min, tt.max, tt.count)
+		if len(got) != len(tt.want) {
+			t.Errorf("niceTicks(%v, %v, %d) = %v", tt.min, tt.max, tt.count, got)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+				t.Errorf("niceTicks(%v, %v, %d) = %v", tt.min, tt.max, tt.count, got)
+				break
+			}
+		}
+	}
+	if formatValue("%.2f", math.NaN()) != "-" || formatValue("%.2f", 1.234) != "1.23" {
+		t.Error("formatValue: NaN输出-")
+	}
+}
@@ -0,0 +1,182 @@
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// MonthCell 热力图中某年某月的数值
+type MonthCell struct {
+	Year  int
+	Month time.Month
+	Value float64
+}
+
+// MonthHeatmap 月度热力图：每年一行、每月一格，末列为全年合计，正值红、负值绿，颜色深浅表示幅度
+type MonthHeatmap struct {
+	widget.BaseWidget
+
+	cells   map[int]map[time.Month]float64
+	totals  map[int]float64
+	years   []int
+	format  string
+	minSize fyne.Size
+}
+
+// 热力图布局
+const (
+	heatYearWidth   float32 = 44
+	heatHeaderRows  float32 = 18
+	heatCellHeight  float32 = 24
+	heatColumnCount         = 13
+)
+
+// NewMonthHeatmap 创建月度热力图
+func NewMonthHeatmap(minWidth float32) *MonthHeatmap {
+	h := &MonthHeatmap{format: "%.1f", minSize: fyne.NewSize(minWidth, heatHeaderRows+heatCellHeight)}
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+// SetValueFormat 设置格内数值格式
+func (h *MonthHeatmap) SetValueFormat(format string) {
+	h.format = format
+	h.Refresh()
+}
+
+// SetData 设置各月数值和各年合计，年份按降序排列
+func (h *MonthHeatmap) SetData(cells []MonthCell, totals map[int]float64) {
+	h.cells = make(map[int]map[time.Month]float64)
+	h.years = nil
+	for _, c := range cells {
+		if h.cells[c.Year] == nil {
+			h.cells[c.Year] = make(map[time.Month]float64)
+			h.years = append(h.years, c.Year)
+		}
+		h.cells[c.Year][c.Month] = c.Value
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(h.years)))
+	h.totals = totals
+	h.Refresh()
+}
+
+// CreateRenderer 实现fyne.Widget
+func (h *MonthHeatmap) CreateRenderer() fyne.WidgetRenderer {
+	r := &heatmapRenderer{heatmap: h}
+	r.Refresh()
+	return r
+}
+
+// heatColor 按幅度在背景色和涨跌色之间插值
+func heatColor(v, maxAbs float64) color.Color {
+	base := ColorUp
+	if v < 0 {
+		base = ColorDown
+	}
+	t := 0.15
+	if maxAbs > 0 {
+		t += 0.85 * math.Min(math.Abs(v)/maxAbs, 1)
+	}
+	return withAlpha(base, uint8(t*255))
+}
+
+type heatmapRenderer struct {
+	heatmap *MonthHeatmap
+	size    fyne.Size
+	objects []fyne.CanvasObject
+}
+
+func (r *heatmapRenderer) Destroy() {}
+
+func (r *heatmapRenderer) MinSize() fyne.Size {
+	rows := float32(maxInt(1, len(r.heatmap.years)))
+	return fyne.NewSize(r.heatmap.minSize.Width, heatHeaderRows+heatCellHeight*rows)
+}
+
+func (r *heatmapRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *heatmapRenderer) Layout(size fyne.Size) {
+	if size != r.size {
+		r.size = size
+		r.Refresh()
+	}
+}
+
+func (r *heatmapRenderer) Refresh() {
+	h := r.heatmap
+	r.objects = nil
+	if len(h.years) == 0 {
+		empty := canvas.NewText("暂无数据", theme.DisabledColor())
+		empty.Alignment = fyne.TextAlignCenter
+		empty.Resize(r.size)
+		r.objects = append(r.objects, empty)
+		canvas.Refresh(h)
+		return
+	}
+
+	// 月份和全年分别按各自的最大幅度着色
+	maxMonth, maxYear := 0.0, 0.0
+	for _, months := range h.cells {
+		for _, v := range months {
+			maxMonth = math.Max(maxMonth, math.Abs(v))
+		}
+	}
+	for _, v := range h.totals {
+		maxYear = math.Max(maxYear, math.Abs(v))
+	}
+
+	cellW := (r.size.Width - heatYearWidth) / heatColumnCount
+	for col := 0; col < heatColumnCount; col++ {
+		name := "全年"
+		if col < 12 {
+			name = fmt.Sprintf("%d月", col+1)
+		}
+		r.objects = append(r.objects, r.text(name, heatYearWidth+cellW*float32(col), 0, cellW, heatHeaderRows))
+	}
+	for row, year := range h.years {
+		y := heatHeaderRows + heatCellHeight*float32(row)
+		r.objects = append(r.objects, r.text(fmt.Sprint(year), 0, y, heatYearWidth, heatCellHeight))
+		for col := 0; col < heatColumnCount; col++ {
+			v, ok := h.cells[year][time.Month(col+1)]
+			maxAbs := maxMonth
+			if col == 12 {
+				v, ok = h.totals[year]
+				maxAbs = maxYear
+			}
+			x := heatYearWidth + cellW*float32(col)
+			cell := canvas.NewRectangle(chartBgColor)
+			if ok {
+				cell.FillColor = heatColor(v, maxAbs)
+			}
+			cell.StrokeColor = theme.BackgroundColor()
+			cell.StrokeWidth = 1
+			cell.Move(fyne.NewPos(x, y))
+			cell.Resize(fyne.NewSize(cellW, heatCellHeight))
+			r.objects = append(r.objects, cell)
+			if ok {
+				r.objects = append(r.objects, r.text(formatValue(h.format, v), x, y, cellW, heatCellHeight))
+			}
+		}
+	}
+	canvas.Refresh(h)
+}
+
+// text 在给定区域内居中显示文字
+func (r *heatmapRenderer) text(s string, x, y, w, h float32) *canvas.Text {
+	t := canvas.NewText(s, theme.ForegroundColor())
+	t.TextSize = labelSize
+	t.Alignment = fyne.TextAlignCenter
+	t.Move(fyne.NewPos(x, y+(h-labelSize-4)/2))
+	t.Resize(fyne.NewSize(w, labelSize+4))
+	return t
+}
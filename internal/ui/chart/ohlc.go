@@ -0,0 +1,47 @@
+package chart
+
+import "time"
+
+// OHLC K线数据
+type OHLC struct {
+	Date  time.Time // 周期最后一个交易日
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// WeeklyOHLC 将按日期升序的日净值聚合为周K线：开盘为上周收盘(首周为当周首日)，高低为周内极值
+func WeeklyOHLC(dates []time.Time, values []float64) []OHLC {
+	var candles []OHLC
+	lastYear, lastWeek := -1, -1
+	prevClose := 0.0
+	for i, d := range dates {
+		v := values[i]
+		year, week := d.ISOWeek()
+		if year != lastYear || week != lastWeek {
+			open := v
+			if len(candles) > 0 {
+				open = prevClose
+			}
+			candles = append(candles, OHLC{Date: d, Open: open, High: v, Low: v, Close: v})
+			if open > v {
+				candles[len(candles)-1].High = open
+			} else {
+				candles[len(candles)-1].Low = open
+			}
+			lastYear, lastWeek = year, week
+		}
+		c := &candles[len(candles)-1]
+		c.Date = d
+		c.Close = v
+		if v > c.High {
+			c.High = v
+		}
+		if v < c.Low {
+			c.Low = v
+		}
+		prevClose = v
+	}
+	return candles
+}
@@ -0,0 +1,205 @@
+package chart
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// PieSlice 饼图扇区，Color为nil时使用默认配色
+type PieSlice struct {
+	Label string
+	Value float64
+	Color color.Color
+}
+
+// PieChart 饼图/环形图，悬停扇区时突出显示并提示名称、数值和占比
+type PieChart struct {
+	widget.BaseWidget
+
+	donut   bool
+	format  string
+	slices  []PieSlice
+	ends    []float64 // 各扇区结束位置的累计占比
+	total   float64
+	hover   int
+	minSize fyne.Size
+}
+
+// NewPieChart 创建饼图，donut为true时绘制为环形图
+func NewPieChart(minSize float32, donut bool) *PieChart {
+	p := &PieChart{donut: donut, format: "%.2f", hover: -1, minSize: fyne.NewSize(minSize, minSize)}
+	p.ExtendBaseWidget(p)
+	return p
+}
+
+// SetValueFormat 设置提示的数值格式
+func (p *PieChart) SetValueFormat(format string) {
+	p.format = format
+	p.Refresh()
+}
+
+// SetSlices 设置扇区，忽略非正值
+func (p *PieChart) SetSlices(slices []PieSlice) {
+	p.slices = nil
+	p.ends = nil
+	p.total = 0
+	for i, s := range slices {
+		if s.Value <= 0 {
+			continue
+		}
+		if s.Color == nil {
+			s.Color = PaletteColor(i)
+		}
+		p.slices = append(p.slices, s)
+		p.total += s.Value
+	}
+	cum := 0.0
+	for _, s := range p.slices {
+		cum += s.Value / p.total
+		p.ends = append(p.ends, cum)
+	}
+	if len(p.ends) > 0 {
+		p.ends[len(p.ends)-1] = 1
+	}
+	p.hover = -1
+	p.Refresh()
+}
+
+// CreateRenderer 实现fyne.Widget
+func (p *PieChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &pieRenderer{pie: p}
+	r.raster = canvas.NewRasterWithPixels(r.pixel)
+	r.title = canvas.NewText("", theme.ForegroundColor())
+	r.title.Alignment = fyne.TextAlignCenter
+	r.title.TextStyle = fyne.TextStyle{Bold: true}
+	r.detail = canvas.NewText("", theme.ForegroundColor())
+	r.detail.Alignment = fyne.TextAlignCenter
+	r.detail.TextSize = labelSize + 2
+	r.Refresh()
+	return r
+}
+
+// MouseIn 实现desktop.Hoverable
+func (p *PieChart) MouseIn(ev *desktop.MouseEvent) {
+	p.MouseMoved(ev)
+}
+
+// MouseMoved 更新悬停扇区
+func (p *PieChart) MouseMoved(ev *desktop.MouseEvent) {
+	size := p.Size()
+	idx := p.sliceAt(float64(ev.Position.X), float64(ev.Position.Y), float64(size.Width), float64(size.Height))
+	if idx != p.hover {
+		p.hover = idx
+		p.Refresh()
+	}
+}
+
+// MouseOut 取消悬停
+func (p *PieChart) MouseOut() {
+	if p.hover != -1 {
+		p.hover = -1
+		p.Refresh()
+	}
+}
+
+// sliceAt 坐标所在扇区，不在饼图内返回-1
+func (p *PieChart) sliceAt(x, y, w, h float64) int {
+	radius := math.Min(w, h)/2 - 2
+	dx, dy := x-w/2, y-h/2
+	dist := math.Hypot(dx, dy)
+	if dist > radius || (p.donut && dist < radius*0.55) {
+		return -1
+	}
+	// 从12点方向顺时针计算角度
+	angle := math.Atan2(dx, -dy)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	frac := angle / (2 * math.Pi)
+	for i, end := range p.ends {
+		if frac <= end {
+			return i
+		}
+	}
+	return -1
+}
+
+type pieRenderer struct {
+	pie    *PieChart
+	raster *canvas.Raster
+	title  *canvas.Text
+	detail *canvas.Text
+}
+
+// pixel 逐像素着色，悬停扇区完整半径，其余略缩小
+func (r *pieRenderer) pixel(x, y, w, h int) color.Color {
+	p := r.pie
+	idx := p.sliceAt(float64(x), float64(y), float64(w), float64(h))
+	if idx < 0 {
+		return color.Transparent
+	}
+	if p.hover >= 0 && idx != p.hover {
+		radius := math.Min(float64(w), float64(h))/2 - 2
+		if math.Hypot(float64(x)-float64(w)/2, float64(y)-float64(h)/2) > radius*0.94 {
+			return color.Transparent
+		}
+	}
+	return p.slices[idx].Color
+}
+
+func (r *pieRenderer) Destroy() {}
+
+func (r *pieRenderer) MinSize() fyne.Size {
+	return r.pie.minSize
+}
+
+func (r *pieRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.raster, r.title, r.detail}
+}
+
+func (r *pieRenderer) Layout(size fyne.Size) {
+	r.raster.Resize(size)
+	lineHeight := r.title.MinSize().Height
+	if r.pie.donut {
+		// 环形图在中心显示
+		r.title.Move(fyne.NewPos(0, size.Height/2-lineHeight))
+		r.detail.Move(fyne.NewPos(0, size.Height/2))
+	} else {
+		r.title.Move(fyne.NewPos(0, 0))
+		r.detail.Move(fyne.NewPos(0, lineHeight))
+	}
+	r.title.Resize(fyne.NewSize(size.Width, lineHeight))
+	r.detail.Resize(fyne.NewSize(size.Width, lineHeight))
+}
+
+func (r *pieRenderer) Refresh() {
+	p := r.pie
+	switch {
+	case p.hover >= 0 && p.hover < len(p.slices):
+		s := p.slices[p.hover]
+		r.title.Text = s.Label
+		r.detail.Text = fmt.Sprintf(p.format+"  %.1f%%", s.Value, s.Value/p.total*100)
+	case p.donut && p.total > 0:
+		r.title.Text = "合计"
+		r.detail.Text = fmt.Sprintf(p.format, p.total)
+	case p.total == 0:
+		r.title.Text = "暂无数据"
+		r.detail.Text = ""
+	default:
+		r.title.Text = ""
+		r.detail.Text = ""
+	}
+	r.title.Color = theme.ForegroundColor()
+	r.detail.Color = theme.ForegroundColor()
+	r.Layout(p.Size())
+	r.raster.Refresh()
+	r.title.Refresh()
+	r.detail.Refresh()
+}
@@ -0,0 +1,71 @@
+package chart
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Range 时间区间
+type Range int
+
+const (
+	Range1M Range = iota
+	Range3M
+	Range6M
+	Range1Y
+	Range3Y
+	RangeAll
+)
+
+// RangeNames 区间显示名称，顺序与Range常量一致
+var RangeNames = []string{"1月", "3月", "6月", "1年", "3年", "全部"}
+
+// Since 区间相对last的起始日期，RangeAll返回零值
+func (r Range) Since(last time.Time) time.Time {
+	switch r {
+	case Range1M:
+		return last.AddDate(0, -1, 0)
+	case Range3M:
+		return last.AddDate(0, -3, 0)
+	case Range6M:
+		return last.AddDate(0, -6, 0)
+	case Range1Y:
+		return last.AddDate(-1, 0, 0)
+	case Range3Y:
+		return last.AddDate(-3, 0, 0)
+	}
+	return time.Time{}
+}
+
+// NewRangeBar 创建区间选择条，选择后同步设置所有图表的显示区间
+func NewRangeBar(initial Range, charts ...*TimeChart) fyne.CanvasObject {
+	radio := widget.NewRadioGroup(RangeNames, func(selected string) {
+		for i, name := range RangeNames {
+			if name == selected {
+				for _, c := range charts {
+					c.SetRange(Range(i))
+				}
+			}
+		}
+	})
+	radio.Horizontal = true
+	radio.Required = true
+	radio.SetSelected(RangeNames[initial])
+	return radio
+}
+
+// LinkViews 联动多个共用日期的图表：任一图表缩放或平移时同步其他图表
+func LinkViews(charts ...*TimeChart) {
+	for _, c := range charts {
+		source := c
+		source.OnViewChanged = func(start, end int) {
+			for _, other := range charts {
+				if other != source {
+					other.setView(start, end, false)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,621 @@
+package chart
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 绘图区边距
+const (
+	padLeft    float32 = 60
+	padRight   float32 = 10
+	padTop     float32 = 8
+	padBottom  float32 = 20
+	labelSize  float32 = 10
+	minVisible         = 10 // 缩放时最少显示的数据点
+)
+
+var (
+	chartBgColor   = color.RGBA{R: 248, G: 250, B: 252, A: 255}
+	gridColor      = color.RGBA{R: 226, G: 232, B: 240, A: 255}
+	refColor       = color.RGBA{R: 180, G: 180, B: 180, A: 255}
+	crosshairColor = color.RGBA{R: 100, G: 116, B: 139, A: 200}
+)
+
+// TimeChart 时间序列图表，支持多序列、周K线、日期轴、悬停十字线提示、滚轮缩放和拖动平移
+type TimeChart struct {
+	widget.BaseWidget
+
+	// OnViewChanged 用户缩放或平移后回调，参数为可见数据下标区间[start,end)
+	OnViewChanged func(start, end int)
+
+	dates   []time.Time
+	series  []Series
+	candles []OHLC
+	refs    []float64
+	format  string
+	rng     Range
+	start   int
+	end     int
+	hover   int
+	stacked bool
+	dragAcc float32
+	minSize fyne.Size
+	dirty   bool
+}
+
+// NewTimeChart 创建时间序列图表，随容器缩放，不小于给定的最小尺寸
+func NewTimeChart(minWidth, minHeight float32) *TimeChart {
+	c := &TimeChart{
+		format:  "%.4f",
+		rng:     RangeAll,
+		hover:   -1,
+		minSize: fyne.NewSize(minWidth, minHeight),
+	}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// SetValueFormat 设置纵轴和提示的数值格式
+func (c *TimeChart) SetValueFormat(format string) {
+	c.format = format
+	c.invalidate()
+}
+
+// SetRefLines 设置水平参考线
+func (c *TimeChart) SetRefLines(refs ...float64) {
+	c.refs = refs
+	c.invalidate()
+}
+
+// SetStacked 设置面积序列是否按顺序堆叠，提示中仍显示各序列原值
+func (c *TimeChart) SetStacked(stacked bool) {
+	c.stacked = stacked
+	c.invalidate()
+}
+
+// SetSeries 设置日期(升序)和序列，可见区间按当前区间选择重置
+func (c *TimeChart) SetSeries(dates []time.Time, series ...Series) {
+	c.dates = dates
+	c.series = series
+	c.candles = nil
+	c.hover = -1
+	c.applyRange()
+	c.invalidate()
+}
+
+// SetCandles 设置K线，可附加与K线等长的序列(如均线)
+func (c *TimeChart) SetCandles(candles []OHLC, series ...Series) {
+	dates := make([]time.Time, len(candles))
+	for i, k := range candles {
+		dates[i] = k.Date
+	}
+	c.dates = dates
+	c.series = series
+	c.candles = candles
+	c.hover = -1
+	c.applyRange()
+	c.invalidate()
+}
+
+// SetRange 设置显示区间
+func (c *TimeChart) SetRange(r Range) {
+	c.rng = r
+	c.applyRange()
+	c.invalidate()
+}
+
+// View 当前可见数据下标区间[start,end)
+func (c *TimeChart) View() (int, int) {
+	return c.start, c.end
+}
+
+// SetView 设置可见数据下标区间[start,end)并同步联动的图表
+func (c *TimeChart) SetView(start, end int) {
+	c.setView(start, end, true)
+}
+
+// applyRange 按区间计算可见下标
+func (c *TimeChart) applyRange() {
+	n := len(c.dates)
+	c.end = n
+	c.start = 0
+	if n == 0 {
+		return
+	}
+	if since := c.rng.Since(c.dates[n-1]); !since.IsZero() {
+		c.start = sort.Search(n, func(i int) bool { return !c.dates[i].Before(since) })
+	}
+	if c.end-c.start < 2 {
+		c.start = maxInt(0, c.end-2)
+	}
+}
+
+// setView 设置可见区间，notify为true时触发OnViewChanged
+func (c *TimeChart) setView(start, end int, notify bool) {
+	n := len(c.dates)
+	if end-start > n {
+		start, end = 0, n
+	}
+	if start < 0 {
+		end -= start
+		start = 0
+	}
+	if end > n {
+		start -= end - n
+		end = n
+	}
+	if start == c.start && end == c.end {
+		return
+	}
+	c.start, c.end = start, end
+	c.invalidate()
+	if notify && c.OnViewChanged != nil {
+		c.OnViewChanged(start, end)
+	}
+}
+
+func (c *TimeChart) invalidate() {
+	c.dirty = true
+	c.Refresh()
+}
+
+// CreateRenderer 实现fyne.Widget
+func (c *TimeChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &timeChartRenderer{chart: c}
+	c.dirty = true
+	return r
+}
+
+// MouseIn 实现desktop.Hoverable
+func (c *TimeChart) MouseIn(ev *desktop.MouseEvent) {
+	c.MouseMoved(ev)
+}
+
+// MouseMoved 更新十字线位置
+func (c *TimeChart) MouseMoved(ev *desktop.MouseEvent) {
+	idx := c.indexAt(ev.Position.X, c.Size().Width)
+	if idx != c.hover {
+		c.hover = idx
+		c.Refresh()
+	}
+}
+
+// MouseOut 隐藏十字线
+func (c *TimeChart) MouseOut() {
+	if c.hover != -1 {
+		c.hover = -1
+		c.Refresh()
+	}
+}
+
+// Scrolled 滚轮缩放，以悬停点为中心
+func (c *TimeChart) Scrolled(ev *fyne.ScrollEvent) {
+	n := c.end - c.start
+	if n <= 0 {
+		return
+	}
+	size := n * 5 / 4
+	if ev.Scrolled.DY > 0 {
+		size = n * 4 / 5
+	}
+	if size == n {
+		size = n + 1
+		if ev.Scrolled.DY > 0 {
+			size = n - 1
+		}
+	}
+	size = maxInt(size, minInt(minVisible, len(c.dates)))
+	center := c.hover
+	if center < 0 {
+		center = (c.start + c.end) / 2
+	}
+	ratio := float64(center-c.start) / float64(n)
+	start := center - int(ratio*float64(size))
+	c.setView(start, start+size, true)
+}
+
+// Dragged 拖动平移
+func (c *TimeChart) Dragged(ev *fyne.DragEvent) {
+	n := c.end - c.start
+	if n < 2 {
+		return
+	}
+	step := (c.Size().Width - padLeft - padRight) / float32(n-1)
+	if step <= 0 {
+		return
+	}
+	c.dragAcc += ev.Dragged.DX
+	if shift := int(c.dragAcc / step); shift != 0 {
+		c.dragAcc -= float32(shift) * step
+		c.setView(c.start-shift, c.end-shift, true)
+	}
+}
+
+// DragEnd 结束拖动
+func (c *TimeChart) DragEnd() {
+	c.dragAcc = 0
+}
+
+// indexAt 横坐标对应的数据下标
+func (c *TimeChart) indexAt(x, width float32) int {
+	n := c.end - c.start
+	if n <= 0 {
+		return -1
+	}
+	if n == 1 {
+		return c.start
+	}
+	plotW := width - padLeft - padRight
+	idx := c.start + int(math.Round(float64((x-padLeft)/plotW*float32(n-1))))
+	return minInt(maxInt(idx, c.start), c.end-1)
+}
+
+// plotSeries 实际绘制的序列：堆叠时面积序列替换为累计值，并以前一层累计值为底
+func (c *TimeChart) plotSeries() []Series {
+	if !c.stacked {
+		return c.series
+	}
+	out := make([]Series, len(c.series))
+	base := make([]float64, len(c.dates))
+	for j, s := range c.series {
+		out[j] = s
+		if s.Kind != KindArea {
+			continue
+		}
+		top := make([]float64, len(base))
+		for i := range top {
+			top[i] = base[i]
+			if i < len(s.Values) && !math.IsNaN(s.Values[i]) {
+				top[i] += s.Values[i]
+			}
+		}
+		out[j].Values, out[j].base = top, base
+		base = top
+	}
+	return out
+}
+
+// valueRange 可见区间内的纵轴范围
+func (c *TimeChart) valueRange() (float64, float64, bool) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range c.plotSeries() {
+		for i := c.start; i < c.end && i < len(s.Values); i++ {
+			v := s.Values[i]
+			if math.IsNaN(v) {
+				continue
+			}
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+			if s.base != nil {
+				lo = math.Min(lo, s.base[i])
+			}
+		}
+		if s.Kind == KindBar {
+			lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+		}
+	}
+	for i := c.start; i < c.end && i < len(c.candles); i++ {
+		lo, hi = math.Min(lo, c.candles[i].Low), math.Max(hi, c.candles[i].High)
+	}
+	if math.IsInf(lo, 1) {
+		return 0, 0, false
+	}
+	for _, r := range c.refs {
+		lo, hi = math.Min(lo, r), math.Max(hi, r)
+	}
+	span := hi - lo
+	if span == 0 {
+		span = math.Max(math.Abs(hi)*0.01, 0.01)
+	}
+	return lo - span*0.05, hi + span*0.05, true
+}
+
+type timeChartRenderer struct {
+	chart   *TimeChart
+	size    fyne.Size
+	plot    []fyne.CanvasObject
+	overlay []fyne.CanvasObject
+	objects []fyne.CanvasObject
+	lo, hi  float64
+	valid   bool
+}
+
+func (r *timeChartRenderer) Destroy() {}
+
+func (r *timeChartRenderer) MinSize() fyne.Size {
+	return r.chart.minSize
+}
+
+func (r *timeChartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *timeChartRenderer) Layout(size fyne.Size) {
+	if size != r.size {
+		r.size = size
+		r.chart.dirty = true
+		r.Refresh()
+	}
+}
+
+func (r *timeChartRenderer) Refresh() {
+	if r.chart.dirty {
+		r.buildPlot()
+		r.chart.dirty = false
+	}
+	r.buildOverlay()
+	r.objects = append(append([]fyne.CanvasObject{}, r.plot...), r.overlay...)
+	canvas.Refresh(r.chart)
+}
+
+func (r *timeChartRenderer) plotWidth() float32 {
+	return r.size.Width - padLeft - padRight
+}
+
+func (r *timeChartRenderer) plotHeight() float32 {
+	return r.size.Height - padTop - padBottom
+}
+
+func (r *timeChartRenderer) xAt(i int) float32 {
+	c := r.chart
+	n := c.end - c.start
+	if n <= 1 {
+		return padLeft + r.plotWidth()/2
+	}
+	return padLeft + float32(i-c.start)/float32(n-1)*r.plotWidth()
+}
+
+func (r *timeChartRenderer) yAt(v float64) float32 {
+	return padTop + r.plotHeight() - float32((v-r.lo)/(r.hi-r.lo))*r.plotHeight()
+}
+
+// buildPlot 绘制背景、坐标轴、参考线和全部序列
+func (r *timeChartRenderer) buildPlot() {
+	c := r.chart
+	bg := canvas.NewRectangle(chartBgColor)
+	bg.Resize(r.size)
+	r.plot = []fyne.CanvasObject{bg}
+
+	r.lo, r.hi, r.valid = c.valueRange()
+	if !r.valid || r.plotWidth() <= 0 || r.plotHeight() <= 0 {
+		empty := canvas.NewText("暂无数据", theme.DisabledColor())
+		empty.Alignment = fyne.TextAlignCenter
+		empty.Resize(r.size)
+		r.plot = append(r.plot, empty)
+		return
+	}
+
+	// 纵轴刻度和网格
+	for _, v := range niceTicks(r.lo, r.hi, 5) {
+		y := r.yAt(v)
+		r.plot = append(r.plot, hLine(gridColor, padLeft, padLeft+r.plotWidth(), y))
+		label := canvas.NewText(formatValue(c.format, v), theme.ForegroundColor())
+		label.TextSize = labelSize
+		label.Alignment = fyne.TextAlignTrailing
+		label.Move(fyne.NewPos(0, y-labelSize/2-2))
+		label.Resize(fyne.NewSize(padLeft-4, labelSize+4))
+		r.plot = append(r.plot, label)
+	}
+
+	// 日期轴
+	n := c.end - c.start
+	layout := "01-02"
+	if n > 0 && c.dates[c.end-1].Sub(c.dates[c.start]) > 300*24*time.Hour {
+		layout = "2006-01"
+	}
+	ticks := minInt(5, n)
+	for t := 0; t < ticks; t++ {
+		i := c.start
+		if ticks > 1 {
+			i = c.start + t*(n-1)/(ticks-1)
+		}
+		label := canvas.NewText(c.dates[i].Format(layout), theme.ForegroundColor())
+		label.TextSize = labelSize
+		label.Alignment = fyne.TextAlignCenter
+		label.Move(fyne.NewPos(r.xAt(i)-30, r.size.Height-padBottom+2))
+		label.Resize(fyne.NewSize(60, labelSize+4))
+		r.plot = append(r.plot, label)
+	}
+
+	for _, ref := range c.refs {
+		r.plot = append(r.plot, hLine(refColor, padLeft, padLeft+r.plotWidth(), r.yAt(ref)))
+	}
+
+	r.drawCandles()
+	for _, s := range c.plotSeries() {
+		r.drawSeries(s)
+	}
+}
+
+// slotWidth 每个数据点占用的宽度
+func (r *timeChartRenderer) slotWidth() float32 {
+	n := r.chart.end - r.chart.start
+	if n <= 1 {
+		return r.plotWidth()
+	}
+	return r.plotWidth() / float32(n-1)
+}
+
+func (r *timeChartRenderer) drawSeries(s Series) {
+	c := r.chart
+	width := s.Width
+	if width <= 0 {
+		width = 1
+	}
+	// 面积以零轴为基准，零轴不在纵轴范围内时取最近的边界
+	base := r.yAt(math.Min(math.Max(0, r.lo), r.hi))
+	slot := r.slotWidth()
+	for i := c.start; i < c.end && i < len(s.Values); i++ {
+		v := s.Values[i]
+		if math.IsNaN(v) {
+			continue
+		}
+		x, y := r.xAt(i), r.yAt(v)
+		switch s.Kind {
+		case KindBar:
+			barColor := s.Color
+			if barColor == nil {
+				barColor = ColorUp
+				if v < 0 {
+					barColor = ColorDown
+				}
+			}
+			w := float32(math.Max(1, float64(slot*0.6)))
+			y0 := r.yAt(0)
+			bar := canvas.NewRectangle(barColor)
+			bar.Move(fyne.NewPos(x-w/2, float32(math.Min(float64(y), float64(y0)))))
+			bar.Resize(fyne.NewSize(w, float32(math.Max(1, math.Abs(float64(y0-y))))))
+			r.plot = append(r.plot, bar)
+			continue
+		case KindArea:
+			bottom := base
+			alpha := uint8(50)
+			if s.base != nil {
+				bottom = r.yAt(s.base[i])
+				alpha = 120
+			}
+			fill := canvas.NewRectangle(withAlpha(s.Color, alpha))
+			w := float32(math.Max(1, float64(slot)))
+			fill.Move(fyne.NewPos(x-w/2, float32(math.Min(float64(y), float64(bottom)))))
+			fill.Resize(fyne.NewSize(w, float32(math.Abs(float64(bottom-y)))))
+			r.plot = append(r.plot, fill)
+		}
+		if i > c.start && i-1 < len(s.Values) && !math.IsNaN(s.Values[i-1]) {
+			line := canvas.NewLine(s.Color)
+			line.StrokeWidth = width
+			line.Position1 = fyne.NewPos(r.xAt(i-1), r.yAt(s.Values[i-1]))
+			line.Position2 = fyne.NewPos(x, y)
+			r.plot = append(r.plot, line)
+		}
+	}
+}
+
+// drawCandles 绘制K线，收盘高于开盘为红
+func (r *timeChartRenderer) drawCandles() {
+	c := r.chart
+	w := float32(math.Max(1, float64(r.slotWidth()*0.6)))
+	for i := c.start; i < c.end && i < len(c.candles); i++ {
+		k := c.candles[i]
+		col := ColorUp
+		if k.Close < k.Open {
+			col = ColorDown
+		}
+		x := r.xAt(i)
+		wick := canvas.NewLine(col)
+		wick.StrokeWidth = 1
+		wick.Position1 = fyne.NewPos(x, r.yAt(k.High))
+		wick.Position2 = fyne.NewPos(x, r.yAt(k.Low))
+		top := r.yAt(math.Max(k.Open, k.Close))
+		body := canvas.NewRectangle(col)
+		body.Move(fyne.NewPos(x-w/2, top))
+		body.Resize(fyne.NewSize(w, float32(math.Max(1, float64(r.yAt(math.Min(k.Open, k.Close))-top)))))
+		r.plot = append(r.plot, wick, body)
+	}
+}
+
+// buildOverlay 绘制悬停十字线和数值提示
+func (r *timeChartRenderer) buildOverlay() {
+	r.overlay = nil
+	c := r.chart
+	i := c.hover
+	if !r.valid || i < c.start || i >= c.end {
+		return
+	}
+	x := r.xAt(i)
+	r.overlay = append(r.overlay, vLine(crosshairColor, x, padTop, padTop+r.plotHeight()))
+
+	lines := []string{c.dates[i].Format("2006-01-02")}
+	anchor := math.NaN()
+	if i < len(c.candles) {
+		k := c.candles[i]
+		anchor = k.Close
+		lines = append(lines,
+			"开 "+formatValue(c.format, k.Open)+"  收 "+formatValue(c.format, k.Close),
+			"高 "+formatValue(c.format, k.High)+"  低 "+formatValue(c.format, k.Low))
+	}
+	for _, s := range c.series {
+		if i >= len(s.Values) {
+			continue
+		}
+		if math.IsNaN(anchor) && !math.IsNaN(s.Values[i]) {
+			anchor = s.Values[i]
+		}
+		if s.Name != "" {
+			lines = append(lines, s.Name+": "+formatValue(c.format, s.Values[i]))
+		}
+	}
+	if !math.IsNaN(anchor) {
+		r.overlay = append(r.overlay, hLine(crosshairColor, padLeft, padLeft+r.plotWidth(), r.yAt(anchor)))
+	}
+
+	r.overlay = append(r.overlay, tooltip(lines, x, r.size.Width)...)
+}
+
+// tooltip 在x右侧绘制多行提示框，超出宽度width时显示在左侧
+func tooltip(lines []string, x, width float32) []fyne.CanvasObject {
+	lineHeight := labelSize + 4
+	boxW := float32(0)
+	for _, l := range lines {
+		w := fyne.MeasureText(l, labelSize, fyne.TextStyle{}).Width
+		if w > boxW {
+			boxW = w
+		}
+	}
+	boxW += 12
+	boxH := lineHeight*float32(len(lines)) + 8
+	bx := x + 8
+	if bx+boxW > width {
+		bx = x - 8 - boxW
+	}
+	box := canvas.NewRectangle(withAlpha(theme.BackgroundColor(), 235))
+	box.StrokeColor = crosshairColor
+	box.StrokeWidth = 1
+	box.Move(fyne.NewPos(bx, padTop))
+	box.Resize(fyne.NewSize(boxW, boxH))
+	objects := []fyne.CanvasObject{box}
+	for j, l := range lines {
+		text := canvas.NewText(l, theme.ForegroundColor())
+		text.TextSize = labelSize
+		text.Move(fyne.NewPos(bx+6, padTop+4+lineHeight*float32(j)))
+		objects = append(objects, text)
+	}
+	return objects
+}
+
+func hLine(col color.Color, x1, x2, y float32) *canvas.Line {
+	l := canvas.NewLine(col)
+	l.StrokeWidth = 1
+	l.Position1 = fyne.NewPos(x1, y)
+	l.Position2 = fyne.NewPos(x2, y)
+	return l
+}
+
+func vLine(col color.Color, x, y1, y2 float32) *canvas.Line {
+	l := canvas.NewLine(col)
+	l.StrokeWidth = 1
+	l.Position1 = fyne.NewPos(x, y1)
+	l.Position2 = fyne.NewPos(x, y2)
+	return l
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
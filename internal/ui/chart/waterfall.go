@@ -0,0 +1,234 @@
+package chart
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// WaterfallItem 瀑布图中的一项增减
+type WaterfallItem struct {
+	Label string
+	Value float64
+}
+
+// WaterfallChart 瀑布图：各项从上一项累计值处浮动绘制，末尾追加合计柱，正值红、负值绿
+type WaterfallChart struct {
+	widget.BaseWidget
+
+	items      []WaterfallItem
+	totalLabel string
+	format     string
+	hover      int
+	minSize    fyne.Size
+}
+
+// NewWaterfallChart 创建瀑布图，totalLabel为合计柱名称
+func NewWaterfallChart(minWidth, minHeight float32, totalLabel string) *WaterfallChart {
+	w := &WaterfallChart{
+		totalLabel: totalLabel,
+		format:     "%.2f",
+		hover:      -1,
+		minSize:    fyne.NewSize(minWidth, minHeight),
+	}
+	w.ExtendBaseWidget(w)
+	return w
+}
+
+// SetValueFormat 设置纵轴和提示的数值格式
+func (w *WaterfallChart) SetValueFormat(format string) {
+	w.format = format
+	w.Refresh()
+}
+
+// SetItems 设置各项，按给定顺序累计
+func (w *WaterfallChart) SetItems(items []WaterfallItem) {
+	w.items = items
+	w.hover = -1
+	w.Refresh()
+}
+
+// bars 各柱的起止值，最后一根为合计
+func (w *WaterfallChart) bars() (from, to []float64) {
+	cum := 0.0
+	for _, it := range w.items {
+		from = append(from, cum)
+		cum += it.Value
+		to = append(to, cum)
+	}
+	if len(w.items) > 0 {
+		from = append(from, 0)
+		to = append(to, cum)
+	}
+	return from, to
+}
+
+// CreateRenderer 实现fyne.Widget
+func (w *WaterfallChart) CreateRenderer() fyne.WidgetRenderer {
+	r := &waterfallRenderer{chart: w}
+	r.Refresh()
+	return r
+}
+
+// MouseIn 实现desktop.Hoverable
+func (w *WaterfallChart) MouseIn(ev *desktop.MouseEvent) {
+	w.MouseMoved(ev)
+}
+
+// MouseMoved 更新悬停的柱
+func (w *WaterfallChart) MouseMoved(ev *desktop.MouseEvent) {
+	idx := -1
+	n := len(w.items) + 1
+	plotW := w.Size().Width - padLeft - padRight
+	if len(w.items) > 0 && plotW > 0 && ev.Position.X >= padLeft {
+		idx = int((ev.Position.X - padLeft) / (plotW / float32(n)))
+		if idx >= n {
+			idx = -1
+		}
+	}
+	if idx != w.hover {
+		w.hover = idx
+		w.Refresh()
+	}
+}
+
+// MouseOut 取消悬停
+func (w *WaterfallChart) MouseOut() {
+	if w.hover != -1 {
+		w.hover = -1
+		w.Refresh()
+	}
+}
+
+type waterfallRenderer struct {
+	chart   *WaterfallChart
+	size    fyne.Size
+	objects []fyne.CanvasObject
+}
+
+func (r *waterfallRenderer) Destroy() {}
+
+func (r *waterfallRenderer) MinSize() fyne.Size {
+	return r.chart.minSize
+}
+
+func (r *waterfallRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *waterfallRenderer) Layout(size fyne.Size) {
+	if size != r.size {
+		r.size = size
+		r.Refresh()
+	}
+}
+
+func (r *waterfallRenderer) Refresh() {
+	w := r.chart
+	bg := canvas.NewRectangle(chartBgColor)
+	bg.Resize(r.size)
+	r.objects = []fyne.CanvasObject{bg}
+
+	from, to := w.bars()
+	plotW := r.size.Width - padLeft - padRight
+	plotH := r.size.Height - padTop - padBottom
+	if len(from) == 0 || plotW <= 0 || plotH <= 0 {
+		empty := canvas.NewText("暂无数据", theme.DisabledColor())
+		empty.Alignment = fyne.TextAlignCenter
+		empty.Resize(r.size)
+		r.objects = append(r.objects, empty)
+		canvas.Refresh(w)
+		return
+	}
+
+	lo, hi := 0.0, 0.0
+	for i := range from {
+		lo = math.Min(lo, math.Min(from[i], to[i]))
+		hi = math.Max(hi, math.Max(from[i], to[i]))
+	}
+	span := hi - lo
+	if span == 0 {
+		span = 1
+	}
+	lo, hi = lo-span*0.05, hi+span*0.05
+	yAt := func(v float64) float32 {
+		return padTop + plotH - float32((v-lo)/(hi-lo))*plotH
+	}
+
+	for _, v := range niceTicks(lo, hi, 5) {
+		y := yAt(v)
+		r.objects = append(r.objects, hLine(gridColor, padLeft, padLeft+plotW, y))
+		label := canvas.NewText(formatValue(w.format, v), theme.ForegroundColor())
+		label.TextSize = labelSize
+		label.Alignment = fyne.TextAlignTrailing
+		label.Move(fyne.NewPos(0, y-labelSize/2-2))
+		label.Resize(fyne.NewSize(padLeft-4, labelSize+4))
+		r.objects = append(r.objects, label)
+	}
+	r.objects = append(r.objects, hLine(refColor, padLeft, padLeft+plotW, yAt(0)))
+
+	slot := plotW / float32(len(from))
+	barW := float32(math.Max(2, float64(slot*0.6)))
+	total := len(from) - 1
+	for i := range from {
+		var col color.Color = ColorUp
+		if to[i] < from[i] {
+			col = ColorDown
+		}
+		if i == total {
+			col = ColorBlue
+		}
+		if w.hover >= 0 && i != w.hover {
+			col = withAlpha(col, 140)
+		}
+		x := padLeft + slot*float32(i) + (slot-barW)/2
+		top, bottom := yAt(math.Max(from[i], to[i])), yAt(math.Min(from[i], to[i]))
+		bar := canvas.NewRectangle(col)
+		bar.Move(fyne.NewPos(x, top))
+		bar.Resize(fyne.NewSize(barW, float32(math.Max(1, float64(bottom-top)))))
+		r.objects = append(r.objects, bar)
+		// 连接线：本柱终点到下一柱起点
+		if i < total-1 {
+			r.objects = append(r.objects, hLine(refColor, x+barW, x+slot, yAt(to[i])))
+		}
+
+		name := w.totalLabel
+		if i < total {
+			name = w.items[i].Label
+		}
+		label := canvas.NewText(truncateLabel(name, slot), theme.ForegroundColor())
+		label.TextSize = labelSize
+		label.Alignment = fyne.TextAlignCenter
+		label.Move(fyne.NewPos(padLeft+slot*float32(i), r.size.Height-padBottom+2))
+		label.Resize(fyne.NewSize(slot, labelSize+4))
+		r.objects = append(r.objects, label)
+	}
+
+	if w.hover >= 0 && w.hover < len(from) {
+		i := w.hover
+		lines := []string{w.totalLabel, formatValue(w.format, to[i])}
+		if i < total {
+			lines = []string{w.items[i].Label, formatValue(w.format, w.items[i].Value), "累计 " + formatValue(w.format, to[i])}
+		}
+		x := padLeft + slot*float32(i) + slot/2
+		r.objects = append(r.objects, tooltip(lines, x, r.size.Width)...)
+	}
+	canvas.Refresh(w)
+}
+
+// truncateLabel 截断名称使其不超过给定宽度
+func truncateLabel(text string, width float32) string {
+	runes := []rune(text)
+	for len(runes) > 1 && fyne.MeasureText(string(runes), labelSize, fyne.TextStyle{}).Width > width-4 {
+		runes = runes[:len(runes)-1]
+	}
+	if len(runes) < len([]rune(text)) {
+		return string(runes[:maxInt(1, len(runes)-1)]) + "…"
+	}
+	return text
+}
@@ -2,12 +2,14 @@ package ui
 
 import (
 	"fmt"
-	"image/color"
 	"strconv"
+	"sync"
+	"time"
 
 	"jijin/internal/model"
 	"jijin/internal/service"
 	apptheme "jijin/internal/theme"
+	"jijin/internal/ui/chart"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -25,8 +27,20 @@ type SearchUI struct {
 
 	searchEntry *widget.Entry
 	resultList  *fyne.Container
+
+	// 搜索状态：输入防抖，只显示最新一次搜索的结果
+	searchMu    sync.Mutex
+	searchTimer *time.Timer
+	searchSeq   int
 	results     []model.FundSearchResult
 
+	// 筛选条件
+	typeSelect      *widget.Select
+	companyEntry    *widget.Entry
+	scaleSelect     *widget.Select
+	establishSelect *widget.Select
+	sortSelect      *widget.Select
+
 	// 基金详情
 	detailCard  fyne.CanvasObject
 	codeLabel   *widget.Label
@@ -36,12 +50,25 @@ type SearchUI struct {
 	estLabel    *widget.Label
 	growthLabel *widget.Label
 	addBtn      *widget.Button
+	watchBtn    *widget.Button
 
 	// 走势图
 	chartContainer *fyne.Container
 	currentCode    string
+	chartHistories []model.NetValueHistory // 按日期升序
+	navChart       *chart.TimeChart
+	panelChart     *chart.TimeChart
+	panelTitle     *widget.Label
+	overlayGroup   *widget.CheckGroup
+	panelSelect    *widget.Select
 }
 
+// searchDebounce 输入停止多久后开始搜索
+const searchDebounce = 300 * time.Millisecond
+
+// chartHistoryDays 走势图获取的交易日数(约3年，另含指标预热)
+const chartHistoryDays = 810
+
 // NewSearchUI 创建搜索页面UI
 func NewSearchUI(onAdd func(code, name string, amount, nav, fee float64)) *SearchUI {
 	s := &SearchUI{
@@ -58,17 +85,52 @@ func (s *SearchUI) build() {
 	s.searchEntry.SetPlaceHolder("输入基金代码或名称搜索...")
 	s.searchEntry.OnChanged = func(text string) {
 		if len(text) >= 2 {
-			s.doSearch(text)
+			s.scheduleSearch(text)
 		}
 	}
+	s.searchEntry.OnSubmitted = func(text string) {
+		s.doSearch(text, false)
+	}
 
 	searchBtn := widget.NewButtonWithIcon("搜索", theme.SearchIcon(), func() {
-		s.doSearch(s.searchEntry.Text)
+		s.doSearch(s.searchEntry.Text, false)
 	})
 	searchBtn.Importance = widget.HighImportance
 
 	searchBar := container.NewBorder(nil, nil, nil, searchBtn, s.searchEntry)
 
+	// 筛选条件
+	s.typeSelect = widget.NewSelect([]string{"全部类型", "股票型", "混合型", "债券型", "指数型", "QDII", "货币型", "FOF"}, nil)
+	s.typeSelect.SetSelected("全部类型")
+
+	s.companyEntry = widget.NewEntry()
+	s.companyEntry.SetPlaceHolder("基金公司")
+
+	s.scaleSelect = widget.NewSelect([]string{"规模不限", "2亿以上", "10亿以上", "50亿以上", "100亿以上"}, nil)
+	s.scaleSelect.SetSelected("规模不限")
+
+	s.establishSelect = widget.NewSelect([]string{"成立不限", "成立1年以上", "成立3年以上", "成立5年以上", "成立1年以内"}, nil)
+	s.establishSelect.SetSelected("成立不限")
+
+	s.sortSelect = widget.NewSelect([]string{"按相关度", "按规模", "按成立日期", "按代码"}, nil)
+	s.sortSelect.SetSelected("按相关度")
+
+	// 筛选条件变化时重新搜索
+	onFilterChanged := func(string) {
+		if s.searchEntry.Text != "" {
+			s.doSearch(s.searchEntry.Text, false)
+		}
+	}
+	s.typeSelect.OnChanged = onFilterChanged
+	s.scaleSelect.OnChanged = onFilterChanged
+	s.establishSelect.OnChanged = onFilterChanged
+	s.sortSelect.OnChanged = onFilterChanged
+
+	filterBar := container.NewVBox(
+		container.NewGridWithColumns(3, s.typeSelect, s.scaleSelect, s.establishSelect),
+		container.NewBorder(nil, nil, nil, s.sortSelect, s.companyEntry),
+	)
+
 	// 搜索结果列表
 	s.resultList = container.NewVBox()
 
@@ -95,6 +157,12 @@ func (s *SearchUI) build() {
 	})
 	s.addBtn.Importance = widget.HighImportance
 
+	s.watchBtn = widget.NewButtonWithIcon("加入自选", theme.ContentAddIcon(), func() {
+		if s.codeLabel.Text != "-" && s.codeLabel.Text != "" {
+			s.addToWatchlist()
+		}
+	})
+
 	detailContent := container.NewVBox(
 		s.createDetailRow("基金代码", s.codeLabel),
 		widget.NewSeparator(),
@@ -108,14 +176,36 @@ func (s *SearchUI) build() {
 		widget.NewSeparator(),
 		s.createDetailRow("估算涨跌", s.growthLabel),
 		layout.NewSpacer(),
-		s.addBtn,
+		container.NewGridWithColumns(2, s.addBtn, s.watchBtn),
 	)
 
 	detailCardWidget := widget.NewCard("基金详情", "", container.NewPadded(detailContent))
 
 	// 走势图容器
+	s.navChart = chart.NewTimeChart(500, 180)
+	s.panelChart = chart.NewTimeChart(500, 90)
+	chart.LinkViews(s.navChart, s.panelChart)
+	s.panelTitle = widget.NewLabel("")
+	s.panelTitle.TextStyle = fyne.TextStyle{Bold: true}
 	s.chartContainer = container.NewVBox()
-	chartCard := widget.NewCard("近期走势", "最近30天净值走势", s.chartContainer)
+
+	overlayOptions := make([]string, len(navOverlays))
+	for i, o := range navOverlays {
+		overlayOptions[i] = o.name
+	}
+	s.overlayGroup = widget.NewCheckGroup(overlayOptions, func([]string) { s.renderChart(true) })
+	s.overlayGroup.Horizontal = true
+	panelOptions := []string{"无副图"}
+	for _, p := range navPanels {
+		panelOptions = append(panelOptions, p.name)
+	}
+	s.panelSelect = widget.NewSelect(panelOptions, func(string) { s.renderChart(true) })
+	s.panelSelect.SetSelectedIndex(0)
+	chartControls := container.NewVBox(
+		container.NewBorder(nil, nil, nil, s.panelSelect, chart.NewRangeBar(chart.Range3M, s.navChart, s.panelChart)),
+		s.overlayGroup,
+	)
+	chartCard := widget.NewCard("近期走势", "净值走势与技术指标，滚轮缩放、拖动平移", container.NewVBox(chartControls, s.chartContainer))
 
 	// 右侧面板
 	rightPanel := container.NewVBox(
@@ -125,7 +215,7 @@ func (s *SearchUI) build() {
 
 	// 左侧面板
 	leftPanel := widget.NewCard("搜索基金", "", container.NewBorder(
-		container.NewVBox(searchBar, widget.NewSeparator()),
+		container.NewVBox(searchBar, filterBar, widget.NewSeparator()),
 		nil, nil, nil,
 		resultScroll,
 	))
@@ -156,7 +246,7 @@ func (s *SearchUI) showAddHoldingDialog() {
 
 	feeEntry := widget.NewEntry()
 	feeEntry.SetPlaceHolder("手续费(可选)")
-	feeEntry.SetText("0")
+	bindDefaultFee(feeEntry, false, func() float64 { return entryFloat(amountEntry) }, amountEntry)
 
 	form := widget.NewForm(
 		widget.NewFormItem("买入金额(元)", amountEntry),
@@ -197,6 +287,18 @@ func (s *SearchUI) showAddHoldingDialog() {
 	)
 }
 
+// addToWatchlist 将当前基金加入自选
+func (s *SearchUI) addToWatchlist() {
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	_, err := service.GetWatchlistService().AddToWatchlist(s.codeLabel.Text, s.nameLabel.Text, "", "")
+	if err != nil {
+		dialog.ShowError(err, win)
+		return
+	}
+	dialog.ShowInformation("成功", "已加入自选: "+s.nameLabel.Text, win)
+}
+
 // createDetailRow 创建详情行
 func (s *SearchUI) createDetailRow(label string, value *widget.Label) fyne.CanvasObject {
 	titleLabel := widget.NewLabel(label)
@@ -214,7 +316,14 @@ func (s *SearchUI) createResultItem(item model.FundSearchResult) fyne.CanvasObje
 	nameLabel := widget.NewLabel(item.Name)
 	nameLabel.Truncation = fyne.TextTruncateEllipsis
 
-	typeLabel := widget.NewLabel(item.Type)
+	typeText := item.Type
+	if item.Company != "" {
+		typeText = fmt.Sprintf("%s · %s", item.Company, item.Type)
+	}
+	if item.Scale > 0 {
+		typeText += fmt.Sprintf(" · %.1f亿", item.Scale)
+	}
+	typeLabel := widget.NewLabel(typeText)
 	typeLabel.Importance = widget.LowImportance
 
 	content := container.NewBorder(
@@ -232,23 +341,49 @@ func (s *SearchUI) createResultItem(item model.FundSearchResult) fyne.CanvasObje
 	return container.NewStack(bg, container.NewPadded(content), btn)
 }
 
-// doSearch 执行搜索
-func (s *SearchUI) doSearch(keyword string) {
+// scheduleSearch 输入停止searchDebounce后搜索，期间继续输入则重新计时
+func (s *SearchUI) scheduleSearch(keyword string) {
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+	if s.searchTimer != nil {
+		s.searchTimer.Stop()
+	}
+	s.searchTimer = time.AfterFunc(searchDebounce, func() {
+		s.doSearch(keyword, true)
+	})
+}
+
+// doSearch 执行搜索，typing为true时是输入触发的即时搜索，只用本地概况筛选。
+// 每次搜索编号，较早的搜索晚返回时丢弃其结果
+func (s *SearchUI) doSearch(keyword string, typing bool) {
 	if keyword == "" {
 		return
 	}
 
+	s.searchMu.Lock()
+	if !typing && s.searchTimer != nil {
+		s.searchTimer.Stop()
+	}
+	s.searchSeq++
+	seq := s.searchSeq
+	s.searchMu.Unlock()
+
 	s.resultList.RemoveAll()
 	loadingLabel := widget.NewLabel("搜索中...")
 	loadingLabel.Alignment = fyne.TextAlignCenter
 	s.resultList.Add(container.NewCenter(loadingLabel))
 	s.resultList.Refresh()
 
+	opts := s.searchOptions()
+	opts.LocalOnly = typing
 	go func() {
-		results, err := service.GetFundAPI().SearchFund(keyword)
+		outcome, err := service.GetFundAPI().SearchFunds(keyword, opts)
 
-		// 使用fyne的方式在主线程更新UI
-		fyne.CurrentApp().Driver().CanvasForObject(s.resultList).Refresh(s.resultList)
+		s.searchMu.Lock()
+		defer s.searchMu.Unlock()
+		if seq != s.searchSeq {
+			return
+		}
 
 		if err != nil {
 			s.resultList.RemoveAll()
@@ -258,8 +393,18 @@ func (s *SearchUI) doSearch(keyword string) {
 			return
 		}
 
+		results := outcome.Results
 		s.results = results
 		s.resultList.RemoveAll()
+		if outcome.Unchecked > 0 {
+			hint := "可输入更具体的关键字"
+			if typing {
+				hint = "按回车或点击搜索可联网获取"
+			}
+			noticeLabel := widget.NewLabel(fmt.Sprintf("另有%d只基金暂无概况数据，未参与公司/规模/成立日期筛选，%s", outcome.Unchecked, hint))
+			noticeLabel.Wrapping = fyne.TextWrapWord
+			s.resultList.Add(noticeLabel)
+		}
 
 		if len(results) == 0 {
 			noResultLabel := widget.NewLabel("未找到相关基金")
@@ -274,6 +419,51 @@ func (s *SearchUI) doSearch(keyword string) {
 	}()
 }
 
+// searchOptions 根据筛选控件生成搜索选项
+func (s *SearchUI) searchOptions() service.SearchOptions {
+	opts := service.SearchOptions{
+		Company: s.companyEntry.Text,
+	}
+
+	if s.typeSelect.Selected != "全部类型" {
+		opts.FundType = s.typeSelect.Selected
+	}
+
+	switch s.scaleSelect.Selected {
+	case "2亿以上":
+		opts.MinScale = 2
+	case "10亿以上":
+		opts.MinScale = 10
+	case "50亿以上":
+		opts.MinScale = 50
+	case "100亿以上":
+		opts.MinScale = 100
+	}
+
+	now := time.Now()
+	switch s.establishSelect.Selected {
+	case "成立1年以上":
+		opts.EstablishTo = now.AddDate(-1, 0, 0)
+	case "成立3年以上":
+		opts.EstablishTo = now.AddDate(-3, 0, 0)
+	case "成立5年以上":
+		opts.EstablishTo = now.AddDate(-5, 0, 0)
+	case "成立1年以内":
+		opts.EstablishFrom = now.AddDate(-1, 0, 0)
+	}
+
+	switch s.sortSelect.Selected {
+	case "按规模":
+		opts.SortBy = service.SearchSortScale
+	case "按成立日期":
+		opts.SortBy = service.SearchSortEstablish
+	case "按代码":
+		opts.SortBy = service.SearchSortCode
+	}
+
+	return opts
+}
+
 // showFundDetail 显示基金详情
 func (s *SearchUI) showFundDetail(code string) {
 	s.currentCode = code
@@ -298,6 +488,7 @@ func (s *SearchUI) showFundDetail(code string) {
 		}
 
 		// 获取类型
+		s.searchMu.Lock()
 		for _, r := range s.results {
 			if r.Code == code {
 				fund.Type = r.Type
@@ -305,6 +496,7 @@ func (s *SearchUI) showFundDetail(code string) {
 				break
 			}
 		}
+		s.searchMu.Unlock()
 
 		s.nameLabel.SetText(fund.Name)
 		s.typeLabel.SetText(fund.Type)
@@ -326,110 +518,66 @@ func (s *SearchUI) showFundDetail(code string) {
 
 // loadChart 加载走势图
 func (s *SearchUI) loadChart(code string) {
-	histories, err := service.GetFundAPI().GetFundHistory(code, 30)
-
-	s.chartContainer.RemoveAll()
-
+	histories, err := service.GetFundAPI().GetFundHistory(code, chartHistoryDays)
 	if err != nil || len(histories) == 0 {
+		s.chartHistories = nil
+		s.chartContainer.RemoveAll()
 		errorLabel := widget.NewLabel("无法加载走势数据")
 		s.chartContainer.Add(container.NewCenter(errorLabel))
 		s.chartContainer.Refresh()
 		return
 	}
 
-	// 创建简易走势图
-	chart := s.createLineChart(histories)
-	s.chartContainer.Add(chart)
+	// 接口返回按日期倒序，转为升序
+	s.chartHistories = make([]model.NetValueHistory, len(histories))
+	for i, h := range histories {
+		s.chartHistories[len(histories)-1-i] = h
+	}
+	s.chartContainer.Objects = []fyne.CanvasObject{s.navChart, s.panelTitle, s.panelChart}
 	s.chartContainer.Refresh()
+	s.renderChart(false)
 }
 
-// createLineChart 创建折线图
-func (s *SearchUI) createLineChart(histories []model.NetValueHistory) fyne.CanvasObject {
-	if len(histories) == 0 {
-		return widget.NewLabel("无数据")
+// renderChart 按选择的叠加指标和副图更新走势图，指标在完整历史上计算；keepView为true时保持当前缩放
+func (s *SearchUI) renderChart(keepView bool) {
+	if s.navChart == nil || len(s.chartHistories) == 0 {
+		return
 	}
-
-	// 找出最大最小值
-	var minVal, maxVal float64 = histories[0].NetValue, histories[0].NetValue
-	for _, h := range histories {
-		if h.NetValue < minVal {
-			minVal = h.NetValue
-		}
-		if h.NetValue > maxVal {
-			maxVal = h.NetValue
-		}
+	dates := make([]time.Time, len(s.chartHistories))
+	prices := make([]float64, len(s.chartHistories))
+	for i, h := range s.chartHistories {
+		dates[i] = h.Date
+		prices[i] = h.NetValue
 	}
 
-	// 添加一些边距
-	valRange := maxVal - minVal
-	if valRange < 0.01 {
-		valRange = 0.01
-	}
-	minVal -= valRange * 0.1
-	maxVal += valRange * 0.1
-	valRange = maxVal - minVal
-
-	chartWidth := float32(500)
-	chartHeight := float32(150)
-
-	// 创建背景
-	bg := canvas.NewRectangle(color.RGBA{R: 248, G: 250, B: 252, A: 255})
-	bg.SetMinSize(fyne.NewSize(chartWidth, chartHeight))
-
-	// 创建折线
-	points := make([]fyne.CanvasObject, 0)
-
-	// 倒序处理(从旧到新)
-	n := len(histories)
-	for i := n - 1; i >= 0; i-- {
-		h := histories[i]
-		idx := n - 1 - i
-
-		x := float32(idx) / float32(n-1) * (chartWidth - 20) + 10
-		y := chartHeight - 10 - float32((h.NetValue-minVal)/valRange)*(chartHeight-20)
-
-		// 绘制点
-		circle := canvas.NewCircle(color.RGBA{R: 64, G: 128, B: 255, A: 255})
-		circle.Resize(fyne.NewSize(6, 6))
-		circle.Move(fyne.NewPos(x-3, y-3))
-		points = append(points, circle)
-
-		// 绘制连线
-		if i < n-1 {
-			nextIdx := n - 2 - i
-			nextX := float32(nextIdx+1) / float32(n-1) * (chartWidth - 20) + 10
-			nextY := chartHeight - 10 - float32((histories[i+1].NetValue-minVal)/valRange)*(chartHeight-20)
-
-			line := canvas.NewLine(color.RGBA{R: 64, G: 128, B: 255, A: 200})
-			line.StrokeWidth = 2
-			line.Position1 = fyne.NewPos(x, y)
-			line.Position2 = fyne.NewPos(nextX, nextY)
-			points = append(points, line)
+	series := []chart.Series{{Name: "净值", Values: prices, Color: chart.ColorBlue, Width: 2}}
+	for _, o := range navOverlays {
+		for _, selected := range s.overlayGroup.Selected {
+			if selected == o.name {
+				series = append(series, o.calc(prices)...)
+			}
 		}
 	}
+	start, end := s.navChart.View()
+	s.navChart.SetSeries(dates, series...)
+
+	if idx := s.panelSelect.SelectedIndex(); idx > 0 {
+		panel := navPanels[idx-1]
+		s.panelTitle.SetText(panel.name)
+		s.panelChart.SetValueFormat(panel.format)
+		s.panelChart.SetRefLines(panel.refs...)
+		s.panelChart.SetSeries(dates, panel.calc(prices)...)
+		s.panelTitle.Show()
+		s.panelChart.Show()
+	} else {
+		s.panelChart.SetSeries(dates)
+		s.panelTitle.Hide()
+		s.panelChart.Hide()
+	}
 
-	// 添加Y轴标签
-	maxLabel := widget.NewLabel(fmt.Sprintf("%.4f", maxVal))
-	maxLabel.TextStyle = fyne.TextStyle{}
-	minLabel := widget.NewLabel(fmt.Sprintf("%.4f", minVal))
-	minLabel.TextStyle = fyne.TextStyle{}
-
-	// 日期标签
-	startDate := histories[len(histories)-1].Date.Format("01-02")
-	endDate := histories[0].Date.Format("01-02")
-	dateLabel := widget.NewLabel(fmt.Sprintf("%s ~ %s", startDate, endDate))
-	dateLabel.Alignment = fyne.TextAlignCenter
-
-	// 组合图表
-	chartContent := container.NewWithoutLayout(append([]fyne.CanvasObject{bg}, points...)...)
-	chartContent.Resize(fyne.NewSize(chartWidth, chartHeight))
-
-	return container.NewVBox(
-		container.NewHBox(maxLabel, layout.NewSpacer()),
-		chartContent,
-		container.NewHBox(minLabel, layout.NewSpacer()),
-		dateLabel,
-	)
+	if keepView && end > start && end <= len(dates) {
+		s.navChart.SetView(start, end)
+	}
 }
 
 // Content 获取内容
@@ -26,6 +26,9 @@ type HomeUI struct {
 	totalProfitLabel *widget.Label
 	profitRateLabel  *widget.Label
 
+	// 当日估算盈亏走势
+	intradayContainer *fyne.Container
+
 	// 持仓列表
 	holdingList *fyne.Container
 	holdings    []holdingItem
@@ -124,9 +127,14 @@ func (h *HomeUI) build() {
 		scroll,
 	))
 
+	// 当日估算盈亏
+	h.intradayContainer = container.NewVBox()
+	intradayCard := widget.NewCard("", "今日估算盈亏", h.intradayContainer)
+
 	h.content = container.NewBorder(
 		container.NewVBox(
 			container.NewPadded(summaryGrid),
+			container.NewPadded(intradayCard),
 			widget.NewSeparator(),
 		),
 		nil, nil, nil,
@@ -247,4 +255,8 @@ func (h *HomeUI) Refresh() {
 	}
 
 	h.holdingList.Refresh()
+
+	h.intradayContainer.RemoveAll()
+	h.intradayContainer.Add(createPortfolioIntradayChart())
+	h.intradayContainer.Refresh()
 }
@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// importColumn 导入预览表的列
+type importColumn struct {
+	Title string
+	Width float32
+	Value func(r *service.ImportRow) string
+}
+
+// importAmount 金额列，0显示为空
+func importAmount(format string, v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf(format, v)
+}
+
+// importTradeColumns 交易记录预览列
+var importTradeColumns = []importColumn{
+	{"行", 44, func(r *service.ImportRow) string { return fmt.Sprint(r.Line) }},
+	{"状态", 60, func(r *service.ImportRow) string { return service.ImportRowStatusNames[r.Status] }},
+	{"日期", 96, func(r *service.ImportRow) string {
+		if r.TradeDate.IsZero() {
+			return ""
+		}
+		return r.TradeDate.Format("2006-01-02")
+	}},
+	{"类型", 50, func(r *service.ImportRow) string {
+		switch r.Type {
+		case "buy":
+			return "买入"
+		case "sell":
+			return "卖出"
+		}
+		return ""
+	}},
+	{"代码", 70, func(r *service.ImportRow) string { return r.FundCode }},
+	{"名称", 150, func(r *service.ImportRow) string { return r.FundName }},
+	{"金额", 90, func(r *service.ImportRow) string { return importAmount("%.2f", r.Amount) }},
+	{"净值", 70, func(r *service.ImportRow) string { return importAmount("%.4f", r.NetValue) }},
+	{"份额", 90, func(r *service.ImportRow) string { return importAmount("%.2f", r.Shares) }},
+	{"手续费", 60, func(r *service.ImportRow) string { return importAmount("%.2f", r.Fee) }},
+	{"说明", 240, func(r *service.ImportRow) string { return r.Message }},
+}
+
+// importHoldingColumns 持仓快照预览列
+var importHoldingColumns = []importColumn{
+	{"行", 44, func(r *service.ImportRow) string { return fmt.Sprint(r.Line) }},
+	{"状态", 60, func(r *service.ImportRow) string { return service.ImportRowStatusNames[r.Status] }},
+	{"代码", 70, func(r *service.ImportRow) string { return r.FundCode }},
+	{"名称", 150, func(r *service.ImportRow) string { return r.FundName }},
+	{"份额", 90, func(r *service.ImportRow) string { return importAmount("%.2f", r.Shares) }},
+	{"成本", 90, func(r *service.ImportRow) string { return importAmount("%.2f", r.Cost) }},
+	{"净值", 70, func(r *service.ImportRow) string { return importAmount("%.4f", r.NetValue) }},
+	{"市值", 90, func(r *service.ImportRow) string { return importAmount("%.2f", r.MarketValue) }},
+	{"说明", 240, func(r *service.ImportRow) string { return r.Message }},
+}
+
+// showImportDialog 选择来源和CSV/XLSX文件，解析后显示预览
+func (p *PortfolioUI) showImportDialog() {
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	sourceNames := []string{"自动识别"}
+	for _, s := range service.ImportSources {
+		sourceNames = append(sourceNames, s.Name)
+	}
+	sourceSelect := widget.NewSelect(sourceNames, nil)
+	sourceSelect.SetSelected(sourceNames[0])
+
+	help := widget.NewLabel("支持支付宝、天天基金、蛋卷基金和银行APP导出的交易记录或持仓明细(CSV/XLSX)。\n" +
+		"其他来源可整理为通用模板，表头: 日期,代码,名称,类型,金额,净值,份额,手续费")
+	help.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	chooseBtn := widget.NewButton("选择文件...", func() {
+		sourceKey := service.ImportSourceAuto
+		for _, s := range service.ImportSources {
+			if s.Name == sourceSelect.Selected {
+				sourceKey = s.Key
+			}
+		}
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			preview, err := service.GetImportService().Parse(reader.URI().Name(), data, sourceKey)
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			d.Hide()
+			p.showImportPreview(preview)
+		}, win)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".xlsx", ".txt"}))
+		open.Show()
+	})
+	chooseBtn.Importance = widget.HighImportance
+
+	content := container.NewVBox(
+		widget.NewForm(widget.NewFormItem("来源", sourceSelect)),
+		help,
+		chooseBtn,
+	)
+	d = dialog.NewCustom("导入持仓/交易", "取消", content, win)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
+
+// showImportPreview 预览解析结果，确认后写入并显示导入结果
+func (p *PortfolioUI) showImportPreview(preview *service.ImportPreview) {
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+	columns := importTradeColumns
+	if preview.Kind == service.ImportKindHoldings {
+		columns = importHoldingColumns
+	}
+
+	table := widget.NewTable(
+		func() (int, int) { return len(preview.Rows) + 1, len(columns) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.Importance = widget.MediumImportance
+				label.SetText(columns[id.Col].Title)
+				return
+			}
+			r := &preview.Rows[id.Row-1]
+			label.TextStyle = fyne.TextStyle{}
+			switch r.Status {
+			case service.ImportRowInvalid:
+				label.Importance = widget.DangerImportance
+			case service.ImportRowDuplicate, service.ImportRowSkipped:
+				label.Importance = widget.LowImportance
+			default:
+				label.Importance = widget.MediumImportance
+			}
+			label.SetText(columns[id.Col].Value(r))
+		},
+	)
+	for i, col := range columns {
+		table.SetColumnWidth(i, col.Width)
+	}
+
+	ready := preview.Count(service.ImportRowOK)
+	summary := widget.NewLabel(fmt.Sprintf("来源: %s  内容: %s  共%d行，待导入%d，错误%d，重复%d，忽略%d",
+		preview.Source.Name, service.ImportKindNames[preview.Kind], len(preview.Rows), ready,
+		preview.Count(service.ImportRowInvalid), preview.Count(service.ImportRowDuplicate), preview.Count(service.ImportRowSkipped)))
+	summary.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(summary, nil, nil, nil, table)
+	d := dialog.NewCustomConfirm("导入预览 - "+preview.FileName, fmt.Sprintf("导入%d条", ready), "取消", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		record, err := service.GetImportService().Commit(preview)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		p.Refresh()
+		msg := fmt.Sprintf("成功导入%d条，失败%d条", record.SuccessCount, record.FailedCount)
+		if failures := service.ImportFailures(*record); len(failures) > 0 {
+			msg += "\n\n" + strings.Join(failures, "\n")
+		}
+		dialog.ShowInformation("导入完成", msg, win)
+	}, win)
+	d.Resize(fyne.NewSize(1000, 560))
+	d.Show()
+}
+
+// showImportHistory 显示最近的导入记录
+func (p *PortfolioUI) showImportHistory() {
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+	records, _ := service.GetImportService().GetImportHistory(50)
+
+	list := container.NewVBox()
+	if len(records) == 0 {
+		list.Add(widget.NewLabel("暂无导入记录"))
+	}
+	for _, r := range records {
+		text := fmt.Sprintf("%s  %s  共%d行，成功%d，失败%d",
+			r.ImportedAt.Format("2006-01-02 15:04"), r.SourceApp, r.ImportedCount, r.SuccessCount, r.FailedCount)
+		item := widget.NewLabel(text)
+		if failures := service.ImportFailures(r); len(failures) > 0 {
+			item.SetText(text + "\n    " + strings.Join(failures, "\n    "))
+		}
+		item.Wrapping = fyne.TextWrapWord
+		list.Add(item)
+	}
+
+	d := dialog.NewCustom("导入记录", "关闭", container.NewVScroll(list), win)
+	d.Resize(fyne.NewSize(640, 420))
+	d.Show()
+}
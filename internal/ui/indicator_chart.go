@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"math"
+
+	"jijin/internal/service"
+	"jijin/internal/ui/chart"
+)
+
+// chartOverlay 叠加在净值图上的指标
+type chartOverlay struct {
+	name string
+	calc func(prices []float64) []chart.Series
+}
+
+// chartPanel 净值图下方的指标副图
+type chartPanel struct {
+	name   string
+	format string
+	refs   []float64 // 参考线，如RSI的30/70
+	calc   func(prices []float64) []chart.Series
+}
+
+// navOverlays 可选的净值叠加指标
+var navOverlays = []chartOverlay{
+	{"MA5", func(p []float64) []chart.Series {
+		return []chart.Series{{Name: "MA5", Values: service.GetIndicatorService().CalculateSMA(p, 5), Color: chart.ColorOrange, Width: 1}}
+	}},
+	{"MA20", func(p []float64) []chart.Series {
+		return []chart.Series{{Name: "MA20", Values: service.GetIndicatorService().CalculateSMA(p, 20), Color: chart.ColorPurple, Width: 1}}
+	}},
+	{"WMA20", func(p []float64) []chart.Series {
+		return []chart.Series{{Name: "WMA20", Values: service.GetIndicatorService().CalculateWMA(p, 20), Color: chart.ColorUp, Width: 1}}
+	}},
+	{"布林带", func(p []float64) []chart.Series {
+		boll := service.GetIndicatorService().CalculateBollinger(p, 20)
+		if boll == nil {
+			return nil
+		}
+		// 布林带预热区间为0，转为NaN避免绘制
+		return []chart.Series{
+			{Name: "布林上轨", Values: zeroAsWarmup(boll.Upper), Color: chart.ColorGray, Width: 1},
+			{Name: "布林中轨", Values: zeroAsWarmup(boll.Middle), Color: chart.ColorGray, Width: 1},
+			{Name: "布林下轨", Values: zeroAsWarmup(boll.Lower), Color: chart.ColorGray, Width: 1},
+		}
+	}},
+	{"分位通道", func(p []float64) []chart.Series {
+		band := service.GetIndicatorService().CalculatePercentileBands(p, 60, 10, 90)
+		return []chart.Series{
+			{Name: "90分位", Values: band.Upper, Color: chart.ColorDown, Width: 1},
+			{Name: "中位数", Values: band.Median, Color: chart.ColorGray, Width: 1},
+			{Name: "10分位", Values: band.Lower, Color: chart.ColorDown, Width: 1},
+		}
+	}},
+}
+
+// navPanels 可选的指标副图
+var navPanels = []chartPanel{
+	{"MACD", "%.4f", []float64{0}, func(p []float64) []chart.Series {
+		macd := service.GetIndicatorService().CalculateMACD(p)
+		if macd == nil {
+			return nil
+		}
+		return []chart.Series{
+			{Name: "MACD柱", Values: warmupFrom(macd.Hist, 33), Kind: chart.KindBar},
+			{Name: "DIF", Values: warmupFrom(macd.MACD, 25), Color: chart.ColorOrange, Width: 1},
+			{Name: "DEA", Values: macd.DEA, Color: chart.ColorPurple, Width: 1},
+		}
+	}},
+	{"RSI(14)", "%.0f", []float64{30, 70}, func(p []float64) []chart.Series {
+		rsi := service.GetIndicatorService().CalculateRSI(p, 14)
+		if rsi == nil {
+			return nil
+		}
+		return []chart.Series{{Name: "RSI", Values: warmupFrom(rsi, 14), Color: chart.ColorPurple, Width: 1}}
+	}},
+	{"KDJ(9)", "%.0f", []float64{20, 80}, func(p []float64) []chart.Series {
+		kdj := service.GetIndicatorService().CalculateKDJ(p, 9)
+		if kdj == nil {
+			return nil
+		}
+		return []chart.Series{
+			{Name: "K", Values: warmupFrom(kdj.K, 8), Color: chart.ColorOrange, Width: 1},
+			{Name: "D", Values: warmupFrom(kdj.D, 8), Color: chart.ColorPurple, Width: 1},
+			{Name: "J", Values: warmupFrom(kdj.J, 8), Color: chart.ColorGray, Width: 1},
+		}
+	}},
+	{"ATR波动(14)", "%.4f", nil, func(p []float64) []chart.Series {
+		return []chart.Series{{Name: "ATR", Values: service.GetIndicatorService().CalculateATR(p, 14), Color: chart.ColorOrange, Width: 1}}
+	}},
+	{"20日滚动收益(%)", "%.2f", []float64{0}, func(p []float64) []chart.Series {
+		return []chart.Series{{Name: "20日收益", Values: service.GetIndicatorService().CalculateRollingReturn(p, 20), Kind: chart.KindBar}}
+	}},
+	{"60日滚动夏普", "%.2f", []float64{0}, func(p []float64) []chart.Series {
+		return []chart.Series{{Name: "夏普", Values: service.GetIndicatorService().CalculateRollingSharpe(p, 60, service.GetSettingsService().Get().RiskFreeRate), Color: chart.ColorPurple, Width: 1}}
+	}},
+	{"回撤(%)", "%.2f", []float64{0}, func(p []float64) []chart.Series {
+		return []chart.Series{{Name: "回撤", Values: service.GetIndicatorService().CalculateDrawdown(p), Color: chart.ColorDown, Kind: chart.KindArea, Width: 1}}
+	}},
+	{"10日动量", "%.4f", []float64{0}, func(p []float64) []chart.Series {
+		return []chart.Series{{Name: "动量", Values: service.GetIndicatorService().CalculateMomentum(p, 10), Kind: chart.KindBar}}
+	}},
+}
+
+// warmupFrom 复制序列并将前start个值标记为预热(NaN)
+func warmupFrom(values []float64, start int) []float64 {
+	out := make([]float64, len(values))
+	for j, v := range values {
+		if j < start {
+			out[j] = math.NaN()
+		} else {
+			out[j] = v
+		}
+	}
+	return out
+}
+
+// zeroAsWarmup 将旧指标预热区间的0值转为NaN
+func zeroAsWarmup(values []float64) []float64 {
+	out := make([]float64, len(values))
+	for j, v := range values {
+		if v == 0 {
+			out[j] = math.NaN()
+		} else {
+			out[j] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// accuracyDays 估值准确度统计的交易日数
+const accuracyDays = 20
+
+// createIntradayChart 创建盘中走势图，以baseline为零轴，高于零轴为红、低于为绿
+func createIntradayChart(times []time.Time, values []float64, baseline float64, format string) fyne.CanvasObject {
+	if len(values) == 0 {
+		return widget.NewLabel("暂无盘中估值数据，交易时段刷新后生成")
+	}
+
+	minVal, maxVal := baseline, baseline
+	for _, v := range values {
+		if v < minVal {
+			minVal = v
+		}
+		if v > maxVal {
+			maxVal = v
+		}
+	}
+	valRange := maxVal - minVal
+	if valRange == 0 {
+		valRange = 1
+	}
+	minVal -= valRange * 0.1
+	maxVal += valRange * 0.1
+	valRange = maxVal - minVal
+
+	chartWidth := float32(500)
+	chartHeight := float32(150)
+	toY := func(v float64) float32 {
+		return chartHeight - 10 - float32((v-minVal)/valRange)*(chartHeight-20)
+	}
+	toX := func(i int) float32 {
+		if len(values) == 1 {
+			return chartWidth / 2
+		}
+		return float32(i)/float32(len(values)-1)*(chartWidth-20) + 10
+	}
+
+	bg := canvas.NewRectangle(color.RGBA{R: 248, G: 250, B: 252, A: 255})
+	bg.SetMinSize(fyne.NewSize(chartWidth, chartHeight))
+	objects := []fyne.CanvasObject{bg}
+
+	// 零轴
+	zero := canvas.NewLine(color.RGBA{R: 180, G: 180, B: 180, A: 255})
+	zero.StrokeWidth = 1
+	zero.Position1 = fyne.NewPos(10, toY(baseline))
+	zero.Position2 = fyne.NewPos(chartWidth-10, toY(baseline))
+	objects = append(objects, zero)
+
+	up := color.RGBA{R: 220, G: 38, B: 38, A: 230}
+	down := color.RGBA{R: 22, G: 163, B: 74, A: 230}
+	for i := 1; i < len(values); i++ {
+		lineColor := up
+		if values[i] < baseline {
+			lineColor = down
+		}
+		line := canvas.NewLine(lineColor)
+		line.StrokeWidth = 2
+		line.Position1 = fyne.NewPos(toX(i-1), toY(values[i-1]))
+		line.Position2 = fyne.NewPos(toX(i), toY(values[i]))
+		objects = append(objects, line)
+	}
+
+	last := len(values) - 1
+	dot := canvas.NewCircle(color.RGBA{R: 64, G: 128, B: 255, A: 255})
+	dot.Resize(fyne.NewSize(6, 6))
+	dot.Move(fyne.NewPos(toX(last)-3, toY(values[last])-3))
+	objects = append(objects, dot)
+
+	chartContent := container.NewWithoutLayout(objects...)
+	chartContent.Resize(fyne.NewSize(chartWidth, chartHeight))
+
+	timeLabel := widget.NewLabel(fmt.Sprintf("%s ~ %s  最新 "+format,
+		times[0].Format("15:04"), times[last].Format("15:04"), values[last]))
+	timeLabel.Alignment = fyne.TextAlignCenter
+
+	return container.NewVBox(
+		container.NewHBox(widget.NewLabel(fmt.Sprintf(format, maxVal)), layout.NewSpacer()),
+		chartContent,
+		container.NewHBox(widget.NewLabel(fmt.Sprintf(format, minVal)), layout.NewSpacer()),
+		timeLabel,
+	)
+}
+
+// createPortfolioIntradayChart 创建组合当日估算盈亏走势图
+func createPortfolioIntradayChart() fyne.CanvasObject {
+	points, _ := service.GetIntradayService().GetPortfolioIntradayPnL(time.Now())
+	times := make([]time.Time, len(points))
+	values := make([]float64, len(points))
+	for i, p := range points {
+		times[i] = p.Time
+		values[i] = p.Profit
+	}
+	return createIntradayChart(times, values, 0, "%+.2f")
+}
+
+// showIntradayDialog 显示基金当日估值走势及估值准确度
+func showIntradayDialog(win fyne.Window, fundCode, fundName string) {
+	estimates, _ := service.GetIntradayService().GetFundIntraday(fundCode, time.Now())
+	times := make([]time.Time, len(estimates))
+	values := make([]float64, len(estimates))
+	for i, e := range estimates {
+		times[i] = e.EstTime
+		values[i] = e.EstGrowth
+	}
+	chart := createIntradayChart(times, values, 0, "%+.2f%%")
+
+	chartTitle := "今日估值涨跌幅"
+	if len(estimates) > 0 {
+		if source := service.EstSourceLabel(estimates[len(estimates)-1].Source); source != "" {
+			chartTitle += " (" + source + ")"
+		}
+	}
+
+	accuracyLabel := widget.NewLabel("估值准确度统计中...")
+	accuracyLabel.Wrapping = fyne.TextWrapWord
+	recordList := container.NewVBox()
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(chartTitle, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		chart,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(fmt.Sprintf("近%d个交易日估值准确度", accuracyDays), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		accuracyLabel,
+		recordList,
+	)
+	scroll := container.NewVScroll(content)
+	scroll.SetMinSize(fyne.NewSize(540, 460))
+
+	dialog.ShowCustom("估值走势 - "+fundName, "关闭", scroll, win)
+
+	go func() {
+		accuracy, err := service.GetIntradayService().EvaluateAccuracy(fundCode, accuracyDays)
+		if err != nil {
+			accuracyLabel.SetText("统计失败: " + err.Error())
+			return
+		}
+		if accuracy.Samples == 0 {
+			accuracyLabel.SetText("暂无可对比的数据，需积累收盘估值并待净值公布后统计")
+			return
+		}
+
+		accuracyLabel.SetText(fmt.Sprintf("样本 %d 天  平均误差 %.2f个百分点  最大误差 %.2f个百分点  方向一致率 %.0f%%",
+			accuracy.Samples, accuracy.MeanAbsError, accuracy.MaxAbsError, accuracy.DirectionHitRate))
+		for _, c := range accuracy.Comparisons {
+			row := widget.NewLabel(fmt.Sprintf("%s  估算 %+.2f%%  实际 %+.2f%%  误差 %+.2f",
+				c.Date.Format("01-02"), c.EstGrowth, c.ActualGrowth, c.Error))
+			recordList.Add(row)
+		}
+		recordList.Refresh()
+	}()
+}
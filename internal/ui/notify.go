@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"jijin/internal/model"
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowChannelsDialog 显示通知渠道管理对话框
+func (u *AlertUI) ShowChannelsDialog() {
+	var channels []model.NotifyChannel
+	load := func() {
+		channels, _ = service.GetNotifyService().GetChannels()
+	}
+	load()
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(channels) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil),
+				container.NewHBox(
+					widget.NewButton("编辑", nil),
+					widget.NewButton("测试", nil),
+					widget.NewButton("删除", nil),
+				),
+				widget.NewLabel("渠道"),
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(channels) {
+				return
+			}
+			ch := channels[id]
+			box := obj.(*fyne.Container)
+			label := box.Objects[0].(*widget.Label)
+			check := box.Objects[1].(*widget.Check)
+			buttons := box.Objects[2].(*fyne.Container)
+
+			label.SetText(fmt.Sprintf("%s (%s)", ch.Name, channelSummary(ch)))
+			check.OnChanged = nil
+			check.SetChecked(ch.Enabled)
+			check.OnChanged = func(on bool) {
+				ch.Enabled = on
+				if err := service.GetNotifyService().SaveChannel(&ch); err != nil {
+					dialog.ShowError(err, u.window)
+				}
+				load()
+			}
+			buttons.Objects[0].(*widget.Button).OnTapped = func() {
+				u.showChannelForm(&ch, func() {
+					load()
+					list.Refresh()
+				})
+			}
+			buttons.Objects[1].(*widget.Button).OnTapped = func() {
+				go func() {
+					if err := service.GetNotifyService().TestChannel(&ch); err != nil {
+						dialog.ShowError(err, u.window)
+						return
+					}
+					dialog.ShowInformation("测试", "测试消息已发送", u.window)
+				}()
+			}
+			buttons.Objects[2].(*widget.Button).OnTapped = func() {
+				dialog.ShowConfirm("删除渠道", "确定删除渠道 "+ch.Name+"？", func(ok bool) {
+					if !ok {
+						return
+					}
+					service.GetNotifyService().DeleteChannel(ch.ID)
+					load()
+					list.Refresh()
+				}, u.window)
+			}
+		},
+	)
+
+	addBtn := widget.NewButton("添加渠道", func() {
+		u.showChannelForm(&model.NotifyChannel{Type: service.ChannelDesktop, Enabled: true}, func() {
+			load()
+			list.Refresh()
+		})
+	})
+	note := widget.NewLabel("未配置任何渠道时使用桌面通知；规则未指定渠道时推送到全部启用的渠道")
+	note.Wrapping = fyne.TextWrapWord
+	note.Importance = widget.LowImportance
+
+	d := dialog.NewCustom("通知渠道", "关闭",
+		container.NewBorder(container.NewVBox(addBtn, note), nil, nil, nil, list), u.window)
+	d.Resize(fyne.NewSize(640, 460))
+	d.Show()
+}
+
+// showChannelForm 显示添加/编辑通知渠道表单
+func (u *AlertUI) showChannelForm(channel *model.NotifyChannel, onSaved func()) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(channel.Name)
+
+	typeOptions := make([]string, len(service.ChannelTypes))
+	for i, t := range service.ChannelTypes {
+		typeOptions[i] = service.ChannelTypeNames[t]
+	}
+	typeSelect := widget.NewSelect(typeOptions, nil)
+
+	hostEntry := widget.NewEntry()
+	hostEntry.SetPlaceHolder("smtp.example.com")
+	hostEntry.SetText(channel.SMTPHost)
+	portEntry := widget.NewEntry()
+	portEntry.SetPlaceHolder("465 或 587")
+	if channel.SMTPPort > 0 {
+		portEntry.SetText(strconv.Itoa(channel.SMTPPort))
+	}
+	userEntry := widget.NewEntry()
+	userEntry.SetText(channel.Username)
+	passEntry := widget.NewPasswordEntry()
+	passEntry.SetText(channel.Password)
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("为空时使用用户名")
+	fromEntry.SetText(channel.From)
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("多个收件人用逗号分隔")
+	toEntry.SetText(channel.To)
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://...")
+	urlEntry.SetText(channel.WebhookURL)
+	formatOptions := make([]string, len(service.WebhookFormats))
+	formatIndex := 0
+	for i, f := range service.WebhookFormats {
+		formatOptions[i] = service.WebhookFormatNames[f]
+		if f == channel.WebhookFormat {
+			formatIndex = i
+		}
+	}
+	formatSelect := widget.NewSelect(formatOptions, nil)
+	formatSelect.SetSelectedIndex(formatIndex)
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetPlaceHolder("钉钉/飞书加签密钥，可选")
+	secretEntry.SetText(channel.Secret)
+
+	logEntry := widget.NewEntry()
+	logEntry.SetPlaceHolder("为空时写入数据目录 alerts.log")
+	logEntry.SetText(channel.LogPath)
+
+	emailFields := []fyne.Disableable{hostEntry, portEntry, userEntry, passEntry, fromEntry, toEntry}
+	webhookFields := []fyne.Disableable{urlEntry, formatSelect, secretEntry}
+	typeSelect.OnChanged = func(string) {
+		t := service.ChannelTypes[typeSelect.SelectedIndex()]
+		for _, f := range emailFields {
+			setEnabled(f, t == service.ChannelEmail)
+		}
+		for _, f := range webhookFields {
+			setEnabled(f, t == service.ChannelWebhook)
+		}
+		setEnabled(logEntry, t == service.ChannelLog)
+	}
+	for i, t := range service.ChannelTypes {
+		if t == channel.Type {
+			typeSelect.SetSelectedIndex(i)
+		}
+	}
+
+	collect := func() *model.NotifyChannel {
+		ch := *channel
+		ch.Name = nameEntry.Text
+		ch.Type = service.ChannelTypes[typeSelect.SelectedIndex()]
+		ch.SMTPHost = hostEntry.Text
+		ch.SMTPPort, _ = strconv.Atoi(portEntry.Text)
+		ch.Username = userEntry.Text
+		ch.Password = passEntry.Text
+		ch.From = fromEntry.Text
+		ch.To = toEntry.Text
+		ch.WebhookURL = urlEntry.Text
+		ch.WebhookFormat = service.WebhookFormats[formatSelect.SelectedIndex()]
+		ch.Secret = secretEntry.Text
+		ch.LogPath = logEntry.Text
+		return &ch
+	}
+
+	testBtn := widget.NewButton("发送测试", func() {
+		ch := collect()
+		go func() {
+			if err := service.GetNotifyService().TestChannel(ch); err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			dialog.ShowInformation("测试", "测试消息已发送", u.window)
+		}()
+	})
+
+	form := dialog.NewForm("通知渠道", "保存", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("名称", nameEntry),
+			widget.NewFormItem("类型", typeSelect),
+			widget.NewFormItem("SMTP服务器", hostEntry),
+			widget.NewFormItem("端口", portEntry),
+			widget.NewFormItem("用户名", userEntry),
+			widget.NewFormItem("密码", passEntry),
+			widget.NewFormItem("发件人", fromEntry),
+			widget.NewFormItem("收件人", toEntry),
+			widget.NewFormItem("Webhook地址", urlEntry),
+			widget.NewFormItem("消息格式", formatSelect),
+			widget.NewFormItem("加签密钥", secretEntry),
+			widget.NewFormItem("日志文件", logEntry),
+			widget.NewFormItem("", testBtn),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			ch := collect()
+			if err := service.GetNotifyService().SaveChannel(ch); err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			onSaved()
+		}, u.window)
+	form.Resize(fyne.NewSize(520, 640))
+	form.Show()
+}
+
+// showHistoryDialog 显示最近提醒及投递状态
+func (u *AlertUI) showHistoryDialog() {
+	histories, err := service.GetAlertService().GetRecentAlerts(100)
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+
+	list := widget.NewList(
+		func() int { return len(histories) },
+		func() fyne.CanvasObject {
+			detail := widget.NewLabel("")
+			detail.Importance = widget.LowImportance
+			detail.Wrapping = fyne.TextWrapWord
+			return container.NewVBox(widget.NewLabel("提醒"), detail)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(histories) {
+				return
+			}
+			h := histories[id]
+			box := obj.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s  %s", h.TriggeredAt.Format("01-02 15:04"), h.Message))
+
+			status := service.DeliveryStatusNames[h.DeliveryStatus]
+			if status == "" {
+				status = "未推送"
+			}
+			detail := fmt.Sprintf("%s · 投递: %s", service.AlertTypeNames[h.AlertType], status)
+			if h.Attempts > 0 {
+				detail += fmt.Sprintf(" · 发送%d次", h.Attempts)
+			}
+			if h.DeliveryError != "" {
+				detail += " · " + h.DeliveryError
+			}
+			box.Objects[1].(*widget.Label).SetText(detail)
+		},
+	)
+
+	d := dialog.NewCustom("提醒历史", "关闭", list, u.window)
+	d.Resize(fyne.NewSize(640, 480))
+	d.Show()
+}
+
+// channelSummary 渠道配置摘要
+func channelSummary(ch model.NotifyChannel) string {
+	switch ch.Type {
+	case service.ChannelEmail:
+		return fmt.Sprintf("邮件 → %s", ch.To)
+	case service.ChannelWebhook:
+		return fmt.Sprintf("%s Webhook", service.WebhookFormatNames[ch.WebhookFormat])
+	case service.ChannelLog:
+		if ch.LogPath != "" {
+			return "日志 " + ch.LogPath
+		}
+		return "日志文件"
+	}
+	return service.ChannelTypeNames[ch.Type]
+}
+
+// setEnabled 启用或禁用输入控件
+func setEnabled(w fyne.Disableable, enabled bool) {
+	if enabled {
+		w.Enable()
+	} else {
+		w.Disable()
+	}
+}
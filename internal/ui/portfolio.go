@@ -8,6 +8,7 @@ import (
 	"jijin/internal/model"
 	"jijin/internal/service"
 	apptheme "jijin/internal/theme"
+	"jijin/internal/ui/chart"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -59,7 +60,13 @@ func (p *PortfolioUI) build() {
 		container.NewTabItemWithIcon("交易记录", theme.HistoryIcon(), txCard),
 	)
 
-	p.content = container.NewPadded(tabs)
+	// 导入
+	importBtn := widget.NewButtonWithIcon("导入", theme.FolderOpenIcon(), p.showImportDialog)
+	screenshotBtn := widget.NewButtonWithIcon("截图导入", theme.MediaPhotoIcon(), p.showScreenshotImportDialog)
+	historyBtn := widget.NewButtonWithIcon("导入记录", theme.HistoryIcon(), p.showImportHistory)
+	toolbar := container.NewHBox(layout.NewSpacer(), importBtn, screenshotBtn, historyBtn)
+
+	p.content = container.NewPadded(container.NewBorder(toolbar, nil, nil, nil, tabs))
 }
 
 // createHoldingItem 创建持仓项
@@ -105,6 +112,22 @@ func (p *PortfolioUI) createHoldingItem(h model.Holding) fyne.CanvasObject {
 	})
 	sellBtn.Importance = widget.WarningImportance
 
+	alertBtn := widget.NewButtonWithIcon("止盈止损", theme.WarningIcon(), func() {
+		p.showHoldingAlertDialog(h)
+	})
+
+	intradayBtn := widget.NewButtonWithIcon("估值走势", theme.InfoIcon(), func() {
+		showIntradayDialog(fyne.CurrentApp().Driver().AllWindows()[0], h.FundCode, h.FundName)
+	})
+
+	navBtn := widget.NewButtonWithIcon("净值走势", theme.ViewFullScreenIcon(), func() {
+		p.showNavChartDialog(h)
+	})
+
+	accountBtn := widget.NewButtonWithIcon("账户", theme.AccountIcon(), func() {
+		p.showAccountDialog(h)
+	})
+
 	deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
 		dialog.ShowConfirm("确认删除", fmt.Sprintf("确定要删除 %s 吗？", h.FundName), func(ok bool) {
 			if ok {
@@ -118,6 +141,16 @@ func (p *PortfolioUI) createHoldingItem(h model.Holding) fyne.CanvasObject {
 		nameLabel,
 		container.NewHBox(codeLabel, sharesLabel, costLabel),
 	)
+	if h.Account != "" {
+		accountLabel := widget.NewLabel("账户: " + h.Account)
+		accountLabel.Importance = widget.LowImportance
+		leftContent.Add(accountLabel)
+	}
+	if !h.NavDate.IsZero() {
+		navDateLabel := widget.NewLabel(fmt.Sprintf("净值: %.4f (%s)", h.CurrentNav, h.NavDate.Format("01-02")))
+		navDateLabel.Importance = widget.LowImportance
+		leftContent.Add(navDateLabel)
+	}
 
 	rightContent := container.NewVBox(
 		valueLabel,
@@ -125,13 +158,75 @@ func (p *PortfolioUI) createHoldingItem(h model.Holding) fyne.CanvasObject {
 	)
 
 	info := container.NewBorder(nil, nil, leftContent, rightContent)
-	buttons := container.NewHBox(layout.NewSpacer(), buyBtn, sellBtn, deleteBtn)
+	buttons := container.NewHBox(layout.NewSpacer(), accountBtn, alertBtn, navBtn, intradayBtn, buyBtn, sellBtn, deleteBtn)
 
 	content := container.NewVBox(info, buttons)
 
 	return container.NewStack(bg, container.NewPadded(content))
 }
 
+// showNavChartDialog 显示持仓基金的净值走势(日线/周K)，虚线为成本价
+func (p *PortfolioUI) showNavChartDialog(h model.Holding) {
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+	navChart := chart.NewTimeChart(640, 300)
+	if h.CostPrice > 0 {
+		navChart.SetRefLines(h.CostPrice)
+	}
+
+	var dates []time.Time
+	var navs []float64
+	modeSelect := widget.NewRadioGroup([]string{"日线", "周K"}, func(mode string) {
+		if mode == "周K" {
+			candles := chart.WeeklyOHLC(dates, navs)
+			closes := make([]float64, len(candles))
+			for i, k := range candles {
+				closes[i] = k.Close
+			}
+			navChart.SetCandles(candles,
+				chart.Series{Name: "5周均线", Values: service.GetIndicatorService().CalculateSMA(closes, 5), Color: chart.ColorOrange, Width: 1})
+			return
+		}
+		navChart.SetSeries(dates,
+			chart.Series{Name: "净值", Values: navs, Color: chart.ColorBlue, Width: 2},
+			chart.Series{Name: "MA20", Values: service.GetIndicatorService().CalculateSMA(navs, 20), Color: chart.ColorPurple, Width: 1})
+	})
+	modeSelect.Horizontal = true
+	modeSelect.Required = true
+
+	status := widget.NewLabel("加载中...")
+	header := container.NewVBox(
+		container.NewHBox(modeSelect, layout.NewSpacer(), status),
+		chart.NewRangeBar(chart.Range1Y, navChart),
+	)
+	if h.CostPrice > 0 {
+		header.Add(widget.NewLabel(fmt.Sprintf("灰线为成本价 %.4f", h.CostPrice)))
+	}
+	content := container.NewBorder(header, nil, nil, nil, navChart)
+	d := dialog.NewCustom(h.FundName+" 净值走势", "关闭", content, win)
+	d.Resize(fyne.NewSize(720, 480))
+	d.Show()
+
+	go func() {
+		histories, err := service.GetFundAPI().GetFundHistory(h.FundCode, 810)
+		if err != nil || len(histories) == 0 {
+			histories, _ = service.GetFundAPI().GetLocalFundHistory(h.FundCode, 810)
+		}
+		if len(histories) == 0 {
+			status.SetText("暂无净值数据")
+			return
+		}
+		// 按日期升序
+		dates = make([]time.Time, len(histories))
+		navs = make([]float64, len(histories))
+		for i, hist := range histories {
+			dates[len(histories)-1-i] = hist.Date
+			navs[len(histories)-1-i] = hist.NetValue
+		}
+		status.SetText(fmt.Sprintf("最新 %.4f (%s)", navs[len(navs)-1], dates[len(dates)-1].Format("2006-01-02")))
+		modeSelect.SetSelected("日线")
+	}()
+}
+
 // createTxItem 创建交易记录项
 func (p *PortfolioUI) createTxItem(tx model.Transaction) fyne.CanvasObject {
 	bg := canvas.NewRectangle(apptheme.GetCardBgColor())
@@ -181,7 +276,7 @@ func (p *PortfolioUI) showBuyDialog(h model.Holding) {
 
 	feeEntry := widget.NewEntry()
 	feeEntry.SetPlaceHolder("手续费(可选)")
-	feeEntry.SetText("0")
+	bindDefaultFee(feeEntry, false, func() float64 { return entryFloat(amountEntry) }, amountEntry)
 
 	form := widget.NewForm(
 		widget.NewFormItem("买入金额", amountEntry),
@@ -234,7 +329,7 @@ func (p *PortfolioUI) showSellDialog(h model.Holding) {
 
 	feeEntry := widget.NewEntry()
 	feeEntry.SetPlaceHolder("手续费(可选)")
-	feeEntry.SetText("0")
+	bindDefaultFee(feeEntry, true, func() float64 { return entryFloat(sharesEntry) * entryFloat(navEntry) }, sharesEntry, navEntry)
 
 	availableLabel := widget.NewLabel(fmt.Sprintf("可用份额: %.2f", h.Shares))
 	availableLabel.Importance = widget.LowImportance
@@ -283,6 +378,87 @@ func (p *PortfolioUI) showSellDialog(h model.Holding) {
 	}, win)
 }
 
+// showHoldingAlertDialog 显示持仓止盈止损设置对话框，留空表示不提醒
+func (p *PortfolioUI) showHoldingAlertDialog(h model.Holding) {
+	alertService := service.GetAlertService()
+	rules, _ := alertService.GetHoldingAlertRules(h.ID)
+	current := make(map[string]float64)
+	for _, r := range rules {
+		current[r.AlertType] = r.Threshold
+	}
+
+	placeholders := map[string]string{
+		service.AlertTypeTakeProfit:   "收益率达到(%)，如 15",
+		service.AlertTypeStopLoss:     "亏损达到(%)，如 8",
+		service.AlertTypeProfitAmount: "收益达到(元)，负数表示亏损",
+		service.AlertTypeTrailingStop: "从最高净值回撤(%)，如 5",
+		service.AlertTypeTargetValue:  "市值达到(元)",
+	}
+
+	entries := make(map[string]*widget.Entry)
+	var items []*widget.FormItem
+	for _, t := range service.HoldingAlertTypes {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder(placeholders[t])
+		if v, ok := current[t]; ok {
+			entry.SetText(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		entries[t] = entry
+		items = append(items, widget.NewFormItem(service.AlertTypeNames[t], entry))
+	}
+
+	statusLabel := widget.NewLabel(fmt.Sprintf("当前收益率 %.2f%%  收益 %.2f元  市值 %.2f元",
+		h.ProfitRate(), h.Profit(), h.MarketValue()))
+	statusLabel.Importance = widget.LowImportance
+	items = append(items, widget.NewFormItem("", statusLabel))
+
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	dialog.ShowCustomConfirm("止盈止损 - "+h.FundName, "保存", "取消", widget.NewForm(items...), func(ok bool) {
+		if !ok {
+			return
+		}
+
+		for _, t := range service.HoldingAlertTypes {
+			threshold := 0.0
+			if text := entries[t].Text; text != "" {
+				v, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("%s: 请输入有效的数字", service.AlertTypeNames[t]), win)
+					return
+				}
+				threshold = v
+			}
+			if err := alertService.SetHoldingAlert(h.ID, t, threshold); err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %v", service.AlertTypeNames[t], err), win)
+				return
+			}
+		}
+		dialog.ShowInformation("成功", "提醒已保存，每次刷新数据时检查", win)
+	}, win)
+}
+
+// showAccountDialog 设置持仓所属账户，可从已有账户中选择，留空为默认账户
+func (p *PortfolioUI) showAccountDialog(h model.Holding) {
+	portfolioService := service.GetPortfolioService()
+	entry := widget.NewSelectEntry(portfolioService.GetAccounts())
+	entry.SetPlaceHolder("如 支付宝、招商银行，留空为默认账户")
+	entry.SetText(h.Account)
+
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+	form := widget.NewForm(widget.NewFormItem("账户", entry))
+	dialog.ShowCustomConfirm("所属账户 - "+h.FundName, "保存", "取消", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := portfolioService.SetHoldingAccount(h.ID, entry.Text); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		p.Refresh()
+	}, win)
+}
+
 // Content 获取内容
 func (p *PortfolioUI) Content() fyne.CanvasObject {
 	return p.content
@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"jijin/internal/model"
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// generateReport 生成报告并显示
+func (u *ToolsUI) generateReport(period string) {
+	u.resultArea.SetText(fmt.Sprintf("正在生成%s...", service.ReportPeriodNames[period]))
+	go func() {
+		report, err := service.GetReportService().Generate(period)
+		if report == nil {
+			u.resultArea.SetText("生成报告失败: " + err.Error())
+			return
+		}
+		text := fmt.Sprintf("已生成%s %s，区间盈亏 %+.2f元 (%+.2f%%)", service.ReportPeriodNames[period],
+			report.EndDate.Format("2006-01-02"), report.PeriodProfit, report.PeriodReturn)
+		if report.FilePath != "" {
+			text += "\n保存位置: " + report.FilePath
+		}
+		if err != nil {
+			text += "\n保存文件出错: " + err.Error()
+		}
+		if report.DeliveryStatus != "" {
+			text += "\n推送: " + service.DeliveryStatusNames[report.DeliveryStatus]
+		}
+		u.resultArea.SetText(text)
+		u.showReport(report)
+	}()
+}
+
+// showReport 显示报告内容
+func (u *ToolsUI) showReport(report *model.PortfolioReport) {
+	content := widget.NewRichTextFromMarkdown(report.Markdown)
+	content.Wrapping = fyne.TextWrapWord
+	title := fmt.Sprintf("持仓%s %s", service.ReportPeriodNames[report.Period], report.EndDate.Format("2006-01-02"))
+	d := dialog.NewCustom(title, "关闭", container.NewVScroll(content), u.window)
+	d.Resize(fyne.NewSize(760, 560))
+	d.Show()
+}
+
+// showReportsDialog 显示历史报告列表
+func (u *ToolsUI) showReportsDialog() {
+	reports, err := service.GetReportService().GetReports(60)
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+	if len(reports) == 0 {
+		dialog.ShowInformation("历史报告", "暂无报告", u.window)
+		return
+	}
+
+	list := widget.NewList(
+		func() int { return len(reports) },
+		func() fyne.CanvasObject { return widget.NewLabel("报告") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			r := reports[id]
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s %s  区间盈亏 %+.2f元 (%+.2f%%)  总市值 %.2f元",
+				r.EndDate.Format("2006-01-02"), service.ReportPeriodNames[r.Period], r.PeriodProfit, r.PeriodReturn, r.TotalValue))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		u.showReport(&reports[id])
+		list.UnselectAll()
+	}
+
+	d := dialog.NewCustom("历史报告", "关闭", list, u.window)
+	d.Resize(fyne.NewSize(560, 420))
+	d.Show()
+}
+
+// showReportSettingsDialog 显示报告设置对话框
+func (u *ToolsUI) showReportSettingsDialog() {
+	settings, err := service.GetReportService().GetSettings()
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+
+	dailyCheck := widget.NewCheck("每个交易日生成日报", nil)
+	dailyCheck.SetChecked(settings.DailyEnabled)
+	weeklyCheck := widget.NewCheck("每周五生成周报", nil)
+	weeklyCheck.SetChecked(settings.WeeklyEnabled)
+	timeEntry := widget.NewEntry()
+	timeEntry.SetText(settings.GenerateAt)
+
+	formatNames := map[string]string{
+		service.ReportFormatMarkdown: "Markdown",
+		service.ReportFormatHTML:     "HTML",
+		service.ReportFormatPDF:      "PDF",
+	}
+	formats := []string{service.ReportFormatMarkdown, service.ReportFormatHTML, service.ReportFormatPDF}
+	formatGroup := widget.NewCheckGroup([]string{"Markdown", "HTML", "PDF"}, nil)
+	formatGroup.Horizontal = true
+	for _, f := range strings.Split(settings.Formats, ",") {
+		if name, ok := formatNames[strings.TrimSpace(f)]; ok {
+			formatGroup.Selected = append(formatGroup.Selected, name)
+		}
+	}
+
+	dirEntry := widget.NewEntry()
+	dirEntry.SetPlaceHolder("为空时保存到数据目录 reports")
+	dirEntry.SetText(settings.SaveDir)
+
+	channels, _ := service.GetNotifyService().GetChannels()
+	channelNames := make([]string, len(channels))
+	selected := make(map[uint]bool)
+	for _, id := range service.ChannelIDs(settings.Channels) {
+		selected[id] = true
+	}
+	channelGroup := widget.NewCheckGroup(nil, nil)
+	for i, ch := range channels {
+		channelNames[i] = ch.Name
+		if selected[ch.ID] {
+			channelGroup.Selected = append(channelGroup.Selected, ch.Name)
+		}
+	}
+	channelGroup.Options = channelNames
+
+	note := widget.NewLabel("生成时间应晚于净值公布，当天净值未公布时会稍后重试；PDF需要本机安装wkhtmltopdf或Chrome")
+	note.Wrapping = fyne.TextWrapWord
+	note.Importance = widget.LowImportance
+
+	form := dialog.NewForm("报告设置", "保存", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("", dailyCheck),
+			widget.NewFormItem("", weeklyCheck),
+			widget.NewFormItem("生成时间", timeEntry),
+			widget.NewFormItem("保存格式", formatGroup),
+			widget.NewFormItem("保存目录", dirEntry),
+			widget.NewFormItem("推送渠道", channelGroup),
+			widget.NewFormItem("", note),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			settings.DailyEnabled = dailyCheck.Checked
+			settings.WeeklyEnabled = weeklyCheck.Checked
+			settings.GenerateAt = timeEntry.Text
+			settings.SaveDir = dirEntry.Text
+
+			var chosen []string
+			for _, f := range formats {
+				for _, name := range formatGroup.Selected {
+					if formatNames[f] == name {
+						chosen = append(chosen, f)
+					}
+				}
+			}
+			settings.Formats = strings.Join(chosen, ",")
+
+			var ids []uint
+			for _, ch := range channels {
+				for _, name := range channelGroup.Selected {
+					if ch.Name == name {
+						ids = append(ids, ch.ID)
+					}
+				}
+			}
+			settings.Channels = service.JoinChannelIDs(ids)
+
+			if err := service.GetReportService().SaveSettings(settings); err != nil {
+				dialog.ShowError(err, u.window)
+			}
+		}, u.window)
+	form.Resize(fyne.NewSize(520, 480))
+	form.Show()
+}
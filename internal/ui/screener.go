@@ -0,0 +1,377 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"jijin/internal/model"
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// screenerColumns 结果表格列(标题, 字段)，字段为空表示文本列
+var screenerColumns = []struct {
+	Title string
+	Field string
+	Width float32
+}{
+	{"代码", "", 70},
+	{"名称", "", 200},
+	{"类型", "", 110},
+	{"近1年%", service.ScreenFieldReturn1Y, 75},
+	{"近3年%", service.ScreenFieldReturn3Y, 75},
+	{"近5年%", service.ScreenFieldReturn5Y, 75},
+	{"回撤%", service.ScreenFieldMaxDrawdown, 70},
+	{"夏普", service.ScreenFieldSharpe, 60},
+	{"规模亿", service.ScreenFieldScale, 75},
+	{"费率%", service.ScreenFieldFeeRate, 60},
+	{"经理", "", 90},
+	{"任职年", service.ScreenFieldManagerTenure, 65},
+}
+
+// ScreenerUI 基金筛选界面
+type ScreenerUI struct {
+	content fyne.CanvasObject
+	window  fyne.Window
+
+	// 筛选条件
+	typeSelect     *widget.Select
+	minReturn1Y    *widget.Entry
+	minReturn3Y    *widget.Entry
+	minReturn5Y    *widget.Entry
+	maxDrawdown    *widget.Entry
+	minSharpe      *widget.Entry
+	minScale       *widget.Entry
+	maxFeeRate     *widget.Entry
+	minTenure      *widget.Entry
+	sortSelect     *widget.Select
+	screenSelect   *widget.Select
+	statusLabel    *widget.Label
+	screens        []model.FundScreen
+	sortFieldNames []string
+
+	// 结果
+	resultTable *widget.Table
+	results     []model.FundMetric
+}
+
+// NewScreenerUI 创建基金筛选界面
+func NewScreenerUI() *ScreenerUI {
+	ui := &ScreenerUI{}
+	ui.build()
+	return ui
+}
+
+func (u *ScreenerUI) build() {
+	u.typeSelect = widget.NewSelect([]string{"全部类型", "股票型", "混合型", "债券型", "指数型", "QDII", "FOF"}, nil)
+	u.typeSelect.SetSelected("全部类型")
+
+	newEntry := func(placeholder string) *widget.Entry {
+		e := widget.NewEntry()
+		e.SetPlaceHolder(placeholder)
+		return e
+	}
+	u.minReturn1Y = newEntry("不限")
+	u.minReturn3Y = newEntry("不限")
+	u.minReturn5Y = newEntry("不限")
+	u.maxDrawdown = newEntry("不限")
+	u.minSharpe = newEntry("不限")
+	u.minScale = newEntry("不限")
+	u.maxFeeRate = newEntry("不限")
+	u.minTenure = newEntry("不限")
+
+	u.sortFieldNames = []string{
+		service.ScreenFieldReturn1Y, service.ScreenFieldReturn3Y, service.ScreenFieldReturn5Y,
+		service.ScreenFieldSharpe, service.ScreenFieldMaxDrawdown, service.ScreenFieldScale,
+		service.ScreenFieldManagerTenure,
+	}
+	sortOptions := make([]string, len(u.sortFieldNames))
+	for i, f := range u.sortFieldNames {
+		sortOptions[i] = service.ScreenFieldNames[f]
+	}
+	u.sortSelect = widget.NewSelect(sortOptions, nil)
+	u.sortSelect.SetSelected(sortOptions[0])
+
+	form := widget.NewForm(
+		widget.NewFormItem("基金类型", u.typeSelect),
+		widget.NewFormItem("近1年收益≥(%)", u.minReturn1Y),
+		widget.NewFormItem("近3年收益≥(%)", u.minReturn3Y),
+		widget.NewFormItem("近5年收益≥(%)", u.minReturn5Y),
+		widget.NewFormItem("最大回撤≤(%)", u.maxDrawdown),
+		widget.NewFormItem("夏普比率≥", u.minSharpe),
+		widget.NewFormItem("规模≥(亿元)", u.minScale),
+		widget.NewFormItem("管理费率≤(%)", u.maxFeeRate),
+		widget.NewFormItem("经理任职≥(年)", u.minTenure),
+		widget.NewFormItem("排序", u.sortSelect),
+	)
+
+	screenBtn := widget.NewButton("开始筛选", u.doScreen)
+	screenBtn.Importance = widget.HighImportance
+	refreshBtn := widget.NewButton("更新指标数据", u.refreshMetrics)
+	saveBtn := widget.NewButton("保存方案", u.showSaveDialog)
+	exportBtn := widget.NewButton("导出CSV", u.exportCSV)
+
+	u.screenSelect = widget.NewSelect(nil, u.loadScreen)
+	u.screenSelect.PlaceHolder = "加载已保存方案"
+
+	u.statusLabel = widget.NewLabel("先点击「更新指标数据」从排行榜拉取基金指标")
+	u.statusLabel.Wrapping = fyne.TextWrapWord
+
+	leftPanel := widget.NewCard("筛选条件", "", container.NewVBox(
+		u.screenSelect,
+		form,
+		container.NewGridWithColumns(2, screenBtn, refreshBtn),
+		container.NewGridWithColumns(2, saveBtn, exportBtn),
+		u.statusLabel,
+	))
+
+	u.resultTable = widget.NewTable(
+		func() (int, int) { return len(u.results) + 1, len(screenerColumns) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			col := screenerColumns[id.Col]
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(col.Title)
+				return
+			}
+			label.TextStyle = fyne.TextStyle{}
+			if id.Row-1 >= len(u.results) {
+				return
+			}
+			m := &u.results[id.Row-1]
+			switch {
+			case col.Field != "":
+				label.SetText(service.FormatMetric(m, col.Field))
+			case id.Col == 0:
+				label.SetText(m.FundCode)
+			case id.Col == 1:
+				label.SetText(m.FundName)
+			case id.Col == 2:
+				label.SetText(m.FundType)
+			default:
+				label.SetText(m.Manager)
+			}
+		},
+	)
+	for i, col := range screenerColumns {
+		u.resultTable.SetColumnWidth(i, col.Width)
+	}
+
+	split := container.NewHSplit(
+		container.NewVScroll(leftPanel),
+		widget.NewCard("筛选结果", "", u.resultTable),
+	)
+	split.SetOffset(0.28)
+
+	u.content = container.NewPadded(split)
+}
+
+// buildQuery 根据表单生成筛选条件
+func (u *ScreenerUI) buildQuery() (service.ScreenQuery, error) {
+	query := service.ScreenQuery{SortDesc: true, Limit: 500}
+
+	if u.typeSelect.Selected != "" && u.typeSelect.Selected != "全部类型" {
+		query.FundTypes = []string{u.typeSelect.Selected}
+	}
+
+	conditions := []struct {
+		entry *widget.Entry
+		field string
+		op    string
+	}{
+		{u.minReturn1Y, service.ScreenFieldReturn1Y, ">="},
+		{u.minReturn3Y, service.ScreenFieldReturn3Y, ">="},
+		{u.minReturn5Y, service.ScreenFieldReturn5Y, ">="},
+		{u.maxDrawdown, service.ScreenFieldMaxDrawdown, "<="},
+		{u.minSharpe, service.ScreenFieldSharpe, ">="},
+		{u.minScale, service.ScreenFieldScale, ">="},
+		{u.maxFeeRate, service.ScreenFieldFeeRate, "<="},
+		{u.minTenure, service.ScreenFieldManagerTenure, ">="},
+	}
+	for _, c := range conditions {
+		text := strings.TrimSpace(c.entry.Text)
+		if text == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return query, fmt.Errorf("%s 请输入数字", service.ScreenFieldNames[c.field])
+		}
+		query = query.Where(c.field, c.op, value)
+	}
+
+	if idx := u.sortSelect.SelectedIndex(); idx >= 0 {
+		query.SortField = u.sortFieldNames[idx]
+		// 回撤越小越好
+		query.SortDesc = query.SortField != service.ScreenFieldMaxDrawdown
+	}
+
+	return query, nil
+}
+
+// doScreen 执行筛选
+func (u *ScreenerUI) doScreen() {
+	query, err := u.buildQuery()
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+
+	results, err := service.GetScreenerService().Screen(query)
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+
+	u.results = results
+	u.resultTable.Refresh()
+	u.statusLabel.SetText(fmt.Sprintf("共筛选出 %d 只基金", len(results)))
+}
+
+// refreshMetrics 从排行榜更新指标数据
+func (u *ScreenerUI) refreshMetrics() {
+	u.statusLabel.SetText("正在更新指标数据...")
+
+	go func() {
+		updated, err := service.GetScreenerService().RefreshMetrics(200, func(done, total int) {
+			u.statusLabel.SetText(fmt.Sprintf("正在更新指标数据 %d/%d", done, total))
+		})
+		if err != nil {
+			u.statusLabel.SetText("更新失败: " + err.Error())
+			return
+		}
+		u.statusLabel.SetText(fmt.Sprintf("已更新 %d 只基金的指标", updated))
+		u.doScreen()
+	}()
+}
+
+// showSaveDialog 保存当前筛选方案
+func (u *ScreenerUI) showSaveDialog() {
+	query, err := u.buildQuery()
+	if err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("方案名称")
+
+	dialog.ShowForm("保存筛选方案", "保存", "取消",
+		[]*widget.FormItem{widget.NewFormItem("名称", nameEntry)},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if _, err := service.GetScreenerService().SaveScreen(nameEntry.Text, query); err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			u.Refresh()
+		}, u.window)
+}
+
+// loadScreen 加载保存的筛选方案到表单
+func (u *ScreenerUI) loadScreen(name string) {
+	for i := range u.screens {
+		if u.screens[i].Name != name {
+			continue
+		}
+		query, err := service.GetScreenerService().ParseScreenQuery(&u.screens[i])
+		if err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		u.applyQuery(query)
+		u.doScreen()
+		return
+	}
+}
+
+// applyQuery 将筛选条件填入表单
+func (u *ScreenerUI) applyQuery(query service.ScreenQuery) {
+	u.typeSelect.SetSelected("全部类型")
+	if len(query.FundTypes) > 0 {
+		u.typeSelect.SetSelected(query.FundTypes[0])
+	}
+
+	entries := map[string]*widget.Entry{
+		service.ScreenFieldReturn1Y:      u.minReturn1Y,
+		service.ScreenFieldReturn3Y:      u.minReturn3Y,
+		service.ScreenFieldReturn5Y:      u.minReturn5Y,
+		service.ScreenFieldMaxDrawdown:   u.maxDrawdown,
+		service.ScreenFieldSharpe:        u.minSharpe,
+		service.ScreenFieldScale:         u.minScale,
+		service.ScreenFieldFeeRate:       u.maxFeeRate,
+		service.ScreenFieldManagerTenure: u.minTenure,
+	}
+	for _, e := range entries {
+		e.SetText("")
+	}
+	for _, c := range query.Conditions {
+		if e, ok := entries[c.Field]; ok {
+			e.SetText(strconv.FormatFloat(c.Value, 'f', -1, 64))
+		}
+	}
+
+	for i, f := range u.sortFieldNames {
+		if f == query.SortField {
+			u.sortSelect.SetSelectedIndex(i)
+		}
+	}
+}
+
+// exportCSV 导出筛选结果
+func (u *ScreenerUI) exportCSV() {
+	if len(u.results) == 0 {
+		dialog.ShowInformation("提示", "没有可导出的筛选结果", u.window)
+		return
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		path := writer.URI().Path()
+		writer.Close()
+
+		if err := service.GetScreenerService().ExportCSV(u.results, path); err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		dialog.ShowInformation("成功", "已导出到 "+path, u.window)
+	}, u.window)
+	save.SetFileName("基金筛选结果.csv")
+	save.Show()
+}
+
+// Content 返回界面内容
+func (u *ScreenerUI) Content() fyne.CanvasObject {
+	return u.content
+}
+
+// SetWindow 设置窗口引用
+func (u *ScreenerUI) SetWindow(w fyne.Window) {
+	u.window = w
+}
+
+// Refresh 刷新界面
+func (u *ScreenerUI) Refresh() {
+	u.screens, _ = service.GetScreenerService().GetScreens()
+	names := make([]string, len(u.screens))
+	for i, s := range u.screens {
+		names[i] = s.Name
+	}
+	u.screenSelect.Options = names
+	u.screenSelect.Refresh()
+}
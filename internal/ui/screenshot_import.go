@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// screenshotApps 截图来源
+var screenshotApps = []string{"支付宝", "天天基金", "蛋卷基金", "银行APP", "其他"}
+
+// screenshotRow 确认表格中可编辑的一行
+type screenshotRow struct {
+	include *widget.Check
+	name    *widget.Entry
+	code    *widget.Entry
+	amount  *widget.Entry
+	profit  *widget.Entry
+	matched *widget.Label
+}
+
+// showScreenshotImportDialog 选择持仓页截图，本地OCR识别后进入确认
+func (p *PortfolioUI) showScreenshotImportDialog() {
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+	ss := service.GetScreenshotService()
+
+	appSelect := widget.NewSelect(screenshotApps, nil)
+	appSelect.SetSelected(screenshotApps[0])
+
+	ocrEntry := widget.NewEntry()
+	ocrEntry.SetText(ss.OCRCommand())
+	ocrEntry.SetPlaceHolder("tesseract {image} stdout -l chi_sim+eng --psm 6")
+
+	help := widget.NewLabel("截取APP的持仓页面，识别基金名称、持有金额和持有收益，确认后创建持仓。\n" +
+		"识别在本地完成，需要安装Tesseract(含chi_sim语言包)或填写其他OCR命令，{image}为截图路径，结果输出到标准输出。")
+	help.Wrapping = fyne.TextWrapWord
+
+	var d dialog.Dialog
+	chooseBtn := widget.NewButton("选择截图...", func() {
+		ss.SetOCRCommand(ocrEntry.Text)
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+			d.Hide()
+
+			progress := dialog.NewCustomWithoutButtons("截图导入", container.NewVBox(
+				widget.NewLabel("正在识别截图并匹配基金..."), widget.NewProgressBarInfinite()), win)
+			progress.Show()
+			go func() {
+				holdings, text, err := ss.Recognize(path)
+				progress.Hide()
+				if err != nil {
+					dialog.ShowError(err, win)
+					return
+				}
+				p.showScreenshotConfirm(appSelect.Selected, holdings, text)
+			}()
+		}, win)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".png", ".jpg", ".jpeg", ".bmp", ".webp"}))
+		open.Show()
+	})
+	chooseBtn.Importance = widget.HighImportance
+
+	content := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("来源", appSelect),
+			widget.NewFormItem("OCR命令", ocrEntry),
+		),
+		help,
+		chooseBtn,
+	)
+	d = dialog.NewCustom("截图导入持仓", "取消", content, win)
+	d.Resize(fyne.NewSize(560, 0))
+	d.Show()
+}
+
+// showScreenshotConfirm 可编辑的识别结果，修改代码后重新匹配名称，确认后进入导入预览
+func (p *PortfolioUI) showScreenshotConfirm(app string, holdings []service.ScreenshotHolding, text string) {
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	grid := container.NewGridWithColumns(6,
+		widget.NewLabelWithStyle("导入", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("识别名称", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("代码", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("匹配基金", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("持有金额", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("持有收益", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+
+	var rows []*screenshotRow
+	addRow := func(h service.ScreenshotHolding) {
+		r := &screenshotRow{
+			include: widget.NewCheck("", nil),
+			name:    widget.NewEntry(),
+			code:    widget.NewEntry(),
+			amount:  widget.NewEntry(),
+			profit:  widget.NewEntry(),
+			matched: widget.NewLabel(""),
+		}
+		r.include.SetChecked(h.FundCode != "")
+		r.name.SetText(h.FundName)
+		r.code.SetText(h.FundCode)
+		r.matched.Truncation = fyne.TextTruncateEllipsis
+		setMatched := func(h service.ScreenshotHolding) {
+			switch {
+			case h.MatchedName == "":
+				r.matched.Importance = widget.DangerImportance
+				r.matched.SetText("未匹配，请填写代码")
+			case h.Exact:
+				r.matched.Importance = widget.MediumImportance
+				r.matched.SetText(h.MatchedName)
+			default:
+				r.matched.Importance = widget.WarningImportance
+				r.matched.SetText(h.MatchedName + "(请核对)")
+			}
+		}
+		setMatched(h)
+		if h.Amount != 0 {
+			r.amount.SetText(strconv.FormatFloat(h.Amount, 'f', 2, 64))
+		}
+		r.profit.SetText(strconv.FormatFloat(h.Profit, 'f', 2, 64))
+		r.code.OnSubmitted = func(code string) {
+			m := service.ScreenshotHolding{FundName: r.name.Text, FundCode: strings.TrimSpace(code)}
+			service.GetScreenshotService().MatchFund(&m)
+			m.Exact = m.FundCode == strings.TrimSpace(code)
+			setMatched(m)
+			if m.FundCode != "" {
+				r.include.SetChecked(true)
+			}
+		}
+		rows = append(rows, r)
+		grid.Add(r.include)
+		grid.Add(r.name)
+		grid.Add(r.code)
+		grid.Add(r.matched)
+		grid.Add(r.amount)
+		grid.Add(r.profit)
+	}
+	for _, h := range holdings {
+		addRow(h)
+	}
+
+	addBtn := widget.NewButton("添加一行", func() {
+		addRow(service.ScreenshotHolding{})
+		grid.Refresh()
+	})
+
+	summary := widget.NewLabel(fmt.Sprintf("来源: %s  识别到%d只基金。请核对名称、金额和收益，修改代码后回车重新匹配。", app, len(holdings)))
+	summary.Wrapping = fyne.TextWrapWord
+
+	raw := widget.NewMultiLineEntry()
+	raw.SetText(text)
+	raw.Wrapping = fyne.TextWrapWord
+	raw.SetMinRowsVisible(8)
+	rawAccordion := widget.NewAccordion(widget.NewAccordionItem("识别原文", raw))
+
+	body := container.NewVScroll(container.NewVBox(grid, addBtn, rawAccordion))
+	content := container.NewBorder(summary, nil, nil, nil, body)
+
+	d := dialog.NewCustomConfirm("确认识别结果", "下一步", "取消", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		var selected []service.ScreenshotHolding
+		for _, r := range rows {
+			if !r.include.Checked {
+				continue
+			}
+			amount, _ := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(r.amount.Text), ",", ""), 64)
+			profit, _ := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(r.profit.Text), ",", ""), 64)
+			h := service.ScreenshotHolding{
+				FundName: strings.TrimSpace(r.name.Text),
+				FundCode: strings.TrimSpace(r.code.Text),
+				Amount:   amount,
+				Profit:   profit,
+			}
+			if name := strings.TrimSuffix(r.matched.Text, "(请核对)"); r.matched.Importance != widget.DangerImportance {
+				h.MatchedName = name
+			}
+			selected = append(selected, h)
+		}
+		if len(selected) == 0 {
+			dialog.ShowInformation("截图导入", "没有选中要导入的基金", win)
+			return
+		}
+		go func() {
+			preview := service.GetScreenshotService().PrepareScreenshotImport(app, selected)
+			p.showImportPreview(preview)
+		}()
+	}, win)
+	d.Resize(fyne.NewSize(1000, 560))
+	d.Show()
+}
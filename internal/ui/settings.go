@@ -0,0 +1,322 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SettingsUI 设置页
+type SettingsUI struct {
+	content fyne.CanvasObject
+	window  fyne.Window
+
+	// 数据
+	dataDirEntry *widget.Entry
+	migrateCheck *widget.Check
+	dataDirLabel *widget.Label
+
+	// 设置项
+	refreshEntry  *widget.Entry
+	policySelect  *widget.Select
+	monitorEntry  *widget.Entry
+	riskFreeEntry *widget.Entry
+	mcEntry       *widget.Entry
+	buyFeeEntry   *widget.Entry
+	sellFeeEntry  *widget.Entry
+	themeSelect   *widget.Select
+	proxyEntry    *widget.Entry
+	channelsLabel *widget.Label
+	parseLabel    *widget.Label
+
+	onManageChannels func()
+}
+
+// NewSettingsUI 创建设置页，onManageChannels打开通知渠道管理
+func NewSettingsUI(onManageChannels func()) *SettingsUI {
+	u := &SettingsUI{onManageChannels: onManageChannels}
+	u.build()
+	return u
+}
+
+// build 构建UI
+func (u *SettingsUI) build() {
+	// 数据目录
+	u.dataDirLabel = widget.NewLabel("")
+	u.dataDirLabel.Wrapping = fyne.TextWrapBreak
+	u.dataDirEntry = widget.NewEntry()
+	u.dataDirEntry.SetPlaceHolder("为空时使用默认目录 ~/.jijin")
+	browseBtn := widget.NewButton("浏览...", func() {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err == nil && dir != nil {
+				u.dataDirEntry.SetText(dir.Path())
+			}
+		}, u.window)
+	})
+	u.migrateCheck = widget.NewCheck("将当前数据复制到新目录", nil)
+	u.migrateCheck.SetChecked(true)
+	dataBtn := widget.NewButton("修改数据目录", u.changeDataDir)
+	dataCard := widget.NewCard("数据目录", "数据库、报告、备份和日志的保存位置，修改后重启生效",
+		container.NewVBox(
+			u.dataDirLabel,
+			container.NewBorder(nil, nil, nil, browseBtn, u.dataDirEntry),
+			container.NewHBox(u.migrateCheck, dataBtn),
+		))
+
+	// 刷新与监控
+	u.refreshEntry = widget.NewEntry()
+	policyOptions := make([]string, len(service.RefreshPolicies))
+	for i, p := range service.RefreshPolicies {
+		policyOptions[i] = service.RefreshPolicyNames[p]
+	}
+	u.policySelect = widget.NewSelect(policyOptions, nil)
+	u.monitorEntry = widget.NewEntry()
+	refreshCard := widget.NewCard("刷新与监控", "", widget.NewForm(
+		widget.NewFormItem("自动刷新间隔(分钟)", u.refreshEntry),
+		widget.NewFormItem("自动刷新时段", u.policySelect),
+		widget.NewFormItem("提醒检查间隔(分钟)", u.monitorEntry),
+	))
+
+	// 计算参数
+	u.riskFreeEntry = widget.NewEntry()
+	u.mcEntry = widget.NewEntry()
+	u.buyFeeEntry = widget.NewEntry()
+	u.sellFeeEntry = widget.NewEntry()
+	calcCard := widget.NewCard("计算参数", "默认费率用于买入、卖出时预填手续费", widget.NewForm(
+		widget.NewFormItem("无风险利率(%)", u.riskFreeEntry),
+		widget.NewFormItem("蒙特卡洛模拟次数", u.mcEntry),
+		widget.NewFormItem("默认申购费率(%)", u.buyFeeEntry),
+		widget.NewFormItem("默认赎回费率(%)", u.sellFeeEntry),
+	))
+
+	// 外观与网络
+	themeOptions := make([]string, len(service.Themes))
+	for i, t := range service.Themes {
+		themeOptions[i] = service.ThemeNames[t]
+	}
+	u.themeSelect = widget.NewSelect(themeOptions, nil)
+	u.proxyEntry = widget.NewEntry()
+	u.proxyEntry.SetPlaceHolder("如 http://127.0.0.1:7890，为空不使用代理")
+	otherCard := widget.NewCard("外观与网络", "", widget.NewForm(
+		widget.NewFormItem("主题", u.themeSelect),
+		widget.NewFormItem("网络代理", u.proxyEntry),
+	))
+
+	// 通知渠道
+	u.channelsLabel = widget.NewLabel("")
+	notifyCard := widget.NewCard("通知渠道", "提醒和报告的推送渠道",
+		container.NewBorder(nil, nil, nil, widget.NewButton("管理通知渠道", func() {
+			if u.onManageChannels != nil {
+				u.onManageChannels()
+			}
+		}), u.channelsLabel))
+
+	// 数据源解析状态
+	u.parseLabel = widget.NewLabel("")
+	u.parseLabel.Wrapping = fyne.TextWrapWord
+	parseCard := widget.NewCard("数据源状态", "接口返回格式变化时解析失败计数会增加，异常数据不会写入数据库",
+		container.NewVBox(
+			u.parseLabel,
+			container.NewHBox(
+				widget.NewButton("刷新", u.refreshParseStats),
+				widget.NewButton("清零", func() {
+					service.GetFundAPI().ResetParseStats()
+					u.refreshParseStats()
+				}),
+			),
+		))
+
+	saveBtn := widget.NewButton("保存设置", u.save)
+	saveBtn.Importance = widget.HighImportance
+	resetBtn := widget.NewButton("撤销修改", u.Refresh)
+
+	u.content = container.NewBorder(nil,
+		container.NewHBox(saveBtn, resetBtn),
+		nil, nil,
+		container.NewVScroll(container.NewVBox(dataCard, refreshCard, calcCard, otherCard, notifyCard, parseCard)),
+	)
+}
+
+// Content 获取内容
+func (u *SettingsUI) Content() fyne.CanvasObject {
+	return u.content
+}
+
+// SetWindow 设置窗口引用
+func (u *SettingsUI) SetWindow(w fyne.Window) {
+	u.window = w
+}
+
+// Refresh 按当前设置填充表单
+func (u *SettingsUI) Refresh() {
+	ss := service.GetSettingsService()
+	settings := ss.Get()
+
+	current, latest := ss.SchemaVersion()
+	u.dataDirLabel.SetText(fmt.Sprintf("当前数据目录: %s\n数据结构版本: %d(最新%d)", ss.DataDir(), current, latest))
+	u.dataDirEntry.SetText(ss.DataDir())
+
+	u.refreshEntry.SetText(strconv.Itoa(settings.RefreshMinutes))
+	u.policySelect.SetSelected(service.RefreshPolicyNames[settings.RefreshPolicy])
+	u.monitorEntry.SetText(strconv.Itoa(settings.MonitorMinutes))
+	u.riskFreeEntry.SetText(strconv.FormatFloat(settings.RiskFreeRate, 'f', -1, 64))
+	u.mcEntry.SetText(strconv.Itoa(settings.MCSimulations))
+	u.buyFeeEntry.SetText(strconv.FormatFloat(settings.BuyFeeRate, 'f', -1, 64))
+	u.sellFeeEntry.SetText(strconv.FormatFloat(settings.SellFeeRate, 'f', -1, 64))
+	u.themeSelect.SetSelected(service.ThemeNames[settings.Theme])
+	u.proxyEntry.SetText(settings.ProxyURL)
+
+	channels, _ := service.GetNotifyService().GetChannels()
+	names := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		if ch.Enabled {
+			names = append(names, ch.Name)
+		}
+	}
+	if len(names) == 0 {
+		u.channelsLabel.SetText("未启用通知渠道，提醒仅在应用内显示")
+	} else {
+		u.channelsLabel.SetText("已启用: " + strings.Join(names, "、"))
+	}
+
+	u.refreshParseStats()
+}
+
+// refreshParseStats 显示各数据接口的解析统计
+func (u *SettingsUI) refreshParseStats() {
+	var lines []string
+	for _, s := range service.GetFundAPI().ParseStats() {
+		name := service.EndpointNames[s.Endpoint]
+		if s.Responses == 0 && s.Rejected == 0 {
+			lines = append(lines, name+": 本次运行未请求")
+			continue
+		}
+		line := fmt.Sprintf("%s: 解析%d次 失败%d次 跳过%d行 拒绝%d条", name, s.Responses, s.Failures, s.BadRows, s.Rejected)
+		if s.Drifting() {
+			line = fmt.Sprintf("%s，连续失败%d次，接口格式可能已变化", line, s.Consecutive)
+		}
+		if s.LastError != "" {
+			line += fmt.Sprintf("\n    %s %s", s.LastErrorAt.Format("01-02 15:04"), s.LastError)
+		}
+		lines = append(lines, line)
+	}
+	u.parseLabel.SetText(strings.Join(lines, "\n"))
+}
+
+// save 校验并保存设置
+func (u *SettingsUI) save() {
+	settings := service.GetSettingsService().Get()
+
+	ints := []struct {
+		label  string
+		entry  *widget.Entry
+		target *int
+	}{
+		{"自动刷新间隔", u.refreshEntry, &settings.RefreshMinutes},
+		{"提醒检查间隔", u.monitorEntry, &settings.MonitorMinutes},
+		{"模拟次数", u.mcEntry, &settings.MCSimulations},
+	}
+	for _, f := range ints {
+		v, err := strconv.Atoi(strings.TrimSpace(f.entry.Text))
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s格式错误: %s", f.label, f.entry.Text), u.window)
+			return
+		}
+		*f.target = v
+	}
+	floats := []struct {
+		label  string
+		entry  *widget.Entry
+		target *float64
+	}{
+		{"无风险利率", u.riskFreeEntry, &settings.RiskFreeRate},
+		{"申购费率", u.buyFeeEntry, &settings.BuyFeeRate},
+		{"赎回费率", u.sellFeeEntry, &settings.SellFeeRate},
+	}
+	for _, f := range floats {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f.entry.Text), 64)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s格式错误: %s", f.label, f.entry.Text), u.window)
+			return
+		}
+		*f.target = v
+	}
+	for _, p := range service.RefreshPolicies {
+		if service.RefreshPolicyNames[p] == u.policySelect.Selected {
+			settings.RefreshPolicy = p
+		}
+	}
+	for _, t := range service.Themes {
+		if service.ThemeNames[t] == u.themeSelect.Selected {
+			settings.Theme = t
+		}
+	}
+	settings.ProxyURL = strings.TrimSpace(u.proxyEntry.Text)
+
+	if err := service.GetSettingsService().Save(&settings); err != nil {
+		dialog.ShowError(err, u.window)
+		return
+	}
+	dialog.ShowInformation("设置", "设置已保存", u.window)
+}
+
+// changeDataDir 修改数据目录，重启后生效
+func (u *SettingsUI) changeDataDir() {
+	ss := service.GetSettingsService()
+	dir := strings.TrimSpace(u.dataDirEntry.Text)
+	if dir == ss.DataDir() {
+		return
+	}
+	msg := "数据目录修改后需要重启应用才能生效。"
+	if u.migrateCheck.Checked {
+		msg += "\n当前数据将复制到新目录，原目录中的数据保留不变。"
+	} else {
+		msg += "\n不复制数据时，重启后将使用新目录中的数据(没有则为空)。"
+	}
+	dialog.ShowConfirm("修改数据目录", msg, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := ss.ChangeDataDir(dir, u.migrateCheck.Checked); err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+		dialog.ShowInformation("修改数据目录", "已保存，重启应用后生效", u.window)
+	}, u.window)
+}
+
+// bindDefaultFee 按设置的默认费率随金额预填手续费，手动修改过手续费后不再覆盖。
+// inputs变化时用amount计算交易金额，sell为赎回
+func bindDefaultFee(feeEntry *widget.Entry, sell bool, amount func() float64, inputs ...*widget.Entry) {
+	auto := feeEntry.Text
+	edited := false
+	feeEntry.OnChanged = func(text string) {
+		if text != auto {
+			edited = true
+		}
+	}
+	update := func(string) {
+		if edited {
+			return
+		}
+		auto = strconv.FormatFloat(service.GetSettingsService().DefaultFee(amount(), sell), 'f', 2, 64)
+		feeEntry.SetText(auto)
+	}
+	for _, e := range inputs {
+		e.OnChanged = update
+	}
+	update("")
+}
+
+// entryFloat 输入框数值，无效时为0
+func entryFloat(e *widget.Entry) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(e.Text), 64)
+	return v
+}
@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"jijin/internal/model"
+	"jijin/internal/service"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showSignalStrategiesDialog 显示信号策略管理对话框
+func (u *ToolsUI) showSignalStrategiesDialog() {
+	var strategies []model.SignalStrategy
+	load := func() {
+		strategies, _ = service.GetSignalService().GetSignalStrategies()
+	}
+	load()
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(strategies) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil,
+				container.NewHBox(
+					widget.NewButton("编辑", nil),
+					widget.NewButton("删除", nil),
+				),
+				widget.NewLabel("策略"),
+			)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(strategies) {
+				return
+			}
+			st := strategies[id]
+			box := obj.(*fyne.Container)
+			label := box.Objects[0].(*widget.Label)
+			buttons := box.Objects[1].(*fyne.Container)
+
+			text := fmt.Sprintf("%s  权重 MACD%.1f KDJ%.1f 布林%.1f 均线%.1f RSI%.1f  买≥%.0f 卖≤-%.0f",
+				st.Name, st.WeightMACD, st.WeightKDJ, st.WeightBoll, st.WeightMA, st.WeightRSI,
+				st.BuyThreshold, st.SellThreshold)
+			if st.IsDefault {
+				text += " · 默认"
+			}
+			label.SetText(text)
+			buttons.Objects[0].(*widget.Button).OnTapped = func() {
+				u.showSignalStrategyForm(&st, func() {
+					load()
+					list.Refresh()
+				})
+			}
+			buttons.Objects[1].(*widget.Button).OnTapped = func() {
+				dialog.ShowConfirm("删除策略", "确定删除信号策略 "+st.Name+"？", func(ok bool) {
+					if !ok {
+						return
+					}
+					service.GetSignalService().DeleteSignalStrategy(st.ID)
+					load()
+					list.Refresh()
+				}, u.window)
+			}
+		},
+	)
+
+	addBtn := widget.NewButton("添加策略", func() {
+		st := service.DefaultSignalStrategy()
+		st.Name = ""
+		u.showSignalStrategyForm(st, func() {
+			load()
+			list.Refresh()
+		})
+	})
+	note := widget.NewLabel("各指标投票(-1~1)按权重加权得到综合评分(-100~100)；目标价和止损价按近14日平均波动的倍数计算。未设置默认策略时使用内置等权策略")
+	note.Wrapping = fyne.TextWrapWord
+	note.Importance = widget.LowImportance
+
+	d := dialog.NewCustom("信号策略", "关闭",
+		container.NewBorder(container.NewVBox(addBtn, note), nil, nil, nil, list), u.window)
+	d.Resize(fyne.NewSize(700, 460))
+	d.Show()
+}
+
+// showSignalStrategyForm 显示添加/编辑信号策略表单
+func (u *ToolsUI) showSignalStrategyForm(strategy *model.SignalStrategy, onSaved func()) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(strategy.Name)
+
+	numEntry := func(v float64) *widget.Entry {
+		e := widget.NewEntry()
+		e.SetText(strconv.FormatFloat(v, 'f', -1, 64))
+		return e
+	}
+	macdEntry := numEntry(strategy.WeightMACD)
+	kdjEntry := numEntry(strategy.WeightKDJ)
+	bollEntry := numEntry(strategy.WeightBoll)
+	maEntry := numEntry(strategy.WeightMA)
+	rsiEntry := numEntry(strategy.WeightRSI)
+	buyEntry := numEntry(strategy.BuyThreshold)
+	sellEntry := numEntry(strategy.SellThreshold)
+	targetEntry := numEntry(strategy.TargetMult)
+	stopEntry := numEntry(strategy.StopMult)
+	defaultCheck := widget.NewCheck("设为默认策略", nil)
+	defaultCheck.SetChecked(strategy.IsDefault)
+
+	form := dialog.NewForm("信号策略", "保存", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("名称", nameEntry),
+			widget.NewFormItem("MACD权重", macdEntry),
+			widget.NewFormItem("KDJ权重", kdjEntry),
+			widget.NewFormItem("布林权重", bollEntry),
+			widget.NewFormItem("均线权重", maEntry),
+			widget.NewFormItem("RSI权重", rsiEntry),
+			widget.NewFormItem("买入阈值", buyEntry),
+			widget.NewFormItem("卖出阈值", sellEntry),
+			widget.NewFormItem("目标倍数", targetEntry),
+			widget.NewFormItem("止损倍数", stopEntry),
+			widget.NewFormItem("", defaultCheck),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			st := *strategy
+			st.Name = nameEntry.Text
+			st.WeightMACD, _ = strconv.ParseFloat(macdEntry.Text, 64)
+			st.WeightKDJ, _ = strconv.ParseFloat(kdjEntry.Text, 64)
+			st.WeightBoll, _ = strconv.ParseFloat(bollEntry.Text, 64)
+			st.WeightMA, _ = strconv.ParseFloat(maEntry.Text, 64)
+			st.WeightRSI, _ = strconv.ParseFloat(rsiEntry.Text, 64)
+			st.BuyThreshold, _ = strconv.ParseFloat(buyEntry.Text, 64)
+			st.SellThreshold, _ = strconv.ParseFloat(sellEntry.Text, 64)
+			st.TargetMult, _ = strconv.ParseFloat(targetEntry.Text, 64)
+			st.StopMult, _ = strconv.ParseFloat(stopEntry.Text, 64)
+			st.IsDefault = defaultCheck.Checked
+			if err := service.GetSignalService().SaveSignalStrategy(&st); err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			onSaved()
+		}, u.window)
+	form.Resize(fyne.NewSize(420, 560))
+	form.Show()
+}
+
+// showSignalPerformanceDialog 统计历史信号或策略回测的命中率
+func (u *ToolsUI) showSignalPerformanceDialog() {
+	sources := []string{"已保存的信号", "默认策略回测持仓"}
+	sourceSelect := widget.NewSelect(sources, nil)
+	sourceSelect.SetSelectedIndex(0)
+	horizonEntry := widget.NewEntry()
+	horizonEntry.SetText(strconv.Itoa(service.DefaultSignalHorizon))
+	daysEntry := widget.NewEntry()
+	daysEntry.SetText("180")
+
+	form := dialog.NewForm("信号表现", "统计", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("数据来源", sourceSelect),
+			widget.NewFormItem("观察交易日", horizonEntry),
+			widget.NewFormItem("统计最近天数", daysEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			horizon, _ := strconv.Atoi(horizonEntry.Text)
+			days, _ := strconv.Atoi(daysEntry.Text)
+
+			var perf *service.SignalPerformance
+			var err error
+			signals := service.GetSignalService()
+			if sourceSelect.SelectedIndex() == 0 {
+				perf, err = signals.EvaluatePerformance(horizon, time.Now().AddDate(0, 0, -days))
+			} else {
+				holdings, _ := service.GetPortfolioService().GetAllHoldings()
+				codes := make([]string, len(holdings))
+				for i, h := range holdings {
+					codes[i] = h.FundCode
+				}
+				perf, err = signals.Backtest(codes, horizon, signals.GetActiveSignalStrategy())
+			}
+			if err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			u.resultArea.SetText(formatSignalPerformance(sourceSelect.Selected, perf))
+		}, u.window)
+	form.Show()
+}
+
+// formatSignalPerformance 信号表现文本
+func formatSignalPerformance(source string, perf *service.SignalPerformance) string {
+	line := func(st service.SignalStats) string {
+		if st.Evaluated == 0 {
+			return fmt.Sprintf("%s: 信号%d个，均未满观察期", st.Name, st.Total)
+		}
+		return fmt.Sprintf("%s: 信号%d个(已评估%d)  命中率%.1f%%  平均收益%+.2f%%  假信号率%.1f%%",
+			st.Name, st.Total, st.Evaluated, st.HitRate, st.AvgReturn, st.FalseRate)
+	}
+
+	text := fmt.Sprintf("信号表现(%s，观察%d个交易日):\n", source, perf.Horizon)
+	if perf.Overall.Total == 0 {
+		return text + "\n暂无买卖信号"
+	}
+	text += "\n" + line(perf.Overall) + "\n\n按指标:"
+	for _, st := range perf.ByIndicator {
+		text += "\n  " + line(st)
+	}
+	text += "\n\n按基金:"
+	for _, st := range perf.ByFund {
+		text += "\n  " + line(st)
+	}
+	return text
+}
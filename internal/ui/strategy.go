@@ -1,41 +1,70 @@
 package ui
 
 import (
-	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	"jijin/internal/model"
 	"jijin/internal/service"
+	"jijin/internal/ui/chart"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
-// StrategyUI 策略配置UI
+// StrategyUI 定投策略工作台：左侧策略列表，右侧选中策略的详情、本期建议和回测
 type StrategyUI struct {
-	content *fyne.Container
+	content fyne.CanvasObject
+	window  fyne.Window
 
 	strategyList *widget.List
 	strategies   []model.Strategy
+	selected     *model.Strategy
 
-	// 策略计算结果
+	// 详情
+	titleLabel  *widget.Label
+	detailLabel *widget.Label
+	actions     *fyne.Container
+	toggleBtn   *widget.Button
+
+	// 本期建议
 	resultLabel *widget.Label
+
+	// 回测
+	startEntry     *widget.Entry
+	endEntry       *widget.Entry
+	backtestLabel  *widget.Label
+	backtestGrid   *fyne.Container
+	valueChart     *chart.TimeChart
+	amountChart    *chart.TimeChart
+	backtestLabels map[string][2]*widget.Label
 }
 
-// NewStrategyUI 创建策略配置UI
+// backtestRows 回测对照表的行
+var backtestRows = []string{"总投入", "期末市值", "总收益", "收益率", "年化收益", "投入次数", "平均成本"}
+
+// NewStrategyUI 创建策略工作台UI
 func NewStrategyUI() *StrategyUI {
 	s := &StrategyUI{}
 	s.build()
 	return s
 }
 
+// SetWindow 设置窗口引用
+func (s *StrategyUI) SetWindow(w fyne.Window) {
+	s.window = w
+}
+
 // build 构建UI
 func (s *StrategyUI) build() {
-	// 添加策略按钮
-	addBtn := widget.NewButton("新建策略", s.showAddDialog)
+	addBtn := widget.NewButtonWithIcon("新建策略", theme.ContentAddIcon(), func() {
+		s.showStrategyForm(nil)
+	})
+	addBtn.Importance = widget.HighImportance
 
 	// 策略列表
 	s.strategyList = widget.NewList(
@@ -43,14 +72,9 @@ func (s *StrategyUI) build() {
 			return len(s.strategies)
 		},
 		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewLabel("策略名称策略名称"),
-				widget.NewLabel("基金代码"),
-				widget.NewLabel("类型"),
-				widget.NewLabel("基准"),
-				widget.NewLabel("状态"),
-				widget.NewButton("计算", nil),
-				widget.NewButton("删除", nil),
+			return container.NewVBox(
+				widget.NewLabelWithStyle("策略名称策略名称", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel("基金代码 类型 频率 状态"),
 			)
 		},
 		func(id widget.ListItemID, obj fyne.CanvasObject) {
@@ -59,102 +83,217 @@ func (s *StrategyUI) build() {
 			}
 			st := s.strategies[id]
 			box := obj.(*fyne.Container)
-
 			box.Objects[0].(*widget.Label).SetText(st.Name)
-			box.Objects[1].(*widget.Label).SetText(st.FundCode)
-
-			typeText := "普通定投"
-			switch st.StrategyType {
-			case "ma_deviation":
-				typeText = "均线偏离"
-			case "valuation":
-				typeText = "估值定投"
-			case "target_value":
-				typeText = "目标市值"
-			}
-			box.Objects[2].(*widget.Label).SetText(typeText)
-			box.Objects[3].(*widget.Label).SetText(fmt.Sprintf("¥%.0f", st.BaseAmount))
-
-			status := "停用"
-			if st.Active {
-				status = "启用"
-			}
-			box.Objects[4].(*widget.Label).SetText(status)
-
-			// 计算按钮
-			calcBtn := box.Objects[5].(*widget.Button)
-			calcBtn.OnTapped = func() {
-				s.calculateStrategy(st)
-			}
-
-			// 删除按钮
-			delBtn := box.Objects[6].(*widget.Button)
-			delBtn.OnTapped = func() {
-				dialog.ShowConfirm("确认删除", "确定要删除策略 "+st.Name+" 吗？", func(ok bool) {
-					if ok {
-						service.GetStrategyService().DeleteStrategy(st.ID)
-						s.Refresh()
-					}
-				}, fyne.CurrentApp().Driver().AllWindows()[0])
-			}
+			box.Objects[1].(*widget.Label).SetText(fmt.Sprintf("%s  %s  %s  %s",
+				st.FundCode, service.StrategyTypeNames[st.StrategyType], service.FrequencyNames[st.Frequency], activeText(st.Active)))
 		},
 	)
+	s.strategyList.OnSelected = func(id widget.ListItemID) {
+		if id < len(s.strategies) {
+			st := s.strategies[id]
+			s.selectStrategy(&st)
+		}
+	}
 
-	// 结果显示
-	s.resultLabel = widget.NewLabel("选择策略并点击'计算'查看建议金额")
-	resultCard := widget.NewCard("策略计算结果", "", s.resultLabel)
-
-	// 策略说明
-	helpText := `智能定投策略说明：
-
-1. 均线偏离法
-   根据当前净值与均线的偏离程度调整定投金额
-   低于均线时加倍，高于均线时减少
+	leftPanel := container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel("我的策略"), addBtn),
+		nil, nil, nil,
+		s.strategyList,
+	)
 
-2. 估值定投
-   根据指数PE/PB估值调整定投金额
-   低估时多投，高估时少投
+	// 详情
+	s.titleLabel = widget.NewLabelWithStyle("请选择或新建策略", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	s.detailLabel = widget.NewLabel("")
+	s.detailLabel.Wrapping = fyne.TextWrapWord
+
+	editBtn := widget.NewButtonWithIcon("编辑", theme.DocumentCreateIcon(), func() {
+		s.showStrategyForm(s.selected)
+	})
+	dupBtn := widget.NewButtonWithIcon("复制", theme.ContentCopyIcon(), s.duplicateSelected)
+	s.toggleBtn = widget.NewButton("停用", s.toggleSelected)
+	deleteBtn := widget.NewButtonWithIcon("删除", theme.DeleteIcon(), s.deleteSelected)
+	suggestBtn := widget.NewButtonWithIcon("本期建议", theme.MediaPlayIcon(), s.suggestSelected)
+	suggestBtn.Importance = widget.HighImportance
+	s.actions = container.NewHBox(suggestBtn, editBtn, dupBtn, s.toggleBtn, deleteBtn)
+	s.actions.Hide()
+
+	detailCard := widget.NewCard("策略详情", "", container.NewVBox(s.titleLabel, s.detailLabel, s.actions))
+
+	s.resultLabel = widget.NewLabel("选择策略后点击'本期建议'查看建议金额")
+	resultCard := widget.NewCard("本期建议", "", s.resultLabel)
+
+	// 回测
+	s.startEntry = widget.NewEntry()
+	s.startEntry.SetText(time.Now().AddDate(-3, 0, 0).Format("2006-01-02"))
+	s.endEntry = widget.NewEntry()
+	s.endEntry.SetText(time.Now().Format("2006-01-02"))
+	backtestBtn := widget.NewButtonWithIcon("回测", theme.SearchIcon(), s.backtestSelected)
+
+	s.backtestLabel = widget.NewLabel("")
+	s.backtestLabel.Wrapping = fyne.TextWrapWord
+	s.backtestLabels = make(map[string][2]*widget.Label)
+	s.backtestGrid = container.NewGridWithColumns(3,
+		widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("本策略", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("普通定投", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+	)
+	for _, row := range backtestRows {
+		labels := [2]*widget.Label{
+			widget.NewLabelWithStyle("-", fyne.TextAlignTrailing, fyne.TextStyle{}),
+			widget.NewLabelWithStyle("-", fyne.TextAlignTrailing, fyne.TextStyle{}),
+		}
+		s.backtestLabels[row] = labels
+		s.backtestGrid.Add(widget.NewLabel(row))
+		s.backtestGrid.Add(labels[0])
+		s.backtestGrid.Add(labels[1])
+	}
+
+	s.valueChart = chart.NewTimeChart(500, 200)
+	s.valueChart.SetValueFormat("%.0f")
+	s.amountChart = chart.NewTimeChart(500, 100)
+	s.amountChart.SetValueFormat("%.0f")
+	chart.LinkViews(s.valueChart, s.amountChart)
+
+	backtestCard := widget.NewCard("历史回测", "按历史净值模拟本策略，并与同期普通定投对照", container.NewVBox(
+		container.NewBorder(nil, nil, nil, backtestBtn, container.NewGridWithColumns(2,
+			container.NewBorder(nil, nil, widget.NewLabel("开始"), nil, s.startEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("结束"), nil, s.endEntry),
+		)),
+		s.backtestLabel,
+		s.backtestGrid,
+		widget.NewLabel("市值与累计投入"),
+		s.valueChart,
+		widget.NewLabel("每期投入金额"),
+		s.amountChart,
+	))
+
+	rightPanel := container.NewVScroll(container.NewVBox(detailCard, resultCard, backtestCard))
+
+	split := container.NewHSplit(leftPanel, rightPanel)
+	split.Offset = 0.3
+	s.content = split
+}
 
-3. 目标市值法
-   设定目标持仓市值，每期补足差额
-   适合有明确目标的投资者`
+// activeText 启用状态文字
+func activeText(active bool) string {
+	if active {
+		return "启用"
+	}
+	return "停用"
+}
 
-	helpCard := widget.NewCard("策略说明", "", widget.NewLabel(helpText))
+// win 对话框使用的窗口
+func (s *StrategyUI) win() fyne.Window {
+	if s.window != nil {
+		return s.window
+	}
+	return fyne.CurrentApp().Driver().AllWindows()[0]
+}
 
-	// 布局
-	header := container.NewBorder(nil, nil, widget.NewLabel("我的策略"), addBtn)
+// selectStrategy 显示选中策略的详情并清空上次的计算结果
+func (s *StrategyUI) selectStrategy(st *model.Strategy) {
+	s.selected = st
+	if st == nil {
+		s.titleLabel.SetText("请选择或新建策略")
+		s.detailLabel.SetText("")
+		s.actions.Hide()
+		return
+	}
+	s.titleLabel.SetText(fmt.Sprintf("%s  (%s)", st.Name, activeText(st.Active)))
+	params, err := service.DecodeStrategyParams(*st)
+	paramText := describeStrategyParams(params)
+	if err != nil {
+		paramText += " (参数无效，已使用默认值，请重新编辑)"
+	}
+	s.detailLabel.SetText(fmt.Sprintf("基金: %s %s\n类型: %s  频率: %s  基准金额: ¥%.2f\n参数: %s\n下次定投: %s",
+		st.FundCode, st.FundName,
+		service.StrategyTypeNames[st.StrategyType], service.FrequencyNames[st.Frequency], st.BaseAmount,
+		paramText,
+		service.GetStrategyService().NextRunDate(*st, time.Now()).Format("2006-01-02")))
+	if st.Active {
+		s.toggleBtn.SetText("停用")
+	} else {
+		s.toggleBtn.SetText("启用")
+	}
+	s.actions.Show()
+	s.resultLabel.SetText("点击'本期建议'查看建议金额")
+	s.backtestLabel.SetText("")
+	for _, labels := range s.backtestLabels {
+		labels[0].SetText("-")
+		labels[1].SetText("-")
+	}
+	s.valueChart.SetSeries(nil)
+	s.amountChart.SetSeries(nil)
+}
 
-	s.content = container.NewBorder(
-		header,
-		container.NewVBox(resultCard, helpCard),
-		nil,
-		nil,
-		s.strategyList,
-	)
+// describeStrategyParams 参数摘要
+func describeStrategyParams(params interface{}) string {
+	switch p := params.(type) {
+	case *service.MADeviationParams:
+		return fmt.Sprintf("均线%d日，倍数%.2f~%.2f", p.MAPeriod, p.MinMultiplier, p.MaxMultiplier)
+	case *service.ValuationParams:
+		return fmt.Sprintf("PE低估%.1f/高估%.1f，当前PE %.1f，倍数%.2f~%.2f", p.LowPE, p.HighPE, p.CurrentPE, p.MinMultiplier, p.MaxMultiplier)
+	case *service.TargetValueParams:
+		return fmt.Sprintf("目标市值¥%.2f，每期增长%.2f%%", p.TargetValue, p.GrowthRate)
+	}
+	return "无"
 }
 
-// showAddDialog 显示添加策略对话框
-func (s *StrategyUI) showAddDialog() {
+// showStrategyForm 新建(st为nil)或编辑策略，参数表单随策略类型切换
+func (s *StrategyUI) showStrategyForm(st *model.Strategy) {
+	editing := st != nil
+	if !editing {
+		st = &model.Strategy{BaseAmount: 1000, Frequency: "monthly", StrategyType: service.StrategyTypeMADeviation, Active: true}
+	}
+
 	nameEntry := widget.NewEntry()
 	nameEntry.SetPlaceHolder("策略名称")
+	nameEntry.SetText(st.Name)
 
 	codeEntry := widget.NewEntry()
 	codeEntry.SetPlaceHolder("基金代码")
+	codeEntry.SetText(st.FundCode)
 
 	amountEntry := widget.NewEntry()
 	amountEntry.SetPlaceHolder("基准金额")
-	amountEntry.SetText("1000")
-
-	frequencySelect := widget.NewSelect([]string{"每日", "每周", "每月"}, nil)
-	frequencySelect.SetSelected("每月")
-
-	typeSelect := widget.NewSelect([]string{"普通定投", "均线偏离", "估值定投", "目标市值"}, nil)
-	typeSelect.SetSelected("均线偏离")
-
-	// 均线周期
-	maPeriodEntry := widget.NewEntry()
-	maPeriodEntry.SetPlaceHolder("均线周期(天)")
-	maPeriodEntry.SetText("250")
+	amountEntry.SetText(strconv.FormatFloat(st.BaseAmount, 'f', -1, 64))
+
+	frequencyOptions := make([]string, len(service.Frequencies))
+	for i, f := range service.Frequencies {
+		frequencyOptions[i] = service.FrequencyNames[f]
+	}
+	frequencySelect := widget.NewSelect(frequencyOptions, nil)
+	frequencySelect.SetSelected(service.FrequencyNames[st.Frequency])
+
+	// 参数区随类型重建
+	paramsBox := container.NewVBox()
+	var params interface{}
+	var paramFields []paramField
+	buildParams := func(strategyType string) {
+		params = service.DefaultStrategyParams(strategyType)
+		if strategyType == st.StrategyType {
+			// 无法解析的参数以默认值填充表单，保存后覆盖
+			params, _ = service.DecodeStrategyParams(*st)
+		}
+		var form *widget.Form
+		form, paramFields = strategyParamForm(params)
+		paramsBox.RemoveAll()
+		if form != nil {
+			paramsBox.Add(form)
+		} else {
+			paramsBox.Add(widget.NewLabel("普通定投每期按基准金额投入，无额外参数"))
+		}
+		paramsBox.Refresh()
+	}
+
+	typeOptions := make([]string, len(service.StrategyTypes))
+	for i, t := range service.StrategyTypes {
+		typeOptions[i] = service.StrategyTypeNames[t]
+	}
+	typeSelect := widget.NewSelect(typeOptions, func(selected string) {
+		buildParams(strategyTypeOf(selected))
+	})
+	typeSelect.SetSelected(service.StrategyTypeNames[st.StrategyType])
 
 	form := widget.NewForm(
 		widget.NewFormItem("策略名称", nameEntry),
@@ -162,142 +301,273 @@ func (s *StrategyUI) showAddDialog() {
 		widget.NewFormItem("基准金额", amountEntry),
 		widget.NewFormItem("定投频率", frequencySelect),
 		widget.NewFormItem("策略类型", typeSelect),
-		widget.NewFormItem("均线周期", maPeriodEntry),
 	)
 
-	win := fyne.CurrentApp().Driver().AllWindows()[0]
-
-	dialog.ShowCustomConfirm("新建策略", "创建", "取消", form, func(ok bool) {
+	title, confirm := "新建策略", "创建"
+	if editing {
+		title, confirm = "编辑策略", "保存"
+	}
+	win := s.win()
+	d := dialog.NewCustomConfirm(title, confirm, "取消", container.NewVBox(form, widget.NewSeparator(), paramsBox), func(ok bool) {
 		if !ok {
 			return
 		}
 
-		name := nameEntry.Text
-		code := codeEntry.Text
-		if name == "" || code == "" {
-			dialog.ShowError(fmt.Errorf("请填写策略名称和基金代码"), win)
-			return
-		}
-
 		amount, err := strconv.ParseFloat(amountEntry.Text, 64)
-		if err != nil || amount <= 0 {
+		if err != nil {
 			dialog.ShowError(fmt.Errorf("请输入有效的基准金额"), win)
 			return
 		}
-
-		// 频率
-		frequency := "monthly"
-		switch frequencySelect.Selected {
-		case "每日":
-			frequency = "daily"
-		case "每周":
-			frequency = "weekly"
+		for _, f := range paramFields {
+			if err := f.apply(); err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
 		}
 
-		// 类型
-		strategyType := "normal"
-		switch typeSelect.Selected {
-		case "均线偏离":
-			strategyType = "ma_deviation"
-		case "估值定投":
-			strategyType = "valuation"
-		case "目标市值":
-			strategyType = "target_value"
+		saved := *st
+		saved.Name = nameEntry.Text
+		saved.BaseAmount = amount
+		saved.StrategyType = strategyTypeOf(typeSelect.Selected)
+		for _, f := range service.Frequencies {
+			if service.FrequencyNames[f] == frequencySelect.Selected {
+				saved.Frequency = f
+			}
+		}
+		if code := codeEntry.Text; code != saved.FundCode || saved.FundName == "" {
+			saved.FundCode = code
+			saved.FundName = lookupFundName(code)
 		}
 
-		// 参数
-		maPeriod, _ := strconv.Atoi(maPeriodEntry.Text)
-		if maPeriod <= 0 {
-			maPeriod = 250
+		if err := service.GetStrategyService().SaveStrategyWithParams(&saved, params); err != nil {
+			dialog.ShowError(err, win)
+			return
 		}
+		s.Refresh()
+		s.selectByID(saved.ID)
+	}, win)
+	d.Resize(fyne.NewSize(460, 0))
+	d.Show()
+}
 
-		params := service.MADeviationParams{
-			MAPeriod:      maPeriod,
-			MaxMultiplier: 2.0,
-			MinMultiplier: 0.5,
+// strategyTypeOf 由显示名称得到策略类型
+func strategyTypeOf(name string) string {
+	for _, t := range service.StrategyTypes {
+		if service.StrategyTypeNames[t] == name {
+			return t
 		}
+	}
+	return service.StrategyTypeNormal
+}
 
-		// 获取基金名称
-		fundName := code
-		results, _ := service.GetFundAPI().SearchFund(code)
-		for _, r := range results {
-			if r.Code == code {
-				fundName = r.Name
-				break
-			}
+// lookupFundName 按代码查询基金名称，查不到时返回代码
+func lookupFundName(code string) string {
+	results, _ := service.GetFundAPI().SearchFund(code)
+	for _, r := range results {
+		if r.Code == code {
+			return r.Name
 		}
+	}
+	return code
+}
 
-		_, err = service.GetStrategyService().CreateStrategy(name, code, fundName, frequency, strategyType, amount, params)
-		if err != nil {
-			dialog.ShowError(err, win)
+// paramField 参数表单中的一个数值输入，set将解析后的值写回参数
+type paramField struct {
+	label string
+	entry *widget.Entry
+	set   func(v float64)
+}
+
+// apply 解析输入并写回参数
+func (f paramField) apply() error {
+	v, err := strconv.ParseFloat(f.entry.Text, 64)
+	if err != nil {
+		return fmt.Errorf("%s: 请输入有效的数字", f.label)
+	}
+	f.set(v)
+	return nil
+}
+
+// strategyParamForm 按参数类型生成表单，字段直接写回params，普通定投返回nil
+func strategyParamForm(params interface{}) (*widget.Form, []paramField) {
+	var fields []paramField
+	addFloat := func(label string, v *float64) {
+		entry := widget.NewEntry()
+		entry.SetText(strconv.FormatFloat(*v, 'f', -1, 64))
+		fields = append(fields, paramField{label: label, entry: entry, set: func(x float64) { *v = x }})
+	}
+	addInt := func(label string, v *int) {
+		entry := widget.NewEntry()
+		entry.SetText(strconv.Itoa(*v))
+		fields = append(fields, paramField{label: label, entry: entry, set: func(x float64) { *v = int(x) }})
+	}
+
+	switch p := params.(type) {
+	case *service.MADeviationParams:
+		addInt("均线周期(天)", &p.MAPeriod)
+		addFloat("最大倍数", &p.MaxMultiplier)
+		addFloat("最小倍数", &p.MinMultiplier)
+	case *service.ValuationParams:
+		addFloat("低估PE", &p.LowPE)
+		addFloat("高估PE", &p.HighPE)
+		addFloat("当前PE", &p.CurrentPE)
+		addFloat("最大倍数", &p.MaxMultiplier)
+		addFloat("最小倍数", &p.MinMultiplier)
+	case *service.TargetValueParams:
+		addFloat("目标市值(元)", &p.TargetValue)
+		addFloat("每期增长(%)", &p.GrowthRate)
+	default:
+		return nil, nil
+	}
+
+	form := widget.NewForm()
+	for _, f := range fields {
+		form.Append(f.label, f.entry)
+	}
+	return form, fields
+}
+
+// selectByID 刷新后重新选中策略
+func (s *StrategyUI) selectByID(id uint) {
+	for i, st := range s.strategies {
+		if st.ID == id {
+			s.strategyList.Select(i)
+			s.selectStrategy(&s.strategies[i])
 			return
 		}
+	}
+	s.strategyList.UnselectAll()
+	s.selectStrategy(nil)
+}
 
-		dialog.ShowInformation("成功", "策略创建成功", win)
-		s.Refresh()
-	}, win)
+// duplicateSelected 复制选中策略
+func (s *StrategyUI) duplicateSelected() {
+	if s.selected == nil {
+		return
+	}
+	dup, err := service.GetStrategyService().DuplicateStrategy(s.selected.ID)
+	if err != nil {
+		dialog.ShowError(err, s.win())
+		return
+	}
+	s.Refresh()
+	s.selectByID(dup.ID)
 }
 
-// calculateStrategy 计算策略建议
-func (s *StrategyUI) calculateStrategy(st model.Strategy) {
-	s.resultLabel.SetText("计算中...")
+// toggleSelected 启用或停用选中策略
+func (s *StrategyUI) toggleSelected() {
+	if s.selected == nil {
+		return
+	}
+	id := s.selected.ID
+	if err := service.GetStrategyService().ToggleStrategy(id); err != nil {
+		dialog.ShowError(err, s.win())
+		return
+	}
+	s.Refresh()
+	s.selectByID(id)
+}
 
-	go func() {
-		var result *service.StrategyResult
-		var err error
-
-		switch st.StrategyType {
-		case "ma_deviation":
-			var params service.MADeviationParams
-			json.Unmarshal([]byte(st.Params), &params)
-			if params.MAPeriod == 0 {
-				params.MAPeriod = 250
-				params.MaxMultiplier = 2.0
-				params.MinMultiplier = 0.5
-			}
-			result, err = service.GetStrategyService().CalculateMADeviation(st.FundCode, st.BaseAmount, params)
-
-		case "valuation":
-			var params service.ValuationParams
-			json.Unmarshal([]byte(st.Params), &params)
-			if params.LowPE == 0 {
-				params.LowPE = 10
-				params.HighPE = 20
-				params.MaxMultiplier = 2.0
-				params.MinMultiplier = 0.5
-			}
-			// 这里需要获取当前PE，暂时用默认值
-			result, err = service.GetStrategyService().CalculateValuation(st.FundCode, st.BaseAmount, 15, params)
-
-		default:
-			result = &service.StrategyResult{
-				BaseAmount:    st.BaseAmount,
-				SuggestAmount: st.BaseAmount,
-				Multiplier:    1.0,
-				Reason:        "普通定投，按基准金额投入",
-			}
+// deleteSelected 删除选中策略
+func (s *StrategyUI) deleteSelected() {
+	if s.selected == nil {
+		return
+	}
+	st := *s.selected
+	dialog.ShowConfirm("确认删除", "确定要删除策略 "+st.Name+" 吗？", func(ok bool) {
+		if ok {
+			service.GetStrategyService().DeleteStrategy(st.ID)
+			s.Refresh()
+			s.selectByID(0)
 		}
+	}, s.win())
+}
 
+// suggestSelected 计算选中策略的本期建议
+func (s *StrategyUI) suggestSelected() {
+	if s.selected == nil {
+		return
+	}
+	st := *s.selected
+	s.resultLabel.SetText("计算中...")
+
+	go func() {
+		result, err := service.GetStrategyService().Suggest(st)
 		if err != nil {
 			s.resultLabel.SetText("计算失败: " + err.Error())
 			return
 		}
 
-		text := fmt.Sprintf(`策略: %s
-基金: %s
-基准金额: ¥%.2f
-建议金额: ¥%.2f
-倍数: %.2fx
-原因: %s`,
-			st.Name,
-			st.FundName,
+		s.resultLabel.SetText(fmt.Sprintf("基准金额: ¥%.2f\n建议金额: ¥%.2f\n倍数: %.2fx\n原因: %s",
 			result.BaseAmount,
 			result.SuggestAmount,
 			result.Multiplier,
 			result.Reason,
-		)
+		))
+	}()
+}
+
+// backtestSelected 回测选中策略并显示对照结果和曲线
+func (s *StrategyUI) backtestSelected() {
+	if s.selected == nil {
+		return
+	}
+	st := *s.selected
+	startDate, err := time.Parse("2006-01-02", s.startEntry.Text)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("开始日期格式错误，请使用YYYY-MM-DD"), s.win())
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", s.endEntry.Text)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("结束日期格式错误，请使用YYYY-MM-DD"), s.win())
+		return
+	}
+	s.backtestLabel.SetText("回测中...")
+
+	go func() {
+		bt, err := service.GetStrategyService().Backtest(st, startDate, endDate)
+		if err != nil {
+			s.backtestLabel.SetText("回测失败: " + err.Error())
+			return
+		}
+		if s.selected == nil || s.selected.ID != st.ID {
+			return
+		}
+		s.backtestLabel.SetText(bt.Note)
+		for i, r := range []*service.InvestmentResult{bt.Result, bt.Baseline} {
+			values := map[string]string{
+				"总投入":  fmt.Sprintf("¥%.2f", r.TotalInvest),
+				"期末市值": fmt.Sprintf("¥%.2f", r.CurrentValue),
+				"总收益":  fmt.Sprintf("¥%.2f", r.TotalProfit),
+				"收益率":  fmt.Sprintf("%.2f%%", r.ProfitRate),
+				"年化收益": fmt.Sprintf("%.2f%%", r.AnnualReturn),
+				"投入次数": fmt.Sprintf("%d次", r.InvestCount),
+				"平均成本": fmt.Sprintf("¥%.4f", r.AvgCost),
+			}
+			for _, row := range backtestRows {
+				s.backtestLabels[row][i].SetText(values[row])
+			}
+		}
 
-		s.resultLabel.SetText(text)
+		n := len(bt.Result.Points)
+		dates := make([]time.Time, n)
+		value, invest, baseValue, amounts := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+		for i, pt := range bt.Result.Points {
+			dates[i] = pt.Date
+			value[i] = pt.Value
+			invest[i] = pt.Invest
+			amounts[i] = pt.Amount
+			baseValue[i] = bt.Baseline.Points[i].Value
+		}
+		s.valueChart.SetSeries(dates,
+			chart.Series{Name: "策略市值", Values: value, Color: chart.ColorBlue, Kind: chart.KindArea, Width: 2},
+			chart.Series{Name: "策略投入", Values: invest, Color: chart.ColorOrange, Width: 1},
+			chart.Series{Name: "普通定投市值", Values: baseValue, Color: chart.ColorGray, Width: 1},
+		)
+		s.amountChart.SetSeries(dates,
+			chart.Series{Name: "本期投入", Values: amounts, Color: chart.ColorPurple, Kind: chart.KindBar},
+		)
 	}()
 }
 
@@ -310,4 +580,14 @@ func (s *StrategyUI) Content() fyne.CanvasObject {
 func (s *StrategyUI) Refresh() {
 	s.strategies, _ = service.GetStrategyService().GetAllStrategies()
 	s.strategyList.Refresh()
+	if s.selected == nil {
+		return
+	}
+	for i, st := range s.strategies {
+		if st.ID == s.selected.ID {
+			s.selected = &s.strategies[i]
+			return
+		}
+	}
+	s.selectStrategy(nil)
 }
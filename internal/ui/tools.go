@@ -3,7 +3,6 @@ package ui
 import (
 	"fmt"
 
-	"jijin/internal/repository"
 	"jijin/internal/service"
 
 	"fyne.io/fyne/v2"
@@ -44,8 +43,12 @@ func (u *ToolsUI) build() {
 	profitCard := widget.NewCard("盈利概率", "计算未来盈利概率", profitBtn)
 
 	// 波段信号卡片
-	signalBtn := widget.NewButton("生成信号", u.showSignalDialog)
-	signalCard := widget.NewCard("波段信号", "技术指标买卖信号", signalBtn)
+	signalCard := widget.NewCard("波段信号", "MACD/KDJ/布林/均线/RSI综合评分",
+		container.NewGridWithColumns(3,
+			widget.NewButton("生成信号", u.showSignalDialog),
+			widget.NewButton("信号策略", u.showSignalStrategiesDialog),
+			widget.NewButton("信号表现", u.showSignalPerformanceDialog),
+		))
 
 	// 基金扫雷卡片
 	riskBtn := widget.NewButton("风险扫描", u.scanRisk)
@@ -55,10 +58,38 @@ func (u *ToolsUI) build() {
 	rankBtn := widget.NewButton("查看排行", u.showRanking)
 	rankCard := widget.NewCard("基金排行", "持仓收益排行", rankBtn)
 
+	// 市场排行卡片
+	marketRankBtn := widget.NewButton("查看市场排行", u.showMarketRankingDialog)
+	marketRankCard := widget.NewCard("市场排行", "分类别、分周期排行及关注基金名次变化", marketRankBtn)
+
+	// QDII卡片
+	qdiiBtn := widget.NewButton("查看QDII", u.showQDIIDialog)
+	qdiiCard := widget.NewCard("QDII基金", "T+1/T+2估值及汇率、指数收益归因", qdiiBtn)
+
+	// 持仓报告卡片
+	reportCard := widget.NewCard("持仓报告", "日报/周报：盈亏、涨跌榜、提醒信号、待执行定投和风险变化",
+		container.NewGridWithColumns(2,
+			widget.NewButton("生成日报", func() { u.generateReport(service.ReportDaily) }),
+			widget.NewButton("生成周报", func() { u.generateReport(service.ReportWeekly) }),
+			widget.NewButton("历史报告", u.showReportsDialog),
+			widget.NewButton("报告设置", u.showReportSettingsDialog),
+		))
+
+	// 数据备份卡片
+	backupCard := widget.NewCard("数据备份", "导出持仓、交易、策略和提醒数据，备份与恢复全部数据",
+		container.NewGridWithColumns(2,
+			widget.NewButton("导出数据", u.showExportDialog),
+			widget.NewButton("立即备份", u.backupNow),
+			widget.NewButton("恢复备份", u.showRestoreDialog),
+			widget.NewButton("备份设置", u.showBackupSettingsDialog),
+		))
+
 	grid := container.NewGridWithColumns(2,
 		recoveryCard, profitCard,
 		signalCard, riskCard,
-		rankCard,
+		rankCard, marketRankCard,
+		qdiiCard, reportCard,
+		backupCard,
 	)
 
 	u.content = container.NewBorder(
@@ -79,7 +110,7 @@ func (u *ToolsUI) SetWindow(w fyne.Window) {
 
 // showRecoveryDialog 显示回本预测对话框
 func (u *ToolsUI) showRecoveryDialog() {
-	holdings, _ := repository.GetAllHoldings()
+	holdings, _ := service.GetPortfolioService().GetAllHoldings()
 	if len(holdings) == 0 {
 		u.resultArea.SetText("暂无持仓数据")
 		return
@@ -142,18 +173,22 @@ func (u *ToolsUI) showProfitDialog() {
 
 // showSignalDialog 显示波段信号
 func (u *ToolsUI) showSignalDialog() {
-	holdings, _ := repository.GetAllHoldings()
+	holdings, _ := service.GetPortfolioService().GetAllHoldings()
 	if len(holdings) == 0 {
 		u.resultArea.SetText("暂无持仓数据")
 		return
 	}
 
-	result := "波段信号分析:\n"
+	strategy := service.GetSignalService().GetActiveSignalStrategy()
+	result := fmt.Sprintf("波段信号分析(策略: %s):\n", strategy.Name)
 	for _, h := range holdings {
-		signal, err := service.GetSignalService().GenerateSignal(h.FundCode)
+		signal, err := service.GetSignalService().GenerateSignalWith(h.FundCode, strategy)
 		if err == nil {
 			result += fmt.Sprintf("\n%s: %s (强度%.0f%%)\n  %s",
-				h.FundName, signal.SignalType, signal.SignalStrength, signal.Reason)
+				h.FundName, service.SignalTypeNames[signal.SignalType], signal.SignalStrength, signal.Reason)
+			if signal.SignalType != service.SignalHold {
+				result += fmt.Sprintf("\n  当前%.4f  目标%.4f  止损%.4f", signal.Price, signal.TargetPrice, signal.StopLoss)
+			}
 		}
 	}
 	u.resultArea.SetText(result)
@@ -161,7 +196,7 @@ func (u *ToolsUI) showSignalDialog() {
 
 // scanRisk 风险扫描
 func (u *ToolsUI) scanRisk() {
-	holdings, _ := repository.GetAllHoldings()
+	holdings, _ := service.GetPortfolioService().GetAllHoldings()
 	if len(holdings) == 0 {
 		u.resultArea.SetText("暂无持仓数据")
 		return
@@ -193,6 +228,152 @@ func (u *ToolsUI) showRanking() {
 	u.resultArea.SetText(result)
 }
 
+// showMarketRankingDialog 显示市场排行对话框
+func (u *ToolsUI) showMarketRankingDialog() {
+	categories := []string{
+		service.RankCategoryAll, service.RankCategoryStock, service.RankCategoryMixed,
+		service.RankCategoryBond, service.RankCategoryIndex, service.RankCategoryQDII, service.RankCategoryFOF,
+	}
+	periods := []string{
+		service.RankPeriodDay, service.RankPeriodWeek, service.RankPeriodMonth, service.RankPeriod3M,
+		service.RankPeriod6M, service.RankPeriodYTD, service.RankPeriod1Y, service.RankPeriod2Y,
+		service.RankPeriod3Y, service.RankPeriodSince,
+	}
+
+	categoryOptions := make([]string, len(categories))
+	for i, c := range categories {
+		categoryOptions[i] = service.RankCategoryNames[c]
+	}
+	periodOptions := make([]string, len(periods))
+	for i, p := range periods {
+		periodOptions[i] = service.RankPeriodNames[p]
+	}
+
+	categorySelect := widget.NewSelect(categoryOptions, nil)
+	categorySelect.SetSelectedIndex(0)
+	periodSelect := widget.NewSelect(periodOptions, nil)
+	periodSelect.SetSelectedIndex(6)
+
+	form := dialog.NewForm("市场排行", "查看", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("基金类别", categorySelect),
+			widget.NewFormItem("统计周期", periodSelect),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			category := categories[categorySelect.SelectedIndex()]
+			period := periods[periodSelect.SelectedIndex()]
+			u.resultArea.SetText("正在获取排行数据...")
+			go u.showMarketRanking(category, period)
+		}, u.window)
+	form.Show()
+}
+
+// showMarketRanking 刷新并显示市场排行和持仓基金名次变化
+func (u *ToolsUI) showMarketRanking(category, period string) {
+	rankingService := service.GetRankingService()
+	items, err := rankingService.RefreshRanking(category, period, service.RankTypeGain, 20)
+	if err != nil {
+		u.resultArea.SetText("获取排行失败: " + err.Error())
+		return
+	}
+
+	result := fmt.Sprintf("%s基金%s排行:\n", service.RankCategoryNames[category], service.RankPeriodNames[period])
+	for _, item := range items {
+		result += fmt.Sprintf("\n%d. %s %s: %.2f%%%s",
+			item.Rank, item.FundCode, item.FundName, item.Value, formatRankChange(item.Change))
+	}
+
+	changes, _ := rankingService.GetWatchedRankChanges(category, period)
+	if len(changes) > 0 {
+		result += "\n\n关注基金排名:"
+		for _, c := range changes {
+			result += fmt.Sprintf("\n%s: 第%d/%d名 (%.2f%%)%s",
+				c.FundName, c.Rank, c.TotalCount, c.Value, formatRankChange(c.Change))
+		}
+	}
+	u.resultArea.SetText(result)
+}
+
+// formatRankChange 格式化名次变化
+func formatRankChange(change int) string {
+	switch {
+	case change > 0:
+		return fmt.Sprintf(" ↑%d", change)
+	case change < 0:
+		return fmt.Sprintf(" ↓%d", -change)
+	}
+	return ""
+}
+
+// showQDIIDialog 显示QDII基金选择对话框
+func (u *ToolsUI) showQDIIDialog() {
+	var options []string
+	funds, _ := service.GetQDIIService().GetAllQDIIFunds()
+	for _, f := range funds {
+		options = append(options, f.FundCode)
+	}
+
+	codeEntry := widget.NewSelectEntry(options)
+	codeEntry.SetPlaceHolder("QDII基金代码")
+
+	form := dialog.NewForm("QDII基金", "查看", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("基金代码", codeEntry),
+		},
+		func(ok bool) {
+			if !ok || codeEntry.Text == "" {
+				return
+			}
+			u.resultArea.SetText("正在计算QDII估值...")
+			go u.showQDII(codeEntry.Text)
+		}, u.window)
+	form.Show()
+}
+
+// showQDII 显示QDII估值和收益归因
+func (u *ToolsUI) showQDII(fundCode string) {
+	qdiiService := service.GetQDIIService()
+	qdii, err := qdiiService.RefreshQDII(fundCode)
+	if err != nil {
+		u.resultArea.SetText("获取QDII数据失败: " + err.Error())
+		return
+	}
+
+	result := fmt.Sprintf("%s(%s) %s市场 %s计价", qdii.FundName, qdii.FundCode,
+		service.QDIIMarketNames[qdii.MarketType], qdii.Currency)
+	if qdii.TrackingIndex != "" {
+		result += "\n跟踪标的: " + qdii.TrackingIndex
+	}
+	result += fmt.Sprintf("\n最新公布净值日期: %s  汇率中间价: %.4f", qdii.NavDate.Format("2006-01-02"), qdii.ExchangeRate)
+
+	switch {
+	case qdii.T2EstValue > 0:
+		result += fmt.Sprintf("\nT+1估值: %.4f  T+2估值: %.4f (截至境外%s)",
+			qdii.T1EstValue, qdii.T2EstValue, qdii.LastTradeDate.Format("01-02"))
+	case qdii.T1EstValue > 0:
+		result += fmt.Sprintf("\nT+1估值: %.4f (截至境外%s)", qdii.T1EstValue, qdii.LastTradeDate.Format("01-02"))
+	default:
+		result += "\n净值已更新至最新境外交易日"
+	}
+
+	result += "\n\n收益归因(基金 = 指数 × 汇率 × 其他):"
+	for _, p := range []struct {
+		name string
+		days int
+	}{{"近1月", 20}, {"近3月", 60}, {"近1年", 250}} {
+		attr, err := qdiiService.Attribution(fundCode, p.days)
+		if err != nil {
+			continue
+		}
+		result += fmt.Sprintf("\n%s: 基金 %+.2f%%  指数 %+.2f%%  汇率 %+.2f%%  其他 %+.2f%%",
+			p.name, attr.FundReturn, attr.IndexReturn, attr.FXReturn, attr.Residual)
+	}
+	u.resultArea.SetText(result)
+}
+
 // Refresh 刷新界面
 func (u *ToolsUI) Refresh() {
 }
@@ -0,0 +1,301 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"jijin/internal/service"
+	apptheme "jijin/internal/theme"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// allGroups 分组筛选中表示全部分组
+const allGroups = "全部分组"
+
+// WatchlistUI 自选基金界面
+type WatchlistUI struct {
+	content   fyne.CanvasObject
+	window    fyne.Window
+	onConvert func()
+
+	groupSelect *widget.Select
+	itemList    *fyne.Container
+	views       []service.WatchItemView
+}
+
+// NewWatchlistUI 创建自选基金界面，onConvert在转为持仓后调用
+func NewWatchlistUI(onConvert func()) *WatchlistUI {
+	ui := &WatchlistUI{onConvert: onConvert}
+	ui.build()
+	return ui
+}
+
+func (u *WatchlistUI) build() {
+	u.groupSelect = widget.NewSelect([]string{allGroups}, func(string) {
+		u.Refresh()
+	})
+	u.groupSelect.SetSelected(allGroups)
+
+	addBtn := widget.NewButtonWithIcon("添加自选", theme.ContentAddIcon(), u.showAddDialog)
+	addBtn.Importance = widget.HighImportance
+
+	header := container.NewBorder(nil, nil,
+		widget.NewLabelWithStyle("我的自选", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewHBox(u.groupSelect, addBtn),
+	)
+
+	u.itemList = container.NewVBox()
+	scroll := container.NewVScroll(u.itemList)
+	scroll.SetMinSize(fyne.NewSize(0, 400))
+
+	u.content = container.NewPadded(container.NewBorder(
+		container.NewVBox(header, widget.NewSeparator()),
+		nil, nil, nil,
+		scroll,
+	))
+}
+
+// createItemCard 创建自选基金卡片
+func (u *WatchlistUI) createItemCard(view service.WatchItemView) fyne.CanvasObject {
+	bg := canvas.NewRectangle(apptheme.GetCardBgColor())
+	bg.CornerRadius = 6
+
+	item := view.Item
+	nameLabel := widget.NewLabelWithStyle(item.FundName, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	codeLabel := widget.NewLabel(item.FundCode)
+	codeLabel.Importance = widget.LowImportance
+	groupLabel := widget.NewLabel("[" + item.GroupName + "]")
+	groupLabel.Importance = widget.LowImportance
+
+	// 实时估值
+	estLabel := widget.NewLabelWithStyle("-", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true})
+	if view.Fund != nil {
+		estText := fmt.Sprintf("%.4f  %+.2f%%", view.Fund.EstValue, view.Fund.EstGrowth)
+		if source := service.EstSourceLabel(view.Fund.EstSource); source != "" {
+			estText += "  [" + source + "]"
+		}
+		estLabel.SetText(estText)
+		if view.Fund.EstGrowth >= 0 {
+			estLabel.Importance = widget.SuccessImportance
+		} else {
+			estLabel.Importance = widget.DangerImportance
+		}
+	}
+
+	returnsLabel := widget.NewLabel(fmt.Sprintf("近1周 %+.2f%%  近1月 %+.2f%%  近3月 %+.2f%%",
+		view.Return1W, view.Return1M, view.Return3M))
+
+	signalText := "暂无信号"
+	if view.Signal != nil {
+		signalText = fmt.Sprintf("信号: %s (强度%.0f) %s", service.SignalTypeNames[view.Signal.SignalType], view.Signal.SignalStrength, view.Signal.Reason)
+		if !view.Signal.IsValid && view.Signal.Status != "" {
+			signalText = fmt.Sprintf("[%s] %s", service.SignalStatusNames[view.Signal.Status], signalText)
+		}
+	}
+	signalLabel := widget.NewLabel(signalText)
+	signalLabel.Truncation = fyne.TextTruncateEllipsis
+
+	leftContent := container.NewVBox(
+		container.NewHBox(nameLabel, codeLabel, groupLabel),
+		returnsLabel,
+		signalLabel,
+	)
+	if item.Note != "" {
+		noteLabel := widget.NewLabel("备注: " + item.Note)
+		noteLabel.Importance = widget.LowImportance
+		leftContent.Add(noteLabel)
+	}
+
+	buyBtn := widget.NewButtonWithIcon("转为持仓", theme.ContentAddIcon(), func() {
+		u.showConvertDialog(view)
+	})
+	buyBtn.Importance = widget.HighImportance
+	if view.IsHolding {
+		buyBtn.SetText("加仓")
+	}
+
+	editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+		u.showEditDialog(view)
+	})
+
+	deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		dialog.ShowConfirm("确认删除", fmt.Sprintf("确定要从自选中删除 %s 吗？", item.FundName), func(ok bool) {
+			if ok {
+				service.GetWatchlistService().RemoveFromWatchlist(item.ID)
+				u.Refresh()
+			}
+		}, u.window)
+	})
+
+	info := container.NewBorder(nil, nil, leftContent, estLabel)
+	buttons := container.NewHBox(layout.NewSpacer(), buyBtn, editBtn, deleteBtn)
+
+	return container.NewStack(bg, container.NewPadded(container.NewVBox(info, buttons)))
+}
+
+// showAddDialog 显示添加自选对话框
+func (u *WatchlistUI) showAddDialog() {
+	codeEntry := widget.NewEntry()
+	codeEntry.SetPlaceHolder("基金代码")
+
+	groupEntry := widget.NewSelectEntry(u.groupOptions())
+	groupEntry.SetText(service.DefaultWatchGroup)
+
+	noteEntry := widget.NewEntry()
+	noteEntry.SetPlaceHolder("备注(可选)")
+
+	dialog.ShowForm("添加自选", "添加", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("基金代码", codeEntry),
+			widget.NewFormItem("分组", groupEntry),
+			widget.NewFormItem("备注", noteEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			code := codeEntry.Text
+			go func() {
+				_, err := service.GetWatchlistService().AddToWatchlist(code, "", groupEntry.Text, noteEntry.Text)
+				if err != nil {
+					dialog.ShowError(err, u.window)
+					return
+				}
+				service.GetFundAPI().RefreshFund(code)
+				u.Refresh()
+			}()
+		}, u.window)
+}
+
+// showEditDialog 显示编辑分组和备注对话框
+func (u *WatchlistUI) showEditDialog(view service.WatchItemView) {
+	groupEntry := widget.NewSelectEntry(u.groupOptions())
+	groupEntry.SetText(view.Item.GroupName)
+
+	noteEntry := widget.NewMultiLineEntry()
+	noteEntry.SetText(view.Item.Note)
+
+	dialog.ShowForm("编辑自选 - "+view.Item.FundName, "保存", "取消",
+		[]*widget.FormItem{
+			widget.NewFormItem("分组", groupEntry),
+			widget.NewFormItem("备注", noteEntry),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := service.GetWatchlistService().UpdateWatchlistItem(view.Item.ID, groupEntry.Text, noteEntry.Text); err != nil {
+				dialog.ShowError(err, u.window)
+				return
+			}
+			u.Refresh()
+		}, u.window)
+}
+
+// showConvertDialog 显示转为持仓对话框
+func (u *WatchlistUI) showConvertDialog(view service.WatchItemView) {
+	amountEntry := widget.NewEntry()
+	amountEntry.SetPlaceHolder("买入金额")
+
+	navEntry := widget.NewEntry()
+	navEntry.SetPlaceHolder("成交净值")
+	if view.Fund != nil {
+		nav := view.Fund.NetValue
+		if view.Fund.EstValue > 0 {
+			nav = view.Fund.EstValue
+		}
+		navEntry.SetText(fmt.Sprintf("%.4f", nav))
+	}
+
+	feeEntry := widget.NewEntry()
+	feeEntry.SetPlaceHolder("手续费(可选)")
+	bindDefaultFee(feeEntry, false, func() float64 { return entryFloat(amountEntry) }, amountEntry)
+
+	form := widget.NewForm(
+		widget.NewFormItem("买入金额(元)", amountEntry),
+		widget.NewFormItem("成交净值", navEntry),
+		widget.NewFormItem("手续费(元)", feeEntry),
+	)
+
+	dialog.ShowCustomConfirm("转为持仓 - "+view.Item.FundName, "确认买入", "取消", form, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		amount, err := strconv.ParseFloat(amountEntry.Text, 64)
+		if err != nil || amount <= 0 {
+			dialog.ShowError(fmt.Errorf("请输入有效的买入金额"), u.window)
+			return
+		}
+
+		nav, err := strconv.ParseFloat(navEntry.Text, 64)
+		if err != nil || nav <= 0 {
+			dialog.ShowError(fmt.Errorf("请输入有效的净值"), u.window)
+			return
+		}
+
+		fee, _ := strconv.ParseFloat(feeEntry.Text, 64)
+
+		if err := service.GetWatchlistService().ConvertToHolding(view.Item.ID, amount, nav, fee); err != nil {
+			dialog.ShowError(err, u.window)
+			return
+		}
+
+		dialog.ShowInformation("成功", "已转为持仓", u.window)
+		u.Refresh()
+		if u.onConvert != nil {
+			u.onConvert()
+		}
+	}, u.window)
+}
+
+// groupOptions 获取已有分组
+func (u *WatchlistUI) groupOptions() []string {
+	groups, _ := service.GetWatchlistService().GetGroups()
+	if len(groups) == 0 {
+		groups = []string{service.DefaultWatchGroup}
+	}
+	return groups
+}
+
+// Content 返回界面内容
+func (u *WatchlistUI) Content() fyne.CanvasObject {
+	return u.content
+}
+
+// SetWindow 设置窗口引用
+func (u *WatchlistUI) SetWindow(w fyne.Window) {
+	u.window = w
+}
+
+// Refresh 刷新界面
+func (u *WatchlistUI) Refresh() {
+	groups, _ := service.GetWatchlistService().GetGroups()
+	u.groupSelect.Options = append([]string{allGroups}, groups...)
+	u.groupSelect.Refresh()
+
+	group := u.groupSelect.Selected
+	if group == allGroups {
+		group = ""
+	}
+	u.views, _ = service.GetWatchlistService().GetWatchlistViews(group)
+
+	u.itemList.RemoveAll()
+	if len(u.views) == 0 {
+		emptyLabel := widget.NewLabel("暂无自选基金，可在「搜索」页面或点击「添加自选」添加")
+		emptyLabel.Alignment = fyne.TextAlignCenter
+		u.itemList.Add(container.NewCenter(emptyLabel))
+	} else {
+		for _, view := range u.views {
+			u.itemList.Add(u.createItemCard(view))
+		}
+	}
+	u.itemList.Refresh()
+}
@@ -2,11 +2,20 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"jijin/internal/app"
 )
 
 func main() {
+	// 命令行子命令：导出、备份、恢复
+	if handled, err := app.RunCommand(os.Args[1:]); handled {
+		if err != nil {
+			log.Fatal("执行失败:", err)
+		}
+		return
+	}
+
 	application := app.NewApp()
 	if err := application.Run(); err != nil {
 		log.Fatal("启动失败:", err)